@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/blob"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	logger "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+func init() {
+	rootCmd.AddCommand(NewAttachmentsCmd())
+}
+
+// NewAttachmentsCmd returns a new `attachments` command to be used as a sub-command to root
+func NewAttachmentsCmd() *cobra.Command {
+	attachmentsCmd := cobra.Command{
+		Use:   "attachments",
+		Short: "Manage todo attachments",
+	}
+	attachmentsCmd.AddCommand(newAttachmentsGCCmd())
+	return &attachmentsCmd
+}
+
+func newAttachmentsGCCmd() *cobra.Command {
+	var (
+		dryRun      bool
+		gracePeriod time.Duration
+	)
+
+	gcCmd := cobra.Command{
+		Use:   "gc",
+		Short: "Remove blobs that are no longer referenced by any attachment",
+		Example: `  # Show what would be removed
+  todo-cli attachments gc --dry-run
+
+  # Remove orphaned blobs older than one day
+  todo-cli attachments gc --grace-period 24h
+`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			dbInstance, err := db.FromConfig(&cfg)
+			if err != nil {
+				log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+				return
+			}
+
+			l := logger.New()
+			attachmentService := service.NewAttachment(&service.InitAttachmentService{
+				Log: l,
+				AttachmentRepository: repository.NewAttachment(&repository.InitAttachmentRepository{
+					Db: dbInstance, Log: l,
+				}),
+				TodoRepository: repository.NewTodo(&repository.InitTodoRepository{
+					Db: dbInstance, Log: l,
+				}),
+				BlobStore: blob.NewLocal(&blob.InitLocalStore{Dir: cfg.Attachments.Dir}),
+				Config:    cfg.Attachments,
+			})
+
+			res, err := attachmentService.GarbageCollect(context.Background(), &model.GarbageCollectRequest{
+				DryRun:      dryRun,
+				GracePeriod: gracePeriod,
+			})
+			if err != nil {
+				log.Fatalf("failed to collect garbage err: %s", err)
+				return
+			}
+
+			verb := "Removed"
+			if dryRun {
+				verb = "Would remove"
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %d blobs (%d bytes) and %d orphan attachments. Attachments.Dir: %s\n",
+				verb, res.Blobs, res.Bytes, res.OrphanAttachments, cfg.Attachments.Dir)
+		},
+	}
+	gcCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only report what would be removed")
+	gcCmd.Flags().DurationVar(&gracePeriod, "grace-period", time.Hour, "Keep unreferenced blobs younger than this")
+	return &gcCmd
+}
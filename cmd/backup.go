@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/backup"
+	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	logger "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+func init() {
+	rootCmd.AddCommand(NewBackupCmd())
+	rootCmd.AddCommand(NewRestoreCmd())
+}
+
+// NewBackupCmd returns a new `backup` command to be used as a sub-command to root
+func NewBackupCmd() *cobra.Command {
+	var (
+		dir    string
+		gzip   bool
+		keep   int
+		maxAge time.Duration
+	)
+
+	backupCmd := cobra.Command{
+		Use:   "backup",
+		Short: "Back up the SQLite database",
+		Long: `Write a consistent copy of the SQLite database to Backup.Dir while the server
+keeps running, then remove the backups the retention policy no longer keeps.
+The flags override the Backup configuration.`,
+		Example: `  # Back up with the configured policy
+  todo-cli backup
+
+  # Compressed, keeping the last 30 backups of at most 90 days
+  todo-cli backup --gzip --keep 30 --max-age 2160h
+`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			config := cfg.Backup
+			flags := cmd.Flags()
+			if flags.Changed("dir") {
+				config.Dir = dir
+			}
+			if flags.Changed("gzip") {
+				config.Gzip = gzip
+			}
+			if flags.Changed("keep") {
+				config.Keep = keep
+			}
+			if flags.Changed("max-age") {
+				config.MaxAge = maxAge
+			}
+			if cfg.Database.Driver != model.DriverSQLite {
+				log.Fatalf("backups need the sqlite driver, use the tools of %s instead", cfg.Database.Driver)
+				return
+			}
+
+			dbInstance, err := db.FromConfig(&cfg)
+			if err != nil {
+				log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+				return
+			}
+			file, err := backup.NewSQLite(&backup.InitSQLiteBackup{
+				Db: dbInstance, DBFilename: cfg.SQLite.DBFilename, Config: config,
+			}).Create(context.Background())
+			if err != nil {
+				log.Fatalf("failed to back up database err: %s", err)
+				return
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Backed up %s to %s (%d bytes).\n", cfg.SQLite.DBFilename, file.Path, file.Size)
+		},
+	}
+	backupCmd.Flags().StringVar(&dir, "dir", "", "Directory of the backups")
+	backupCmd.Flags().BoolVar(&gzip, "gzip", false, "Compress the backup")
+	backupCmd.Flags().IntVar(&keep, "keep", 0, "Number of latest backups kept, 0 for any number")
+	backupCmd.Flags().DurationVar(&maxAge, "max-age", 0, "How long backups are kept, 0 for ever")
+	return &backupCmd
+}
+
+// NewRestoreCmd returns a new `restore` command to be used as a sub-command to root
+func NewRestoreCmd() *cobra.Command {
+	restoreCmd := cobra.Command{
+		Use:   "restore FILE",
+		Short: "Replace the SQLite database with a backup",
+		Long: `Replace the SQLite database with a backup written by "todo-cli backup". The
+backup is checked and brought up to the schema version of this build first, and
+the replaced database is kept next to it. Stop the server before restoring.`,
+		Example: `  todo-cli restore tmp/backups/todos-20240510T120000.000Z.db.gz
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if cfg.Database.Driver != model.DriverSQLite {
+				log.Fatalf("restoring needs the sqlite driver, use the tools of %s instead", cfg.Database.Driver)
+				return
+			}
+
+			ctx := context.Background()
+			res, err := backup.NewSQLite(&backup.InitSQLiteBackup{DBFilename: cfg.SQLite.DBFilename}).Restore(ctx, args[0])
+			if err != nil {
+				log.Fatalf("failed to restore backup: %s err: %s", args[0], err)
+				return
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %s at schema version %d to %s, applying %d migrations.\n",
+				args[0], res.Version, cfg.SQLite.DBFilename, res.Migrations)
+			if res.Previous != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "The replaced database was moved to %s.\n", res.Previous)
+			}
+
+			// The cache holds todos of the replaced database; they are read from the backup again.
+			if cfg.Redis != nil {
+				l := logger.New()
+				redisCache := repository.NewRedisCache(&repository.InitRedisCache{Client: cache.New(cfg.Redis), Log: l})
+				if err := redisCache.DeleteAll(ctx); err != nil {
+					log.Warnf("failed to clear the cached todos, clear them by hand err: %s", err)
+				}
+			}
+		},
+	}
+	return &restoreCmd
+}
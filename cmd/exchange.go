@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/exchange"
+	logger "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+	"gorm.io/gorm"
+)
+
+func init() {
+	rootCmd.AddCommand(NewExportCmd())
+	rootCmd.AddCommand(NewImportCmd())
+}
+
+// NewExportCmd returns a new `export` command to be used as a sub-command to root
+func NewExportCmd() *cobra.Command {
+	var (
+		req    model.ExportRequest
+		output string
+	)
+
+	exportCmd := cobra.Command{
+		Use:   "export",
+		Short: "Export the todos to a file",
+		Long: `Export the todos in board order as JSON, as the API returns them, CSV, todo.txt,
+iCalendar or a Markdown report. The format defaults to the extension of the output file,
+and to JSON on the standard output.`,
+		Example: `  # Export every todo as JSON
+  todo-cli export > todos.json
+
+  # Report the todos of a project in Markdown
+  todo-cli export --format markdown --project web
+
+  # Export the open todos to todo.txt
+  todo-cli export --status created -o todo.txt
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if req.Format == "" {
+				req.Format = exchange.FormatOf(output)
+			}
+			if req.Format == "" {
+				req.Format = model.FormatJSON
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					log.Fatalf("failed to create %s err: %s", output, err)
+					return
+				}
+				defer file.Close()
+				w = file
+			}
+			enc, err := exchange.NewEncoder(req.Format, w)
+			if err != nil {
+				log.Fatalf("failed to export err: %s", err)
+				return
+			}
+
+			dbInstance, err := db.FromConfig(&cfg)
+			if err != nil {
+				log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+				return
+			}
+			todoService := newTodoService(dbInstance, nil)
+			if err := todoService.Export(context.Background(), &req, enc.Encode); err != nil {
+				log.Fatalf("failed to export err: %s", err)
+				return
+			}
+			if err := enc.Close(); err != nil {
+				log.Fatalf("failed to export err: %s", err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&req.Format, "format", "", "File format: json, csv, todotxt, ical or markdown")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Write to this file instead of the standard output")
+	exportCmd.Flags().StringVar(&req.Status, "status", "", "Only export the todos of this status")
+	exportCmd.Flags().StringVar(&req.Project, "project", "", "Only export the todos of this project")
+	return &exportCmd
+}
+
+// NewImportCmd returns a new `import` command to be used as a sub-command to root
+func NewImportCmd() *cobra.Command {
+	var req model.ImportRequest
+
+	importCmd := cobra.Command{
+		Use:   "import FILE",
+		Short: "Import todos from a file",
+		Long: `Import todos from a JSON, CSV, todo.txt or iCalendar file, "-" for the standard input. The
+format defaults to the extension of the file. A todo with the ID or the task of
+an existing one conflicts: it is skipped, or updates the existing one with
+--on-conflict update. A file with invalid lines imports nothing.`,
+		Example: `  # Check a todo.txt file
+  todo-cli import todo.txt --dry-run
+
+  # Import the tasks exported by a calendar app
+  todo-cli import tasks.ics
+
+  # Import an export of another server, updating the todos it has already
+  todo-cli import todos.json --on-conflict update
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			if req.Format == "" {
+				req.Format = exchange.FormatOf(args[0])
+			}
+			if req.Format == "" {
+				log.Fatalf("unknown format of %s, set it with --format", args[0])
+				return
+			}
+			if req.OnConflict != "" && req.OnConflict != model.ConflictSkip && req.OnConflict != model.ConflictUpdate {
+				log.Fatalf("invalid --on-conflict %q, use %s or %s", req.OnConflict, model.ConflictSkip, model.ConflictUpdate)
+				return
+			}
+			if cfg.Redis == nil {
+				log.Fatalf("failed to import: the redis configuration is missing")
+				return
+			}
+
+			var r io.Reader = cmd.InOrStdin()
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					log.Fatalf("failed to open %s err: %s", args[0], err)
+					return
+				}
+				defer file.Close()
+				r = file
+			}
+			records, err := exchange.Decode(req.Format, r)
+			if err != nil {
+				log.Fatalf("failed to read %s err: %s", args[0], err)
+				return
+			}
+
+			dbInstance, err := db.FromConfig(&cfg)
+			if err != nil {
+				log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+				return
+			}
+			l := logger.New()
+			redisCache := repository.NewRedisCache(&repository.InitRedisCache{Client: cache.New(cfg.Redis), Log: l})
+			res, err := newTodoService(dbInstance, redisCache).Import(ctx, &req, records)
+			if err != nil {
+				log.Fatalf("failed to import err: %s", err)
+				return
+			}
+			printImport(cmd.OutOrStdout(), res)
+			if res.Invalid > 0 && !res.DryRun {
+				log.Fatalf("%d invalid lines, nothing was imported", res.Invalid)
+			}
+		},
+	}
+	importCmd.Flags().StringVar(&req.Format, "format", "", "File format: json, csv, todotxt or ical")
+	importCmd.Flags().BoolVar(&req.DryRun, "dry-run", false, "Only report what would be imported")
+	importCmd.Flags().StringVar(&req.OnConflict, "on-conflict", model.ConflictSkip, "What to do with the todos that exist already: skip or update")
+	return &importCmd
+}
+
+// newTodoService returns the todo service of the configured store. The events
+// of its changes are left to the outbox dispatcher of the server.
+func newTodoService(dbInstance *gorm.DB, redisCache repository.IRedisCache) service.ITodo {
+	l := logger.New()
+	return service.NewTodo(&service.InitTodoService{
+		Log: l, TodoRepository: newTodoRepository(dbInstance, l), RedisCache: redisCache, Board: cfg.Board,
+		Outbox: service.NewOutbox(&service.InitOutboxService{
+			Log: l, EventRepository: repository.NewEvent(&repository.InitEventRepository{Db: dbInstance, Log: l}),
+		}),
+	})
+}
+
+// newTodoRepository returns the todo repository of the configured store, so
+// that the changes of the commands are recorded as those of the server.
+func newTodoRepository(dbInstance *gorm.DB, l *logger.Logger) repository.ITodo {
+	if cfg.TodoStore.Mode == model.StoreEventSourced {
+		return repository.NewEventSourcedTodo(&repository.InitEventSourcedTodoRepository{
+			Db: dbInstance, Log: l, SnapshotInterval: cfg.TodoStore.SnapshotInterval,
+		})
+	}
+	return repository.NewTodo(&repository.InitTodoRepository{Db: dbInstance, Log: l})
+}
+
+// printImport writes the lines of an import that were not created as they are, and its totals.
+func printImport(out io.Writer, res *model.ImportResponse) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	header := false
+	for _, line := range res.Lines {
+		if line.Action == model.ImportCreate {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "LINE\tACTION\tID\tTASK\tERROR")
+			header = true
+		}
+		id := "-"
+		if line.ID != 0 {
+			id = fmt.Sprint(line.ID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", line.Line, line.Action, id, line.Task, line.Error)
+	}
+	_ = w.Flush()
+
+	verb := "Imported"
+	switch {
+	case res.DryRun:
+		verb = "Would import"
+	case !res.Applied:
+		verb = "Not imported"
+	}
+	fmt.Fprintf(out, "%s %d todos: %d created, %d updated, %d skipped, %d invalid.\n",
+		verb, len(res.Lines), res.Created, res.Updated, res.Skipped, res.Invalid)
+}
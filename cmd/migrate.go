@@ -3,37 +3,211 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"text/tabwriter"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
 )
 
-// migrateCmd represents the migrate command
-var migrateCmd = &cobra.Command{
-	Use:   "migrate",
-	Short: "Migrate the database",
-	Run: func(_ *cobra.Command, _ []string) {
+func init() {
+	rootCmd.AddCommand(NewMigrateCmd())
+}
+
+// NewMigrateCmd returns a new `migrate` command to be used as a sub-command to root
+func NewMigrateCmd() *cobra.Command {
+	migrateCmd := cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database",
+		Long: `Migrate the database with the numbered SQL migrations of the configured driver.
+Without a sub-command, the pending migrations are applied as with "migrate up".`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			migrateUp(cmd)
+		},
+	}
+	migrateCmd.AddCommand(newMigrateUpCmd())
+	migrateCmd.AddCommand(newMigrateDownCmd())
+	migrateCmd.AddCommand(newMigrateStatusCmd())
+	migrateCmd.AddCommand(newMigrateGotoCmd())
+	migrateCmd.AddCommand(newMigrateCreateCmd())
+	return &migrateCmd
+}
+
+func newMigrateUpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "up",
+		Short: "Apply the pending migrations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			migrateUp(cmd)
+		},
+	}
+}
+
+func newMigrateDownCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "down N",
+		Short: "Revert the N latest applied migrations",
+		Example: `  # Revert the latest migration
+  todo-cli migrate down 1
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				log.Fatalf("invalid number of migrations: %s", args[0])
+				return
+			}
+
+			dbInstance := openMigrationDatabase()
+			reverted, err := db.MigrateDown(dbInstance, n)
+			printMigrations(cmd.OutOrStdout(), "Reverted", reverted)
+			if err != nil {
+				log.Fatalf("failed to revert migrations err: %s", err)
+				return
+			}
+			printVersion(cmd.OutOrStdout(), dbInstance)
+		},
+	}
+}
+
+func newMigrateStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the migrations and whether they are applied",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			statuses, err := db.MigrationStatuses(openMigrationDatabase())
+			if err != nil {
+				log.Fatalf("failed to read migration status err: %s", err)
+				return
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+			for _, status := range statuses {
+				appliedAt := "-"
+				if status.AppliedAt != nil {
+					appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt)
+			}
+			_ = w.Flush()
+		},
+	}
+}
+
+func newMigrateGotoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "goto V",
+		Short: "Apply or revert migrations until V is the latest applied one",
+		Example: `  # Revert every migration
+  todo-cli migrate goto 0
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			version, err := strconv.Atoi(args[0])
+			if err != nil || version < 0 {
+				log.Fatalf("invalid migration version: %s", args[0])
+				return
+			}
+
+			dbInstance := openMigrationDatabase()
+			done, err := db.MigrateTo(dbInstance, version)
+			for _, migration := range done {
+				verb := "Applied"
+				if migration.Version > version {
+					verb = "Reverted"
+				}
+				printMigrations(cmd.OutOrStdout(), verb, []*db.Migration{migration})
+			}
+			if err != nil {
+				log.Fatalf("failed to migrate database err: %s", err)
+				return
+			}
+			printVersion(cmd.OutOrStdout(), dbInstance)
+		},
+	}
+}
+
+func newMigrateCreateCmd() *cobra.Command {
+	var dir string
+
+	createCmd := cobra.Command{
+		Use:   "create NAME",
+		Short: "Create the up and down files of a new migration for every driver",
+		Example: `  # Run from the root of the source tree, then fill in the files and rebuild
+  todo-cli migrate create add_todo_color
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			paths, err := db.CreateMigration(dir, args[0])
+			for _, p := range paths {
+				fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", p)
+			}
+			if err != nil {
+				log.Fatalf("failed to create migration err: %s", err)
+			}
+		},
+	}
+	createCmd.Flags().StringVar(&dir, "dir", filepath.Join("internal", "db", "migrations"), "Migrations directory of the source tree")
+	return &createCmd
+}
+
+func openMigrationDatabase() *gorm.DB {
+	if cfg.Database.Driver == model.DriverSQLite {
 		dbDir := filepath.Dir(cfg.SQLite.DBFilename)
 		if err := os.MkdirAll(dbDir, os.ModePerm); err != nil {
 			log.Fatalf("failed to create directory: %s err: %s", dbDir, err)
-			return
 		}
+	}
 
-		dbInstance, err := db.New(cfg.SQLite.DBFilename)
-		if err != nil {
-			log.Fatalf("failed to open database filename: %s err: %s", cfg.SQLite.DBFilename, err)
-			return
-		}
-		if err := db.Migrate(dbInstance); err != nil {
-			log.Fatalf("failed to migrate database err: %s", err)
-		}
-		fmt.Println("Migration completed. SQLite.DBFilename: ", cfg.SQLite.DBFilename)
-	},
+	dbInstance, err := db.FromConfig(&cfg)
+	if err != nil {
+		log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+	}
+	return dbInstance
 }
 
-func init() {
-	rootCmd.AddCommand(migrateCmd)
+func migrateUp(cmd *cobra.Command) {
+	dbInstance := openMigrationDatabase()
+	applied, err := db.MigrateUp(dbInstance)
+	printMigrations(cmd.OutOrStdout(), "Applied", applied)
+	if err != nil {
+		log.Fatalf("failed to migrate database err: %s", err)
+		return
+	}
+	printVersion(cmd.OutOrStdout(), dbInstance)
+}
+
+func printMigrations(w io.Writer, verb string, migrations []*db.Migration) {
+	for _, migration := range migrations {
+		fmt.Fprintf(w, "%s %s\n", verb, migration)
+	}
+}
+
+func printVersion(w io.Writer, dbInstance *gorm.DB) {
+	statuses, err := db.MigrationStatuses(dbInstance)
+	if err != nil {
+		log.Fatalf("failed to read migration status err: %s", err)
+		return
+	}
+	version := 0
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			version = status.Version
+		}
+	}
+
+	target := "Database.Driver: " + cfg.Database.Driver
+	if cfg.Database.Driver == model.DriverSQLite {
+		target = "SQLite.DBFilename: " + cfg.SQLite.DBFilename
+	}
+	fmt.Fprintf(w, "Migration completed at version %d. %s\n", version, target)
 }
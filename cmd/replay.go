@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	logger "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+func init() {
+	rootCmd.AddCommand(NewReplayCmd())
+}
+
+// NewReplayCmd returns a new `replay` command to be used as a sub-command to root
+func NewReplayCmd() *cobra.Command {
+	var (
+		fromScratch  bool
+		baseline     bool
+		takeSnapshot bool
+	)
+
+	replayCmd := cobra.Command{
+		Use:   "replay",
+		Short: "Rebuild the todos table from the todo events",
+		Long: `Rebuild the todos table from the events of the event-sourced todo store,
+starting from the latest snapshot. The cached todos are cleared afterwards.`,
+		Example: `  # Rebuild from the latest snapshot
+  todo-cli replay
+
+  # Switch from crud to eventsourced: record the existing todos first
+  todo-cli replay --baseline
+
+  # Check the snapshots by rebuilding from the first event
+  todo-cli replay --from-scratch
+`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			ctx := context.Background()
+			dbInstance, err := db.FromConfig(&cfg)
+			if err != nil {
+				log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+				return
+			}
+
+			l := logger.New()
+			todoRepository := repository.NewEventSourcedTodo(&repository.InitEventSourcedTodoRepository{
+				Db: dbInstance, Log: l, SnapshotInterval: cfg.TodoStore.SnapshotInterval,
+			})
+
+			if baseline {
+				n, err := todoRepository.Baseline(ctx)
+				if err != nil {
+					log.Fatalf("failed to record todos without events err: %s", err)
+					return
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Recorded %d todos without events.\n", n)
+			}
+
+			res, err := todoRepository.Replay(ctx, fromScratch)
+			if err != nil {
+				log.Fatalf("failed to replay err: %s", err)
+				return
+			}
+			from := "the first event"
+			if res.SnapshotEventID > 0 {
+				from = fmt.Sprintf("the snapshot at event %d", res.SnapshotEventID)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Replayed %d events from %s into %d todos.\n", res.Events, from, res.Todos)
+
+			if takeSnapshot {
+				if err := todoRepository.Snapshot(ctx); err != nil {
+					log.Fatalf("failed to snapshot err: %s", err)
+					return
+				}
+			}
+
+			// The cache may hold todos the replay changed; they are read from the database again.
+			if cfg.Redis != nil {
+				redisCache := repository.NewRedisCache(&repository.InitRedisCache{Client: cache.New(cfg.Redis), Log: l})
+				if err := redisCache.DeleteAll(ctx); err != nil {
+					log.Warnf("failed to clear the cached todos, clear them by hand err: %s", err)
+				}
+			}
+		},
+	}
+	replayCmd.Flags().BoolVar(&fromScratch, "from-scratch", false, "Ignore the snapshots and replay every event")
+	replayCmd.Flags().BoolVar(&baseline, "baseline", false, "First record a TodoCreated event for every todo without events")
+	replayCmd.Flags().BoolVar(&takeSnapshot, "snapshot", false, "Snapshot the rebuilt todos")
+	return &replayCmd
+}
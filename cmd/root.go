@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spf13/cobra"
@@ -59,6 +60,9 @@ func initConfig() {
 	}
 
 	viper.AutomaticEnv() // read in environment variables that match
+	// The todo commands usually run away from the config file of the server.
+	_ = viper.BindEnv("client.url", "TODO_API_URL")
+	_ = viper.BindEnv("client.token", "TODO_API_TOKEN")
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
@@ -68,6 +72,26 @@ func initConfig() {
 	cfg = model.Config{
 		APIServer:     model.Server{Enable: true, Port: 8080},
 		SwaggerServer: model.Server{Enable: false, Port: 1314},
+		Database:      model.Database{Driver: model.DriverSQLite, LogLevel: "warn", SlowThreshold: 200 * time.Millisecond},
+		SQLite: model.SQLite{
+			JournalMode: "wal", Synchronous: "normal", BusyTimeout: 5 * time.Second, ForeignKeys: true, MaxReadConns: 4,
+		},
+		Attachments: model.Attachments{
+			Dir:     "tmp/blobs",
+			MaxSize: 10 << 20,
+		},
+		Idempotency: model.Idempotency{TTL: 24 * time.Hour, Lease: time.Minute},
+		Events: model.Events{
+			Broker: "memory", Channel: "todo-events", HeartbeatInterval: 15 * time.Second, Retention: 7 * 24 * time.Hour,
+		},
+		Realtime: model.Realtime{SendBuffer: 64, PingInterval: 30 * time.Second, MaxMessageSize: 64 << 10},
+		Webhooks: model.Webhooks{
+			PollInterval: time.Second, Timeout: 10 * time.Second, MaxAttempts: 8, RetryDelay: 30 * time.Second, MaxRetryDelay: time.Hour,
+		},
+		Outbox:    model.Outbox{PollInterval: time.Second, BatchSize: 100, RetryDelay: time.Second, MaxRetryDelay: time.Minute},
+		TodoStore: model.TodoStore{Mode: model.StoreCRUD, SnapshotInterval: 1000},
+		Backup:    model.Backup{Dir: "tmp/backups", Keep: 7},
+		Client:    model.Client{URL: "http://localhost:8080"},
 	}
 
 	err := viper.Unmarshal(&cfg)
@@ -79,4 +103,7 @@ func initConfig() {
 	if err := validate.Struct(&cfg); err != nil {
 		logger.Fatal(ctx, fmt.Sprintf("config validation failed: %s", err.Error()))
 	}
+	if cfg.Database.Driver == model.DriverSQLite && cfg.SQLite.DBFilename == "" {
+		logger.Fatal(ctx, "config validation failed: SQLite.DBFilename is required with the sqlite driver")
+	}
 }
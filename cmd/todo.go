@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/client"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// The output formats of the todo commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// dueLayout is the layout of the due dates without a time.
+const dueLayout = "2006-01-02"
+
+func init() {
+	rootCmd.AddCommand(NewTodoCmd())
+}
+
+// todoOptions are the flags shared by the todo commands.
+type todoOptions struct {
+	url    string
+	token  string
+	output string
+}
+
+func (o *todoOptions) client() client.ITodo {
+	url, token := cfg.Client.URL, cfg.Client.Token
+	if o.url != "" {
+		url = o.url
+	}
+	if o.token != "" {
+		token = o.token
+	}
+	return client.NewTodo(&client.InitTodoClient{URL: url, Token: token})
+}
+
+// NewTodoCmd returns a new `todo` command to be used as a sub-command to root
+func NewTodoCmd() *cobra.Command {
+	var opts todoOptions
+
+	todoCmd := cobra.Command{
+		Use:   "todo",
+		Short: "Manage the todos of a running server",
+		Long: `Manage the todos through the API of a running server. The server is Client.URL of
+the config file, or TODO_API_URL, and the bearer token Client.Token, or TODO_API_TOKEN.`,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			switch opts.output {
+			case outputTable, outputJSON, outputYAML:
+			default:
+				log.Fatalf("invalid --output %q, use %s, %s or %s", opts.output, outputTable, outputJSON, outputYAML)
+			}
+		},
+	}
+	todoCmd.PersistentFlags().StringVar(&opts.url, "server", "", "Address of the server (default Client.URL)")
+	todoCmd.PersistentFlags().StringVar(&opts.token, "token", "", "Bearer token (default Client.Token)")
+	todoCmd.PersistentFlags().StringVarP(&opts.output, "output", "o", outputTable, "Output format: table, json or yaml")
+
+	todoCmd.AddCommand(newTodoListCmd(&opts))
+	todoCmd.AddCommand(newTodoAddCmd(&opts))
+	todoCmd.AddCommand(newTodoShowCmd(&opts))
+	todoCmd.AddCommand(newTodoEditCmd(&opts))
+	todoCmd.AddCommand(newTodoDoneCmd(&opts))
+	todoCmd.AddCommand(newTodoRmCmd(&opts))
+	return &todoCmd
+}
+
+func newTodoListCmd(opts *todoOptions) *cobra.Command {
+	var req model.FindAllRequest
+
+	listCmd := cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the todos",
+		Example: `  # List the todos in progress of a project
+  todo-cli todo list --status processing --project web
+
+  # List the todos of alice in board order, as YAML
+  todo-cli todo list --assignee alice --sort manual -o yaml
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			todos, err := opts.client().FindAll(context.Background(), &req)
+			if err != nil {
+				log.Fatalf("failed to list todos err: %s", err)
+				return
+			}
+			printTodos(cmd.OutOrStdout(), opts.output, todos)
+		},
+	}
+	listCmd.Flags().StringVar(&req.Task, "task", "", "Only list the todos with this text in the task or description")
+	listCmd.Flags().StringVar(&req.Status, "status", "", "Only list the todos of this status")
+	listCmd.Flags().StringVar(&req.Project, "project", "", "Only list the todos of this project")
+	listCmd.Flags().StringVar(&req.Assignee, "assignee", "", "Only list the todos of this assignee")
+	listCmd.Flags().StringVar(&req.Sort, "sort", "", "Set to manual for the board order")
+	return &listCmd
+}
+
+func newTodoAddCmd(opts *todoOptions) *cobra.Command {
+	var (
+		req model.CreateRequest
+		due string
+	)
+
+	addCmd := cobra.Command{
+		Use:   "add TASK",
+		Short: "Add a todo",
+		Example: `  # Add a todo due on a date
+  todo-cli todo add "Ship release" --priority high --due 2024-06-01 --tag release
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			req.Task = args[0]
+			if due != "" {
+				req.DueAt = parseDue(due)
+			}
+			todo, err := opts.client().Create(context.Background(), &req)
+			if err != nil {
+				log.Fatalf("failed to add todo err: %s", err)
+				return
+			}
+			printTodo(cmd.OutOrStdout(), opts.output, todo)
+		},
+	}
+	addCmd.Flags().StringVar(&req.Description, "description", "", "Description in Markdown")
+	addCmd.Flags().StringVar(&req.Priority, "priority", string(model.TP_Medium), "Priority: high, medium or low")
+	addCmd.Flags().StringSliceVar(&req.Tags, "tag", nil, "Tag, repeated for several")
+	addCmd.Flags().StringVar(&req.Assignee, "assignee", "", "Assignee")
+	addCmd.Flags().StringVar(&due, "due", "", "Due date, as 2006-01-02 or RFC 3339")
+	addCmd.Flags().StringVar(&req.Project, "project", "", "Project")
+	addCmd.Flags().IntVar(&req.EstimateMinutes, "estimate", 0, "Expected effort in minutes")
+	return &addCmd
+}
+
+func newTodoShowCmd(opts *todoOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show ID",
+		Short: "Show a todo",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			todo, err := opts.client().Find(context.Background(), &model.FindRequest{ID: parseID(args[0])})
+			if err != nil {
+				log.Fatalf("failed to show todo err: %s", err)
+				return
+			}
+			printTodo(cmd.OutOrStdout(), opts.output, todo)
+		},
+	}
+}
+
+func newTodoEditCmd(opts *todoOptions) *cobra.Command {
+	var (
+		req model.UpdateRequest
+		due string
+	)
+
+	editCmd := cobra.Command{
+		Use:   "edit ID",
+		Short: "Edit a todo",
+		Long:  `Edit the fields of a todo given as flags. The others are left as they are.`,
+		Example: `  # Hand a todo over to bob
+  todo-cli todo edit 7 --assignee bob --status processing
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			req.ID = parseID(args[0])
+			if due != "" {
+				req.DueAt = parseDue(due)
+			}
+			// The fields left empty are not sent, so that the server keeps them.
+			if body, _ := json.Marshal(&req.UpdateRequestBody); string(body) == "{}" {
+				log.Fatalf("nothing to edit, set the fields with flags")
+				return
+			}
+			todo, err := opts.client().Update(context.Background(), &req)
+			if err != nil {
+				log.Fatalf("failed to edit todo err: %s", err)
+				return
+			}
+			printTodo(cmd.OutOrStdout(), opts.output, todo)
+		},
+	}
+	editCmd.Flags().StringVar(&req.Task, "task", "", "Task")
+	editCmd.Flags().StringVar(&req.Description, "description", "", "Description in Markdown")
+	editCmd.Flags().StringVar((*string)(&req.Status), "status", "", "Status: created, processing or done")
+	editCmd.Flags().StringSliceVar(&req.Tags, "tag", nil, "Tag, repeated for several; replaces the tags")
+	editCmd.Flags().StringVar(&req.Assignee, "assignee", "", "Assignee")
+	editCmd.Flags().StringVar(&due, "due", "", "Due date, as 2006-01-02 or RFC 3339")
+	editCmd.Flags().StringVar(&req.Project, "project", "", "Project")
+	editCmd.Flags().IntVar(&req.EstimateMinutes, "estimate", 0, "Expected effort in minutes")
+	return &editCmd
+}
+
+func newTodoDoneCmd(opts *todoOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "done ID...",
+		Short: "Mark todos as done",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, arg := range args {
+				todo, err := opts.client().Update(context.Background(), &model.UpdateRequest{
+					UpdateRequestBody: model.UpdateRequestBody{Status: model.Done},
+					UpdateRequestPath: model.UpdateRequestPath{ID: parseID(arg)},
+				})
+				if err != nil {
+					log.Fatalf("failed to mark todo %s as done err: %s", arg, err)
+					return
+				}
+				printTodo(cmd.OutOrStdout(), opts.output, todo)
+			}
+		},
+	}
+}
+
+func newTodoRmCmd(opts *todoOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm ID...",
+		Aliases: []string{"delete"},
+		Short:   "Delete todos",
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, arg := range args {
+				if err := opts.client().Delete(context.Background(), &model.DeleteRequest{ID: parseID(arg)}); err != nil {
+					log.Fatalf("failed to delete todo %s err: %s", arg, err)
+					return
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted todo %s\n", arg)
+			}
+		},
+	}
+}
+
+func parseID(arg string) int {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id <= 0 {
+		log.Fatalf("invalid todo ID %q", arg)
+	}
+	return id
+}
+
+// parseDue reads a due date, which is midnight UTC without a time.
+func parseDue(s string) *time.Time {
+	for _, layout := range []string{dueLayout, time.RFC3339} {
+		if due, err := time.Parse(layout, s); err == nil {
+			return &due
+		}
+	}
+	log.Fatalf("invalid due date %q, use 2006-01-02 or RFC 3339", s)
+	return nil
+}
+
+func printTodos(w io.Writer, output string, todos []*model.Todo) {
+	if output != outputTable {
+		printStructured(w, output, todos)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tPRIORITY\tTASK\tASSIGNEE\tPROJECT\tDUE")
+	for _, todo := range todos {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", todo.ID, todo.Status, todo.Priority, todo.Task,
+			todo.Assignee, todo.Project, formatDue(todo.DueAt))
+	}
+	_ = tw.Flush()
+}
+
+func printTodo(w io.Writer, output string, todo *model.Todo) {
+	if output != outputTable {
+		printStructured(w, output, todo)
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, field := range [][2]string{
+		{"ID", strconv.Itoa(todo.ID)},
+		{"Task", todo.Task},
+		{"Status", string(todo.Status)},
+		{"Priority", string(todo.Priority)},
+		{"Tags", strings.Join(todo.Tags, ", ")},
+		{"Assignee", todo.Assignee},
+		{"Project", todo.Project},
+		{"Due", formatDue(todo.DueAt)},
+		{"Estimate", formatEstimate(todo.EstimateMinutes)},
+		{"Created", todo.CreatedAt.Local().Format(time.RFC3339)},
+		{"Updated", todo.UpdatedAt.Local().Format(time.RFC3339)},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", field[0], field[1])
+	}
+	_ = tw.Flush()
+	if todo.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", todo.Description)
+	}
+}
+
+// printStructured writes v as JSON or YAML, with the field names of the API.
+func printStructured(w io.Writer, output string, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("failed to write %s err: %s", output, err)
+		return
+	}
+	if output == outputJSON {
+		fmt.Fprintln(w, string(b))
+		return
+	}
+
+	// JSON is YAML: reading it as a node keeps the order of the fields.
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		log.Fatalf("failed to write %s err: %s", output, err)
+		return
+	}
+	clearStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		log.Fatalf("failed to write %s err: %s", output, err)
+	}
+}
+
+// clearStyle writes a node read from JSON in block style, quoting only the
+// strings that need it.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+func formatDue(due *time.Time) string {
+	if due == nil {
+		return ""
+	}
+	if due.Hour() == 0 && due.Minute() == 0 && due.Second() == 0 && due.Location() == time.UTC {
+		return due.Format(dueLayout)
+	}
+	return due.Local().Format("2006-01-02 15:04")
+}
+
+func formatEstimate(minutes int) string {
+	if minutes == 0 {
+		return ""
+	}
+	return (time.Duration(minutes) * time.Minute).String()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	logger "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/tui"
+	"go.uber.org/zap"
+	"golang.org/x/term"
+)
+
+func init() {
+	rootCmd.AddCommand(NewTUICmd())
+}
+
+// NewTUICmd returns a new `tui` command to be used as a sub-command to root
+func NewTUICmd() *cobra.Command {
+	var (
+		opts  todoOptions
+		local bool
+	)
+
+	tuiCmd := cobra.Command{
+		Use:   "tui",
+		Short: "Manage the todos in a full-screen terminal interface",
+		Long: `Manage the todos in a full-screen terminal interface, as a list or as a board.
+It works with the API of a running server as the todo commands, or with --local
+directly with the database of the config file, for when no server is running.`,
+		Example: `  # Work with the server of Client.URL
+  todo-cli tui
+
+  # Work with the local database
+  todo-cli tui --local
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				log.Fatalf("failed to start the terminal interface: the standard input is not a terminal")
+				return
+			}
+
+			var store tui.Store
+			if local {
+				// Nothing but the interface may write to the terminal.
+				cfg.Database.LogLevel = "silent"
+				dbInstance, err := db.FromConfig(&cfg)
+				if err != nil {
+					log.Fatalf("failed to open database driver: %s err: %s", cfg.Database.Driver, err)
+					return
+				}
+				store = tui.NewLocalStore(&tui.InitLocalStore{
+					TodoRepository: newTodoRepository(dbInstance, &logger.Logger{Logger: zap.NewNop()}),
+				})
+			} else {
+				store = opts.client()
+			}
+
+			ctx := context.Background()
+			model, load := tui.NewModel(ctx, store)
+			if err := tui.Run(ctx, model, load, os.Stdin, cmd.OutOrStdout()); err != nil {
+				log.Fatalf("failed to run the terminal interface err: %s", err)
+			}
+		},
+	}
+	tuiCmd.Flags().BoolVar(&local, "local", false, "Use the database of the config file instead of the API")
+	tuiCmd.Flags().StringVar(&opts.url, "server", "", "Address of the server (default Client.URL)")
+	tuiCmd.Flags().StringVar(&opts.token, "token", "", "Bearer token (default Client.Token)")
+	return &tuiCmd
+}
@@ -18,15 +18,24 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/healthz": {
+        "/board": {
             "get": {
+                "description": "Returns the todos grouped into status columns in workflow order, with counts and WIP limits.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "board"
+                ],
+                "summary": "Get the Kanban board",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project",
+                        "name": "project",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -38,23 +47,80 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "data": {
-                                            "type": "string"
+                                        "Data": {
+                                            "$ref": "#/definitions/model.Board"
                                         }
                                     }
                                 }
                             ]
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
                     }
                 }
             }
         },
-        "/todos": {
+        "/calendar/:token": {
             "get": {
+                "description": "Returns the todos of the feed as iCalendar VTODOs (RFC 5545), for calendar apps to subscribe to.",
+                "produces": [
+                    "text/calendar"
+                ],
                 "tags": [
-                    "todos"
+                    "calendar"
                 ],
-                "summary": "Find all todos",
+                "summary": "Read a calendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token is the token of the feed, optionally followed by \".ics\".",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/feeds": {
+            "get": {
+                "description": "Lists the feeds without their tokens, which only the creation returns.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Find all calendar feeds",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -66,10 +132,10 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "Data": {
+                                        "data": {
                                             "type": "array",
                                             "items": {
-                                                "$ref": "#/definitions/model.Todo"
+                                                "$ref": "#/definitions/model.CalendarFeed"
                                             }
                                         }
                                     }
@@ -80,12 +146,13 @@ const docTemplate = `{
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     }
                 }
             },
             "post": {
+                "description": "Creates an iCalendar feed of the todos of an assignee, a project or both, to subscribe to from calendar apps at /calendar/{token}.ics. The token is the only protection of the feed: delete the feed to revoke it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -93,9 +160,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "calendar"
                 ],
-                "summary": "Create a new todo",
+                "summary": "Create a calendar feed",
                 "parameters": [
                     {
                         "description": "json",
@@ -103,7 +170,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.CreateRequest"
+                            "$ref": "#/definitions/model.CreateCalendarFeedRequest"
                         }
                     }
                 ],
@@ -113,13 +180,13 @@ const docTemplate = `{
                         "schema": {
                             "allOf": [
                                 {
-                                    "$ref": "#/definitions/handler.ResponseError"
+                                    "$ref": "#/definitions/handler.ResponseData"
                                 },
                                 {
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.Todo"
+                                            "$ref": "#/definitions/model.CreateCalendarFeedResponse"
                                         }
                                     }
                                 }
@@ -129,24 +196,25 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     }
                 }
             }
         },
-        "/todos/:id": {
-            "get": {
+        "/calendar/feeds/:id": {
+            "delete": {
+                "description": "Deletes the feed, so that its token no longer reads the todos.",
                 "tags": [
-                    "todos"
+                    "calendar"
                 ],
-                "summary": "Find a todo",
+                "summary": "Delete a calendar feed",
                 "parameters": [
                     {
                         "type": "integer",
@@ -156,75 +224,159 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/handler.ResponseData"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "Data": {
-                                            "$ref": "#/definitions/model.Todo"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "consumes": [
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "Streams todo.created, todo.updated, todo.completed and todo.deleted events as Server-Sent Events. Each event has the todo as JSON data and an ID; reconnecting with Last-Event-ID replays the events missed in between.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream todo changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created",
+                            "processing",
+                            "done"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "string"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/time": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "todos"
+                    "time tracking"
                 ],
-                "summary": "Update a todo",
+                "summary": "Report the tracked time",
                 "parameters": [
                     {
-                        "description": "body",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.UpdateRequestBody"
-                        }
+                        "type": "string",
+                        "description": "start of the range, RFC 3339 or YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "description": "end of the range (exclusive), RFC 3339 or YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "todo (default), project, user, day, week or month",
+                        "name": "groupBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user",
+                        "name": "user",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project",
+                        "name": "project",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "allOf": [
                                 {
@@ -233,8 +385,8 @@ const docTemplate = `{
                                 {
                                     "type": "object",
                                     "properties": {
-                                        "Data": {
-                                            "$ref": "#/definitions/model.Todo"
+                                        "data": {
+                                            "$ref": "#/definitions/model.TimeReport"
                                         }
                                     }
                                 }
@@ -244,103 +396,2153 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/timer": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "todos"
+                    "time tracking"
                 ],
-                "summary": "Delete a todo",
+                "summary": "Find the running timer of the user",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "user",
+                        "name": "X-User",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TimeEntry"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handler.ResponseError"
+                            "$ref": "#/definitions/errors.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.Error": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
-        },
-        "handler.ResponseData": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "description": "Data is the response data."
-                }
-            }
         },
-        "handler.ResponseError": {
-            "type": "object",
-            "properties": {
-                "errors": {
-                    "description": "Errors is the response errors.",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/handler.Error"
+        "/todos": {
+            "get": {
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Find all todos",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "substring of the task or description",
+                        "name": "task",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "project",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "assignee",
+                        "name": "assignee",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "description": "set to html to include DescriptionHTML",
+                        "name": "render",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual"
+                        ],
+                        "type": "string",
+                        "description": "set to manual to order by status column and rank",
+                        "name": "sort",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "model.CreateRequest": {
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Todo"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Create a new todo",
+                "parameters": [
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Todo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id": {
+            "get": {
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Find a todo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "name": "render",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "$ref": "#/definitions/model.Todo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Update a todo",
+                "parameters": [
+                    {
+                        "description": "body",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpdateRequestBody"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "$ref": "#/definitions/model.Todo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Delete a todo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/attachments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Find all attachments of a todo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Attachment"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Upload an attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Attachment"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/attachments/:attachmentId": {
+            "get": {
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Download an attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "attachmentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Delete an attachment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "attachmentID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/move": {
+            "post": {
+                "description": "Places the todo right before or after another todo, taking over its status, or at the end of a status column.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Move a todo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "html"
+                        ],
+                        "type": "string",
+                        "name": "render",
+                        "in": "path"
+                    },
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.MoveRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "$ref": "#/definitions/model.Todo"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/time-entries": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "time tracking"
+                ],
+                "summary": "Find all time entries of a todo",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.TimeEntry"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "time tracking"
+                ],
+                "summary": "Record time on a todo manually",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateTimeEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TimeEntry"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/time-entries/:entryId": {
+            "delete": {
+                "tags": [
+                    "time tracking"
+                ],
+                "summary": "Delete a time entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "entryID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/timer/start": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "time tracking"
+                ],
+                "summary": "Start a timer on a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "user",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TimeEntry"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/:id/timer/stop": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "time tracking"
+                ],
+                "summary": "Stop the timer on a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user",
+                        "name": "X-User",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "todoID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "user",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.TimeEntry"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/export": {
+            "get": {
+                "description": "Streams the todos in board order as JSON, as the API returns them, CSV, todo.txt, iCalendar VTODOs or a Markdown report.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain",
+                    "text/markdown",
+                    "text/calendar"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Export todos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "todotxt",
+                            "markdown",
+                            "ical"
+                        ],
+                        "type": "string",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "project",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created",
+                            "processing",
+                            "done"
+                        ],
+                        "type": "string",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/import": {
+            "post": {
+                "description": "Reads a JSON, CSV, todo.txt or iCalendar file from the request body. A todo with the ID or the task of an existing one conflicts: it is skipped, or updates the existing one with onConflict=update. An import with invalid lines writes nothing; a dry run reports what the import would do without writing either.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Import todos",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "DryRun reports what the import would do without changing anything.",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "todotxt",
+                            "ical"
+                        ],
+                        "type": "string",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "skip",
+                            "update"
+                        ],
+                        "type": "string",
+                        "description": "OnConflict is what happens to a todo that exists already, ConflictSkip by default.",
+                        "name": "onConflict",
+                        "in": "query"
+                    },
+                    {
+                        "description": "file",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "$ref": "#/definitions/model.ImportResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/quick": {
+            "post": {
+                "description": "Parses text such as \"Pay invoice tomorrow 5pm !high #finance @alice\" into priority, tags, assignee and due date.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Create a new todo from free-form text",
+                "parameters": [
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.QuickCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.QuickCreateResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos:batch": {
+            "post": {
+                "description": "All operations run in one transaction. In atomic mode (default) any failure rolls back the whole batch; in partial mode each operation succeeds or fails on its own and is reported in Results.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "todos"
+                ],
+                "summary": "Apply many create, update and delete operations at once",
+                "parameters": [
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "Data": {
+                                            "$ref": "#/definitions/model.BatchResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Find all webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Webhook"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes a URL to todo events. Each delivery is a POST of {\"id\",\"type\",\"createdAt\",\"data\"} signed with the secret: X-Webhook-Signature is \"sha256=\" and the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body. Deliveries answered with anything but 2xx are retried with exponential backoff.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create a webhook",
+                "parameters": [
+                    {
+                        "description": "json",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/:id": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Find a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Webhook"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes the webhook along with its pending deliveries and delivery log.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/:id/deliveries": {
+            "get": {
+                "description": "Returns the delivery log of the webhook, newest first: the status, attempts and last response of each delivery.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Find the deliveries of a webhook",
+                "parameters": [
+                    {
+                        "maximum": 500,
+                        "type": "integer",
+                        "description": "Limit is the maximum number of deliveries returned, newest first.",
+                        "name": "limit",
+                        "in": "path"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "x-enum-varnames": [
+                            "DeliveryPending",
+                            "DeliverySucceeded",
+                            "DeliveryFailed"
+                        ],
+                        "name": "status",
+                        "in": "path"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "webhookID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of deliveries, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/handler.ResponseData"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.WebhookDelivery"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Upgrades to a WebSocket speaking JSON messages of the form {\"type\",\"id\",\"data\"}. Clients send subscribe (model.EventsRequest), create (model.CreateRequest), update (model.UpdateRequest), delete (model.DeleteRequest) and view (model.ViewRequest); each is answered with an ack carrying the same id, or an error carrying a problem. The server pushes event messages for the subscription and presence messages when the viewers of a todo change.",
+                "tags": [
+                    "realtime"
+                ],
+                "summary": "Connect to the realtime API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user",
+                        "name": "X-User",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user, for clients that cannot set headers",
+                        "name": "user",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/errors.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "errors.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the stable, machine readable error code.",
+                    "type": "string"
+                },
+                "detail": {
+                    "description": "Detail is the explanation of this occurrence.",
+                    "type": "string"
+                },
+                "instance": {
+                    "description": "Instance is the request path.",
+                    "type": "string"
+                },
+                "requestId": {
+                    "description": "RequestID is the ID of the request, for correlation with the logs.",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the HTTP status code.",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Title is the summary of the problem type.",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type identifies the problem type.",
+                    "type": "string"
+                },
+                "violations": {
+                    "description": "Violations are the field-level validation failures.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/errors.Violation"
+                    }
+                }
+            }
+        },
+        "errors.Violation": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Field is the path of the field, such as \"operations[0].op\".",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Message describes the failure.",
+                    "type": "string"
+                },
+                "param": {
+                    "description": "Param is the parameter of the rule, such as the allowed values of \"oneof\".",
+                    "type": "string"
+                },
+                "rule": {
+                    "description": "Rule is the validation rule that failed.",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.ResponseData": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Data is the response data."
+                }
+            }
+        },
+        "model.Attachment": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "digest": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "todoID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BatchOperation": {
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "create": {
+                    "description": "Create is the todo to create when Op is create.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.CreateRequest"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "ID is the todo to update or delete.",
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "update": {
+                    "description": "Update is the fields to update when Op is update.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.UpdateRequestBody"
+                        }
+                    ]
+                }
+            }
+        },
+        "model.BatchRequest": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "description": "Mode is atomic (default) or partial.",
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "partial"
+                    ]
+                },
+                "operations": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/model.BatchOperation"
+                    }
+                }
+            }
+        },
+        "model.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BatchResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.BatchResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is the error code of a failed operation.",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the HTTP status the operation would have had on its own.",
+                    "type": "integer"
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
+        "model.Board": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.BoardColumn"
+                    }
+                }
+            }
+        },
+        "model.BoardColumn": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "overLimit": {
+                    "description": "OverLimit is true when WIPCount is over the WIP limit.",
+                    "type": "boolean"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.Status"
+                },
+                "todos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Todo"
+                    }
+                },
+                "wipcount": {
+                    "description": "WIPCount is the number of todos in the column across every project,\nwhich the WIP limit applies to. It is only set for a column with a limit.",
+                    "type": "integer"
+                },
+                "wiplimit": {
+                    "description": "WIPLimit is the configured limit of the column, or 0 when unlimited.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CalendarFeed": {
+            "type": "object",
+            "properties": {
+                "assignee": {
+                    "description": "Assignee and Project select the todos of the feed. An empty one selects them all.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateCalendarFeedRequest": {
+            "type": "object",
+            "properties": {
+                "assignee": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is the name of the calendar in the calendar apps.",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "project": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateCalendarFeedResponse": {
+            "type": "object",
+            "properties": {
+                "assignee": {
+                    "description": "Assignee and Project select the todos of the feed. An empty one selects them all.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateRequest": {
+            "type": "object",
+            "required": [
+                "priority",
+                "task"
+            ],
+            "properties": {
+                "assignee": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dueAt": {
+                    "type": "string"
+                },
+                "estimateMinutes": {
+                    "description": "EstimateMinutes is the expected effort in minutes.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "priority": {
+                    "type": "string"
+                },
+                "project": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "task": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateTimeEntryRequest": {
             "type": "object",
             "required": [
-                "priority",
-                "task"
+                "startedAt"
+            ],
+            "properties": {
+                "durationMinutes": {
+                    "description": "DurationMinutes is used when EndedAt is not given.",
+                    "type": "integer"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "events",
+                "secret",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "description": "Secret is the key for the X-Webhook-Signature header of the deliveries.",
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "failed"
             ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliverySucceeded",
+                "DeliveryFailed"
+            ]
+        },
+        "model.ImportLine": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action is one of ImportCreate, ImportUpdate, ImportSkip and ImportInvalid.",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is the created or updated todo, or the existing one that conflicts.",
+                    "type": "integer"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "task": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied tells whether the todos were written. An import with invalid\nlines writes none of them.",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ImportLine"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.MoveRequest": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "integer"
+                },
+                "status": {
+                    "enum": [
+                        "created",
+                        "processing",
+                        "done"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "model.QuickAddResult": {
+            "type": "object",
             "properties": {
+                "assignee": {
+                    "description": "Assignee is the \"@user\" token without the leading '@'.",
+                    "type": "string"
+                },
+                "dueAt": {
+                    "description": "DueAt is the resolved due date, or nil when no date or time was given.",
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Matched lists the input fragments that were recognised, in input order.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "priority": {
+                    "description": "Priority is one of \"low\", \"medium\" or \"high\", or empty when not given.",
                     "type": "string"
                 },
+                "tags": {
+                    "description": "Tags are the \"#tag\" tokens without the leading '#', in input order.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "task": {
+                    "description": "Task is the input with every recognised token removed.",
                     "type": "string"
                 }
             }
         },
+        "model.QuickCreateRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.QuickCreateResponse": {
+            "type": "object",
+            "properties": {
+                "parsed": {
+                    "$ref": "#/definitions/model.QuickAddResult"
+                },
+                "todo": {
+                    "$ref": "#/definitions/model.Todo"
+                }
+            }
+        },
         "model.Status": {
             "type": "string",
             "enum": [
@@ -354,21 +2556,121 @@ const docTemplate = `{
                 "Done"
             ]
         },
+        "model.TimeEntry": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "endedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "manual": {
+                    "description": "Manual is true for entries recorded after the fact rather than with a timer.",
+                    "type": "boolean"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "todoID": {
+                    "type": "integer"
+                },
+                "user": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.TimeReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "groupBy": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TimeReportGroup"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                },
+                "totalSeconds": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.TimeReportGroup": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "estimateMinutes": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "label": {
+                    "description": "Label is the task of the todo when grouping by todo.",
+                    "type": "string"
+                },
+                "seconds": {
+                    "type": "integer"
+                }
+            }
+        },
         "model.Todo": {
             "type": "object",
             "properties": {
+                "assignee": {
+                    "type": "string"
+                },
                 "createdAt": {
                     "type": "string"
                 },
+                "description": {
+                    "description": "Description is the long-form description in Markdown.",
+                    "type": "string"
+                },
+                "descriptionHTML": {
+                    "description": "DescriptionHTML is the sanitized HTML rendering of Description, only set on request.",
+                    "type": "string"
+                },
+                "dueAt": {
+                    "type": "string"
+                },
+                "estimateMinutes": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "priority": {
                     "$ref": "#/definitions/model.TodoPriority"
                 },
+                "project": {
+                    "type": "string"
+                },
                 "status": {
                     "$ref": "#/definitions/model.Status"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "task": {
                     "type": "string"
                 },
@@ -393,13 +2695,107 @@ const docTemplate = `{
         "model.UpdateRequestBody": {
             "type": "object",
             "properties": {
+                "assignee": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dueAt": {
+                    "type": "string"
+                },
+                "estimateMinutes": {
+                    "description": "EstimateMinutes is the expected effort in minutes.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "project": {
+                    "type": "string"
+                },
                 "status": {
                     "$ref": "#/definitions/model.Status"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "task": {
                     "type": "string"
                 }
             }
+        },
+        "model.Webhook": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "events": {
+                    "description": "Events are the event types delivered to the URL.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts is the number of attempts made so far.",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is why the last attempt failed.",
+                    "type": "string"
+                },
+                "eventID": {
+                    "type": "integer"
+                },
+                "eventType": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastAttemptAt": {
+                    "description": "LastAttemptAt is when the delivery was last attempted.",
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "description": "NextAttemptAt is when the delivery is due. A worker delivering it moves it\nahead, so another worker only takes it over if the first one died.",
+                    "type": "string"
+                },
+                "responseStatus": {
+                    "description": "ResponseStatus is the HTTP status the receiver answered the last attempt with.",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.DeliveryStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "webhookID": {
+                    "description": "An event is delivered to a webhook once, however often it is dispatched.",
+                    "type": "integer"
+                }
+            }
         }
     }
 }`
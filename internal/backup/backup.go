@@ -0,0 +1,25 @@
+// Package backup provides consistent backups of the SQLite database and their restore.
+package backup
+
+import (
+	"context"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// IBackup backs up a database and restores its backups.
+type IBackup interface {
+	// Create writes a backup of the database and prunes the backups the
+	// retention policy no longer keeps.
+	Create(ctx context.Context) (*model.BackupFile, error)
+	// List returns the backups, latest first.
+	List() ([]*model.BackupFile, error)
+	// Prune removes the backups the retention policy no longer keeps at now
+	// and returns them.
+	Prune(now time.Time) ([]*model.BackupFile, error)
+	// Restore replaces the database with the backup at path, after checking
+	// it and applying the migrations it lacks. Nothing may use the database
+	// meanwhile.
+	Restore(ctx context.Context, path string) (*model.RestoreResult, error)
+}
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// timeLayout is the creation time in the name of a backup, in UTC.
+const timeLayout = "20060102T150405.000Z"
+
+var backupName = regexp.MustCompile(`^todos-(\d{8}T\d{6}\.\d{3}Z)\.db(\.gz)?$`)
+
+// InitSQLiteBackup is the options for backing up a SQLite database.
+type InitSQLiteBackup struct {
+	// Db is the database backed up. It is not needed to restore.
+	Db *gorm.DB
+	// DBFilename is the database file restored.
+	DBFilename string
+	Config     model.Backup
+}
+
+type sqliteBackup struct {
+	db         *gorm.DB
+	dbFilename string
+	config     model.Backup
+}
+
+// NewSQLite returns backups of a SQLite database, written to
+// Config.Dir/todos-<UTC time>.db, or .db.gz with Config.Gzip.
+func NewSQLite(initSQLiteBackup *InitSQLiteBackup) IBackup {
+	return &sqliteBackup{
+		db:         initSQLiteBackup.Db,
+		dbFilename: initSQLiteBackup.DBFilename,
+		config:     initSQLiteBackup.Config,
+	}
+}
+
+func (b *sqliteBackup) Create(ctx context.Context) (*model.BackupFile, error) {
+	if b.db.Dialector.Name() != model.DriverSQLite {
+		return nil, fmt.Errorf("backups need the sqlite driver, not %s", b.db.Dialector.Name())
+	}
+	if err := os.MkdirAll(b.config.Dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := "todos-" + now.Format(timeLayout) + ".db"
+	// VACUUM INTO reads the database in one transaction, so the copy is
+	// consistent while the server keeps writing. It is written under a
+	// temporary name so that a partial backup is never listed.
+	tmp := filepath.Join(b.config.Dir, "."+name+".tmp")
+	defer os.Remove(tmp)
+	if err := b.db.WithContext(ctx).Exec("VACUUM INTO ?", tmp).Error; err != nil {
+		return nil, err
+	}
+
+	src := tmp
+	if b.config.Gzip {
+		name += ".gz"
+		src = filepath.Join(b.config.Dir, "."+name+".tmp")
+		defer os.Remove(src)
+		if err := compress(tmp, src); err != nil {
+			return nil, err
+		}
+	}
+	path := filepath.Join(b.config.Dir, name)
+	if err := os.Rename(src, path); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := b.Prune(now); err != nil {
+		return nil, fmt.Errorf("failed to prune backups: %w", err)
+	}
+	return &model.BackupFile{Path: path, Size: info.Size(), CreatedAt: now}, nil
+}
+
+func compress(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (b *sqliteBackup) List() ([]*model.BackupFile, error) {
+	entries, err := os.ReadDir(b.config.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*model.BackupFile
+	for _, entry := range entries {
+		match := backupName.FindStringSubmatch(entry.Name())
+		if match == nil || !entry.Type().IsRegular() {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, &model.BackupFile{
+			Path: filepath.Join(b.config.Dir, entry.Name()), Size: info.Size(), CreatedAt: createdAt,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+func (b *sqliteBackup) Prune(now time.Time) ([]*model.BackupFile, error) {
+	backups, err := b.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*model.BackupFile
+	// The latest backup is kept whatever its age.
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := b.config.Keep > 0 && i >= b.config.Keep
+		tooOld := b.config.MaxAge > 0 && now.Sub(backup.CreatedAt) > b.config.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+func (b *sqliteBackup) Restore(ctx context.Context, path string) (*model.RestoreResult, error) {
+	dir := filepath.Dir(b.dbFilename)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	// The backup is checked and migrated in a copy next to the database, so
+	// that swapping it in is a rename.
+	tmp, err := os.CreateTemp(dir, ".restore-*.db")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	err = decompress(path, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := prepare(ctx, tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	// The replaced database is kept, with the journals that belong to it.
+	if _, err := os.Stat(b.dbFilename); err == nil {
+		res.Previous = b.dbFilename + ".before-restore-" + time.Now().UTC().Format(timeLayout)
+		for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+			err := os.Rename(b.dbFilename+suffix, res.Previous+suffix)
+			if err != nil && !(suffix != "" && errors.Is(err, os.ErrNotExist)) {
+				return nil, err
+			}
+		}
+	}
+	if err := os.Rename(tmp.Name(), b.dbFilename); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// decompress copies the backup at path to dst, uncompressed.
+func decompress(path string, dst io.Writer) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("%w: %s", model.ErrInvalidBackup, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// prepare checks the copy of a backup at filename and applies the migrations it lacks.
+func prepare(ctx context.Context, filename string) (*model.RestoreResult, error) {
+	dbInstance, err := db.New(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidBackup, err)
+	}
+	// Closing the last connection checkpoints the WAL into the file renamed afterwards.
+	defer db.Close(dbInstance)
+	dbInstance = dbInstance.WithContext(ctx)
+
+	var integrity string
+	if err := dbInstance.Raw("PRAGMA integrity_check").Scan(&integrity).Error; err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidBackup, err)
+	}
+	if integrity != "ok" {
+		return nil, fmt.Errorf("%w: integrity check: %s", model.ErrInvalidBackup, integrity)
+	}
+	if !dbInstance.Migrator().HasTable(&model.SchemaMigration{}) {
+		return nil, fmt.Errorf("%w: no schema version", model.ErrInvalidBackup)
+	}
+
+	statuses, err := db.MigrationStatuses(dbInstance)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.RestoreResult{}
+	for _, status := range statuses {
+		switch status.State {
+		case model.MigrationUnknown:
+			return nil, fmt.Errorf("%w: %04d_%s, the backup is newer than this build", model.ErrUnknownMigration, status.Version, status.Name)
+		case model.MigrationModified:
+			return nil, fmt.Errorf("%w: %04d_%s", model.ErrMigrationChecksum, status.Version, status.Name)
+		case model.MigrationApplied:
+			res.Version = status.Version
+		}
+	}
+	if res.Version == 0 {
+		return nil, fmt.Errorf("%w: no migration applied", model.ErrInvalidBackup)
+	}
+
+	applied, err := db.MigrateUp(dbInstance)
+	if err != nil {
+		return nil, err
+	}
+	res.Migrations = len(applied)
+	return res, nil
+}
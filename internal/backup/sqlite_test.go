@@ -0,0 +1,117 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T, filename string) *gorm.DB {
+	dbInstance, err := db.New(filename)
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(dbInstance))
+	t.Cleanup(func() { _ = db.Close(dbInstance) })
+	return dbInstance
+}
+
+func tasks(t *testing.T, filename string) []string {
+	var names []string
+	require.NoError(t, newTestDB(t, filename).Model(&model.Todo{}).Order("id").Pluck("task", &names).Error)
+	return names
+}
+
+func TestSQLiteBackup(t *testing.T) {
+	ctx := context.Background()
+
+	for _, gzip := range []bool{false, true} {
+		gzip := gzip
+		t.Run(map[bool]string{false: "plain", true: "gzip"}[gzip], func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "todos.db")
+			dbInstance := newTestDB(t, filename)
+			require.NoError(t, dbInstance.Create(&model.Todo{Task: "Backed up"}).Error)
+
+			backup := NewSQLite(&InitSQLiteBackup{
+				Db: dbInstance, DBFilename: filename, Config: model.Backup{Dir: filepath.Join(dir, "backups"), Gzip: gzip},
+			})
+			file, err := backup.Create(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, gzip, filepath.Ext(file.Path) == ".gz")
+			assert.NotZero(t, file.Size)
+			require.NoError(t, dbInstance.Create(&model.Todo{Task: "Not backed up"}).Error)
+
+			target := filepath.Join(dir, "restored", "todos.db")
+			restore := NewSQLite(&InitSQLiteBackup{DBFilename: target})
+			res, err := restore.Restore(ctx, file.Path)
+			require.NoError(t, err)
+			assert.NotZero(t, res.Version)
+			assert.Zero(t, res.Migrations)
+			assert.Empty(t, res.Previous)
+			assert.Equal(t, []string{"Backed up"}, tasks(t, target))
+
+			// Restoring again keeps the replaced database
+			res, err = NewSQLite(&InitSQLiteBackup{DBFilename: filename}).Restore(ctx, file.Path)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"Backed up"}, tasks(t, filename))
+			assert.Equal(t, []string{"Backed up", "Not backed up"}, tasks(t, res.Previous))
+		})
+	}
+
+	t.Run("rejects_invalid_backups", func(t *testing.T) {
+		dir := t.TempDir()
+		target := filepath.Join(dir, "todos.db")
+		restore := NewSQLite(&InitSQLiteBackup{DBFilename: target})
+
+		garbage := filepath.Join(dir, "garbage.db")
+		require.NoError(t, os.WriteFile(garbage, []byte("not a database"), 0o644))
+		_, err := restore.Restore(ctx, garbage)
+		assert.ErrorIs(t, err, model.ErrInvalidBackup)
+
+		// A backup of a newer build has migrations this one does not know
+		newer := filepath.Join(dir, "newer.db")
+		newerDB := newTestDB(t, newer)
+		require.NoError(t, newerDB.Create(&model.SchemaMigration{Version: 999, Name: "newer"}).Error)
+		// Closing checkpoints the WAL into the file copied
+		require.NoError(t, db.Close(newerDB))
+		_, err = restore.Restore(ctx, newer)
+		assert.ErrorIs(t, err, model.ErrUnknownMigration)
+
+		_, err = os.Stat(target)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		dir := t.TempDir()
+		now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+		for _, age := range []time.Duration{0, time.Hour, 2 * time.Hour, 48 * time.Hour} {
+			name := "todos-" + now.Add(-age).Format(timeLayout) + ".db.gz"
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644))
+
+		backup := NewSQLite(&InitSQLiteBackup{Config: model.Backup{Dir: dir, Keep: 3, MaxAge: 90 * time.Minute}})
+		removed, err := backup.Prune(now)
+		require.NoError(t, err)
+		// The fourth is one too many, and the third too old
+		require.Len(t, removed, 2)
+		assert.Equal(t, now.Add(-2*time.Hour), removed[0].CreatedAt)
+		assert.Equal(t, now.Add(-48*time.Hour), removed[1].CreatedAt)
+
+		// The latest backup is kept whatever its age
+		removed, err = backup.Prune(now.Add(24 * time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, removed, 1)
+		backups, err := backup.List()
+		require.NoError(t, err)
+		require.Len(t, backups, 1)
+		assert.Equal(t, now, backups[0].CreatedAt)
+	})
+}
@@ -0,0 +1,37 @@
+// Package blob provides content-addressed storage for binary objects such as attachments.
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrNotFound is the error for a blob that does not exist in the store.
+var ErrNotFound = fmt.Errorf("blob not found")
+
+// Info describes a stored blob.
+type Info struct {
+	// Digest is the hex encoded SHA-256 of the content.
+	Digest string
+	// Size is the content length in bytes.
+	Size int64
+	// ModTime is when the blob was last written.
+	ModTime time.Time
+}
+
+// IStore is a content-addressed blob store.
+//
+// Blobs are identified by the SHA-256 digest of their content, so storing the
+// same content twice keeps a single copy.
+type IStore interface {
+	// Put stores the content read from r and returns its digest and size.
+	Put(ctx context.Context, r io.Reader) (*Info, error)
+	// Get opens the blob with the given digest. The caller must close it.
+	Get(ctx context.Context, digest string) (io.ReadCloser, error)
+	// Delete removes the blob with the given digest.
+	Delete(ctx context.Context, digest string) error
+	// List returns every blob in the store.
+	List(ctx context.Context) ([]*Info, error)
+}
@@ -0,0 +1,142 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var digestPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// InitLocalStore is the options for the local filesystem blob store.
+type InitLocalStore struct {
+	Dir string
+}
+
+type localStore struct {
+	dir string
+}
+
+// NewLocal returns a blob store that keeps blobs on the local filesystem under
+// dir/<first two hex chars>/<digest>. Directories are created on first write.
+func NewLocal(initLocalStore *InitLocalStore) IStore {
+	return &localStore{
+		dir: initLocalStore.Dir,
+	}
+}
+
+func (l *localStore) path(digest string) string {
+	return filepath.Join(l.dir, digest[:2], digest)
+}
+
+func (l *localStore) Put(_ context.Context, r io.Reader) (*Info, error) {
+	if err := os.MkdirAll(l.dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first so that a partially written upload is
+	// never visible under its digest.
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Digest: hex.EncodeToString(hash.Sum(nil)), Size: size}
+	dst := l.path(info.Digest)
+
+	// Deduplicate: identical content is already stored. Touch it so that a
+	// concurrent garbage collection treats it as freshly written.
+	if _, err := os.Stat(dst); err == nil {
+		now := time.Now()
+		if err := os.Chtimes(dst, now, now); err != nil {
+			return nil, err
+		}
+		info.ModTime = now
+		return info, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return nil, err
+	}
+	info.ModTime = time.Now()
+
+	return info, nil
+}
+
+func (l *localStore) Get(_ context.Context, digest string) (io.ReadCloser, error) {
+	if !digestPattern.MatchString(digest) {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(l.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (l *localStore) Delete(_ context.Context, digest string) error {
+	if !digestPattern.MatchString(digest) {
+		return ErrNotFound
+	}
+
+	err := os.Remove(l.path(digest))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (l *localStore) List(_ context.Context) ([]*Info, error) {
+	var blobs []*Info
+
+	err := filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == l.dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !digestPattern.MatchString(d.Name()) {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, &Info{Digest: d.Name(), Size: fi.Size(), ModTime: fi.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blobs, nil
+}
@@ -0,0 +1,58 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocal(&InitLocalStore{Dir: t.TempDir()})
+
+	// sha256("hello")
+	const digest = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+	first, err := store.Put(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, digest, first.Digest)
+	assert.Equal(t, int64(5), first.Size)
+
+	// Same content is deduplicated
+	second, err := store.Put(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, first.Digest, second.Digest)
+
+	blobs, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	assert.Equal(t, digest, blobs[0].Digest)
+
+	rc, err := store.Get(ctx, digest)
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	assert.Equal(t, "hello", string(content))
+
+	require.NoError(t, store.Delete(ctx, digest))
+	_, err = store.Get(ctx, digest)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, store.Delete(ctx, digest), ErrNotFound)
+
+	// Digests are validated so paths cannot escape the store
+	_, err = store.Get(ctx, "../../etc/passwd")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStore_ListMissingDir(t *testing.T) {
+	store := NewLocal(&InitLocalStore{Dir: t.TempDir() + "/missing"})
+
+	blobs, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+}
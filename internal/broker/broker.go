@@ -0,0 +1,24 @@
+// Package broker fans todo events out to the event streams of every replica.
+package broker
+
+import (
+	"context"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// DefaultBuffer is the number of events a subscriber may fall behind by.
+const DefaultBuffer = 64
+
+// IBroker delivers published events to every subscriber.
+//
+// A subscriber that falls more than its buffer behind is dropped: its channel
+// is closed, and the client is expected to reconnect and replay the missed
+// events from the event log.
+type IBroker interface {
+	// Publish delivers event to the current subscribers.
+	Publish(ctx context.Context, event *model.Event) error
+	// Subscribe returns a channel of the events published from now on. The
+	// channel is closed when ctx is done or the subscriber is dropped.
+	Subscribe(ctx context.Context) (<-chan *model.Event, error)
+}
@@ -0,0 +1,75 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func receive(t *testing.T, ch <-chan *model.Event) *model.Event {
+	t.Helper()
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "channel closed")
+		return event
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "no event received")
+		return nil
+	}
+}
+
+func testBroker(t *testing.T, b IBroker) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := b.Subscribe(ctx)
+	require.NoError(t, err)
+	second, err := b.Subscribe(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish(ctx, &model.Event{ID: 1, Type: model.EventTodoCreated, TodoID: 7, Project: "web", Data: `{"ID":7}`}))
+	for _, ch := range []<-chan *model.Event{first, second} {
+		event := receive(t, ch)
+		assert.Equal(t, uint(1), event.ID)
+		assert.Equal(t, model.EventTodoCreated, event.Type)
+		assert.Equal(t, "web", event.Project)
+		assert.Equal(t, `{"ID":7}`, event.Data)
+	}
+
+	// Cancelling the context closes the channel
+	cancel()
+	select {
+	case _, ok := <-first:
+		assert.False(t, ok)
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "channel not closed")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testBroker(t, NewMemory(&InitMemoryBroker{}))
+}
+
+func TestMemory_DropsSlowSubscriber(t *testing.T) {
+	b := NewMemory(&InitMemoryBroker{Buffer: 2})
+	ch, err := b.Subscribe(context.Background())
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, b.Publish(context.Background(), &model.Event{ID: uint(i)}))
+	}
+	assert.Equal(t, uint(1), receive(t, ch).ID)
+	assert.Equal(t, uint(2), receive(t, ch).ID)
+	_, ok := <-ch
+	assert.False(t, ok)
+}
+
+func TestRedis(t *testing.T) {
+	client := cache.New(&cache.Config{Addr: miniredis.RunT(t).Addr()})
+	testBroker(t, NewRedis(&InitRedisBroker{Client: client, Channel: "test-events", Log: log.New()}))
+}
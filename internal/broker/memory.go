@@ -0,0 +1,64 @@
+package broker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+type InitMemoryBroker struct {
+	// Buffer is the number of events a subscriber may fall behind by. Defaults to DefaultBuffer.
+	Buffer int
+}
+
+type memoryBroker struct {
+	buffer int
+
+	mu          sync.Mutex
+	subscribers map[chan *model.Event]struct{}
+}
+
+// NewMemory returns a broker that delivers events within this process only.
+func NewMemory(init *InitMemoryBroker) IBroker {
+	buffer := init.Buffer
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &memoryBroker{buffer: buffer, subscribers: map[chan *model.Event]struct{}{}}
+}
+
+func (b *memoryBroker) Publish(_ context.Context, event *model.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// The subscriber is too far behind: drop it so that it resumes from the log.
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) Subscribe(ctx context.Context) (<-chan *model.Event, error) {
+	ch := make(chan *model.Event, b.buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}()
+	return ch, nil
+}
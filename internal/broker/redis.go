@@ -0,0 +1,91 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// DefaultChannel is the Redis channel events are published on by default.
+const DefaultChannel = "todo-events"
+
+type InitRedisBroker struct {
+	Client *redis.Client
+	// Channel is the Redis channel to publish on. Defaults to DefaultChannel.
+	Channel string
+	// Buffer is the number of events a subscriber may fall behind by. Defaults to DefaultBuffer.
+	Buffer int
+	Log    *log.Logger
+}
+
+type redisBroker struct {
+	client  *redis.Client
+	channel string
+	buffer  int
+	log     *log.Logger
+}
+
+// NewRedis returns a broker that delivers events to the subscribers of every
+// replica through Redis Pub/Sub.
+func NewRedis(init *InitRedisBroker) IBroker {
+	channel := init.Channel
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	buffer := init.Buffer
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &redisBroker{client: init.Client, channel: channel, buffer: buffer, log: init.Log}
+}
+
+func (b *redisBroker) Publish(ctx context.Context, event *model.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *redisBroker) Subscribe(ctx context.Context) (<-chan *model.Event, error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// Wait for the confirmation so that no event published after Subscribe returns is missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	ch := make(chan *model.Event, b.buffer)
+	go func() {
+		defer close(ch)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				event := &model.Event{}
+				if err := json.Unmarshal([]byte(msg.Payload), event); err != nil {
+					b.log.Error(ctx, fmt.Sprintf("invalid event on %s: %s", b.channel, err.Error()))
+					continue
+				}
+				select {
+				case ch <- event:
+				default:
+					// The subscriber is too far behind: drop it so that it resumes from the log.
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,178 @@
+// Package client is the client of the todo API, for the todo commands of
+// todo-cli to work with a running server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// APIPrefix is the path of the API on the server.
+const APIPrefix = "/api/v1"
+
+// Error is the error for a request the server did not accept, with the
+// problem details it answered.
+type Error struct {
+	apperrors.Problem
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s", e.Status, http.StatusText(e.Status))
+	if e.Detail != "" {
+		fmt.Fprintf(&b, ": %s", e.Detail)
+	}
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "; %s: %s", v.Field, v.Message)
+	}
+	return b.String()
+}
+
+// ITodo is the client of the todo endpoints.
+type ITodo interface {
+	Create(ctx context.Context, reqParams *model.CreateRequest) (*model.Todo, error)
+	Update(ctx context.Context, reqParams *model.UpdateRequest) (*model.Todo, error)
+	Delete(ctx context.Context, reqParams *model.DeleteRequest) error
+	Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error)
+	FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error)
+}
+
+type InitTodoClient struct {
+	// URL is the address of the server, such as http://localhost:8080.
+	URL string
+	// Token is sent as a bearer token when it is set.
+	Token string
+	// HTTPClient defaults to a client with a timeout of 30 seconds.
+	HTTPClient *http.Client
+}
+
+type todoClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewTodo returns a new client of the todo endpoints.
+func NewTodo(initTodoClient *InitTodoClient) ITodo {
+	httpClient := initTodoClient.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &todoClient{
+		baseURL:    strings.TrimSuffix(initTodoClient.URL, "/") + APIPrefix,
+		token:      initTodoClient.Token,
+		httpClient: httpClient,
+	}
+}
+
+func (t *todoClient) Create(ctx context.Context, reqParams *model.CreateRequest) (*model.Todo, error) {
+	var todo model.Todo
+	if err := t.do(ctx, http.MethodPost, "/todos", reqParams, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (t *todoClient) Update(ctx context.Context, reqParams *model.UpdateRequest) (*model.Todo, error) {
+	var todo model.Todo
+	if err := t.do(ctx, http.MethodPut, todoPath(reqParams.ID), &reqParams.UpdateRequestBody, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (t *todoClient) Delete(ctx context.Context, reqParams *model.DeleteRequest) error {
+	return t.do(ctx, http.MethodDelete, todoPath(reqParams.ID), nil, nil)
+}
+
+func (t *todoClient) Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error) {
+	var todo model.Todo
+	if err := t.do(ctx, http.MethodGet, todoPath(reqParams.ID), nil, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (t *todoClient) FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"task": reqParams.Task, "status": reqParams.Status, "project": reqParams.Project,
+		"assignee": reqParams.Assignee, "render": reqParams.Render, "sort": reqParams.Sort,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	path := "/todos"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var todos []*model.Todo
+	if err := t.do(ctx, http.MethodGet, path, nil, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func todoPath(id int) string {
+	return "/todos/" + strconv.Itoa(id)
+}
+
+// do sends a request with body as JSON, and reads the data of the response into data.
+// Responses other than 2xx are returned as *Error.
+func (t *todoClient) do(ctx context.Context, method, path string, body, data interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if t.token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiErr := &Error{}
+		if err := json.NewDecoder(res.Body).Decode(&apiErr.Problem); err != nil || apiErr.Status == 0 {
+			apiErr.Status = res.StatusCode
+		}
+		return apiErr
+	}
+	if data == nil {
+		return nil
+	}
+	envelope := struct{ Data interface{} }{Data: data}
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to read the response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
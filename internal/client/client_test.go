@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// request is a request received by the test server.
+type request struct {
+	Method, URI, Authorization, ContentType, Body string
+}
+
+// newServer returns a client of a server that answers with status and body,
+// and the requests it received.
+func newServer(t *testing.T, status int, body string) (ITodo, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{
+			Method: r.Method, URI: r.RequestURI, Authorization: r.Header.Get("Authorization"),
+			ContentType: r.Header.Get("Content-Type"), Body: string(b),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return NewTodo(&InitTodoClient{URL: server.URL + "/", Token: "secret"}), &requests
+}
+
+func TestTodoClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("find_all", func(t *testing.T) {
+		client, requests := newServer(t, http.StatusOK, `{"data":[{"ID":1,"Task":"Ship release","Status":"processing","Priority":"high"}]}`)
+
+		todos, err := client.FindAll(ctx, &model.FindAllRequest{Status: "processing", Assignee: "alice bob", Sort: model.SortManual})
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		assert.Equal(t, "Ship release", todos[0].Task)
+		assert.Equal(t, model.Processing, todos[0].Status)
+		assert.Equal(t, []request{{
+			Method: http.MethodGet, URI: "/api/v1/todos?assignee=alice+bob&sort=manual&status=processing",
+			Authorization: "Bearer secret",
+		}}, *requests)
+	})
+
+	t.Run("find_all_without_filters", func(t *testing.T) {
+		client, requests := newServer(t, http.StatusOK, `{"data":[]}`)
+
+		todos, err := client.FindAll(ctx, &model.FindAllRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, todos)
+		assert.Equal(t, "/api/v1/todos", (*requests)[0].URI)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		client, requests := newServer(t, http.StatusCreated, `{"data":{"ID":3,"Task":"Write notes","Status":"created","Priority":"low"}}`)
+
+		todo, err := client.Create(ctx, &model.CreateRequest{Task: "Write notes", Priority: "low", Tags: []string{"docs"}})
+		require.NoError(t, err)
+		assert.Equal(t, 3, todo.ID)
+		require.Len(t, *requests, 1)
+		assert.Equal(t, http.MethodPost, (*requests)[0].Method)
+		assert.Equal(t, "/api/v1/todos", (*requests)[0].URI)
+		assert.Equal(t, "application/json", (*requests)[0].ContentType)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte((*requests)[0].Body), &body))
+		assert.Equal(t, "Write notes", body["task"])
+		assert.Equal(t, []interface{}{"docs"}, body["tags"])
+	})
+
+	t.Run("update_sends_the_fields_set", func(t *testing.T) {
+		client, requests := newServer(t, http.StatusOK, `{"data":{"ID":3,"Task":"Write notes","Status":"done"}}`)
+
+		todo, err := client.Update(ctx, &model.UpdateRequest{
+			UpdateRequestBody: model.UpdateRequestBody{Status: model.Done},
+			UpdateRequestPath: model.UpdateRequestPath{ID: 3},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, model.Done, todo.Status)
+		assert.Equal(t, http.MethodPut, (*requests)[0].Method)
+		assert.Equal(t, "/api/v1/todos/3", (*requests)[0].URI)
+		assert.JSONEq(t, `{"status":"done"}`, (*requests)[0].Body)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		client, requests := newServer(t, http.StatusOK, `"Deleted successfully"`)
+
+		require.NoError(t, client.Delete(ctx, &model.DeleteRequest{ID: 3}))
+		assert.Equal(t, http.MethodDelete, (*requests)[0].Method)
+		assert.Equal(t, "/api/v1/todos/3", (*requests)[0].URI)
+		assert.Empty(t, (*requests)[0].Body)
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		client, _ := newServer(t, http.StatusBadRequest, `{"type":"urn:problem-type:validation-failed","title":"Bad Request",`+
+			`"status":400,"detail":"The request has invalid fields.","code":"VALIDATION_FAILED",`+
+			`"violations":[{"field":"task","rule":"required","message":"task is required"}]}`)
+
+		_, err := client.Create(ctx, &model.CreateRequest{Priority: "low"})
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, "VALIDATION_FAILED", apiErr.Code)
+		assert.EqualError(t, err, "400 Bad Request: The request has invalid fields.; task: task is required")
+	})
+
+	t.Run("error_without_problem", func(t *testing.T) {
+		client, _ := newServer(t, http.StatusBadGateway, `<html>Bad Gateway</html>`)
+
+		_, err := client.Find(ctx, &model.FindRequest{ID: 1})
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusBadGateway, apiErr.Status)
+		assert.EqualError(t, err, "502 Bad Gateway")
+	})
+}
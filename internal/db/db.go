@@ -2,29 +2,224 @@
 package db
 
 import (
+	"database/sql"
+	"fmt"
+	stdlog "log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// defaultDatabase and defaultSQLite are the settings of New and Open, as in
+// the default configuration.
+var (
+	defaultDatabase = model.Database{LogLevel: "warn", SlowThreshold: 200 * time.Millisecond}
+	defaultSQLite   = model.SQLite{
+		JournalMode: "wal", Synchronous: "normal", BusyTimeout: 5 * time.Second, ForeignKeys: true, MaxReadConns: 4,
+	}
 )
 
+var logLevels = map[string]logger.LogLevel{
+	"silent": logger.Silent,
+	"error":  logger.Error,
+	"warn":   logger.Warn,
+	"info":   logger.Info,
+}
+
 // New creates a new database connection
 func New(filename string) (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(filename), &gorm.Config{})
+	return Open(model.DriverSQLite, filename)
+}
+
+// Open creates a new database connection with the driver, one of model.DriverSQLite,
+// model.DriverPostgres or model.DriverMySQL. For SQLite, dsn is the file name.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	return open(driver, dsn, defaultDatabase, defaultSQLite)
+}
+
+// FromConfig creates a new database connection with the driver of the configuration.
+func FromConfig(config *model.Config) (*gorm.DB, error) {
+	if config.Database.Driver == "" || config.Database.Driver == model.DriverSQLite {
+		return open(model.DriverSQLite, config.SQLite.DBFilename, config.Database, config.SQLite)
+	}
+	return open(config.Database.Driver, config.Database.DSN, config.Database, config.SQLite)
+}
+
+func open(driver, dsn string, database model.Database, sqliteConfig model.SQLite) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case model.DriverSQLite:
+		// Transactions take the write lock up front: one that read first could not
+		// upgrade while the outbox dispatcher writes, and would fail at once
+		// instead of waiting out the busy timeout.
+		dialector = sqlite.Open(withParam(sqliteDSN(dsn, sqliteConfig), "_txlock=immediate"))
+	case model.DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case model.DriverMySQL:
+		// Times are scanned into time.Time rather than []byte.
+		if !strings.Contains(dsn, "parseTime=") {
+			dsn = withParam(dsn, "parseTime=true")
+		}
+		dialector = mysql.Open(dsn)
+	default:
+		return nil, fmt.Errorf("unknown database driver: %q", driver)
+	}
 
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: newLogger(database)})
 	if err != nil {
 		return nil, err
 	}
-	db = db.Debug()
+	if driver == model.DriverSQLite && sqliteConfig.MaxReadConns > 0 {
+		if err := splitPools(db, dsn, sqliteConfig); err != nil {
+			Close(db)
+			return nil, err
+		}
+	}
 
 	return db, nil
 }
 
 // NewMemory creates a new in-memory database connection
 func NewMemory() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	return gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{Logger: newLogger(defaultDatabase)})
+}
+
+// newLogger returns the logger of the SQL statements at database.LogLevel.
+func newLogger(database model.Database) logger.Interface {
+	level, ok := logLevels[database.LogLevel]
+	if !ok {
+		level = logLevels[defaultDatabase.LogLevel]
+	}
+	return logger.New(stdlog.New(os.Stdout, "\r\n", stdlog.LstdFlags), logger.Config{
+		SlowThreshold:             database.SlowThreshold,
+		LogLevel:                  level,
+		IgnoreRecordNotFoundError: true,
+		Colorful:                  true,
+	})
+}
+
+// sqliteDSN adds the connection settings of config to the file name. They
+// apply to every connection of the pool, unlike a PRAGMA statement.
+func sqliteDSN(filename string, config model.SQLite) string {
+	dsn := filename
+	if config.JournalMode != "" {
+		dsn = withParam(dsn, "_journal_mode="+strings.ToUpper(config.JournalMode))
+	}
+	if config.Synchronous != "" {
+		dsn = withParam(dsn, "_synchronous="+strings.ToUpper(config.Synchronous))
+	}
+	if config.BusyTimeout > 0 {
+		dsn = withParam(dsn, fmt.Sprintf("_busy_timeout=%d", config.BusyTimeout.Milliseconds()))
+	}
+	if config.ForeignKeys {
+		dsn = withParam(dsn, "_foreign_keys=1")
+	}
+	return dsn
+}
+
+// readPool is the plugin sending the reads outside a transaction to its
+// connections, and the other statements to those of the database.
+type readPool struct {
+	*sql.DB
+}
+
+func (readPool) Name() string {
+	return "todo:read_pool"
+}
+
+func (p readPool) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Query().Before("*").Register(p.Name(), p.switchRead),
+		callbacks.Row().Before("*").Register(p.Name(), p.switchRead),
+		callbacks.Raw().Before("*").Register(p.Name(), p.switchRead),
+		callbacks.Create().Before("*").Register(p.Name(), p.switchWrite),
+		callbacks.Update().Before("*").Register(p.Name(), p.switchWrite),
+		callbacks.Delete().Before("*").Register(p.Name(), p.switchWrite),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// switchRead sends a read to the read pool. Raw SQL is set already, and only
+// a SELECT is known to be a read; the SQL of Find and the like is built
+// afterwards, always as a SELECT.
+func (p readPool) switchRead(db *gorm.DB) {
+	sql := strings.TrimSpace(db.Statement.SQL.String())
+	if sql != "" && (len(sql) < 6 || !strings.EqualFold(sql[:6], "select")) {
+		p.switchWrite(db)
+		return
+	}
+	if !inTransaction(db) {
+		db.Statement.ConnPool = p.DB
+	}
+}
+
+// switchWrite sends a write to the connections of the database, as a chained
+// statement may have kept the read pool of the previous one.
+func (p readPool) switchWrite(db *gorm.DB) {
+	if !inTransaction(db) {
+		db.Statement.ConnPool = db.Config.ConnPool
+	}
+}
+
+func inTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// splitPools sends the writes and transactions of db to a single connection
+// and the reads to a pool of config.MaxReadConns connections. SQLite has one
+// writer at a time: writers queue for the connection instead of retrying for
+// the lock, while the readers of a WAL database go on alongside them.
+func splitPools(db *gorm.DB, filename string, config model.SQLite) error {
+	writeDB, err := db.DB()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	db = db.Debug()
+	writeDB.SetMaxOpenConns(1)
 
-	return db, nil
+	readDB, err := sql.Open(sqlite.DriverName, withParam(sqliteDSN(filename, config), "_query_only=1"))
+	if err != nil {
+		return err
+	}
+	readDB.SetMaxOpenConns(config.MaxReadConns)
+	readDB.SetMaxIdleConns(config.MaxReadConns)
+	if err := db.Use(readPool{readDB}); err != nil {
+		readDB.Close()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connections of db, including its read pool.
+func Close(db *gorm.DB) error {
+	if plugin, ok := db.Config.Plugins[readPool{}.Name()]; ok {
+		if err := plugin.(readPool).Close(); err != nil {
+			return err
+		}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// withParam adds the query parameter param to dsn.
+func withParam(dsn, param string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + param
+	}
+	return dsn + "?" + param
 }
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+func newMigratedDB(t *testing.T) *gorm.DB {
+	dbInstance := newTestDB(t)
+	require.NoError(t, Migrate(dbInstance))
+	t.Cleanup(func() { _ = Close(dbInstance) })
+	return dbInstance
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("pragmas", func(t *testing.T) {
+		dbInstance := newMigratedDB(t)
+		for pragma, want := range map[string]string{
+			"journal_mode": "wal", "synchronous": "1", "busy_timeout": "5000", "foreign_keys": "1", "query_only": "0",
+		} {
+			var got string
+			require.NoError(t, dbInstance.Raw("PRAGMA "+pragma).Scan(&got).Error)
+			assert.Equal(t, want, got, pragma)
+		}
+	})
+
+	t.Run("reads_alongside_a_write", func(t *testing.T) {
+		dbInstance := newMigratedDB(t)
+		require.NoError(t, dbInstance.Create(&model.Todo{Task: "Committed"}).Error)
+
+		err := dbInstance.Transaction(func(tx *gorm.DB) error {
+			require.NoError(t, tx.Create(&model.Todo{Task: "Uncommitted"}).Error)
+
+			// The write connection is taken by the transaction: the read goes
+			// to the read pool and sees the committed todos only.
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var tasks []string
+			require.NoError(t, dbInstance.WithContext(ctx).Model(&model.Todo{}).Pluck("task", &tasks).Error)
+			assert.Equal(t, []string{"Committed"}, tasks)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("chained_write_after_read", func(t *testing.T) {
+		dbInstance := newMigratedDB(t)
+		require.NoError(t, dbInstance.Create(&model.Todo{Task: "A"}).Error)
+
+		// The statement of the chain is shared by the read and the write
+		query := dbInstance.Where("task = ?", "A")
+		var todos []*model.Todo
+		require.NoError(t, query.Find(&todos).Error)
+		assert.Len(t, todos, 1)
+		require.NoError(t, query.Delete(&model.Todo{}).Error)
+	})
+
+	t.Run("empty_settings", func(t *testing.T) {
+		dbInstance, err := FromConfig(&model.Config{
+			Database: model.Database{Driver: model.DriverSQLite, LogLevel: "silent"},
+			SQLite:   model.SQLite{DBFilename: t.TempDir() + "/todos.db"},
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = Close(dbInstance) })
+		var mode string
+		require.NoError(t, dbInstance.Raw("PRAGMA journal_mode").Scan(&mode).Error)
+		// Empty settings keep the defaults of SQLite
+		assert.Equal(t, "delete", mode)
+	})
+}
+
+// TestConcurrentWrites writes from many goroutines while others read, as the
+// handlers and the outbox dispatcher do: no statement may fail with
+// "database is locked".
+func TestConcurrentWrites(t *testing.T) {
+	if testing.Short() {
+		t.Skip("stress test")
+	}
+	const (
+		writers      = 8
+		readers      = 8
+		transactions = 50
+	)
+	dbInstance := newMigratedDB(t)
+
+	var (
+		wg      sync.WaitGroup
+		readWg  sync.WaitGroup
+		done    = make(chan struct{})
+		errs    = make(chan error, writers+readers)
+		queries = make([]int, readers)
+	)
+	for w := 0; w < writers; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < transactions; i++ {
+				err := dbInstance.Transaction(func(tx *gorm.DB) error {
+					todo := &model.Todo{Task: fmt.Sprintf("%d-%d", w, i), Status: model.Created}
+					if err := tx.Create(todo).Error; err != nil {
+						return err
+					}
+					event, err := model.NewEvent(model.EventTodoCreated, todo)
+					if err != nil {
+						return err
+					}
+					return tx.Create(event).Error
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for r := 0; r < readers; r++ {
+		r := r
+		readWg.Add(1)
+		go func() {
+			defer readWg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				var todos []*model.Todo
+				if err := dbInstance.Where("status = ?", model.Created).Limit(20).Find(&todos).Error; err != nil {
+					errs <- err
+					return
+				}
+				queries[r]++
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readWg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var todos, events int64
+	require.NoError(t, dbInstance.Model(&model.Todo{}).Count(&todos).Error)
+	require.NoError(t, dbInstance.Model(&model.Event{}).Count(&events).Error)
+	assert.EqualValues(t, writers*transactions, todos)
+	assert.EqualValues(t, writers*transactions, events)
+	for r, n := range queries {
+		assert.NotZero(t, n, "reader %d", r)
+	}
+}
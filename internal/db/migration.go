@@ -1,20 +1,472 @@
 package db
 
 import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
 	"gorm.io/gorm"
 )
 
-// Migrate runs the auto-migration for the database
-func Migrate(db *gorm.DB) error {
-	if err := db.AutoMigrate(&model.Todo{}); err != nil {
-		return err
+// migrationFiles holds the migrations of every driver, in migrations/<driver>.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// drivers are the drivers with migrations. A new migration is created for each of them.
+var drivers = []string{model.DriverSQLite, model.DriverPostgres, model.DriverMySQL}
+
+var (
+	migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+	migrationName = regexp.MustCompile(`^\w+$`)
+)
+
+// Migration is a numbered schema change, read from <version>_<name>.up.sql
+// and the <version>_<name>.down.sql undoing it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+	// Checksum is the SHA-256 of Up, in hex.
+	Checksum string
+}
+
+// String returns the file name of the migration without its direction.
+func (m *Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Models returns the models stored in the tables the migrations create.
+func Models() []interface{} {
+	return append(initModels(), &model.CalendarFeed{}, &model.CalDAVObject{}, &model.EventSink{})
+}
+
+// initModels returns the models of the tables of the first migration, those
+// AutoMigrate created before the versioned migrations.
+func initModels() []interface{} {
+	return []interface{}{
+		&model.Todo{}, &model.Attachment{}, &model.TimeEntry{}, &model.IdempotencyRecord{}, &model.Event{},
+		&model.Webhook{}, &model.WebhookDelivery{}, &model.TodoEvent{}, &model.TodoSnapshot{},
+	}
+}
+
+// Migrations returns the migrations of driver by version.
+func Migrations(driver string) ([]*Migration, error) {
+	return readMigrations(migrationFiles, path.Join("migrations", driver))
+}
+
+func readMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
 	}
 
-	err := db.Exec("CREATE INDEX IF NOT EXISTS idx_status ON todos (status)").Error
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %s needs both an up and a down file", migration)
+		}
+		sum := sha256.Sum256([]byte(migration.Up))
+		migration.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// migrator applies the migrations of the driver of a database.
+type migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+	applied    map[int]*model.SchemaMigration
+}
+
+func newMigrator(db *gorm.DB) (*migrator, error) {
+	migrations, err := Migrations(db.Dialector.Name())
 	if err != nil {
+		return nil, err
+	}
+	m := &migrator{db: db, migrations: migrations, applied: map[int]*model.SchemaMigration{}}
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+
+	var applied []*model.SchemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	for _, record := range applied {
+		m.applied[record.Version] = record
+	}
+	return m, nil
+}
+
+// prepare creates the schema_migrations table. A database created by
+// AutoMigrate, before the versioned migrations, is brought to the schema of
+// the first migration, which is then recorded as applied instead of run: the
+// database may come from any earlier build, down to a todos table alone.
+func (m *migrator) prepare() error {
+	if m.db.Migrator().HasTable(&model.SchemaMigration{}) {
+		return nil
+	}
+	adopt := len(m.migrations) > 0 && m.db.Migrator().HasTable(&model.Todo{})
+	if adopt {
+		if err := autoMigrate(m.db); err != nil {
+			return fmt.Errorf("failed to upgrade the schema before %s: %w", m.migrations[0], err)
+		}
+	}
+	if err := m.db.Migrator().CreateTable(&model.SchemaMigration{}); err != nil {
 		return err
 	}
+	if adopt {
+		return m.db.Create(record(m.migrations[0])).Error
+	}
+	return nil
+}
+
+// autoMigrate creates the tables, columns and indexes of the first migration
+// missing from a database created by AutoMigrate, as Migrate did before the
+// versioned migrations. It leaves those already there.
+func autoMigrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(initModels()...); err != nil {
+		return err
+	}
+
+	// MySQL has no CREATE INDEX IF NOT EXISTS, so the indexes are looked up first.
+	if !db.Migrator().HasIndex(&model.Todo{}, "idx_status") {
+		if err := db.Exec("CREATE INDEX idx_status ON todos (status)").Error; err != nil {
+			return err
+		}
+	}
+
+	// A user can only have one running timer.
+	if !db.Migrator().HasIndex(&model.TimeEntry{}, "idx_time_entries_running") {
+		running := "CREATE UNIQUE INDEX idx_time_entries_running ON time_entries (user_name) WHERE ended_at IS NULL"
+		if db.Dialector.Name() == model.DriverMySQL {
+			// MySQL has no partial indexes, but NULLs never collide in a unique index.
+			running = "CREATE UNIQUE INDEX idx_time_entries_running ON time_entries ((CASE WHEN ended_at IS NULL THEN user_name END))"
+		}
+		if err := db.Exec(running).Error; err != nil {
+			return err
+		}
+	}
 
+	// Ranks compare byte by byte, as in SQLite, rather than by the language
+	// rules of the server's default collation.
+	switch db.Dialector.Name() {
+	case model.DriverPostgres:
+		return db.Exec(`ALTER TABLE todos ALTER COLUMN sort_rank TYPE text COLLATE "C"`).Error
+	case model.DriverMySQL:
+		return db.Exec("ALTER TABLE todos MODIFY sort_rank varchar(191) COLLATE utf8mb4_bin").Error
+	}
 	return nil
 }
+
+func record(migration *Migration) *model.SchemaMigration {
+	return &model.SchemaMigration{
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Checksum:  migration.Checksum,
+		AppliedAt: time.Now().UTC(),
+	}
+}
+
+func (m *migrator) find(version int) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+	return nil
+}
+
+// check returns an error if an applied migration is unknown or was edited:
+// the schema is then not the one the migrations describe.
+func (m *migrator) check() error {
+	for version, applied := range m.applied {
+		migration := m.find(version)
+		if migration == nil {
+			return fmt.Errorf("%w: %04d_%s", model.ErrUnknownMigration, version, applied.Name)
+		}
+		if migration.Checksum != applied.Checksum {
+			return fmt.Errorf("%w: %s", model.ErrMigrationChecksum, migration)
+		}
+	}
+	return nil
+}
+
+// run applies or reverts migration and updates schema_migrations in one
+// transaction. MySQL commits each statement changing the schema on its own, so
+// a failing migration may be left half applied there.
+func (m *migrator) run(migration *Migration, up bool) error {
+	sql := migration.Down
+	if up {
+		sql = migration.Up
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(sql) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(record(migration)).Error
+		}
+		return tx.Delete(&model.SchemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", migration, err)
+	}
+
+	if up {
+		m.applied[migration.Version] = record(migration)
+	} else {
+		delete(m.applied, migration.Version)
+	}
+	return nil
+}
+
+// migrateTo applies the pending migrations up to version, then reverts the
+// applied ones above it.
+func (m *migrator) migrateTo(version int) ([]*Migration, error) {
+	applied, err := m.apply(version)
+	if err != nil {
+		return applied, err
+	}
+	reverted, err := m.revert(version)
+	return append(applied, reverted...), err
+}
+
+// apply applies the pending migrations up to version, in order.
+func (m *migrator) apply(version int) ([]*Migration, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for _, migration := range m.migrations {
+		if _, ok := m.applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.run(migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// revert reverts the applied migrations above version, latest first.
+func (m *migrator) revert(version int) ([]*Migration, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := m.applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.run(migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// statements splits sql into its statements, each ending with a semicolon at
+// the end of a line: not every driver runs several statements at once.
+func statements(sql string) []string {
+	var (
+		result    []string
+		statement strings.Builder
+	)
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// Migrate applies the pending migrations to the database.
+func Migrate(db *gorm.DB) error {
+	_, err := MigrateUp(db)
+	return err
+}
+
+// MigrateUp applies the pending migrations and returns them.
+func MigrateUp(db *gorm.DB) ([]*Migration, error) {
+	m, err := newMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	if len(m.migrations) > 0 {
+		latest = m.migrations[len(m.migrations)-1].Version
+	}
+	return m.apply(latest)
+}
+
+// MigrateDown reverts the n latest applied migrations and returns them.
+func MigrateDown(db *gorm.DB, n int) ([]*Migration, error) {
+	m, err := newMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(m.applied))
+	for version := range m.applied {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+
+	target := 0
+	if n < len(versions) {
+		target = versions[len(versions)-n-1]
+	}
+	return m.revert(target)
+}
+
+// MigrateTo applies or reverts the migrations so that version is the latest
+// applied one, and returns them. Version 0 reverts every migration.
+func MigrateTo(db *gorm.DB, version int) ([]*Migration, error) {
+	m, err := newMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("unknown migration version: %d", version)
+	}
+	return m.migrateTo(version)
+}
+
+// MigrationStatuses returns the state of every migration, and of the applied
+// migrations unknown to this build, by version.
+func MigrationStatuses(db *gorm.DB) ([]*model.MigrationStatus, error) {
+	m, err := newMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []*model.MigrationStatus
+	for _, migration := range m.migrations {
+		status := &model.MigrationStatus{Version: migration.Version, Name: migration.Name, State: model.MigrationPending}
+		if applied, ok := m.applied[migration.Version]; ok {
+			status.State, status.AppliedAt = model.MigrationApplied, &applied.AppliedAt
+			if applied.Checksum != migration.Checksum {
+				status.State = model.MigrationModified
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	for version, applied := range m.applied {
+		if m.find(version) == nil {
+			statuses = append(statuses, &model.MigrationStatus{
+				Version: version, Name: applied.Name, State: model.MigrationUnknown, AppliedAt: &applied.AppliedAt,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// CreateMigration writes the up and down files of a new migration named name
+// for every driver, in the migrations directory dir of the source tree, and
+// returns their paths. The migrations are embedded when the binary is built.
+func CreateMigration(dir, name string) ([]string, error) {
+	if !migrationName.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+
+	version := 1
+	for _, driver := range drivers {
+		migrations, err := readMigrations(os.DirFS(dir), driver)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, migration := range migrations {
+			if migration.Version >= version {
+				version = migration.Version + 1
+			}
+		}
+	}
+
+	var paths []string
+	for _, driver := range drivers {
+		if err := os.MkdirAll(filepath.Join(dir, driver), os.ModePerm); err != nil {
+			return paths, err
+		}
+		for _, direction := range []string{"up", "down"} {
+			p := filepath.Join(dir, driver, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+			if err != nil {
+				return paths, err
+			}
+			_, err = fmt.Fprintf(f, "-- %s %s for %s: end each statement with a semicolon at the end of a line.\n",
+				name, direction, driver)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return paths, err
+			}
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
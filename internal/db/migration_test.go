@@ -0,0 +1,180 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	dbInstance, err := New(filepath.Join(t.TempDir(), "todos.db"))
+	require.NoError(t, err)
+	return dbInstance
+}
+
+func states(t *testing.T, dbInstance *gorm.DB) map[int]string {
+	statuses, err := MigrationStatuses(dbInstance)
+	require.NoError(t, err)
+	result := map[int]string{}
+	for _, status := range statuses {
+		result[status.Version] = status.State
+	}
+	return result
+}
+
+func TestMigrations(t *testing.T) {
+	for _, driver := range drivers {
+		migrations, err := Migrations(driver)
+		require.NoError(t, err, driver)
+		require.NotEmpty(t, migrations, driver)
+		for i, migration := range migrations {
+			assert.Equal(t, i+1, migration.Version, "%s %s", driver, migration)
+			assert.NotEmpty(t, statements(migration.Up), "%s %s", driver, migration)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("models_match_schema", func(t *testing.T) {
+		dbInstance := newTestDB(t)
+		require.NoError(t, Migrate(dbInstance))
+
+		for _, m := range Models() {
+			stmt := &gorm.Statement{DB: dbInstance}
+			require.NoError(t, stmt.Parse(m))
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					assert.True(t, dbInstance.Migrator().HasColumn(m, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+				}
+			}
+		}
+	})
+
+	t.Run("up_down_goto", func(t *testing.T) {
+		dbInstance := newTestDB(t)
+		migrations, err := Migrations(model.DriverSQLite)
+		require.NoError(t, err)
+
+		applied, err := MigrateUp(dbInstance)
+		require.NoError(t, err)
+		assert.Equal(t, migrations, applied)
+		applied, err = MigrateUp(dbInstance)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, model.MigrationApplied, states(t, dbInstance)[1])
+
+		reverted, err := MigrateDown(dbInstance, len(migrations)+1)
+		require.NoError(t, err)
+		assert.Len(t, reverted, len(migrations))
+		assert.False(t, dbInstance.Migrator().HasTable(&model.Todo{}))
+		assert.Equal(t, model.MigrationPending, states(t, dbInstance)[1])
+
+		done, err := MigrateTo(dbInstance, 1)
+		require.NoError(t, err)
+		assert.Equal(t, migrations[:1], done)
+		assert.True(t, dbInstance.Migrator().HasTable(&model.Todo{}))
+		_, err = MigrateTo(dbInstance, len(migrations)+1)
+		assert.Error(t, err)
+	})
+
+	t.Run("adopts_auto_migrated_database", func(t *testing.T) {
+		dbInstance := newTestDB(t)
+		require.NoError(t, dbInstance.AutoMigrate(&model.Todo{}))
+
+		_, err := MigrateUp(dbInstance)
+		require.NoError(t, err)
+		assert.Equal(t, model.MigrationApplied, states(t, dbInstance)[1])
+	})
+
+	t.Run("upgrades_baseline_database", func(t *testing.T) {
+		// The todos table of the first release, created by AutoMigrate.
+		dbInstance := newTestDB(t)
+		require.NoError(t, dbInstance.Exec("CREATE TABLE `todos` (`id` integer PRIMARY KEY AUTOINCREMENT,`task` text,"+
+			"`status` text,`priority` text,`created_at` datetime,`updated_at` datetime)").Error)
+		require.NoError(t, dbInstance.Exec("CREATE INDEX idx_status ON todos (status)").Error)
+		require.NoError(t, dbInstance.Exec("INSERT INTO todos (task, status, priority) VALUES ('Ship release', 'created', 'high')").Error)
+
+		require.NoError(t, Migrate(dbInstance))
+		for _, state := range states(t, dbInstance) {
+			assert.Equal(t, model.MigrationApplied, state)
+		}
+		for _, m := range Models() {
+			stmt := &gorm.Statement{DB: dbInstance}
+			require.NoError(t, stmt.Parse(m))
+			for _, field := range stmt.Schema.Fields {
+				if field.DBName != "" {
+					assert.True(t, dbInstance.Migrator().HasColumn(m, field.DBName), "%s.%s", stmt.Schema.Table, field.DBName)
+				}
+			}
+		}
+		assert.True(t, dbInstance.Migrator().HasIndex(&model.TimeEntry{}, "idx_time_entries_running"))
+
+		var todo model.Todo
+		require.NoError(t, dbInstance.First(&todo).Error)
+		assert.Equal(t, "Ship release", todo.Task)
+		require.NoError(t, dbInstance.Create(&model.Todo{Task: "Write notes", Description: "**soon**", Project: "docs"}).Error)
+	})
+
+	t.Run("edited_migration", func(t *testing.T) {
+		dbInstance := newTestDB(t)
+		require.NoError(t, Migrate(dbInstance))
+		require.NoError(t, dbInstance.Model(&model.SchemaMigration{}).Where("version = ?", 1).
+			Update("checksum", "edited").Error)
+
+		assert.Equal(t, model.MigrationModified, states(t, dbInstance)[1])
+		_, err := MigrateUp(dbInstance)
+		assert.ErrorIs(t, err, model.ErrMigrationChecksum)
+		_, err = MigrateDown(dbInstance, 1)
+		assert.ErrorIs(t, err, model.ErrMigrationChecksum)
+	})
+
+	t.Run("unknown_migration", func(t *testing.T) {
+		dbInstance := newTestDB(t)
+		require.NoError(t, Migrate(dbInstance))
+		require.NoError(t, dbInstance.Create(&model.SchemaMigration{Version: 999, Name: "newer"}).Error)
+
+		assert.Equal(t, model.MigrationUnknown, states(t, dbInstance)[999])
+		_, err := MigrateUp(dbInstance)
+		assert.ErrorIs(t, err, model.ErrUnknownMigration)
+	})
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, model.DriverSQLite), os.ModePerm))
+	for _, name := range []string{"0003_old.up.sql", "0003_old.down.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, model.DriverSQLite, name), []byte("SELECT 1;\n"), 0o644))
+	}
+
+	paths, err := CreateMigration(dir, "add_color")
+	require.NoError(t, err)
+	assert.Len(t, paths, 2*len(drivers))
+	assert.Contains(t, paths, filepath.Join(dir, model.DriverMySQL, "0004_add_color.down.sql"))
+
+	migrations, err := readMigrations(os.DirFS(dir), model.DriverPostgres)
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Empty(t, statements(migrations[0].Up))
+
+	_, err = CreateMigration(dir, "bad name")
+	assert.Error(t, err)
+}
+
+func TestStatements(t *testing.T) {
+	sql := `-- A comment
+CREATE TABLE a (id integer);
+
+CREATE INDEX idx_a
+  ON a (id);
+DROP TABLE b`
+	assert.Equal(t, []string{
+		"CREATE TABLE a (id integer);",
+		"CREATE INDEX idx_a\n  ON a (id);",
+		"DROP TABLE b",
+	}, statements(sql))
+}
@@ -0,0 +1,9 @@
+DROP TABLE todo_snapshots;
+DROP TABLE todo_events;
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
+DROP TABLE events;
+DROP TABLE idempotency_records;
+DROP TABLE time_entries;
+DROP TABLE attachments;
+DROP TABLE todos;
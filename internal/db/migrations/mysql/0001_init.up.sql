@@ -0,0 +1,21 @@
+-- The schema created by AutoMigrate before the versioned migrations.
+CREATE TABLE `todos` (`id` bigint AUTO_INCREMENT,`task` longtext,`description` longtext,`description_text` longtext,`status` varchar(191),`priority` longtext,`tags` longtext,`assignee` longtext,`due_at` datetime(3) NULL,`project` varchar(191),`estimate_minutes` bigint,`sort_rank` varchar(191) COLLATE utf8mb4_bin,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_todos_project` (`project`),INDEX `idx_todos_rank` (`sort_rank`),INDEX `idx_status` (`status`));
+
+CREATE TABLE `attachments` (`id` bigint AUTO_INCREMENT,`todo_id` bigint,`filename` longtext,`content_type` longtext,`size` bigint,`digest` varchar(191),`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_attachments_todo_id` (`todo_id`),INDEX `idx_attachments_digest` (`digest`));
+
+CREATE TABLE `time_entries` (`id` bigint AUTO_INCREMENT,`todo_id` bigint,`user_name` varchar(191),`started_at` datetime(3) NULL,`ended_at` datetime(3) NULL,`note` longtext,`manual` boolean,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),INDEX `idx_time_entries_todo_id` (`todo_id`),INDEX `idx_time_entries_user` (`user_name`));
+-- A user can only have one running timer. MySQL has no partial indexes, but
+-- NULLs never collide in a unique index.
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries ((CASE WHEN ended_at IS NULL THEN user_name END));
+
+CREATE TABLE `idempotency_records` (`idempotency_key` varchar(255),`fingerprint` longtext,`status_code` bigint,`content_type` longtext,`body` longblob,`created_at` datetime(3) NULL,`expires_at` datetime(3) NULL,PRIMARY KEY (`idempotency_key`),INDEX `idx_idempotency_records_expires_at` (`expires_at`));
+
+CREATE TABLE `events` (`id` bigint unsigned AUTO_INCREMENT,`type` longtext,`todo_id` bigint,`project` longtext,`status` longtext,`data` longtext,`created_at` datetime(3) NULL,`dispatched_at` datetime(3) NULL,`next_attempt_at` datetime(3) NULL,`attempts` bigint,`error` longtext,PRIMARY KEY (`id`),INDEX `idx_events_todo_id` (`todo_id`),INDEX `idx_events_created_at` (`created_at`),INDEX `idx_events_dispatched_at` (`dispatched_at`),INDEX `idx_events_next_attempt_at` (`next_attempt_at`));
+
+CREATE TABLE `webhooks` (`id` bigint AUTO_INCREMENT,`url` longtext,`events` longtext,`secret` longtext,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`));
+
+CREATE TABLE `webhook_deliveries` (`id` bigint AUTO_INCREMENT,`webhook_id` bigint,`event_id` bigint unsigned,`event_type` longtext,`payload` longtext,`status` longtext,`attempts` bigint,`next_attempt_at` datetime(3) NULL,`last_attempt_at` datetime(3) NULL,`response_status` bigint,`error` longtext,`created_at` datetime(3) NULL,`updated_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_webhook_deliveries_event` (`webhook_id`,`event_id`),INDEX `idx_webhook_deliveries_next_attempt_at` (`next_attempt_at`),INDEX `idx_webhook_deliveries_created_at` (`created_at`));
+
+CREATE TABLE `todo_events` (`id` bigint unsigned AUTO_INCREMENT,`todo_id` bigint,`version` bigint,`type` longtext,`data` longtext,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_todo_events_version` (`todo_id`,`version`));
+
+CREATE TABLE `todo_snapshots` (`id` bigint unsigned AUTO_INCREMENT,`event_id` bigint unsigned,`data` longtext,`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_todo_snapshots_event_id` (`event_id`));
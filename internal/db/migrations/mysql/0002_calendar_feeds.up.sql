@@ -0,0 +1 @@
+CREATE TABLE `calendar_feeds` (`id` bigint AUTO_INCREMENT,`name` longtext,`assignee` longtext,`project` longtext,`token` varchar(64),`created_at` datetime(3) NULL,PRIMARY KEY (`id`),UNIQUE INDEX `idx_calendar_feeds_token` (`token`));
@@ -0,0 +1 @@
+CREATE TABLE `caldav_objects` (`todo_id` bigint,`name` varchar(255),`uid` varchar(255),PRIMARY KEY (`todo_id`),UNIQUE INDEX `idx_caldav_objects_name` (`name`),UNIQUE INDEX `idx_caldav_objects_uid` (`uid`));
@@ -0,0 +1 @@
+CREATE TABLE `event_sinks` (`event_id` bigint unsigned,`sink` varchar(64),PRIMARY KEY (`event_id`,`sink`));
@@ -0,0 +1,37 @@
+-- The schema created by AutoMigrate before the versioned migrations.
+CREATE TABLE "todos" ("id" bigserial,"task" text,"description" text,"description_text" text,"status" text,"priority" text,"tags" text,"assignee" text,"due_at" timestamptz,"project" text,"estimate_minutes" bigint,"sort_rank" text COLLATE "C","created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_todos_project" ON "todos" ("project");
+CREATE INDEX "idx_todos_rank" ON "todos" ("sort_rank");
+CREATE INDEX idx_status ON todos (status);
+
+CREATE TABLE "attachments" ("id" bigserial,"todo_id" bigint,"filename" text,"content_type" text,"size" bigint,"digest" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_attachments_digest" ON "attachments" ("digest");
+CREATE INDEX "idx_attachments_todo_id" ON "attachments" ("todo_id");
+
+CREATE TABLE "time_entries" ("id" bigserial,"todo_id" bigint,"user_name" text,"started_at" timestamptz,"ended_at" timestamptz,"note" text,"manual" boolean,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_time_entries_user" ON "time_entries" ("user_name");
+CREATE INDEX "idx_time_entries_todo_id" ON "time_entries" ("todo_id");
+-- A user can only have one running timer.
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries (user_name) WHERE ended_at IS NULL;
+
+CREATE TABLE "idempotency_records" ("idempotency_key" varchar(255),"fingerprint" text,"status_code" bigint,"content_type" text,"body" bytea,"created_at" timestamptz,"expires_at" timestamptz,PRIMARY KEY ("idempotency_key"));
+CREATE INDEX "idx_idempotency_records_expires_at" ON "idempotency_records" ("expires_at");
+
+CREATE TABLE "events" ("id" bigserial,"type" text,"todo_id" bigint,"project" text,"status" text,"data" text,"created_at" timestamptz,"dispatched_at" timestamptz,"next_attempt_at" timestamptz,"attempts" bigint,"error" text,PRIMARY KEY ("id"));
+CREATE INDEX "idx_events_created_at" ON "events" ("created_at");
+CREATE INDEX "idx_events_todo_id" ON "events" ("todo_id");
+CREATE INDEX "idx_events_next_attempt_at" ON "events" ("next_attempt_at");
+CREATE INDEX "idx_events_dispatched_at" ON "events" ("dispatched_at");
+
+CREATE TABLE "webhooks" ("id" bigserial,"url" text,"events" text,"secret" text,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+
+CREATE TABLE "webhook_deliveries" ("id" bigserial,"webhook_id" bigint,"event_id" bigint,"event_type" text,"payload" text,"status" text,"attempts" bigint,"next_attempt_at" timestamptz,"last_attempt_at" timestamptz,"response_status" bigint,"error" text,"created_at" timestamptz,"updated_at" timestamptz,PRIMARY KEY ("id"));
+CREATE INDEX "idx_webhook_deliveries_next_attempt_at" ON "webhook_deliveries" ("next_attempt_at");
+CREATE UNIQUE INDEX "idx_webhook_deliveries_event" ON "webhook_deliveries" ("webhook_id","event_id");
+CREATE INDEX "idx_webhook_deliveries_created_at" ON "webhook_deliveries" ("created_at");
+
+CREATE TABLE "todo_events" ("id" bigserial,"todo_id" bigint,"version" bigint,"type" text,"data" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_todo_events_version" ON "todo_events" ("todo_id","version");
+
+CREATE TABLE "todo_snapshots" ("id" bigserial,"event_id" bigint,"data" text,"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_todo_snapshots_event_id" ON "todo_snapshots" ("event_id");
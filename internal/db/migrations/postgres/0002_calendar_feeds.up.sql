@@ -0,0 +1,2 @@
+CREATE TABLE "calendar_feeds" ("id" bigserial,"name" text,"assignee" text,"project" text,"token" varchar(64),"created_at" timestamptz,PRIMARY KEY ("id"));
+CREATE UNIQUE INDEX "idx_calendar_feeds_token" ON "calendar_feeds" ("token");
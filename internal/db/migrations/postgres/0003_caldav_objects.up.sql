@@ -0,0 +1,3 @@
+CREATE TABLE "caldav_objects" ("todo_id" bigint,"name" varchar(255),"uid" varchar(255),PRIMARY KEY ("todo_id"));
+CREATE UNIQUE INDEX "idx_caldav_objects_name" ON "caldav_objects" ("name");
+CREATE UNIQUE INDEX "idx_caldav_objects_uid" ON "caldav_objects" ("uid");
@@ -0,0 +1 @@
+DROP TABLE event_sinks;
@@ -0,0 +1 @@
+CREATE TABLE "event_sinks" ("event_id" bigint,"sink" varchar(64),PRIMARY KEY ("event_id","sink"));
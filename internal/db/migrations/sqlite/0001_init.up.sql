@@ -0,0 +1,37 @@
+-- The schema created by AutoMigrate before the versioned migrations.
+CREATE TABLE `todos` (`id` integer PRIMARY KEY AUTOINCREMENT,`task` text,`description` text,`description_text` text,`status` text,`priority` text,`tags` text,`assignee` text,`due_at` datetime,`project` text,`estimate_minutes` integer,`sort_rank` text,`created_at` datetime,`updated_at` datetime);
+CREATE INDEX `idx_todos_rank` ON `todos`(`sort_rank`);
+CREATE INDEX `idx_todos_project` ON `todos`(`project`);
+CREATE INDEX idx_status ON todos (status);
+
+CREATE TABLE `attachments` (`id` integer PRIMARY KEY AUTOINCREMENT,`todo_id` integer,`filename` text,`content_type` text,`size` integer,`digest` text,`created_at` datetime);
+CREATE INDEX `idx_attachments_digest` ON `attachments`(`digest`);
+CREATE INDEX `idx_attachments_todo_id` ON `attachments`(`todo_id`);
+
+CREATE TABLE `time_entries` (`id` integer PRIMARY KEY AUTOINCREMENT,`todo_id` integer,`user_name` text,`started_at` datetime,`ended_at` datetime,`note` text,`manual` numeric,`created_at` datetime);
+CREATE INDEX `idx_time_entries_user` ON `time_entries`(`user_name`);
+CREATE INDEX `idx_time_entries_todo_id` ON `time_entries`(`todo_id`);
+-- A user can only have one running timer.
+CREATE UNIQUE INDEX idx_time_entries_running ON time_entries (user_name) WHERE ended_at IS NULL;
+
+CREATE TABLE `idempotency_records` (`idempotency_key` text,`fingerprint` text,`status_code` integer,`content_type` text,`body` blob,`created_at` datetime,`expires_at` datetime,PRIMARY KEY (`idempotency_key`));
+CREATE INDEX `idx_idempotency_records_expires_at` ON `idempotency_records`(`expires_at`);
+
+CREATE TABLE `events` (`id` integer PRIMARY KEY AUTOINCREMENT,`type` text,`todo_id` integer,`project` text,`status` text,`data` text,`created_at` datetime,`dispatched_at` datetime,`next_attempt_at` datetime,`attempts` integer,`error` text);
+CREATE INDEX `idx_events_dispatched_at` ON `events`(`dispatched_at`);
+CREATE INDEX `idx_events_created_at` ON `events`(`created_at`);
+CREATE INDEX `idx_events_todo_id` ON `events`(`todo_id`);
+CREATE INDEX `idx_events_next_attempt_at` ON `events`(`next_attempt_at`);
+
+CREATE TABLE `webhooks` (`id` integer PRIMARY KEY AUTOINCREMENT,`url` text,`events` text,`secret` text,`created_at` datetime,`updated_at` datetime);
+
+CREATE TABLE `webhook_deliveries` (`id` integer PRIMARY KEY AUTOINCREMENT,`webhook_id` integer,`event_id` integer,`event_type` text,`payload` text,`status` text,`attempts` integer,`next_attempt_at` datetime,`last_attempt_at` datetime,`response_status` integer,`error` text,`created_at` datetime,`updated_at` datetime);
+CREATE UNIQUE INDEX `idx_webhook_deliveries_event` ON `webhook_deliveries`(`webhook_id`,`event_id`);
+CREATE INDEX `idx_webhook_deliveries_created_at` ON `webhook_deliveries`(`created_at`);
+CREATE INDEX `idx_webhook_deliveries_next_attempt_at` ON `webhook_deliveries`(`next_attempt_at`);
+
+CREATE TABLE `todo_events` (`id` integer PRIMARY KEY AUTOINCREMENT,`todo_id` integer,`version` integer,`type` text,`data` text,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_todo_events_version` ON `todo_events`(`todo_id`,`version`);
+
+CREATE TABLE `todo_snapshots` (`id` integer PRIMARY KEY AUTOINCREMENT,`event_id` integer,`data` text,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_todo_snapshots_event_id` ON `todo_snapshots`(`event_id`);
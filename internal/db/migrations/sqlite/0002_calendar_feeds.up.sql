@@ -0,0 +1,2 @@
+CREATE TABLE `calendar_feeds` (`id` integer PRIMARY KEY AUTOINCREMENT,`name` text,`assignee` text,`project` text,`token` text,`created_at` datetime);
+CREATE UNIQUE INDEX `idx_calendar_feeds_token` ON `calendar_feeds`(`token`);
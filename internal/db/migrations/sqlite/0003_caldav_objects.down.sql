@@ -0,0 +1 @@
+DROP TABLE caldav_objects;
@@ -0,0 +1 @@
+CREATE TABLE `event_sinks` (`event_id` integer,`sink` text,PRIMARY KEY (`event_id`,`sink`));
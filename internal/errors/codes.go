@@ -12,10 +12,59 @@ const (
 	CodeNotFound = "NOT_FOUND"
 	// CodeBadRequest is a generic error message returned when the request is bad.
 	CodeBadRequest = "BAD_REQUEST"
+	// CodeConflict is a generic error message returned when the request conflicts with the current state.
+	CodeConflict = "CONFLICT"
+	// CodePayloadTooLarge is a generic error message returned when the request body exceeds the allowed size.
+	CodePayloadTooLarge = "PAYLOAD_TOO_LARGE"
+	// CodeUnprocessableEntity is a generic error message returned when the request is well-formed but cannot be processed.
+	CodeUnprocessableEntity = "UNPROCESSABLE_ENTITY"
+	// CodeUnsupportedMediaType is a generic error message returned when the request content type is not accepted.
+	CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+	// CodeMethodNotAllowed is a generic error message returned when the route does not accept the request method.
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
 )
 
+const (
+	// CodeValidationFailed is returned when request fields fail validation.
+	CodeValidationFailed = "VALIDATION_FAILED"
+	// CodeEmptyTask is returned when quick-add text has no task left after parsing.
+	CodeEmptyTask = "EMPTY_TASK"
+	// CodeInvalidMove is returned when a todo cannot be moved to the requested place.
+	CodeInvalidMove = "INVALID_MOVE"
+	// CodeWIPLimitReached is returned when a status column is at its WIP limit.
+	CodeWIPLimitReached = "WIP_LIMIT_REACHED"
+	// CodeAttachmentTooLarge is returned when an attachment exceeds the size limit.
+	CodeAttachmentTooLarge = "ATTACHMENT_TOO_LARGE"
+	// CodeAttachmentTypeNotAllowed is returned when an attachment content type is not allowed.
+	CodeAttachmentTypeNotAllowed = "ATTACHMENT_TYPE_NOT_ALLOWED"
+	// CodeMissingUser is returned when a request needs the user header and has none.
+	CodeMissingUser = "MISSING_USER"
+	// CodeTimerRunning is returned when the user already has a running timer.
+	CodeTimerRunning = "TIMER_RUNNING"
+	// CodeNoRunningTimer is returned when the user has no running timer.
+	CodeNoRunningTimer = "NO_RUNNING_TIMER"
+	// CodeInvalidTimeEntry is returned when a time entry does not end after it starts.
+	CodeInvalidTimeEntry = "INVALID_TIME_ENTRY"
+	// CodeInvalidTimeRange is returned when a report range is invalid.
+	CodeInvalidTimeRange = "INVALID_TIME_RANGE"
+	// CodeInvalidImport is returned when an import file cannot be read.
+	CodeInvalidImport = "INVALID_IMPORT"
+	// CodeInvalidIdempotencyKey is returned when the Idempotency-Key header is malformed.
+	CodeInvalidIdempotencyKey = "INVALID_IDEMPOTENCY_KEY"
+	// CodeIdempotencyKeyReused is returned when an idempotency key is reused for a different request.
+	CodeIdempotencyKeyReused = "IDEMPOTENCY_KEY_REUSED"
+	// CodeIdempotencyKeyInProgress is returned when a request with the same idempotency key is still running.
+	CodeIdempotencyKeyInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+)
+
+// ErrorCodeDescriptions maps HTTP status codes to the generic error codes.
 var ErrorCodeDescriptions = map[int]string{
-	http.StatusInternalServerError: CodeInternalServerError,
-	http.StatusBadRequest:          CodeBadRequest,
-	http.StatusNotFound:            CodeNotFound,
+	http.StatusInternalServerError:   CodeInternalServerError,
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeUnprocessableEntity,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
 }
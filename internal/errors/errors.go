@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MIMEApplicationProblemJSON is the content type of problem details responses.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Error is an application error with a stable code and the HTTP status it is reported with.
+type Error struct {
+	// Status is the HTTP status code.
+	Status int
+	// Code is the stable, machine readable error code.
+	Code string
+	// Detail is the explanation shown to clients.
+	Detail string
+	// Violations are the field-level validation failures.
+	Violations []Violation
+	// Err is the underlying error. It is never shown to clients.
+	Err error
+}
+
+// Violation is a validation failure of one request field.
+type Violation struct {
+	// Field is the path of the field, such as "operations[0].op".
+	Field string `json:"field"`
+	// Rule is the validation rule that failed.
+	Rule string `json:"rule"`
+	// Param is the parameter of the rule, such as the allowed values of "oneof".
+	Param string `json:"param,omitempty"`
+	// Message describes the failure.
+	Message string `json:"message"`
+}
+
+// New returns an application error.
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+// Wrap returns an application error that reports err to clients.
+func Wrap(err error, status int, code string) *Error {
+	return &Error{Status: status, Code: code, Detail: err.Error(), Err: err}
+}
+
+// Internal returns an application error that hides err from clients.
+func Internal(err error) *Error {
+	return &Error{
+		Status: http.StatusInternalServerError,
+		Code:   CodeInternalServerError,
+		Detail: "An unexpected error occurred.",
+		Err:    err,
+	}
+}
+
+// Validation returns an application error for a request that failed validation.
+func Validation(violations []Violation) *Error {
+	return &Error{
+		Status:     http.StatusBadRequest,
+		Code:       CodeValidationFailed,
+		Detail:     "The request has invalid fields.",
+		Violations: violations,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	// Type identifies the problem type.
+	Type string `json:"type"`
+	// Title is the summary of the problem type.
+	Title string `json:"title"`
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+	// Detail is the explanation of this occurrence.
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request path.
+	Instance string `json:"instance,omitempty"`
+	// Code is the stable, machine readable error code.
+	Code string `json:"code"`
+	// RequestID is the ID of the request, for correlation with the logs.
+	RequestID string `json:"requestId,omitempty"`
+	// Violations are the field-level validation failures.
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Problem returns the problem details of the error.
+func (e *Error) Problem(instance, requestID string) Problem {
+	return Problem{
+		Type:       "urn:problem-type:" + strings.ToLower(strings.ReplaceAll(e.Code, "_", "-")),
+		Title:      http.StatusText(e.Status),
+		Status:     e.Status,
+		Detail:     e.Detail,
+		Instance:   instance,
+		Code:       e.Code,
+		RequestID:  requestID,
+		Violations: e.Violations,
+	}
+}
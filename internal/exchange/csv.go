@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// csvColumns is the header of the CSV files. Imports match the columns by
+// name, regardless of case and order, and ignore the unknown ones.
+var csvColumns = []string{
+	"id", "task", "description", "status", "priority", "tags", "assignee",
+	"dueAt", "project", "estimateMinutes", "createdAt", "updatedAt",
+}
+
+// csvTagSeparator separates the tags within their column.
+const csvTagSeparator = ","
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) Encoder {
+	e := &csvEncoder{w: csv.NewWriter(w)}
+	// An error is kept by the writer and returned by Close.
+	_ = e.w.Write(csvColumns)
+	return e
+}
+
+func (e *csvEncoder) Encode(todo *model.Todo) error {
+	var dueAt string
+	if todo.DueAt != nil {
+		dueAt = todo.DueAt.Format(time.RFC3339)
+	}
+	var estimate string
+	if todo.EstimateMinutes != 0 {
+		estimate = strconv.Itoa(todo.EstimateMinutes)
+	}
+	return e.w.Write([]string{
+		strconv.Itoa(todo.ID),
+		todo.Task,
+		todo.Description,
+		string(todo.Status),
+		string(todo.Priority),
+		strings.Join(todo.Tags, csvTagSeparator),
+		todo.Assignee,
+		dueAt,
+		todo.Project,
+		estimate,
+		todo.CreatedAt.Format(time.RFC3339),
+		todo.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func decodeCSV(r io.Reader) ([]*model.ImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %s", model.ErrInvalidImport, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["task"]; !ok {
+		return nil, fmt.Errorf("%w: the header has no task column", model.ErrInvalidImport)
+	}
+
+	var records []*model.ImportRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			record := newRecord(parseErr.StartLine)
+			record.Err = parseErr.Err
+			records = append(records, record)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := newRecord(line)
+		records = append(records, record)
+		if len(row) != len(header) {
+			record.Err = fmt.Errorf("%d fields, the header has %d", len(row), len(header))
+			continue
+		}
+		record.Err = parseCSVRow(record, func(name string) string {
+			if i, ok := columns[strings.ToLower(name)]; ok {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		})
+	}
+}
+
+// parseCSVRow fills record with the columns read by field.
+func parseCSVRow(record *model.ImportRecord, field func(name string) string) error {
+	var err error
+	if id := field("id"); id != "" {
+		if record.ID, err = strconv.Atoi(id); err != nil {
+			return fmt.Errorf("id: %q is not a number", id)
+		}
+	}
+	record.Todo.Task = field("task")
+	record.Todo.Description = field("description")
+	if status := field("status"); status != "" {
+		record.Status = model.Status(status)
+	}
+	if priority := field("priority"); priority != "" {
+		record.Todo.Priority = priority
+	}
+	for _, tag := range strings.Split(field("tags"), csvTagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			record.Todo.Tags = append(record.Todo.Tags, tag)
+		}
+	}
+	record.Todo.Assignee = field("assignee")
+	if record.Todo.DueAt, err = parseCSVTime("dueAt", field("dueAt")); err != nil {
+		return err
+	}
+	record.Todo.Project = field("project")
+	if estimate := field("estimateMinutes"); estimate != "" {
+		if record.Todo.EstimateMinutes, err = strconv.Atoi(estimate); err != nil {
+			return fmt.Errorf("estimateMinutes: %q is not a number", estimate)
+		}
+	}
+	record.CreatedAt, err = parseCSVTime("createdAt", field("createdAt"))
+	return err
+}
+
+// parseCSVTime reads an RFC 3339 time or a date, or nothing from an empty value.
+func parseCSVTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, dateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %q is not a date", name, value)
+}
@@ -0,0 +1,104 @@
+// Package exchange reads and writes todos in the file formats of other tools:
+// JSON as the API returns it, CSV for spreadsheets, todo.txt, iCalendar for
+// calendar apps, and Markdown for reports, which is written only.
+//
+// Exports are written a todo at a time, so that they stream. Imports are read
+// whole, into a record per todo: a line that cannot be read becomes a record
+// with an error rather than failing the file.
+package exchange
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// dateLayout is the layout of the dates without a time.
+const dateLayout = "2006-01-02"
+
+// Encoder writes todos in a format.
+type Encoder interface {
+	Encode(todo *model.Todo) error
+	// Close ends the document and flushes it. It does not close the writer.
+	Close() error
+}
+
+type format struct {
+	contentType string
+	extension   string
+	newEncoder  func(w io.Writer) Encoder
+	decode      func(r io.Reader) ([]*model.ImportRecord, error)
+}
+
+var formats = map[string]format{
+	model.FormatJSON:     {contentType: "application/json", extension: ".json", newEncoder: newJSONEncoder, decode: decodeJSON},
+	model.FormatCSV:      {contentType: "text/csv; charset=utf-8", extension: ".csv", newEncoder: newCSVEncoder, decode: decodeCSV},
+	model.FormatTodoTxt:  {contentType: "text/plain; charset=utf-8", extension: ".txt", newEncoder: newTodoTxtEncoder, decode: decodeTodoTxt},
+	model.FormatMarkdown: {contentType: "text/markdown; charset=utf-8", extension: ".md", newEncoder: newMarkdownEncoder},
+	model.FormatICal:     {contentType: "text/calendar; charset=utf-8", extension: ".ics", newEncoder: newICalEncoder, decode: decodeICal},
+}
+
+func lookup(name string) (format, error) {
+	f, ok := formats[name]
+	if !ok {
+		return format{}, fmt.Errorf("unknown format: %q", name)
+	}
+	return f, nil
+}
+
+// NewEncoder returns an encoder writing the format to w.
+func NewEncoder(name string, w io.Writer) (Encoder, error) {
+	f, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.newEncoder(w), nil
+}
+
+// Decode reads the todos of a file in the format. It fails with
+// model.ErrInvalidImport only when the file cannot be read at all.
+func Decode(name string, r io.Reader) ([]*model.ImportRecord, error) {
+	f, err := lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if f.decode == nil {
+		return nil, fmt.Errorf("%w: %s files cannot be imported", model.ErrInvalidImport, name)
+	}
+	return f.decode(r)
+}
+
+// ContentType returns the media type of the format.
+func ContentType(name string) string {
+	return formats[name].contentType
+}
+
+// Extension returns the file name extension of the format, with its dot.
+func Extension(name string) string {
+	return formats[name].extension
+}
+
+// FormatOf returns the format of a file name by its extension, or "" when
+// the extension is not one of a format.
+func FormatOf(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for name, f := range formats {
+		if f.extension == ext {
+			return name
+		}
+	}
+	return ""
+}
+
+// newRecord returns a record of line with the defaults of an import: new
+// todos are created with medium priority.
+func newRecord(line int) *model.ImportRecord {
+	return &model.ImportRecord{
+		Line:   line,
+		Todo:   model.CreateRequest{Priority: string(model.TP_Medium)},
+		Status: model.Created,
+	}
+}
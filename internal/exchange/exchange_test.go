@@ -0,0 +1,192 @@
+package exchange
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func testTodos() []*model.Todo {
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 5, 10, 18, 30, 0, 0, time.UTC)
+	due := time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)
+	return []*model.Todo{
+		{
+			ID: 7, Task: "Review release notes", Status: model.Done, Priority: model.TP_High,
+			Tags: []string{"docs"}, DueAt: &due, Project: "web",
+			CreatedAt: created, UpdatedAt: updated,
+		},
+		{
+			ID: 8, Task: "Write tests", Description: "Cover the importer.", Status: model.Processing,
+			Priority: model.TP_Medium, Assignee: "alice", EstimateMinutes: 30,
+			CreatedAt: created, UpdatedAt: created,
+		},
+	}
+}
+
+func encode(t *testing.T, format string, todos []*model.Todo) string {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(format, &buf)
+	require.NoError(t, err)
+	for _, todo := range todos {
+		require.NoError(t, enc.Encode(todo))
+	}
+	require.NoError(t, enc.Close())
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	due := time.Date(2024, 5, 12, 0, 0, 0, 0, time.UTC)
+
+	for _, format := range []string{model.FormatJSON, model.FormatCSV, model.FormatTodoTxt, model.FormatICal} {
+		t.Run(format, func(t *testing.T) {
+			records, err := Decode(format, strings.NewReader(encode(t, format, testTodos())))
+			require.NoError(t, err)
+			require.Len(t, records, 2)
+
+			for _, record := range records {
+				assert.NoError(t, record.Err)
+				require.NotNil(t, record.CreatedAt)
+				assert.True(t, record.CreatedAt.Truncate(24*time.Hour).Equal(created.Truncate(24*time.Hour)))
+			}
+
+			assert.Equal(t, 7, records[0].ID)
+			assert.Equal(t, model.Done, records[0].Status)
+			assert.Equal(t, model.CreateRequest{
+				Task: "Review release notes", Priority: "high", Tags: []string{"docs"}, DueAt: &due, Project: "web",
+			}, records[0].Todo)
+
+			want := model.CreateRequest{
+				Task: "Write tests", Description: "Cover the importer.", Priority: "medium",
+				Assignee: "alice", EstimateMinutes: 30,
+			}
+			if format == model.FormatTodoTxt {
+				want.Description = ""
+			}
+			assert.Equal(t, 8, records[1].ID)
+			assert.Equal(t, model.Processing, records[1].Status)
+			if diff := cmp.Diff(want, records[1].Todo); diff != "" {
+				t.Errorf("todo mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecode_Lines(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		// lines are the lines of the records, with the invalid ones as a negative.
+		lines []int
+	}{
+		{
+			name:   "json_wrong_type",
+			format: model.FormatJSON,
+			input:  "[\n{\"task\": \"Buy milk\"},\n{\"task\": 3},\n{\"task\": \"Call Bob\"}\n]\n",
+			lines:  []int{2, -3, 4},
+		},
+		{
+			name:   "csv_bad_fields",
+			format: model.FormatCSV,
+			input:  "Task,Due\nBuy milk,2024-05-01\nCall Bob\nWalk dog,someday\nPay \"rent,\n",
+			lines:  []int{2, -3, 4, -5},
+		},
+		{
+			name:   "todotxt",
+			format: model.FormatTodoTxt,
+			input:  "(A) Buy milk @home\n\nCall Bob at 10:30 due:tomorrow\nx Walk dog\n",
+			lines:  []int{1, -3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := Decode(tt.format, strings.NewReader(tt.input))
+			require.NoError(t, err)
+
+			var lines []int
+			for _, record := range records {
+				if record.Err != nil {
+					lines = append(lines, -record.Line)
+				} else {
+					lines = append(lines, record.Line)
+				}
+			}
+			assert.Equal(t, tt.lines, lines)
+		})
+	}
+}
+
+func TestDecode_TodoTxt(t *testing.T) {
+	records, err := Decode(model.FormatTodoTxt, strings.NewReader("(D) Call Bob at 10:30 +sales @phone @work\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, model.CreateRequest{
+		Task: "Call Bob at 10:30", Priority: "low", Project: "sales", Tags: []string{"phone", "work"},
+	}, records[0].Todo)
+	assert.Equal(t, model.Created, records[0].Status)
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{name: "json_object", format: model.FormatJSON, input: `{"task": "Buy milk"}`},
+		{name: "json_syntax", format: model.FormatJSON, input: "[\n{\"task\": \"Buy milk\"\n"},
+		{name: "csv_no_task", format: model.FormatCSV, input: "title,due\nBuy milk,\n"},
+		{name: "markdown", format: model.FormatMarkdown, input: "- [ ] Buy milk\n"},
+		{name: "ical_not_a_calendar", format: model.FormatICal, input: "BEGIN:VTODO\r\nSUMMARY:Buy milk\r\nEND:VTODO\r\n"},
+		{name: "ical_truncated", format: model.FormatICal, input: "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:Buy milk\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.format, strings.NewReader(tt.input))
+			assert.ErrorIs(t, err, model.ErrInvalidImport)
+		})
+	}
+
+	_, err := Decode("xml", strings.NewReader(""))
+	assert.Error(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("todotxt", func(t *testing.T) {
+		assert.Equal(t, "x 2024-05-10 2024-05-01 Review release notes +web @docs due:2024-05-12 pri:A id:7\n"+
+			"(B) 2024-05-01 Write tests assignee:alice estimate:30 status:processing id:8\n",
+			encode(t, model.FormatTodoTxt, testTodos()))
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		assert.Equal(t, "# Todos\n\n"+
+			"## Done\n\n"+
+			"- [x] Review release notes _(high, due 2024-05-12, project web, tags docs)_\n\n"+
+			"## Processing\n\n"+
+			"- [ ] Write tests _(medium, assignee alice, estimate 30 min)_\n\n"+
+			"  Cover the importer.\n\n",
+			encode(t, model.FormatMarkdown, testTodos()))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, "[]\n", encode(t, model.FormatJSON, nil))
+		assert.Equal(t, strings.Join(csvColumns, ",")+"\n", encode(t, model.FormatCSV, nil))
+		assert.Equal(t, "# Todos\n\nNo todos.\n", encode(t, model.FormatMarkdown, nil))
+	})
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, model.FormatTodoTxt, FormatOf("todo.txt"))
+	assert.Equal(t, model.FormatCSV, FormatOf("/tmp/Todos.CSV"))
+	assert.Equal(t, model.FormatMarkdown, FormatOf("report.md"))
+	assert.Equal(t, "", FormatOf("todos.xml"))
+	assert.Equal(t, "", FormatOf("-"))
+	assert.Equal(t, model.FormatICal, FormatOf("tasks.ics"))
+}
@@ -0,0 +1,490 @@
+package exchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// The iCalendar format (RFC 5545) is a calendar with a VTODO per todo:
+//
+//	BEGIN:VTODO
+//	UID:todo-7@fullstack-examination-2024
+//	DTSTAMP:20240510T183000Z
+//	CREATED:20240501T090000Z
+//	LAST-MODIFIED:20240510T183000Z
+//	SUMMARY:Review release notes
+//	STATUS:COMPLETED
+//	COMPLETED:20240510T183000Z
+//	PRIORITY:1
+//	DUE;VALUE=DATE:20240512
+//	CATEGORIES:docs
+//	X-TODO-PROJECT:web
+//	END:VTODO
+//
+// The priorities are 1 for high, 5 for medium and 9 for low; imports read 1
+// to 4 as high and 6 to 9 as low. A due time at midnight UTC is written as a
+// date. The project, the assignee and the estimate in minutes, which iCalendar
+// has no property for, are X-TODO- properties.
+
+const (
+	icalProductID = "-//zuu-development//fullstack-examination-2024//EN"
+	// icalUIDDomain is the right-hand side of the UIDs, which makes them
+	// globally unique as RFC 5545 wants.
+	icalUIDDomain       = "fullstack-examination-2024"
+	icalTimeLayout      = "20060102T150405Z"
+	icalLocalTimeLayout = "20060102T150405"
+	icalDateLayout      = "20060102"
+	// icalLineLength is the maximum length of a line in octets, without its CRLF.
+	icalLineLength = 75
+)
+
+var icalUID = regexp.MustCompile(`^todo-(\d+)@`)
+
+// icalStatuses maps the statuses to those of a VTODO.
+var icalStatuses = map[model.Status]string{
+	model.Created:    "NEEDS-ACTION",
+	model.Processing: "IN-PROCESS",
+	model.Done:       "COMPLETED",
+}
+
+// icalPriorities maps the priorities to those of a VTODO.
+var icalPriorities = map[model.TodoPriority]int{
+	model.TP_High:   1,
+	model.TP_Medium: 5,
+	model.TP_Low:    9,
+}
+
+type icalEncoder struct {
+	w       *bufio.Writer
+	name    string
+	started bool
+}
+
+func newICalEncoder(w io.Writer) Encoder {
+	return NewICalEncoder(w, "Todos")
+}
+
+// NewICalEncoder returns an encoder writing an iCalendar calendar of the given name.
+func NewICalEncoder(w io.Writer, name string) Encoder {
+	return &icalEncoder{w: bufio.NewWriter(w), name: name}
+}
+
+func (e *icalEncoder) Encode(todo *model.Todo) error {
+	var b strings.Builder
+	e.start(&b)
+	writeICalTodo(&b, todo, ICalUID(todo.ID))
+	_, err := e.w.WriteString(b.String())
+	return err
+}
+
+func (e *icalEncoder) Close() error {
+	var b strings.Builder
+	e.start(&b)
+	writeICalLine(&b, "END:VCALENDAR")
+	if _, err := e.w.WriteString(b.String()); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// writeICalTodo writes the VTODO of todo.
+func writeICalTodo(b *strings.Builder, todo *model.Todo, uid string) {
+	writeICalLine(b, "BEGIN:VTODO")
+	writeICalLine(b, "UID:"+uid)
+	writeICalLine(b, "DTSTAMP:"+todo.UpdatedAt.UTC().Format(icalTimeLayout))
+	writeICalLine(b, "CREATED:"+todo.CreatedAt.UTC().Format(icalTimeLayout))
+	writeICalLine(b, "LAST-MODIFIED:"+todo.UpdatedAt.UTC().Format(icalTimeLayout))
+	writeICalLine(b, "SUMMARY:"+escapeICalText(todo.Task))
+	if todo.Description != "" {
+		writeICalLine(b, "DESCRIPTION:"+escapeICalText(todo.Description))
+	}
+	if status, ok := icalStatuses[todo.Status]; ok {
+		writeICalLine(b, "STATUS:"+status)
+	}
+	if todo.Status == model.Done {
+		writeICalLine(b, "COMPLETED:"+todo.UpdatedAt.UTC().Format(icalTimeLayout))
+	}
+	if priority, ok := icalPriorities[todo.Priority]; ok {
+		writeICalLine(b, "PRIORITY:"+strconv.Itoa(priority))
+	}
+	if todo.DueAt != nil {
+		due := todo.DueAt.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			writeICalLine(b, "DUE;VALUE=DATE:"+due.Format(icalDateLayout))
+		} else {
+			writeICalLine(b, "DUE:"+due.Format(icalTimeLayout))
+		}
+	}
+	if len(todo.Tags) > 0 {
+		categories := make([]string, len(todo.Tags))
+		for i, tag := range todo.Tags {
+			categories[i] = escapeICalText(tag)
+		}
+		writeICalLine(b, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	if todo.Project != "" {
+		writeICalLine(b, "X-TODO-PROJECT:"+escapeICalText(todo.Project))
+	}
+	if todo.Assignee != "" {
+		writeICalLine(b, "X-TODO-ASSIGNEE:"+escapeICalText(todo.Assignee))
+	}
+	if todo.EstimateMinutes != 0 {
+		writeICalLine(b, "X-TODO-ESTIMATE:"+strconv.Itoa(todo.EstimateMinutes))
+	}
+	writeICalLine(b, "END:VTODO")
+}
+
+// ICalUID returns the UID of the todo of an ID in iCalendar.
+func ICalUID(id int) string {
+	return fmt.Sprintf("todo-%d@%s", id, icalUIDDomain)
+}
+
+// WriteICalObject writes a calendar object resource of CalDAV: a calendar of
+// todo alone, with the given UID.
+func WriteICalObject(w io.Writer, todo *model.Todo, uid string) error {
+	var b strings.Builder
+	(&icalEncoder{}).start(&b)
+	writeICalTodo(&b, todo, uid)
+	writeICalLine(&b, "END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// start writes the beginning of the calendar, before its first todo.
+func (e *icalEncoder) start(b *strings.Builder) {
+	if e.started {
+		return
+	}
+	e.started = true
+	writeICalLine(b, "BEGIN:VCALENDAR")
+	writeICalLine(b, "VERSION:2.0")
+	writeICalLine(b, "PRODID:"+icalProductID)
+	writeICalLine(b, "CALSCALE:GREGORIAN")
+	if e.name != "" {
+		writeICalLine(b, "X-WR-CALNAME:"+escapeICalText(e.name))
+	}
+}
+
+// writeICalLine writes a content line, folded into lines of icalLineLength
+// octets without splitting a character.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalLineLength
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		b.WriteString(line[:i])
+		b.WriteString("\r\n ")
+		line = line[i:]
+		// The space starting a continuation line counts.
+		limit = icalLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+var icalTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeICalText escapes a TEXT value.
+func escapeICalText(s string) string {
+	return icalTextEscaper.Replace(s)
+}
+
+// splitICalText splits a list of TEXT values at their unescaped commas and
+// unescapes them.
+func splitICalText(s string) []string {
+	return readICalText(s, true)
+}
+
+// unescapeICalText unescapes a TEXT value.
+func unescapeICalText(s string) string {
+	return readICalText(s, false)[0]
+}
+
+func readICalText(s string, split bool) []string {
+	var values []string
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && i+1 < len(s):
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+		case c == ',' && split:
+			values = append(values, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(values, b.String())
+}
+
+// icalProperty is a content line: NAME;PARAM=value:VALUE.
+type icalProperty struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// readICalProperties unfolds the content lines of r.
+func readICalProperties(r io.Reader) ([]*icalProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	var starts []int
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		if text == "" {
+			continue
+		}
+		lines = append(lines, text)
+		starts = append(starts, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidImport, err)
+	}
+
+	properties := make([]*icalProperty, len(lines))
+	for i, line := range lines {
+		properties[i] = parseICalProperty(starts[i], line)
+	}
+	return properties, nil
+}
+
+// parseICalProperty parses a content line. A line without a colon is a
+// property without a value.
+func parseICalProperty(line int, text string) *icalProperty {
+	// The value starts at the first colon outside of a quoted parameter value.
+	quoted := false
+	colon := len(text)
+	for i, c := range text {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	property := &icalProperty{line: line, params: map[string]string{}}
+	if colon < len(text) {
+		property.value = text[colon+1:]
+	}
+	parts := strings.Split(text[:colon], ";")
+	property.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			property.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return property
+}
+
+func decodeICal(r io.Reader) ([]*model.ImportRecord, error) {
+	todos, err := readICalTodos(r)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*model.ImportRecord, len(todos))
+	for i, todo := range todos {
+		records[i] = todo.record
+	}
+	return records, nil
+}
+
+// ReadICalObject reads a calendar object resource of CalDAV: a calendar of a
+// single todo. It returns the todo along with its UID, which it must have.
+func ReadICalObject(r io.Reader) (*model.ImportRecord, string, error) {
+	todos, err := readICalTodos(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(todos) != 1 {
+		return nil, "", fmt.Errorf("%w: %d todos in a calendar object", model.ErrInvalidImport, len(todos))
+	}
+	if todos[0].record.Err != nil {
+		return nil, "", fmt.Errorf("%w: %s", model.ErrInvalidImport, todos[0].record.Err)
+	}
+	if todos[0].uid == "" {
+		return nil, "", fmt.Errorf("%w: the todo has no UID", model.ErrInvalidImport)
+	}
+	return todos[0].record, todos[0].uid, nil
+}
+
+// icalTodo is a VTODO read from a calendar.
+type icalTodo struct {
+	record *model.ImportRecord
+	uid    string
+}
+
+func readICalTodos(r io.Reader) ([]*icalTodo, error) {
+	properties, err := readICalProperties(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 || properties[0].name != "BEGIN" || !strings.EqualFold(properties[0].value, "VCALENDAR") {
+		return nil, fmt.Errorf("%w: expected BEGIN:VCALENDAR", model.ErrInvalidImport)
+	}
+
+	var todos []*icalTodo
+	var todo *icalTodo
+	// components are the components the line is in, such as VCALENDAR, VTODO
+	// and VALARM.
+	var components []string
+	for _, property := range properties {
+		switch property.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(property.value))
+			if len(components) == 2 && components[1] == "VTODO" {
+				todo = &icalTodo{record: newRecord(property.line)}
+				todos = append(todos, todo)
+			}
+			continue
+		case "END":
+			if len(components) == 0 || components[len(components)-1] != strings.ToUpper(property.value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", model.ErrInvalidImport, property.line, property.value)
+			}
+			components = components[:len(components)-1]
+			if len(components) == 1 {
+				todo = nil
+			}
+			continue
+		}
+
+		// The properties of the alarms of a todo are not those of the todo.
+		if todo == nil || len(components) != 2 {
+			continue
+		}
+		if property.name == "UID" {
+			todo.uid = property.value
+		}
+		if todo.record.Err == nil {
+			todo.record.Err = parseICalTodoProperty(todo.record, property)
+		}
+	}
+	if len(components) > 0 {
+		return nil, fmt.Errorf("%w: the calendar does not end with END:VCALENDAR", model.ErrInvalidImport)
+	}
+	return todos, nil
+}
+
+// parseICalTodoProperty fills record with a property of its VTODO.
+func parseICalTodoProperty(record *model.ImportRecord, property *icalProperty) error {
+	var err error
+	switch property.name {
+	case "UID":
+		if match := icalUID.FindStringSubmatch(property.value); match != nil {
+			record.ID, _ = strconv.Atoi(match[1])
+		}
+	case "SUMMARY":
+		record.Todo.Task = unescapeICalText(property.value)
+	case "DESCRIPTION":
+		record.Todo.Description = unescapeICalText(property.value)
+	case "STATUS":
+		switch strings.ToUpper(property.value) {
+		case "NEEDS-ACTION":
+			record.Status = model.Created
+		case "IN-PROCESS":
+			record.Status = model.Processing
+		// The board has no column for cancelled todos: they are done with.
+		case "COMPLETED", "CANCELLED":
+			record.Status = model.Done
+		default:
+			return fmt.Errorf("STATUS: %q is not a status of a todo", property.value)
+		}
+	case "PRIORITY":
+		priority, err := strconv.Atoi(property.value)
+		if err != nil || priority < 0 || priority > 9 {
+			return fmt.Errorf("PRIORITY: %q is not a number from 0 to 9", property.value)
+		}
+		switch {
+		case priority == 0:
+			// 0 is undefined: the default priority is kept.
+		case priority < 5:
+			record.Todo.Priority = string(model.TP_High)
+		case priority == 5:
+			record.Todo.Priority = string(model.TP_Medium)
+		default:
+			record.Todo.Priority = string(model.TP_Low)
+		}
+	case "DUE":
+		due, err := parseICalTime(property)
+		if err != nil {
+			return err
+		}
+		record.Todo.DueAt = &due
+	case "CREATED":
+		created, err := parseICalTime(property)
+		if err != nil {
+			return err
+		}
+		record.CreatedAt = &created
+	case "CATEGORIES":
+		for _, category := range splitICalText(property.value) {
+			if category = strings.TrimSpace(category); category != "" {
+				record.Todo.Tags = append(record.Todo.Tags, category)
+			}
+		}
+	case "X-TODO-PROJECT":
+		record.Todo.Project = unescapeICalText(property.value)
+	case "X-TODO-ASSIGNEE":
+		record.Todo.Assignee = unescapeICalText(property.value)
+	case "X-TODO-ESTIMATE":
+		if record.Todo.EstimateMinutes, err = strconv.Atoi(property.value); err != nil {
+			return fmt.Errorf("X-TODO-ESTIMATE: %q is not a number of minutes", property.value)
+		}
+	}
+	return nil
+}
+
+// parseICalTime reads a DATE or DATE-TIME value: in UTC, in the time zone of
+// its TZID parameter, or floating, which is read as UTC. Dates are midnight UTC.
+func parseICalTime(property *icalProperty) (time.Time, error) {
+	value := property.value
+	if strings.EqualFold(property.params["VALUE"], "DATE") || len(value) == len(icalDateLayout) {
+		t, err := time.Parse(icalDateLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: %q is not a date", property.name, value)
+		}
+		return t, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalTimeLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s: %q is not a time", property.name, value)
+		}
+		return t, nil
+	}
+
+	location := time.UTC
+	if tzid := property.params["TZID"]; tzid != "" {
+		// Time zones unknown to the tz database, such as those of Windows, are taken for UTC.
+		if l, err := time.LoadLocation(tzid); err == nil {
+			location = l
+		}
+	}
+	t, err := time.ParseInLocation(icalLocalTimeLayout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %q is not a time", property.name, value)
+	}
+	return t, nil
+}
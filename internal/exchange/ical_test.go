@@ -0,0 +1,141 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func TestICalEncoder(t *testing.T) {
+	t.Run("todo", func(t *testing.T) {
+		assert.Equal(t, strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:" + icalProductID,
+			"CALSCALE:GREGORIAN",
+			"X-WR-CALNAME:Todos",
+			"BEGIN:VTODO",
+			"UID:todo-7@fullstack-examination-2024",
+			"DTSTAMP:20240510T183000Z",
+			"CREATED:20240501T090000Z",
+			"LAST-MODIFIED:20240510T183000Z",
+			"SUMMARY:Review release notes",
+			"STATUS:COMPLETED",
+			"COMPLETED:20240510T183000Z",
+			"PRIORITY:1",
+			"DUE;VALUE=DATE:20240512",
+			"CATEGORIES:docs",
+			"X-TODO-PROJECT:web",
+			"END:VTODO",
+			"END:VCALENDAR",
+			"",
+		}, "\r\n"), encode(t, model.FormatICal, testTodos()[:1]))
+	})
+
+	t.Run("escaped_and_folded", func(t *testing.T) {
+		due := time.Date(2024, 5, 12, 9, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+		todo := &model.Todo{
+			ID: 1, Task: "Plan; review, ship", Description: strings.Repeat("ドキュメント", 10) + "\nDone.",
+			Status: model.Created, Priority: model.TP_Low, Tags: []string{"a,b", `c\d`}, DueAt: &due,
+		}
+		out := encode(t, model.FormatICal, []*model.Todo{todo})
+
+		assert.Contains(t, out, "\r\nSUMMARY:Plan\\; review\\, ship\r\n")
+		assert.Contains(t, out, "\r\nSTATUS:NEEDS-ACTION\r\nPRIORITY:9\r\nDUE:20240512T003000Z\r\n")
+		assert.Contains(t, out, "\r\nCATEGORIES:a\\,b,c\\\\d\r\n")
+		for _, line := range strings.Split(out, "\r\n") {
+			assert.LessOrEqual(t, len(line), icalLineLength, line)
+		}
+
+		records, err := Decode(model.FormatICal, strings.NewReader(out))
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.NoError(t, records[0].Err)
+		assert.Equal(t, todo.Task, records[0].Todo.Task)
+		assert.Equal(t, todo.Description, records[0].Todo.Description)
+		assert.Equal(t, todo.Tags, records[0].Todo.Tags)
+		assert.True(t, due.Equal(*records[0].Todo.DueAt))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		out := encode(t, model.FormatICal, nil)
+		assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+		assert.True(t, strings.HasSuffix(out, "X-WR-CALNAME:Todos\r\nEND:VCALENDAR\r\n"))
+	})
+}
+
+func TestDecode_ICal(t *testing.T) {
+	// A calendar of another app: time zones, alarms, folded lines and events.
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Example//Tasks//EN",
+		"BEGIN:VTIMEZONE",
+		"TZID:Asia/Tokyo",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"SUMMARY:Not a todo",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:0a1b2c@example.com",
+		"SUMMARY:Book the",
+		"  venue",
+		"DUE;TZID=Asia/Tokyo:20240601T170000",
+		"PRIORITY:3",
+		"STATUS:IN-PROCESS",
+		"CATEGORIES:events,team",
+		"CATEGORIES:q2",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Cancelled",
+		"STATUS:CANCELLED",
+		"PRIORITY:0",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"SUMMARY:Broken",
+		"PRIORITY:high",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:todo-12@fullstack-examination-2024",
+		"SUMMARY:Floating",
+		"DUE:20240601T170000",
+		"PRIORITY:7",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	records, err := Decode(model.FormatICal, strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	due := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, 10, records[0].Line)
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, 0, records[0].ID)
+	assert.Equal(t, "Book the venue", records[0].Todo.Task)
+	assert.Empty(t, records[0].Todo.Description)
+	assert.Equal(t, "high", records[0].Todo.Priority)
+	assert.Equal(t, model.Processing, records[0].Status)
+	assert.Equal(t, []string{"events", "team", "q2"}, records[0].Todo.Tags)
+	require.NotNil(t, records[0].Todo.DueAt)
+	assert.True(t, due.Equal(*records[0].Todo.DueAt))
+
+	assert.Equal(t, model.Done, records[1].Status)
+	assert.Equal(t, "medium", records[1].Todo.Priority)
+
+	assert.Equal(t, 29, records[2].Line)
+	assert.EqualError(t, records[2].Err, `PRIORITY: "high" is not a number from 0 to 9`)
+
+	assert.Equal(t, 12, records[3].ID)
+	assert.Equal(t, "low", records[3].Todo.Priority)
+	assert.Equal(t, time.Date(2024, 6, 1, 17, 0, 0, 0, time.UTC), *records[3].Todo.DueAt)
+}
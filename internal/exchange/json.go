@@ -0,0 +1,130 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// jsonEncoder writes an array of todos as the API returns them, a todo per line.
+type jsonEncoder struct {
+	w     *bufio.Writer
+	count int
+}
+
+func newJSONEncoder(w io.Writer) Encoder {
+	return &jsonEncoder{w: bufio.NewWriter(w)}
+}
+
+func (e *jsonEncoder) Encode(todo *model.Todo) error {
+	b, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+	if _, err := e.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := e.w.WriteString(end); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// jsonTodo is an imported todo. The keys are matched regardless of case, so
+// both the exported "Task" and "task" of the create request are read.
+type jsonTodo struct {
+	ID              int
+	Task            string
+	Description     string
+	Status          model.Status
+	Priority        string
+	Tags            []string
+	Assignee        string
+	DueAt           *time.Time
+	Project         string
+	EstimateMinutes int
+	CreatedAt       *time.Time
+}
+
+func decodeJSON(r io.Reader) ([]*model.ImportRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("%w: expected an array of todos", model.ErrInvalidImport)
+	}
+
+	var records []*model.ImportRecord
+	for dec.More() {
+		record := newRecord(lineAt(data, dec.InputOffset()))
+		records = append(records, record)
+
+		var todo jsonTodo
+		// A value of the wrong type is skipped by the decoder, which goes on
+		// with the next todo; broken JSON cannot be read any further.
+		if err := dec.Decode(&todo); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, fmt.Errorf("%w: line %d: %s", model.ErrInvalidImport, record.Line, err)
+			}
+			record.Err = err
+			continue
+		}
+
+		record.ID = todo.ID
+		record.Todo = model.CreateRequest{
+			Task:            todo.Task,
+			Description:     todo.Description,
+			Priority:        todo.Priority,
+			Tags:            todo.Tags,
+			Assignee:        todo.Assignee,
+			DueAt:           todo.DueAt,
+			Project:         todo.Project,
+			EstimateMinutes: todo.EstimateMinutes,
+		}
+		if record.Todo.Priority == "" {
+			record.Todo.Priority = string(model.TP_Medium)
+		}
+		if todo.Status != "" {
+			record.Status = todo.Status
+		}
+		record.CreatedAt = todo.CreatedAt
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidImport, err)
+	}
+	return records, nil
+}
+
+// lineAt returns the line of the value following offset in data.
+func lineAt(data []byte, offset int64) int {
+	i := int(offset)
+	for i < len(data) && bytes.IndexByte([]byte(" \t\r\n,"), data[i]) >= 0 {
+		i++
+	}
+	return 1 + bytes.Count(data[:i], []byte("\n"))
+}
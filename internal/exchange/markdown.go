@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// markdownEncoder writes a report: a task list per status, in the order the
+// todos come, with the descriptions below their todo.
+type markdownEncoder struct {
+	w      *bufio.Writer
+	status model.Status
+	count  int
+}
+
+func newMarkdownEncoder(w io.Writer) Encoder {
+	return &markdownEncoder{w: bufio.NewWriter(w)}
+}
+
+func (e *markdownEncoder) Encode(todo *model.Todo) error {
+	var b strings.Builder
+	if e.count == 0 {
+		b.WriteString("# Todos\n")
+	}
+	if e.count == 0 || todo.Status != e.status {
+		fmt.Fprintf(&b, "\n## %s\n\n", statusTitle(todo.Status))
+		e.status = todo.Status
+	}
+	e.count++
+
+	check := " "
+	if todo.Status == model.Done {
+		check = "x"
+	}
+	fmt.Fprintf(&b, "- [%s] %s", check, oneLine(todo.Task))
+	if details := markdownDetails(todo); len(details) > 0 {
+		fmt.Fprintf(&b, " _(%s)_", strings.Join(details, ", "))
+	}
+	b.WriteString("\n")
+	if todo.Description != "" {
+		b.WriteString("\n")
+		for _, line := range strings.Split(strings.TrimRight(todo.Description, "\n"), "\n") {
+			if line != "" {
+				b.WriteString("  " + line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := e.w.WriteString(b.String())
+	return err
+}
+
+func (e *markdownEncoder) Close() error {
+	if e.count == 0 {
+		if _, err := e.w.WriteString("# Todos\n\nNo todos.\n"); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
+
+// markdownDetails returns the fields of todo shown after its task.
+func markdownDetails(todo *model.Todo) []string {
+	var details []string
+	if todo.Priority != "" {
+		details = append(details, string(todo.Priority))
+	}
+	if todo.DueAt != nil {
+		details = append(details, "due "+todo.DueAt.Format(dateLayout))
+	}
+	if todo.Project != "" {
+		details = append(details, "project "+todo.Project)
+	}
+	if todo.Assignee != "" {
+		details = append(details, "assignee "+todo.Assignee)
+	}
+	if len(todo.Tags) > 0 {
+		details = append(details, "tags "+strings.Join(todo.Tags, " "))
+	}
+	if todo.EstimateMinutes != 0 {
+		details = append(details, fmt.Sprintf("estimate %d min", todo.EstimateMinutes))
+	}
+	return details
+}
+
+// statusTitle returns the heading of a status column, such as "Processing".
+func statusTitle(status model.Status) string {
+	s := string(status)
+	if s == "" {
+		return "No status"
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
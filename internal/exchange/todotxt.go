@@ -0,0 +1,199 @@
+package exchange
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// The todo.txt format is a line per todo:
+//
+//	x 2024-05-10 2024-05-01 Review release notes +web @docs due:2024-05-12 pri:A id:7
+//	(B) 2024-05-02 Write tests +api assignee:alice estimate:30 status:processing id:8
+//
+// A done todo starts with "x" and its completion date. The priority, (A) high,
+// (B) medium and (C) low, is followed by the creation date. +project and
+// @context go to the project and the tags; the due date, the priority of done
+// todos, and the fields todo.txt has no syntax for are key:value pairs.
+// Descriptions are not written.
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	todoTxtKeyValue = regexp.MustCompile(`^([a-z]+):(\S+)$`)
+)
+
+// todoTxtPriorities maps the priorities to their letters.
+var todoTxtPriorities = map[model.TodoPriority]string{
+	model.TP_High:   "A",
+	model.TP_Medium: "B",
+	model.TP_Low:    "C",
+}
+
+type todoTxtEncoder struct {
+	w *bufio.Writer
+}
+
+func newTodoTxtEncoder(w io.Writer) Encoder {
+	return &todoTxtEncoder{w: bufio.NewWriter(w)}
+}
+
+func (e *todoTxtEncoder) Encode(todo *model.Todo) error {
+	var words []string
+	priority := todoTxtPriorities[todo.Priority]
+	if todo.Status == model.Done {
+		words = append(words, "x", todo.UpdatedAt.Format(dateLayout))
+	} else if priority != "" {
+		words = append(words, "("+priority+")")
+	}
+	words = append(words, todo.CreatedAt.Format(dateLayout), oneLine(todo.Task))
+
+	if todo.Project != "" {
+		words = append(words, "+"+strings.Join(strings.Fields(todo.Project), "_"))
+	}
+	for _, tag := range todo.Tags {
+		words = append(words, "@"+strings.Join(strings.Fields(tag), "_"))
+	}
+	if todo.DueAt != nil {
+		words = append(words, "due:"+todo.DueAt.Format(dateLayout))
+	}
+	if todo.Status == model.Done && priority != "" {
+		words = append(words, "pri:"+priority)
+	}
+	if todo.Assignee != "" {
+		words = append(words, "assignee:"+strings.Join(strings.Fields(todo.Assignee), "_"))
+	}
+	if todo.EstimateMinutes != 0 {
+		words = append(words, "estimate:"+strconv.Itoa(todo.EstimateMinutes))
+	}
+	if todo.Status == model.Processing {
+		words = append(words, "status:"+string(todo.Status))
+	}
+	words = append(words, "id:"+strconv.Itoa(todo.ID))
+
+	_, err := e.w.WriteString(strings.Join(words, " ") + "\n")
+	return err
+}
+
+func (e *todoTxtEncoder) Close() error {
+	return e.w.Flush()
+}
+
+// oneLine joins the lines of s with spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func decodeTodoTxt(r io.Reader) ([]*model.ImportRecord, error) {
+	scanner := bufio.NewScanner(r)
+	var records []*model.ImportRecord
+	for line := 1; scanner.Scan(); line++ {
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+		record := newRecord(line)
+		record.Err = parseTodoTxt(record, words)
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidImport, err)
+	}
+	return records, nil
+}
+
+// parseTodoTxt fills record with the words of a todo.txt line.
+func parseTodoTxt(record *model.ImportRecord, words []string) error {
+	if words[0] == "x" {
+		record.Status = model.Done
+		words = words[1:]
+		// The completion date is followed by the creation date, if any.
+		if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+			words = words[1:]
+		}
+	} else if todoTxtPriority.MatchString(words[0]) {
+		record.Todo.Priority = string(priorityOf(words[0][1:2]))
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+		createdAt, err := time.Parse(dateLayout, words[0])
+		if err != nil {
+			return fmt.Errorf("creation date: %s", err)
+		}
+		record.CreatedAt = &createdAt
+		words = words[1:]
+	}
+
+	var task []string
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && word[0] == '+' && record.Todo.Project == "":
+			record.Todo.Project = word[1:]
+		case len(word) > 1 && word[0] == '@':
+			record.Todo.Tags = append(record.Todo.Tags, word[1:])
+		case todoTxtKeyValue.MatchString(word):
+			match := todoTxtKeyValue.FindStringSubmatch(word)
+			known, err := parseTodoTxtKeyValue(record, match[1], match[2])
+			if err != nil {
+				return err
+			}
+			if !known {
+				task = append(task, word)
+			}
+		default:
+			task = append(task, word)
+		}
+	}
+	record.Todo.Task = strings.Join(task, " ")
+	return nil
+}
+
+// parseTodoTxtKeyValue fills record with a key:value pair. It tells whether
+// the key is known; the others are words of the task, such as a time of day.
+func parseTodoTxtKeyValue(record *model.ImportRecord, key, value string) (bool, error) {
+	var err error
+	switch key {
+	case "due":
+		var due time.Time
+		if due, err = time.Parse(dateLayout, value); err != nil {
+			return true, fmt.Errorf("due: %q is not a date", value)
+		}
+		record.Todo.DueAt = &due
+	case "pri":
+		if len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+			return true, fmt.Errorf("pri: %q is not a letter", value)
+		}
+		record.Todo.Priority = string(priorityOf(value))
+	case "id":
+		if record.ID, err = strconv.Atoi(value); err != nil {
+			return true, fmt.Errorf("id: %q is not a number", value)
+		}
+	case "assignee":
+		record.Todo.Assignee = value
+	case "estimate":
+		if record.Todo.EstimateMinutes, err = strconv.Atoi(value); err != nil {
+			return true, fmt.Errorf("estimate: %q is not a number of minutes", value)
+		}
+	case "status":
+		record.Status = model.Status(value)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// priorityOf returns the priority of a todo.txt letter: every letter after C is low.
+func priorityOf(letter string) model.TodoPriority {
+	for priority, l := range todoTxtPriorities {
+		if l == letter {
+			return priority
+		}
+	}
+	return model.TP_Low
+}
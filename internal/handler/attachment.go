@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// AttachmentHandler is the request handler for the attachment endpoint.
+type AttachmentHandler interface {
+	Upload(c echo.Context) error
+	Download(c echo.Context) error
+	Delete(c echo.Context) error
+	FindAll(c echo.Context) error
+}
+
+type InitAttachmentHandler struct {
+	Service service.IAttachment
+	Log     *log.Logger
+}
+
+type attachmentHandler struct {
+	Handler
+	service service.IAttachment
+	log     *log.Logger
+}
+
+// NewAttachment returns a new instance of the attachment handler.
+func NewAttachment(initAttachmentHandler *InitAttachmentHandler) AttachmentHandler {
+	return &attachmentHandler{
+		log:     initAttachmentHandler.Log,
+		service: initAttachmentHandler.Service,
+	}
+}
+
+// @Summary	Upload an attachment
+// @Tags		attachments
+// @Accept		multipart/form-data
+// @Produce	json
+// @Param		path	path		model.AttachmentRequestPath	false	"path"
+// @Param		file	formData	file						true	"file"
+// @Success	201		{object}	ResponseData{data=model.Attachment}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	413		{object}	errors.Problem
+// @Failure	415		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/attachments [post]
+func (a *attachmentHandler) Upload(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.AttachmentRequestPath
+
+	if err := a.MustBind(c, &req); err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, apperrors.Validation([]apperrors.Violation{{Field: "file", Rule: "required"}}))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, apperrors.Wrap(err, http.StatusBadRequest, apperrors.CodeBadRequest))
+	}
+	defer file.Close()
+
+	attachment, err := a.service.Upload(ctx, &model.UploadAttachmentRequest{
+		TodoID:   req.TodoID,
+		Filename: fileHeader.Filename,
+		Size:     fileHeader.Size,
+	}, file)
+	if err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: attachment})
+}
+
+// @Summary	Download an attachment
+// @Tags		attachments
+// @Produce	octet-stream
+// @Param		path	path	model.FindAttachmentRequest	false	"path"
+// @Success	200
+// @Success	304
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/todos/:id/attachments/:attachmentId [get]
+func (a *attachmentHandler) Download(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.FindAttachmentRequest
+
+	if err := a.MustBind(c, &req); err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	attachment, content, err := a.service.Download(ctx, &req)
+	if err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+	defer content.Close()
+
+	// The digest identifies the content, so it is a strong validator.
+	etag := fmt.Sprintf(`"%s"`, attachment.Digest)
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Stream(http.StatusOK, attachment.ContentType, content)
+}
+
+// @Summary	Delete an attachment
+// @Tags		attachments
+// @Param		path	path	model.DeleteAttachmentRequest	false	"path"
+// @Success	204
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/todos/:id/attachments/:attachmentId [delete]
+func (a *attachmentHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.DeleteAttachmentRequest
+
+	if err := a.MustBind(c, &req); err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	if err := a.service.Delete(ctx, &req); err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary	Find all attachments of a todo
+// @Tags		attachments
+// @Produce	json
+// @Param		path	path		model.AttachmentRequestPath	false	"path"
+// @Success	200		{object}	ResponseData{Data=[]model.Attachment}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/attachments [get]
+func (a *attachmentHandler) FindAll(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.AttachmentRequestPath
+
+	if err := a.MustBind(c, &req); err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	res, err := a.service.FindAll(ctx, &req)
+	if err != nil {
+		a.log.Error(ctx, err.Error())
+		return a.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/blob"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+func initAttachmentSetup(t *testing.T) (AttachmentHandler, service.IAttachment, blob.IStore, *model.Todo) {
+	// The environment is private: attachments share blobs by digest, so counts must not leak between tests.
+	env := newTestEnv(t)
+	todo := &model.Todo{Task: "With attachments", Status: model.Created, Priority: model.TP_Low}
+	require.NoError(t, env.todos.Create(context.Background(), todo))
+
+	store := blob.NewLocal(&blob.InitLocalStore{Dir: t.TempDir()})
+	attachmentService := service.NewAttachment(&service.InitAttachmentService{
+		Log:                  env.log,
+		AttachmentRepository: repository.NewAttachment(&repository.InitAttachmentRepository{Db: env.db, Log: env.log}),
+		TodoRepository:       env.todos,
+		BlobStore:            store,
+		Config: model.Attachments{
+			MaxSize:      16,
+			AllowedTypes: []string{"text/plain", "image/*"},
+		},
+	})
+	return NewAttachment(&InitAttachmentHandler{Service: attachmentService, Log: env.log}), attachmentService, store, todo
+}
+
+func uploadRequest(t *testing.T, e *echo.Echo, todoID string, content []byte) (echo.Context, *httptest.ResponseRecorder) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	part, err := w.CreateFormFile("file", "notes.txt")
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/dummy/target", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/todos/:id/attachments")
+	c.SetParamNames("id")
+	c.SetParamValues(todoID)
+	return c, rec
+}
+
+func TestAttachmentHandler_Upload(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, _, _, todo := initAttachmentSetup(t)
+
+	tests := []struct {
+		name       string
+		todoID     string
+		content    []byte
+		wantStatus int
+	}{
+		{name: "successful_upload", todoID: strconv.Itoa(todo.ID), content: []byte("hello"), wantStatus: http.StatusCreated},
+		{name: "too_large", todoID: strconv.Itoa(todo.ID), content: bytes.Repeat([]byte("a"), 17), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "type_not_allowed", todoID: strconv.Itoa(todo.ID), content: []byte("%PDF-1.4"), wantStatus: http.StatusUnsupportedMediaType},
+		{name: "todo_not_found", todoID: "-1", content: []byte("hello"), wantStatus: http.StatusNotFound},
+		{name: "invalid_request_parameter", todoID: "invalid", content: []byte("hello"), wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := uploadRequest(t, e, tt.todoID, tt.content)
+			require.NoError(t, handler.Upload(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestAttachmentHandler_DownloadAndDelete(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, _, store, todo := initAttachmentSetup(t)
+	todoID := strconv.Itoa(todo.ID)
+
+	// Upload the same content twice; the blob is stored once
+	var attachments []model.Attachment
+	for i := 0; i < 2; i++ {
+		c, rec := uploadRequest(t, e, todoID, []byte("hello"))
+		require.NoError(t, handler.Upload(c))
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var res struct{ Data model.Attachment }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		attachments = append(attachments, res.Data)
+	}
+	assert.Equal(t, attachments[0].Digest, attachments[1].Digest)
+	blobs, err := store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+
+	newContext := func(method string, attachmentID int) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(method, "/dummy/target", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/todos/:id/attachments/:attachmentId")
+		c.SetParamNames("id", "attachmentId")
+		c.SetParamValues(todoID, strconv.Itoa(attachmentID))
+		return c, rec
+	}
+
+	c, rec := newContext(http.MethodGet, attachments[0].ID)
+	require.NoError(t, handler.Download(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello", rec.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename=notes.txt`, rec.Header().Get("Content-Disposition"))
+
+	// Deleting one reference keeps the shared blob
+	c, rec = newContext(http.MethodDelete, attachments[0].ID)
+	require.NoError(t, handler.Delete(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	blobs, err = store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+
+	c, rec = newContext(http.MethodGet, attachments[0].ID)
+	require.NoError(t, handler.Download(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Deleting the last reference leaves the blob to the garbage collection
+	c, rec = newContext(http.MethodDelete, attachments[1].ID)
+	require.NoError(t, handler.Delete(c))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	blobs, err = store.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1)
+}
+
+func TestAttachmentService_GarbageCollect(t *testing.T) {
+	ctx := context.Background()
+	_, attachmentService, store, todo := initAttachmentSetup(t)
+
+	_, err := attachmentService.Upload(ctx, &model.UploadAttachmentRequest{TodoID: todo.ID, Filename: "kept.txt"}, bytes.NewReader([]byte("kept")))
+	require.NoError(t, err)
+	orphan, err := store.Put(ctx, bytes.NewReader([]byte("orphan")))
+	require.NoError(t, err)
+
+	// Recently written blobs are protected by the grace period
+	res, err := attachmentService.GarbageCollect(ctx, &model.GarbageCollectRequest{GracePeriod: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Blobs)
+
+	res, err = attachmentService.GarbageCollect(ctx, &model.GarbageCollectRequest{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Blobs)
+	assert.Equal(t, orphan.Size, res.Bytes)
+
+	res, err = attachmentService.GarbageCollect(ctx, &model.GarbageCollectRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Blobs)
+
+	blobs, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+	assert.NotEqual(t, orphan.Digest, blobs[0].Digest)
+
+	// The blob of a deleted attachment is removed by the sweep once its grace
+	// period is over, not by the delete that an upload of it may be racing.
+	deleted, err := attachmentService.Upload(ctx, &model.UploadAttachmentRequest{TodoID: todo.ID, Filename: "gone.txt"}, bytes.NewReader([]byte("gone")))
+	require.NoError(t, err)
+	require.NoError(t, attachmentService.Delete(ctx, &model.DeleteAttachmentRequest{TodoID: todo.ID, AttachmentID: deleted.ID}))
+	res, err = attachmentService.GarbageCollect(ctx, &model.GarbageCollectRequest{GracePeriod: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, 0, res.Blobs)
+	res, err = attachmentService.GarbageCollect(ctx, &model.GarbageCollectRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Blobs)
+}
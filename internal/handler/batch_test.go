@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func TestTodoHandler_Batch(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	todoHandler, boardHandler := initBoardSetup(t, model.BoardConfig{})
+	e.POST("/todos", todoHandler.Create)
+	e.POST("/todos\\:batch", todoHandler.Batch)
+	e.GET("/todos", todoHandler.FindAll)
+
+	a := createTask(t, e, todoHandler, `{"task":"A","priority":"low"}`)
+	b := createTask(t, e, todoHandler, `{"task":"B","priority":"low"}`)
+
+	batch := func(body string) (int, model.BatchResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/todos:batch", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var res struct{ Data model.BatchResponse }
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res.Data
+	}
+	tasks := func() []string {
+		var got []string
+		for _, column := range getBoard(t, e, boardHandler, "").Columns {
+			for _, todo := range column.Todos {
+				got = append(got, string(todo.Status)+":"+todo.Task)
+			}
+		}
+		return got
+	}
+	cached := func() []string {
+		req := httptest.NewRequest(http.MethodGet, "/todos?sort=manual", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var res struct{ Data []model.Todo }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		var got []string
+		for _, todo := range res.Data {
+			got = append(got, string(todo.Status)+":"+todo.Task)
+		}
+		return got
+	}
+
+	t.Run("atomic_rolls_back_on_failure", func(t *testing.T) {
+		status, _ := batch(fmt.Sprintf(`{"operations":[
+			{"op":"update","id":%d,"update":{"status":"done"}},
+			{"op":"create","create":{"task":"C","priority":"high"}},
+			{"op":"delete","id":99999}
+		]}`, a))
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, []string{"created:A", "created:B"}, tasks())
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		status, res := batch(fmt.Sprintf(`{"operations":[
+			{"op":"update","id":%d,"update":{"status":"done"}},
+			{"op":"create","create":{"task":"C","priority":"high"}},
+			{"op":"delete","id":%d}
+		]}`, a, b))
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, model.BatchAtomic, res.Mode)
+		assert.Equal(t, 3, res.Succeeded)
+		require.Len(t, res.Results, 3)
+		assert.Equal(t, []int{http.StatusOK, http.StatusCreated, http.StatusNoContent},
+			[]int{res.Results[0].Status, res.Results[1].Status, res.Results[2].Status})
+		assert.Equal(t, "C", res.Results[1].Todo.Task)
+		assert.Equal(t, []string{"created:C", "done:A"}, tasks())
+		assert.Equal(t, []string{"created:C", "done:A"}, cached())
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		status, res := batch(fmt.Sprintf(`{"mode":"partial","operations":[
+			{"op":"update","id":%d,"update":{"task":"A2"}},
+			{"op":"delete","id":99999},
+			{"op":"create","create":{"task":"D","priority":"bogus"}},
+			{"op":"create","create":{"task":"E","priority":"low"}}
+		]}`, a))
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 2, res.Succeeded)
+		assert.Equal(t, 2, res.Failed)
+		require.Len(t, res.Results, 4)
+		assert.Equal(t, http.StatusOK, res.Results[0].Status)
+		assert.Equal(t, http.StatusNotFound, res.Results[1].Status)
+		assert.NotEmpty(t, res.Results[1].Error)
+		assert.Equal(t, http.StatusBadRequest, res.Results[2].Status)
+		assert.Equal(t, http.StatusCreated, res.Results[3].Status)
+		assert.Equal(t, []string{"created:C", "created:E", "done:A2"}, tasks())
+		assert.Equal(t, []string{"created:C", "created:E", "done:A2"}, cached())
+	})
+
+	t.Run("invalid_request", func(t *testing.T) {
+		for _, body := range []string{
+			`{"operations":[]}`,
+			`{"mode":"sometimes","operations":[{"op":"delete","id":1}]}`,
+			`{"operations":[{"op":"archive","id":1}]}`,
+			`{"operations":[{"op":"delete"}]}`,
+			`{"operations":[{"op":"update","id":1}]}`,
+			`{"operations":[{"op":"create","id":1,"create":{"task":"X","priority":"low"}}]}`,
+			`{"operations":[{"op":"create","create":{"priority":"low"}}]}`,
+		} {
+			status, _ := batch(body)
+			assert.Equal(t, http.StatusBadRequest, status, body)
+		}
+	})
+}
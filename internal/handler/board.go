@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// BoardHandler is the request handler for the board endpoint.
+type BoardHandler interface {
+	Board(c echo.Context) error
+}
+
+type InitBoardHandler struct {
+	Service service.ITodo
+	Log     *log.Logger
+}
+
+type boardHandler struct {
+	Handler
+	service service.ITodo
+	log     *log.Logger
+}
+
+// NewBoard returns a new instance of the board handler.
+func NewBoard(initBoardHandler *InitBoardHandler) BoardHandler {
+	return &boardHandler{
+		log:     initBoardHandler.Log,
+		service: initBoardHandler.Service,
+	}
+}
+
+// @Summary	Get the Kanban board
+// @Description	Returns the todos grouped into status columns in workflow order, with counts and WIP limits.
+// @Tags		board
+// @Produce	json
+// @Param		project	query		string	false	"project"
+// @Success	200		{object}	ResponseData{Data=model.Board}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/board [get]
+func (b *boardHandler) Board(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.BoardRequest
+
+	if err := b.MustBind(c, &req); err != nil {
+		b.log.Error(ctx, err.Error())
+		return b.Fail(c, err)
+	}
+
+	res, err := b.service.Board(ctx, &req)
+	if err != nil {
+		b.log.Error(ctx, err.Error())
+		return b.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
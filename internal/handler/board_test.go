@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+func initBoardSetup(t *testing.T, config model.BoardConfig) (TodoHandler, BoardHandler) {
+	// The environment is private: the board shows every todo.
+	env := newTestEnv(t)
+	todoService := env.todoService(&service.InitTodoService{Board: config})
+	return NewTodo(&InitTodoHandler{Service: todoService, Log: env.log}),
+		NewBoard(&InitBoardHandler{Service: todoService, Log: env.log})
+}
+
+func moveTask(t *testing.T, e *echo.Echo, handler TodoHandler, id int, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/dummy/target", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/todos/:id/move")
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(id))
+	require.NoError(t, handler.Move(c))
+	return rec.Code
+}
+
+func getBoard(t *testing.T, e *echo.Echo, handler BoardHandler, query string) model.Board {
+	req := httptest.NewRequest(http.MethodGet, "/dummy/target"+query, nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.Board(e.NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct{ Data model.Board }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return res.Data
+}
+
+func TestBoardHandler_Board(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	todoHandler, boardHandler := initBoardSetup(t, model.BoardConfig{WIPLimits: map[string]int{"processing": 1}})
+
+	a := createTask(t, e, todoHandler, `{"task":"A","priority":"low","project":"web"}`)
+	b := createTask(t, e, todoHandler, `{"task":"B","priority":"high"}`)
+	createTask(t, e, todoHandler, `{"task":"C","priority":"medium","project":"web"}`)
+	require.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, a, `{"status":"processing"}`))
+	// Limits are not enforced, so the column is only flagged
+	require.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, b, fmt.Sprintf(`{"before":%d}`, a)))
+
+	board := getBoard(t, e, boardHandler, "")
+	require.Len(t, board.Columns, 3)
+
+	type column struct {
+		Status    model.Status
+		Count     int
+		WIPLimit  int
+		WIPCount  int
+		OverLimit bool
+		Tasks     []string
+	}
+	var got []column
+	for _, c := range board.Columns {
+		col := column{Status: c.Status, Count: c.Count, WIPLimit: c.WIPLimit, WIPCount: c.WIPCount, OverLimit: c.OverLimit, Tasks: []string{}}
+		for _, todo := range c.Todos {
+			col.Tasks = append(col.Tasks, todo.Task)
+		}
+		got = append(got, col)
+	}
+	assert.Equal(t, []column{
+		{Status: model.Created, Count: 1, Tasks: []string{"C"}},
+		{Status: model.Processing, Count: 2, WIPLimit: 1, WIPCount: 2, OverLimit: true, Tasks: []string{"B", "A"}},
+		{Status: model.Done, Count: 0, Tasks: []string{}},
+	}, got)
+
+	board = getBoard(t, e, boardHandler, "?project=web")
+	assert.Equal(t, 1, board.Columns[0].Count)
+	assert.Equal(t, 1, board.Columns[1].Count)
+	// The limit counts the todos of every project, as when it is enforced
+	assert.Equal(t, 2, board.Columns[1].WIPCount)
+	assert.True(t, board.Columns[1].OverLimit)
+	assert.Zero(t, board.Columns[0].WIPCount, "a column without a limit has no WIP count")
+}
+
+func TestBoardHandler_EnforceWIPLimits(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	todoHandler, boardHandler := initBoardSetup(t, model.BoardConfig{
+		WIPLimits:        map[string]int{"processing": 1},
+		EnforceWIPLimits: true,
+	})
+
+	a := createTask(t, e, todoHandler, `{"task":"A","priority":"low"}`)
+	b := createTask(t, e, todoHandler, `{"task":"B","priority":"low","project":"web"}`)
+	require.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, a, `{"status":"processing"}`))
+
+	// The board of another project shows the column full, as the move below finds it
+	board := getBoard(t, e, boardHandler, "?project=web")
+	assert.Equal(t, 0, board.Columns[1].Count)
+	assert.Equal(t, 1, board.Columns[1].WIPCount)
+
+	// The processing column is full
+	assert.Equal(t, http.StatusConflict, moveTask(t, e, todoHandler, b, `{"status":"processing"}`))
+	assert.Equal(t, http.StatusConflict, moveTask(t, e, todoHandler, b, fmt.Sprintf(`{"after":%d}`, a)))
+
+	req := httptest.NewRequest(http.MethodPut, "/dummy/target", bytes.NewReader([]byte(`{"status":"processing"}`)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/todos/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(b))
+	require.NoError(t, todoHandler.Update(c))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Reordering within the full column is still allowed
+	assert.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, a, `{"status":"processing"}`))
+
+	require.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, a, `{"status":"done"}`))
+	assert.Equal(t, http.StatusOK, moveTask(t, e, todoHandler, b, `{"status":"processing"}`))
+
+	board = getBoard(t, e, boardHandler, "")
+	assert.Equal(t, []int{0, 1, 1}, []int{board.Columns[0].Count, board.Columns[1].Count, board.Columns[2].Count})
+}
@@ -0,0 +1,520 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+const (
+	// CalDAVPath is the principal and calendar home of the CalDAV endpoint.
+	CalDAVPath = "/caldav/"
+	// CalDAVCalendarPath is the calendar of the todos, one VTODO resource each.
+	CalDAVCalendarPath = CalDAVPath + "todos/"
+
+	// maxCalDAVBody limits the request bodies of CalDAV.
+	maxCalDAVBody = 1 << 20
+
+	nsDAV         = "DAV:"
+	nsCalDAV      = "urn:ietf:params:xml:ns:caldav"
+	nsCalServer   = "http://calendarserver.org/ns/"
+	calDAVAllow   = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	calDAVClasses = "1, 3, calendar-access"
+)
+
+// davPrefixes are the prefixes of the namespaces of the multistatus responses.
+var davPrefixes = map[string]string{nsDAV: "D", nsCalDAV: "C", nsCalServer: "CS"}
+
+// The properties of the resources.
+var (
+	propResourceType     = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName      = xml.Name{Space: nsDAV, Local: "displayname"}
+	propPrincipal        = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL     = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propPrivileges       = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propETag             = xml.Name{Space: nsDAV, Local: "getetag"}
+	propContentType      = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propContentLength    = xml.Name{Space: nsDAV, Local: "getcontentlength"}
+	propCalendarHome     = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propComponentSet     = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData     = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propCTag             = xml.Name{Space: nsCalServer, Local: "getctag"}
+	reportCalendarQuery  = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	reportCalendarMulti  = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+	davTextEscaper       = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	calDAVObjectMIMEType = "text/calendar; charset=utf-8; component=VTODO"
+)
+
+// CalDAVHandler is the request handler for the CalDAV endpoint (RFC 4791),
+// for the task apps of phones to sync the todos as VTODOs.
+type CalDAVHandler interface {
+	WellKnown(c echo.Context) error
+	Options(c echo.Context) error
+	Propfind(c echo.Context) error
+	Report(c echo.Context) error
+	Get(c echo.Context) error
+	Put(c echo.Context) error
+	Delete(c echo.Context) error
+}
+
+type InitCalDAVHandler struct {
+	Service service.ICalDAV
+	Log     *log.Logger
+}
+
+type calDAVHandler struct {
+	Handler
+	service service.ICalDAV
+	log     *log.Logger
+}
+
+// NewCalDAV returns a new instance of the CalDAV handler.
+func NewCalDAV(initCalDAVHandler *InitCalDAVHandler) CalDAVHandler {
+	return &calDAVHandler{
+		log:     initCalDAVHandler.Log,
+		service: initCalDAVHandler.Service,
+	}
+}
+
+// davProperty is a property of a resource, with its value as XML.
+type davProperty struct {
+	name  xml.Name
+	value string
+}
+
+// davPropNames are the properties asked for by a PROPFIND or a REPORT.
+type davPropNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+type davPropfind struct {
+	XMLName  xml.Name      `xml:"DAV: propfind"`
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+type calCompFilter struct {
+	Name        string          `xml:"name,attr"`
+	CompFilters []calCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type calReport struct {
+	XMLName xml.Name
+	AllProp *struct{}     `xml:"DAV: allprop"`
+	Prop    *davPropNames `xml:"DAV: prop"`
+	Hrefs   []string      `xml:"DAV: href"`
+	Filter  struct {
+		CompFilters []calCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+// WellKnown points the clients looking for the service (RFC 6764) to the principal.
+func (ch *calDAVHandler) WellKnown(c echo.Context) error {
+	return c.Redirect(http.StatusMovedPermanently, CalDAVPath)
+}
+
+func (ch *calDAVHandler) Options(c echo.Context) error {
+	c.Response().Header().Set("DAV", calDAVClasses)
+	c.Response().Header().Set(echo.HeaderAllow, calDAVAllow)
+	return c.NoContent(http.StatusOK)
+}
+
+// Propfind returns the properties of the principal, the calendar or a todo,
+// and with a Depth of 1, of their members.
+func (ch *calDAVHandler) Propfind(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req davPropfind
+	if err := readDAVBody(c, &req); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	depth := c.Request().Header.Get("Depth")
+	if depth != "" && depth != "0" && depth != "1" && depth != "infinity" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	members := depth != "0"
+
+	var b strings.Builder
+	startMultistatus(&b)
+	write := func(href string, props []davProperty) {
+		switch {
+		case req.PropName != nil:
+			names := make([]davProperty, 0, len(props))
+			for _, p := range props {
+				names = append(names, davProperty{name: p.name})
+			}
+			writeDAVResponse(&b, href, names, nil)
+		case req.Prop != nil:
+			found, missing := selectProperties(props, req.Prop)
+			writeDAVResponse(&b, href, found, missing)
+		default:
+			writeDAVResponse(&b, href, allProperties(props), nil)
+		}
+	}
+
+	name, err := calDAVObjectName(c)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	switch {
+	case name != "":
+		resource, err := ch.service.Resource(ctx, name)
+		if err != nil {
+			return ch.fail(c, err)
+		}
+		write(calDAVHref(resource.Name), objectProperties(resource))
+	case strings.HasPrefix(c.Request().URL.Path, strings.TrimSuffix(CalDAVCalendarPath, "/")):
+		resources, err := ch.service.Resources(ctx)
+		if err != nil {
+			return ch.fail(c, err)
+		}
+		write(CalDAVCalendarPath, calendarProperties(resources))
+		if members {
+			for _, resource := range resources {
+				write(calDAVHref(resource.Name), objectProperties(resource))
+			}
+		}
+	default:
+		write(CalDAVPath, principalProperties())
+		if members {
+			resources, err := ch.service.Resources(ctx)
+			if err != nil {
+				return ch.fail(c, err)
+			}
+			write(CalDAVCalendarPath, calendarProperties(resources))
+		}
+	}
+	return endMultistatus(c, &b)
+}
+
+// Report answers the calendar-query and calendar-multiget reports of the calendar.
+// The filters only tell VTODOs from other components: the time ranges are ignored.
+func (ch *calDAVHandler) Report(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req calReport
+	if err := readDAVBody(c, &req); err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	props := func(resource *model.CalDAVResource) ([]davProperty, []xml.Name) {
+		if req.Prop == nil {
+			return allProperties(objectProperties(resource)), nil
+		}
+		return selectProperties(objectProperties(resource), req.Prop)
+	}
+
+	var b strings.Builder
+	switch req.XMLName {
+	case reportCalendarQuery:
+		resources, err := ch.service.Resources(ctx)
+		if err != nil {
+			return ch.fail(c, err)
+		}
+		startMultistatus(&b)
+		if matchesVTODO(req.Filter.CompFilters) {
+			for _, resource := range resources {
+				found, missing := props(resource)
+				writeDAVResponse(&b, calDAVHref(resource.Name), found, missing)
+			}
+		}
+	case reportCalendarMulti:
+		startMultistatus(&b)
+		for _, href := range req.Hrefs {
+			href = strings.TrimSpace(href)
+			resource, err := ch.hrefResource(c, href)
+			if errors.Is(err, model.ErrNotFound) {
+				writeDAVStatus(&b, href, http.StatusNotFound)
+				continue
+			}
+			if err != nil {
+				return ch.fail(c, err)
+			}
+			found, missing := props(resource)
+			writeDAVResponse(&b, calDAVHref(resource.Name), found, missing)
+		}
+	default:
+		return writeDAVError(c, http.StatusForbidden, "<D:supported-report/>")
+	}
+	return endMultistatus(c, &b)
+}
+
+// Get returns the calendar of a todo, with its entity tag.
+func (ch *calDAVHandler) Get(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	name, err := calDAVObjectName(c)
+	if err != nil {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	resource, err := ch.service.Resource(ctx, name)
+	if err != nil {
+		return ch.fail(c, err)
+	}
+
+	c.Response().Header().Set("ETag", resource.ETag)
+	return c.Blob(http.StatusOK, calDAVObjectMIMEType, resource.Data)
+}
+
+// Put creates or replaces the todo of a resource from a calendar of one VTODO.
+// No entity tag is returned: the todo is not stored byte for byte, so the
+// clients are to fetch it again.
+func (ch *calDAVHandler) Put(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	name, err := calDAVObjectName(c)
+	if err != nil || name == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCalDAVBody+1))
+	if err != nil {
+		return ch.fail(c, err)
+	}
+	if len(data) > maxCalDAVBody {
+		return writeDAVError(c, http.StatusRequestEntityTooLarge, "<C:max-resource-size/>")
+	}
+
+	_, created, err := ch.service.Put(ctx, &model.PutCalDAVRequest{
+		Name: name, Data: data,
+		IfMatch:     c.Request().Header.Get("If-Match"),
+		IfNoneMatch: c.Request().Header.Get("If-None-Match"),
+	})
+	if err != nil {
+		return ch.fail(c, err)
+	}
+	if created {
+		return c.NoContent(http.StatusCreated)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (ch *calDAVHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	name, err := calDAVObjectName(c)
+	if err != nil || name == "" {
+		return c.NoContent(http.StatusBadRequest)
+	}
+	err = ch.service.Delete(ctx, &model.DeleteCalDAVRequest{
+		Name: name, IfMatch: c.Request().Header.Get("If-Match"),
+	})
+	if err != nil {
+		return ch.fail(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// fail writes the CalDAV response of an error: the clients expect the
+// preconditions of RFC 4791, not problem+json.
+func (ch *calDAVHandler) fail(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return c.NoContent(http.StatusNotFound)
+	case errors.Is(err, model.ErrPreconditionFailed):
+		return c.NoContent(http.StatusPreconditionFailed)
+	case errors.Is(err, model.ErrInvalidImport):
+		return writeDAVError(c, http.StatusForbidden, "<C:valid-calendar-data/>")
+	case errors.Is(err, model.ErrInvalidTodo):
+		return writeDAVError(c, http.StatusForbidden, "<C:valid-calendar-object-resource/>")
+	case errors.Is(err, model.ErrUIDConflict):
+		return writeDAVError(c, http.StatusForbidden, "<C:no-uid-conflict/>")
+	case errors.Is(err, model.ErrWIPLimitReached):
+		return c.NoContent(http.StatusConflict)
+	}
+	ch.log.Error(c.Request().Context(), err.Error())
+	return c.NoContent(http.StatusInternalServerError)
+}
+
+// hrefResource returns the resource of an href of a calendar-multiget.
+func (ch *calDAVHandler) hrefResource(c echo.Context, href string) (*model.CalDAVResource, error) {
+	u, err := url.Parse(href)
+	if err != nil || !strings.HasPrefix(u.Path, CalDAVCalendarPath) {
+		return nil, model.ErrNotFound
+	}
+	name := strings.TrimPrefix(u.Path, CalDAVCalendarPath)
+	if name == "" || strings.Contains(name, "/") {
+		return nil, model.ErrNotFound
+	}
+	return ch.service.Resource(c.Request().Context(), name)
+}
+
+// calDAVObjectName returns the name of the resource of the request, or "" for the collections.
+func calDAVObjectName(c echo.Context) (string, error) {
+	return url.PathUnescape(c.Param("name"))
+}
+
+func calDAVHref(name string) string {
+	return CalDAVCalendarPath + url.PathEscape(name)
+}
+
+// readDAVBody reads the XML body of a request into v, leaving it empty without one.
+func readDAVBody(c echo.Context, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxCalDAVBody))
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// matchesVTODO tells whether the comp-filters of a calendar-query select the VTODOs.
+func matchesVTODO(filters []calCompFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, calendar := range filters {
+		if calendar.Name != "VCALENDAR" {
+			continue
+		}
+		if len(calendar.CompFilters) == 0 {
+			return true
+		}
+		for _, component := range calendar.CompFilters {
+			if component.Name == "VTODO" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func principalProperties() []davProperty {
+	home := "<D:href>" + CalDAVPath + "</D:href>"
+	return []davProperty{
+		{propResourceType, "<D:collection/>"},
+		{propDisplayName, "Todo"},
+		{propPrincipal, home},
+		{propPrincipalURL, home},
+		{propCalendarHome, home},
+	}
+}
+
+func calendarProperties(resources []*model.CalDAVResource) []davProperty {
+	// The ctag changes with any todo, for the clients to skip the unchanged calendars.
+	hash := sha256.New()
+	for _, resource := range resources {
+		hash.Write([]byte(resource.Name + resource.ETag))
+	}
+	return []davProperty{
+		{propResourceType, "<D:collection/><C:calendar/>"},
+		{propDisplayName, "Todos"},
+		{propPrincipal, "<D:href>" + CalDAVPath + "</D:href>"},
+		{propComponentSet, `<C:comp name="VTODO"/>`},
+		{propPrivileges, "<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>"},
+		{propCTag, fmt.Sprintf("%x", hash.Sum(nil)[:16])},
+	}
+}
+
+func objectProperties(resource *model.CalDAVResource) []davProperty {
+	return []davProperty{
+		{propResourceType, ""},
+		{propETag, davTextEscaper.Replace(resource.ETag)},
+		{propContentType, calDAVObjectMIMEType},
+		{propContentLength, strconv.Itoa(len(resource.Data))},
+		{propCalendarData, davTextEscaper.Replace(strings.ReplaceAll(string(resource.Data), "\r\n", "\n"))},
+	}
+}
+
+// allProperties returns the properties of an allprop, which leaves out the calendar data.
+func allProperties(props []davProperty) []davProperty {
+	all := make([]davProperty, 0, len(props))
+	for _, p := range props {
+		if p.name != propCalendarData {
+			all = append(all, p)
+		}
+	}
+	return all
+}
+
+// selectProperties returns the properties asked for, in their order, and those the resource does not have.
+func selectProperties(props []davProperty, names *davPropNames) ([]davProperty, []xml.Name) {
+	var found []davProperty
+	var missing []xml.Name
+next:
+	for _, n := range names.Names {
+		for _, p := range props {
+			if p.name == n.XMLName {
+				found = append(found, p)
+				continue next
+			}
+		}
+		missing = append(missing, n.XMLName)
+	}
+	return found, missing
+}
+
+func startMultistatus(b *strings.Builder) {
+	b.WriteString(xml.Header)
+	fmt.Fprintf(b, "<D:multistatus xmlns:D=%q xmlns:C=%q xmlns:CS=%q>\n", nsDAV, nsCalDAV, nsCalServer)
+}
+
+func endMultistatus(c echo.Context, b *strings.Builder) error {
+	b.WriteString("</D:multistatus>\n")
+	return c.Blob(http.StatusMultiStatus, echo.MIMEApplicationXMLCharsetUTF8, []byte(b.String()))
+}
+
+func writeDAVResponse(b *strings.Builder, href string, found []davProperty, missing []xml.Name) {
+	fmt.Fprintf(b, "  <D:response>\n    <D:href>%s</D:href>\n", davTextEscaper.Replace(href))
+	if len(found) > 0 {
+		b.WriteString("    <D:propstat>\n      <D:prop>\n")
+		for _, p := range found {
+			b.WriteString("        ")
+			writeDAVElement(b, p.name, p.value)
+			b.WriteString("\n")
+		}
+		b.WriteString("      </D:prop>\n      <D:status>HTTP/1.1 200 OK</D:status>\n    </D:propstat>\n")
+	}
+	if len(missing) > 0 {
+		b.WriteString("    <D:propstat>\n      <D:prop>\n")
+		for _, name := range missing {
+			b.WriteString("        ")
+			writeDAVElement(b, name, "")
+			b.WriteString("\n")
+		}
+		b.WriteString("      </D:prop>\n      <D:status>HTTP/1.1 404 Not Found</D:status>\n    </D:propstat>\n")
+	}
+	b.WriteString("  </D:response>\n")
+}
+
+func writeDAVStatus(b *strings.Builder, href string, status int) {
+	fmt.Fprintf(b, "  <D:response>\n    <D:href>%s</D:href>\n    <D:status>HTTP/1.1 %d %s</D:status>\n  </D:response>\n",
+		davTextEscaper.Replace(href), status, http.StatusText(status))
+}
+
+// writeDAVElement writes an element of value, empty without one.
+func writeDAVElement(b *strings.Builder, name xml.Name, value string) {
+	tag, attr := name.Local, ""
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		var space strings.Builder
+		_ = xml.EscapeText(&space, []byte(name.Space))
+		attr = fmt.Sprintf(` xmlns="%s"`, space.String())
+	}
+	if value == "" {
+		fmt.Fprintf(b, "<%s%s/>", tag, attr)
+		return
+	}
+	fmt.Fprintf(b, "<%s%s>%s</%s>", tag, attr, value, tag)
+}
+
+// writeDAVError writes a precondition that does not hold (RFC 4918, section 16).
+func writeDAVError(c echo.Context, status int, precondition string) error {
+	body := fmt.Sprintf("%s<D:error xmlns:D=%q xmlns:C=%q>%s</D:error>\n", xml.Header, nsDAV, nsCalDAV, precondition)
+	return c.Blob(status, echo.MIMEApplicationXMLCharsetUTF8, []byte(body))
+}
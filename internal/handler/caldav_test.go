@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+var updateCalDAV = flag.Bool("update", false, "record the responses of testdata/caldav")
+
+// calDAVFixtureSeparator separates the request of a fixture from its response.
+const calDAVFixtureSeparator = "### response\n"
+
+// calDAVHeaders are the response headers compared with the fixtures.
+var calDAVHeaders = []string{"Allow", "Content-Type", "DAV", "ETag", "Location"}
+
+var (
+	// The hashes and times change from run to run.
+	calDAVHash = regexp.MustCompile(`[0-9a-f]{32}`)
+	calDAVTime = regexp.MustCompile(`\d{8}T\d{6}Z`)
+)
+
+// TestCalDAVHandler replays the requests of testdata/caldav, in order against
+// one calendar, and compares the responses with those recorded. Run with
+// -update to record them again. {{etag}} in a request is the last ETag returned.
+func TestCalDAVHandler(t *testing.T) {
+	env := newTestEnv(t)
+	todoService := env.todoService(&service.InitTodoService{})
+	calDAVHandler := NewCalDAV(&InitCalDAVHandler{Service: service.NewCalDAV(&service.InitCalDAVService{
+		Log: env.log, TodoService: todoService, TodoRepository: env.todos, RedisCache: env.cache,
+		CalDAVObjectRepository: repository.NewCalDAVObject(&repository.InitCalDAVObjectRepository{Db: env.db, Log: env.log}),
+	}), Log: env.log})
+
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	addCalDAVRoutes(e, calDAVHandler)
+	todoHandler := NewTodo(&InitTodoHandler{Service: todoService, Log: env.log})
+	createTask(t, e, todoHandler, `{"task":"Ship release","priority":"high","dueAt":"2024-06-01T00:00:00Z"}`)
+
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "caldav", "*.http"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+
+	etag := ""
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".http")
+		data, err := os.ReadFile(fixture)
+		require.NoError(t, err)
+		request, expected, _ := strings.Cut(strings.ReplaceAll(string(data), "\r\n", "\n"), calDAVFixtureSeparator)
+
+		req := readCalDAVRequest(t, strings.ReplaceAll(request, "{{etag}}", etag))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if tag := rec.Header().Get("ETag"); tag != "" {
+			etag = tag
+		}
+
+		actual := calDAVResponse(rec)
+		if *updateCalDAV {
+			require.NoError(t, os.WriteFile(fixture, []byte(request+calDAVFixtureSeparator+actual), 0o644))
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+// readCalDAVRequest reads a request as written in a fixture: the request line,
+// the headers, an empty line and the body.
+func readCalDAVRequest(t *testing.T, s string) *http.Request {
+	head, body, _ := strings.Cut(s, "\n\n")
+	scanner := bufio.NewScanner(strings.NewReader(head))
+	require.True(t, scanner.Scan())
+	line := strings.Fields(scanner.Text())
+	require.Len(t, line, 3, scanner.Text())
+
+	req := httptest.NewRequest(line[0], line[1], strings.NewReader(strings.ReplaceAll(body, "\n", "\r\n")))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		require.True(t, ok, scanner.Text())
+		req.Header.Set(key, strings.TrimSpace(value))
+	}
+	return req
+}
+
+// calDAVResponse writes a response as recorded in the fixtures, without what
+// changes from run to run.
+func calDAVResponse(rec *httptest.ResponseRecorder) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP/1.1 %d %s\n", rec.Code, http.StatusText(rec.Code))
+	for _, key := range calDAVHeaders {
+		if value := rec.Header().Get(key); value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", key, value)
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(strings.ReplaceAll(rec.Body.String(), "\r\n", "\n"))
+
+	s := calDAVHash.ReplaceAllString(b.String(), "{{hash}}")
+	return calDAVTime.ReplaceAllString(s, "{{time}}")
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zuu-development/fullstack-examination-2024/internal/exchange"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// CalendarHandler is the request handler for the calendar endpoint.
+type CalendarHandler interface {
+	CreateFeed(c echo.Context) error
+	FindAllFeeds(c echo.Context) error
+	DeleteFeed(c echo.Context) error
+	Feed(c echo.Context) error
+}
+
+type InitCalendarHandler struct {
+	Service service.ICalendar
+	Log     *log.Logger
+}
+
+type calendarHandler struct {
+	Handler
+	service service.ICalendar
+	log     *log.Logger
+}
+
+// NewCalendar returns a new instance of the calendar handler.
+func NewCalendar(initCalendarHandler *InitCalendarHandler) CalendarHandler {
+	return &calendarHandler{
+		log:     initCalendarHandler.Log,
+		service: initCalendarHandler.Service,
+	}
+}
+
+// @Summary	Create a calendar feed
+// @Description	Creates an iCalendar feed of the todos of an assignee, a project or both, to subscribe to from calendar apps at /calendar/{token}.ics. The token is the only protection of the feed: delete the feed to revoke it.
+// @Tags		calendar
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.CreateCalendarFeedRequest	true	"json"
+// @Success	201		{object}	ResponseData{data=model.CreateCalendarFeedResponse}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/calendar/feeds [post]
+func (ch *calendarHandler) CreateFeed(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CreateCalendarFeedRequest
+
+	if err := ch.MustBind(c, &req); err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	feed, err := ch.service.CreateFeed(ctx, &req)
+	if err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: &model.CreateCalendarFeedResponse{CalendarFeed: *feed, Token: feed.Token}})
+}
+
+// @Summary	Find all calendar feeds
+// @Description	Lists the feeds without their tokens, which only the creation returns.
+// @Tags		calendar
+// @Produce	json
+// @Success	200	{object}	ResponseData{data=[]model.CalendarFeed}
+// @Failure	500	{object}	errors.Problem
+// @Router		/calendar/feeds [get]
+func (ch *calendarHandler) FindAllFeeds(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	feeds, err := ch.service.FindAllFeeds(ctx)
+	if err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: feeds})
+}
+
+// @Summary	Delete a calendar feed
+// @Description	Deletes the feed, so that its token no longer reads the todos.
+// @Tags		calendar
+// @Param		path	path	model.DeleteCalendarFeedRequest	false	"path"
+// @Success	204
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/calendar/feeds/:id [delete]
+func (ch *calendarHandler) DeleteFeed(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.DeleteCalendarFeedRequest
+
+	if err := ch.MustBind(c, &req); err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	if err := ch.service.DeleteFeed(ctx, &req); err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary	Read a calendar feed
+// @Description	Returns the todos of the feed as iCalendar VTODOs (RFC 5545), for calendar apps to subscribe to.
+// @Tags		calendar
+// @Produce	text/calendar
+// @Param		path	path		model.CalendarFeedRequest	false	"path"
+// @Success	200		{file}		file
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/calendar/:token [get]
+func (ch *calendarHandler) Feed(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CalendarFeedRequest
+
+	if err := ch.MustBind(c, &req); err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+	req.Token = strings.TrimSuffix(req.Token, exchange.Extension(model.FormatICal))
+
+	feed, todos, err := ch.service.Feed(ctx, &req)
+	if err != nil {
+		ch.log.Error(ctx, err.Error())
+		return ch.Fail(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, exchange.ContentType(model.FormatICal))
+	enc := exchange.NewICalEncoder(res, feed.Name)
+	for _, todo := range todos {
+		if err := enc.Encode(todo); err != nil {
+			ch.log.Error(ctx, err.Error())
+			return nil
+		}
+	}
+	if err := enc.Close(); err != nil {
+		ch.log.Error(ctx, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	env := newTestEnv(t)
+	todoHandler := NewTodo(&InitTodoHandler{Service: env.todoService(&service.InitTodoService{}), Log: env.log})
+	calendarHandler := NewCalendar(&InitCalendarHandler{Service: service.NewCalendar(&service.InitCalendarService{
+		Log: env.log, TodoRepository: env.todos,
+		CalendarFeedRepository: repository.NewCalendarFeed(&repository.InitCalendarFeedRepository{Db: env.db, Log: env.log}),
+	}), Log: env.log})
+
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	e.POST("/calendar/feeds", calendarHandler.CreateFeed)
+	e.GET("/calendar/feeds", calendarHandler.FindAllFeeds)
+	e.DELETE("/calendar/feeds/:id", calendarHandler.DeleteFeed)
+	e.GET("/calendar/:token", calendarHandler.Feed)
+
+	createTask(t, e, todoHandler, `{"task":"Ship release","priority":"high","assignee":"alice","project":"web","dueAt":"2024-06-01T08:00:00Z"}`)
+	createTask(t, e, todoHandler, `{"task":"Write notes","priority":"low","assignee":"bob","project":"web"}`)
+	createTask(t, e, todoHandler, `{"task":"Buy milk","priority":"medium","assignee":"alice"}`)
+
+	createFeed := func(body string) (int, model.CreateCalendarFeedResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/calendar/feeds", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var res struct {
+			Data model.CreateCalendarFeedResponse
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res.Data
+	}
+	readFeed := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("create_requires_assignee_or_project", func(t *testing.T) {
+		status, _ := createFeed(`{"name":"Everything"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	status, feed := createFeed(`{"assignee":"alice"}`)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "Todos of alice", feed.Name)
+	assert.Len(t, feed.Token, 64)
+
+	t.Run("feed_of_an_assignee", func(t *testing.T) {
+		rec := readFeed("/calendar/" + feed.Token + ".ics")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+
+		body := rec.Body.String()
+		assert.Contains(t, body, "X-WR-CALNAME:Todos of alice\r\n")
+		assert.Equal(t, 2, strings.Count(body, "BEGIN:VTODO"))
+		assert.Contains(t, body, "SUMMARY:Ship release\r\nSTATUS:NEEDS-ACTION\r\nPRIORITY:1\r\nDUE:20240601T080000Z\r\n")
+		assert.Contains(t, body, "SUMMARY:Buy milk\r\n")
+		assert.NotContains(t, body, "Write notes")
+	})
+
+	t.Run("feed_of_a_project_and_assignee", func(t *testing.T) {
+		status, feed := createFeed(`{"name":"Web","assignee":"bob","project":"web"}`)
+		require.Equal(t, http.StatusCreated, status)
+
+		body := readFeed("/calendar/" + feed.Token).Body.String()
+		assert.Equal(t, 1, strings.Count(body, "BEGIN:VTODO"))
+		assert.Contains(t, body, "SUMMARY:Write notes\r\n")
+	})
+
+	t.Run("unknown_token", func(t *testing.T) {
+		rec := readFeed("/calendar/" + strings.Repeat("0", 64) + ".ics")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("find_all_omits_the_tokens", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/calendar/feeds", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res struct{ Data []map[string]interface{} }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Data, 2)
+		for _, feed := range res.Data {
+			assert.NotContains(t, feed, "Token")
+		}
+		assert.NotContains(t, rec.Body.String(), feed.Token)
+	})
+
+	t.Run("delete_revokes_the_token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/calendar/feeds/%d", feed.ID), nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusNoContent, rec.Code)
+
+		assert.Equal(t, http.StatusNotFound, readFeed("/calendar/"+feed.Token+".ics").Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/calendar/feeds", nil)
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var res struct{ Data []model.CalendarFeed }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Data, 1)
+		assert.Equal(t, "Web", res.Data[0].Name)
+	})
+}
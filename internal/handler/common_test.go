@@ -1,14 +1,95 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/broker"
+	cache2 "github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+	"gorm.io/gorm"
 )
 
+// testEnv is what the handlers of a test are wired with: a migrated database
+// and a Redis of the test's own, and the todo repositories over them.
+type testEnv struct {
+	log   *log.Logger
+	db    *gorm.DB
+	redis *redis.Client
+	cache repository.IRedisCache
+	todos repository.ITodo
+}
+
+// newTestEnv returns a new environment for t. Redis is an in-process
+// miniredis, so that tests running at the same time, in this package or in
+// another, do not share or flush each other's cache.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	logger := log.New()
+	dbInstance, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(dbInstance))
+	t.Cleanup(func() { _ = db.Close(dbInstance) })
+
+	client := cache2.New(&cache2.Config{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return &testEnv{
+		log:   logger,
+		db:    dbInstance,
+		redis: client,
+		cache: repository.NewRedisCache(&repository.InitRedisCache{Client: client, Log: logger}),
+		todos: repository.NewTodo(&repository.InitTodoRepository{Db: dbInstance, Log: logger}),
+	}
+}
+
+// todoService returns a todo service configured by init, whose Log,
+// TodoRepository and RedisCache default to those of env.
+func (env *testEnv) todoService(init *service.InitTodoService) service.ITodo {
+	if init.Log == nil {
+		init.Log = env.log
+	}
+	if init.TodoRepository == nil {
+		init.TodoRepository = env.todos
+	}
+	if init.RedisCache == nil {
+		init.RedisCache = env.cache
+	}
+	return service.NewTodo(init)
+}
+
+// eventService returns an event service publishing to an in-memory broker.
+func (env *testEnv) eventService() service.IEvent {
+	return service.NewEvent(&service.InitEventService{
+		Log: env.log, Broker: broker.NewMemory(&broker.InitMemoryBroker{}),
+		EventRepository: repository.NewEvent(&repository.InitEventRepository{Db: env.db, Log: env.log}),
+	})
+}
+
+// startOutbox dispatches the events written to the database to sinks until the test ends.
+func (env *testEnv) startOutbox(t *testing.T, sinks ...service.ISink) service.IOutbox {
+	outbox := service.NewOutbox(&service.InitOutboxService{
+		Log: env.log, Sinks: sinks, Config: model.Outbox{PollInterval: 10 * time.Millisecond},
+		EventRepository: repository.NewEvent(&repository.InitEventRepository{Db: env.db, Log: env.log}),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go outbox.Run(ctx)
+	return outbox
+}
+
 // cmpTransformJSON はcmp.DiffでJSON文字列([]byte)を比較のためのオプション
 //
 // この設定を入れることでJSON文字列の改行や空白を無視してくれる。
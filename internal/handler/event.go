@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+const (
+	// defaultHeartbeatInterval is the heartbeat interval when none is configured.
+	defaultHeartbeatInterval = 15 * time.Second
+	// HeaderLastEventID is the request header an EventSource resumes a stream with.
+	HeaderLastEventID = "Last-Event-ID"
+	// MIMETextEventStream is the content type of Server-Sent Events.
+	MIMETextEventStream = "text/event-stream"
+)
+
+// EventHandler is the request handler for the event stream.
+type EventHandler interface {
+	Stream(c echo.Context) error
+}
+
+type InitEventHandler struct {
+	Service service.IEvent
+	// HeartbeatInterval is how often an idle stream gets a comment to keep proxies from closing it.
+	HeartbeatInterval time.Duration
+	Log               *log.Logger
+}
+
+type eventHandler struct {
+	Handler
+	service   service.IEvent
+	heartbeat time.Duration
+	log       *log.Logger
+}
+
+// NewEvent returns a new instance of the event handler.
+func NewEvent(initEventHandler *InitEventHandler) EventHandler {
+	heartbeat := initEventHandler.HeartbeatInterval
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeatInterval
+	}
+	return &eventHandler{
+		service:   initEventHandler.Service,
+		heartbeat: heartbeat,
+		log:       initEventHandler.Log,
+	}
+}
+
+// @Summary	Stream todo changes
+// @Description	Streams todo.created, todo.updated, todo.completed and todo.deleted events as Server-Sent Events. Each event has the todo as JSON data and an ID; reconnecting with Last-Event-ID replays the events missed in between.
+// @Tags		events
+// @Produce	text/event-stream
+// @Param		Last-Event-ID	header		int		false	"ID of the last event received"
+// @Param		project			query		string	false	"project"
+// @Param		status			query		string	false	"status"	Enums(created, processing, done)
+// @Success	200
+// @Failure	400	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/events [get]
+func (e *eventHandler) Stream(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.EventsRequest
+
+	if err := e.MustBind(c, &req); err != nil {
+		e.log.Error(ctx, err.Error())
+		return e.Fail(c, err)
+	}
+	if lastEventID := c.Request().Header.Get(HeaderLastEventID); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 0)
+		if err != nil {
+			e.log.Error(ctx, err.Error())
+			return e.Fail(c, apperrors.Validation([]apperrors.Violation{{Field: HeaderLastEventID, Rule: "type"}}))
+		}
+		req.LastEventID = uint(id)
+	}
+
+	events, err := e.service.Subscribe(ctx, &req)
+	if err != nil {
+		e.log.Error(ctx, err.Error())
+		return e.Fail(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, MIMETextEventStream)
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream.
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	heartbeat := time.NewTicker(e.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// The stream fell behind or is shutting down; the client resumes with Last-Event-ID.
+				return nil
+			}
+			if _, err := fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
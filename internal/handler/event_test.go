@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// sseEvent is one frame of an event stream; comments have only Comment set.
+type sseEvent struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+}
+
+type sseStream struct {
+	t      *testing.T
+	frames chan sseEvent
+	cancel context.CancelFunc
+}
+
+func openStream(t *testing.T, url, lastEventID string) *sseStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, MIMETextEventStream, res.Header.Get(echo.HeaderContentType))
+
+	s := &sseStream{t: t, frames: make(chan sseEvent, 100), cancel: cancel}
+	go func() {
+		defer res.Body.Close()
+		scanner := bufio.NewScanner(res.Body)
+		var frame sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				select {
+				case s.frames <- frame:
+				case <-ctx.Done():
+					return
+				}
+				frame = sseEvent{}
+			case strings.HasPrefix(line, ": "):
+				frame.Comment = strings.TrimPrefix(line, ": ")
+			case strings.HasPrefix(line, "id: "):
+				frame.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				frame.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				frame.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	t.Cleanup(cancel)
+	return s
+}
+
+// next returns the next event, skipping heartbeats.
+func (s *sseStream) next() sseEvent {
+	s.t.Helper()
+	for {
+		select {
+		case frame := <-s.frames:
+			if frame.Comment == "" {
+				return frame
+			}
+		case <-time.After(2 * time.Second):
+			require.Fail(s.t, "no event received")
+		}
+	}
+}
+
+func (s *sseStream) nextTask() string {
+	s.t.Helper()
+	frame := s.next()
+	var todo model.Todo
+	require.NoError(s.t, json.Unmarshal([]byte(frame.Data), &todo))
+	return frame.Event + ":" + todo.Task
+}
+
+func TestEventHandler_Stream(t *testing.T) {
+	env := newTestEnv(t)
+	eventService := env.eventService()
+	todoService := env.todoService(&service.InitTodoService{Outbox: env.startOutbox(t, eventService)})
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	e.GET("/events", NewEvent(&InitEventHandler{
+		Service: eventService, HeartbeatInterval: 50 * time.Millisecond, Log: env.log,
+	}).Stream)
+	// Registered first so that it runs after the streams are cancelled
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	all := openStream(t, server.URL+"/events", "")
+	web := openStream(t, server.URL+"/events?project=web", "")
+
+	a, err := todoService.Create(ctx, &model.CreateRequest{Task: "A", Priority: "low", Project: "web"})
+	require.NoError(t, err)
+	_, err = todoService.Create(ctx, &model.CreateRequest{Task: "B", Priority: "low"})
+	require.NoError(t, err)
+	_, err = todoService.Update(ctx, &model.UpdateRequest{
+		UpdateRequestPath: model.UpdateRequestPath{ID: a.ID},
+		UpdateRequestBody: model.UpdateRequestBody{Task: "A2"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, todoService.Delete(ctx, &model.DeleteRequest{ID: a.ID}))
+
+	first := all.next()
+	assert.Equal(t, model.EventTodoCreated, first.Event)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, []string{"todo.created:B", "todo.updated:A2", "todo.deleted:A2"},
+		[]string{all.nextTask(), all.nextTask(), all.nextTask()})
+	assert.Equal(t, []string{"todo.created:A", "todo.updated:A2", "todo.deleted:A2"},
+		[]string{web.nextTask(), web.nextTask(), web.nextTask()})
+
+	t.Run("resume", func(t *testing.T) {
+		resumed := openStream(t, server.URL+"/events", first.ID)
+		assert.Equal(t, []string{"todo.created:B", "todo.updated:A2", "todo.deleted:A2"},
+			[]string{resumed.nextTask(), resumed.nextTask(), resumed.nextTask()})
+
+		// New events follow the replayed ones
+		_, err := todoService.Create(ctx, &model.CreateRequest{Task: "C", Priority: "low"})
+		require.NoError(t, err)
+		assert.Equal(t, "todo.created:C", resumed.nextTask())
+	})
+
+	t.Run("status_filter", func(t *testing.T) {
+		done := openStream(t, server.URL+"/events?status=done&project=ops", "")
+		c, err := todoService.Create(ctx, &model.CreateRequest{Task: "D", Priority: "low", Project: "ops"})
+		require.NoError(t, err)
+		_, err = todoService.Move(ctx, &model.MoveRequest{ID: c.ID, Status: model.Done})
+		require.NoError(t, err)
+		assert.Equal(t, "todo.updated:D", done.nextTask())
+		assert.Equal(t, "todo.completed:D", done.nextTask())
+	})
+
+	t.Run("heartbeat", func(t *testing.T) {
+		stream := openStream(t, server.URL+"/events", "")
+		select {
+		case frame := <-stream.frames:
+			assert.Equal(t, "heartbeat", frame.Comment)
+		case <-time.After(2 * time.Second):
+			require.Fail(t, "no heartbeat")
+		}
+	})
+
+	t.Run("invalid_request", func(t *testing.T) {
+		for _, tt := range []struct{ query, lastEventID string }{
+			{query: "?status=archived"},
+			{lastEventID: "abc"},
+		} {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/events"+tt.query, nil)
+			require.NoError(t, err)
+			if tt.lastEventID != "" {
+				req.Header.Set(HeaderLastEventID, tt.lastEventID)
+			}
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, fmt.Sprintf("%+v", tt))
+		}
+	})
+}
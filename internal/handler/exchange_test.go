@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func TestTodoHandler_Export(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	todoHandler, _ := initBoardSetup(t, model.BoardConfig{})
+	e.GET("/todos/export", todoHandler.Export)
+
+	createTask(t, e, todoHandler, `{"task":"Buy milk","priority":"high","project":"home"}`)
+	createTask(t, e, todoHandler, `{"task":"Call Bob","priority":"low"}`)
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/todos/export"+query, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rec := export("")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `attachment; filename=todos.json`, rec.Header().Get(echo.HeaderContentDisposition))
+
+		var todos []model.Todo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &todos))
+		require.Len(t, todos, 2)
+		assert.Equal(t, "Buy milk", todos[0].Task)
+		assert.Equal(t, "Call Bob", todos[1].Task)
+	})
+
+	t.Run("csv_of_a_project", func(t *testing.T) {
+		rec := export("?format=csv&project=home")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[1], "Buy milk")
+	})
+
+	t.Run("unknown_format", func(t *testing.T) {
+		rec := export("?format=xml")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentDisposition))
+	})
+}
+
+func TestTodoHandler_Import(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	todoHandler, _ := initBoardSetup(t, model.BoardConfig{})
+	e.POST("/todos/import", todoHandler.Import)
+	e.GET("/todos", todoHandler.FindAll)
+
+	createTask(t, e, todoHandler, `{"task":"Buy milk","priority":"low"}`)
+
+	importTodos := func(query, body string) (int, model.ImportResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/todos/import"+query, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var res struct{ Data model.ImportResponse }
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res.Data
+	}
+	tasks := func() []string {
+		req := httptest.NewRequest(http.MethodGet, "/todos?sort=manual", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		var res struct{ Data []model.Todo }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		var got []string
+		for _, todo := range res.Data {
+			got = append(got, string(todo.Status)+":"+todo.Task)
+		}
+		return got
+	}
+	const file = "(A) Buy milk\nx Call Bob\nWalk dog due:tomorrow\n"
+
+	t.Run("dry_run_reports_lines", func(t *testing.T) {
+		status, res := importTodos("?format=todotxt&dryRun=true", file)
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, res.DryRun)
+		assert.False(t, res.Applied)
+		assert.Equal(t, 1, res.Skipped)
+		assert.Equal(t, 1, res.Created)
+		assert.Equal(t, 1, res.Invalid)
+		require.Len(t, res.Lines, 3)
+		assert.Equal(t, 3, res.Lines[2].Line)
+		assert.Contains(t, res.Lines[2].Error, "due")
+		assert.Equal(t, []string{"created:Buy milk"}, tasks())
+	})
+
+	t.Run("invalid_lines_import_nothing", func(t *testing.T) {
+		status, res := importTodos("?format=todotxt", file)
+		require.Equal(t, http.StatusOK, status)
+		assert.False(t, res.Applied)
+		assert.Equal(t, []string{"created:Buy milk"}, tasks())
+	})
+
+	t.Run("update_on_conflict", func(t *testing.T) {
+		status, res := importTodos("?format=todotxt&onConflict=update", "(A) Buy milk\nx Call Bob\n")
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, res.Applied)
+		assert.Equal(t, 1, res.Updated)
+		assert.Equal(t, 1, res.Created)
+		assert.Equal(t, []string{"created:Buy milk", "done:Call Bob"}, tasks())
+	})
+
+	t.Run("unreadable_file", func(t *testing.T) {
+		status, _ := importTodos("", `{"task":"Buy milk"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("markdown_cannot_be_imported", func(t *testing.T) {
+		status, _ := importTodos("?format=markdown", "- [ ] Buy milk\n")
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
@@ -16,7 +16,25 @@ func (h Handler) MustBind(c echo.Context, req interface{}) error {
 		return err
 	}
 	if err := c.Validate(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		// The validation errors are kept so that the problem response can list the fields.
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
 	return nil
 }
+
+// MustBindQuery はクエリパラメータのみをバインドしてバリデーションを行います。
+// リクエストボディはハンドラーが読み込みます。
+func (h Handler) MustBindQuery(c echo.Context, req interface{}) error {
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, req); err != nil {
+		return err
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// Fail はエラーを problem+json のレスポンスとして書き込みます。
+func (h Handler) Fail(c echo.Context, err error) error {
+	return writeProblem(c, err)
+}
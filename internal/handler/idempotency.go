@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header a client sets to make a mutating request safe to retry.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses replayed from an earlier request with the same key.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// purgeInterval is how often expired keys are deleted.
+	purgeInterval = time.Minute
+	// defaultLease is the lease of a request in progress when none is configured.
+	defaultLease = time.Minute
+)
+
+var (
+	errIdempotencyKeyTooLong = apperrors.New(http.StatusBadRequest, apperrors.CodeInvalidIdempotencyKey,
+		fmt.Sprintf("%s must be at most %d characters", HeaderIdempotencyKey, maxIdempotencyKeyLength))
+	errIdempotencyKeyReused = apperrors.New(http.StatusUnprocessableEntity, apperrors.CodeIdempotencyKeyReused,
+		fmt.Sprintf("%s was already used with a different request", HeaderIdempotencyKey))
+	errIdempotencyInProgress = apperrors.New(http.StatusConflict, apperrors.CodeIdempotencyKeyInProgress,
+		fmt.Sprintf("a request with this %s is still being processed", HeaderIdempotencyKey))
+)
+
+type InitIdempotency struct {
+	Repository repository.IIdempotency
+	// TTL is how long a key and its response are kept.
+	TTL time.Duration
+	// Lease is how long a request in progress holds its key before a retry can
+	// take it over. Defaults to a minute.
+	Lease time.Duration
+	Log   *log.Logger
+	// Now is the clock. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Idempotency returns a middleware that makes POST, PUT, PATCH and DELETE
+// requests carrying an Idempotency-Key header safe to retry. Keys belong to
+// the caller named by X-User and to the method and path they are sent to. The
+// first response for a key is stored and replayed for retries with the same
+// body; reusing the key with a different body is rejected with 422. A retry
+// while the request is in progress conflicts until its lease is over, then
+// runs again. Failed requests (5xx) are not stored, so they can be retried for
+// real.
+func Idempotency(init *InitIdempotency) echo.MiddlewareFunc {
+	now := init.Now
+	if now == nil {
+		now = time.Now
+	}
+	lease := init.Lease
+	if lease <= 0 {
+		lease = defaultLease
+	}
+
+	var (
+		purgeMu   sync.Mutex
+		lastPurge time.Time
+	)
+	purge := func(c echo.Context, ts time.Time) {
+		purgeMu.Lock()
+		defer purgeMu.Unlock()
+		if ts.Sub(lastPurge) < purgeInterval {
+			return
+		}
+		lastPurge = ts
+		if _, err := init.Repository.DeleteExpired(c.Request().Context(), ts); err != nil {
+			init.Log.Error(c.Request().Context(), fmt.Sprintf("failed to delete expired idempotency keys: %s", err.Error()))
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := req.Context()
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" || !isMutating(req.Method) {
+				return next(c)
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				return writeProblem(c, errIdempotencyKeyTooLong)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				init.Log.Error(ctx, err.Error())
+				return writeProblem(c, apperrors.Wrap(err, http.StatusBadRequest, apperrors.CodeBadRequest))
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Every database keeps milliseconds, so the reservation is found again by its creation time.
+			ts := now().Truncate(time.Millisecond)
+			purge(c, ts)
+
+			record := &model.IdempotencyRecord{
+				Key:         scopedKey(req.Header.Get(HeaderUser), req.Method, req.URL.Path, key),
+				Fingerprint: fingerprint(req.Method, req.URL.Path, body),
+				CreatedAt:   ts,
+				ExpiresAt:   ts.Add(lease),
+			}
+			existing, err := init.Repository.Reserve(ctx, record)
+			if err != nil {
+				init.Log.Error(ctx, err.Error())
+				return writeProblem(c, err)
+			}
+			if existing != nil {
+				switch {
+				case existing.Fingerprint != record.Fingerprint:
+					return writeProblem(c, errIdempotencyKeyReused)
+				case !existing.Completed():
+					return writeProblem(c, errIdempotencyInProgress)
+				}
+				c.Response().Header().Set(HeaderIdempotentReplayed, "true")
+				return c.Blob(existing.StatusCode, existing.ContentType, existing.Body)
+			}
+
+			res := c.Response()
+			recorder := &bodyRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			defer func() { res.Writer = recorder.ResponseWriter }()
+
+			if err := next(c); err != nil {
+				// The error is written by the error handler after this middleware, so there is nothing to store.
+				if releaseErr := init.Repository.Release(ctx, record); releaseErr != nil {
+					init.Log.Error(ctx, releaseErr.Error())
+				}
+				return err
+			}
+
+			if !res.Committed || res.Status >= http.StatusInternalServerError {
+				if err := init.Repository.Release(ctx, record); err != nil {
+					init.Log.Error(ctx, err.Error())
+				}
+				return nil
+			}
+
+			record.StatusCode = res.Status
+			record.ContentType = res.Header().Get(echo.HeaderContentType)
+			record.Body = recorder.body.Bytes()
+			record.ExpiresAt = ts.Add(init.TTL)
+			if err := init.Repository.Complete(ctx, record); err != nil {
+				init.Log.Error(ctx, fmt.Sprintf("failed to store response for %s: %s", HeaderIdempotencyKey, err.Error()))
+			}
+			return nil
+		}
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// scopedKey is the stored key of an Idempotency-Key sent by user to the method and path.
+func scopedKey(user, method, path, key string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s %s\n%s", user, method, path, key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprint identifies a request by its method, path and body.
+func fingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyRecorder keeps a copy of the response body while writing it through.
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *bodyRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *bodyRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+func TestIdempotency(t *testing.T) {
+	env := newTestEnv(t)
+	repo := repository.NewIdempotency(&repository.InitIdempotencyRepository{Db: env.db, Log: env.log})
+
+	clock := &fakeClock{now: time.Date(2024, 10, 16, 9, 0, 0, 0, time.UTC)}
+	e := echo.New()
+	e.Use(Idempotency(&InitIdempotency{Repository: repo, TTL: time.Hour, Log: env.log, Now: clock.Now}))
+
+	calls := 0
+	e.POST("/todos", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusCreated, map[string]int{"call": calls})
+	})
+	e.POST("/fail", func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "boom"})
+	})
+	e.GET("/todos", func(c echo.Context) error {
+		calls++
+		return c.NoContent(http.StatusOK)
+	})
+
+	sendAs := func(user, method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if user != "" {
+			req.Header.Set(HeaderUser, user)
+		}
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	send := func(method, path, key, body string) *httptest.ResponseRecorder {
+		return sendAs("", method, path, key, body)
+	}
+
+	// The first request runs and its response is stored
+	rec := send(http.MethodPost, "/todos", "key-1", `{"task":"A"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"call":1}`, strings.TrimSpace(rec.Body.String()))
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+	contentType := rec.Header().Get(echo.HeaderContentType)
+
+	// A retry replays it without running the handler again
+	rec = send(http.MethodPost, "/todos", "key-1", `{"task":"A"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"call":1}`, strings.TrimSpace(rec.Body.String()))
+	assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, contentType, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, 1, calls)
+
+	// Reusing the key for another request is rejected
+	rec = send(http.MethodPost, "/todos", "key-1", `{"task":"B"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, calls)
+
+	// Requests without a key, and reads, are not affected
+	send(http.MethodPost, "/todos", "", `{"task":"A"}`)
+	send(http.MethodPost, "/todos", "", `{"task":"A"}`)
+	send(http.MethodGet, "/todos", "key-1", "")
+	assert.Equal(t, 4, calls)
+
+	// Server errors are not stored, so a retry runs again
+	send(http.MethodPost, "/fail", "key-2", "")
+	send(http.MethodPost, "/fail", "key-2", "")
+	assert.Equal(t, 6, calls)
+
+	// The same key of another caller, or sent to another path, is another key
+	rec = sendAs("alice", http.MethodPost, "/todos", "key-1", `{"task":"A"}`)
+	assert.Equal(t, `{"call":7}`, strings.TrimSpace(rec.Body.String()))
+	rec = sendAs("alice", http.MethodPost, "/todos", "key-1", `{"task":"A"}`)
+	assert.Equal(t, `{"call":7}`, strings.TrimSpace(rec.Body.String()))
+	rec = sendAs("bob", http.MethodPost, "/todos", "key-1", `{"task":"A"}`)
+	assert.Equal(t, `{"call":8}`, strings.TrimSpace(rec.Body.String()))
+	rec = send(http.MethodPost, "/fail", "key-1", `{"task":"A"}`)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, 9, calls)
+
+	// A request still in progress makes a concurrent retry conflict until its lease is over
+	_, err := repo.Reserve(context.Background(), &model.IdempotencyRecord{
+		Key: scopedKey("", http.MethodPost, "/todos", "key-3"), Fingerprint: fingerprint(http.MethodPost, "/todos", nil),
+		CreatedAt: clock.Now(), ExpiresAt: clock.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	rec = send(http.MethodPost, "/todos", "key-3", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	clock.Advance(2 * time.Minute)
+	rec = send(http.MethodPost, "/todos", "key-3", "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"call":10}`, strings.TrimSpace(rec.Body.String()))
+
+	// The response taken over is kept for the TTL
+	clock.Advance(30 * time.Minute)
+	rec = send(http.MethodPost, "/todos", "key-3", "")
+	assert.Equal(t, `{"call":10}`, strings.TrimSpace(rec.Body.String()))
+	assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+
+	// Keys expire after the TTL
+	clock.Advance(2 * time.Hour)
+	rec = send(http.MethodPost, "/todos", "key-1", `{"task":"B"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"call":11}`, strings.TrimSpace(rec.Body.String()))
+
+	rec = send(http.MethodPost, "/todos", strings.Repeat("k", 256), "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
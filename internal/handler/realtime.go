@@ -0,0 +1,446 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/i18n"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+const (
+	// defaultSendBuffer is the number of messages queued for a connection when none is configured.
+	defaultSendBuffer = 64
+	// defaultPingInterval is the ping interval when none is configured.
+	defaultPingInterval = 30 * time.Second
+	// defaultMaxMessageSize is the maximum client message size when none is configured.
+	defaultMaxMessageSize = 64 << 10
+	// writeWait is how long writing one message to a connection may take.
+	writeWait = 10 * time.Second
+)
+
+// Message types of the realtime protocol. Clients send subscribe, create,
+// update, delete and view; the server answers each of them with an ack or an
+// error, and pushes event and presence messages.
+const (
+	MessageSubscribe = "subscribe"
+	MessageCreate    = "create"
+	MessageUpdate    = "update"
+	MessageDelete    = "delete"
+	MessageView      = "view"
+	MessageAck       = "ack"
+	MessageError     = "error"
+	MessageEvent     = "event"
+	MessagePresence  = "presence"
+)
+
+// errMissingRealtimeUser is the error for a connection that does not name its user.
+var errMissingRealtimeUser = apperrors.New(http.StatusBadRequest, apperrors.CodeMissingUser,
+	HeaderUser+" header or user query parameter is required")
+
+// errUnreadableMessage is the error for a message that is not valid JSON.
+var errUnreadableMessage = apperrors.New(http.StatusBadRequest, apperrors.CodeBadRequest, "The message could not be read.")
+
+// ClientMessage is a message from a realtime client.
+type ClientMessage struct {
+	Type string `json:"type"`
+	// ID is chosen by the client and sent back in the ack or error of the message.
+	ID string `json:"id,omitempty"`
+	// Data is the request: model.EventsRequest for subscribe, model.CreateRequest
+	// for create, model.UpdateRequest for update, model.DeleteRequest for delete
+	// and model.ViewRequest for view.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// ServerMessage is a message to a realtime client.
+type ServerMessage struct {
+	Type string `json:"type"`
+	// ID is the ID of the client message an ack or error answers.
+	ID string `json:"id,omitempty"`
+	// Data is the todo for the ack of create and update, the presence for the
+	// ack of view, a RealtimeEvent for event and a model.Presence for presence.
+	Data interface{} `json:"data,omitempty"`
+	// Error is the problem of an error message.
+	Error *apperrors.Problem `json:"error,omitempty"`
+}
+
+// RealtimeEvent is the data of an event message.
+type RealtimeEvent struct {
+	// ID is the ID to resume from with the lastEventId of subscribe.
+	ID   uint   `json:"id"`
+	Type string `json:"type"`
+	// Todo is the todo after the change, or before it for a delete.
+	Todo json.RawMessage `json:"todo"`
+}
+
+// RealtimeHandler is the request handler for the WebSocket API.
+type RealtimeHandler interface {
+	Connect(c echo.Context) error
+}
+
+type InitRealtimeHandler struct {
+	Service  service.ITodo
+	Events   service.IEvent
+	Presence service.IPresence
+	Config   model.Realtime
+	// AllowedOrigins are the origins of the pages allowed to connect. Empty
+	// allows only pages served from the API's own host.
+	AllowedOrigins []string
+	Log            *log.Logger
+}
+
+type realtimeHandler struct {
+	Handler
+	service  service.ITodo
+	events   service.IEvent
+	presence service.IPresence
+	config   model.Realtime
+	upgrader websocket.Upgrader
+	log      *log.Logger
+
+	sessions uint64
+	mu       sync.Mutex
+	conns    map[*realtimeConn]struct{}
+}
+
+// NewRealtime returns a new instance of the realtime handler.
+func NewRealtime(initRealtimeHandler *InitRealtimeHandler) RealtimeHandler {
+	config := initRealtimeHandler.Config
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = defaultSendBuffer
+	}
+	if config.PingInterval <= 0 {
+		config.PingInterval = defaultPingInterval
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultMaxMessageSize
+	}
+
+	upgrader := websocket.Upgrader{}
+	if origins := initRealtimeHandler.AllowedOrigins; len(origins) > 0 {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get(echo.HeaderOrigin)
+			if origin == "" {
+				// Not a browser
+				return true
+			}
+			if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+				return true
+			}
+			for _, allowed := range origins {
+				if origin == allowed {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return &realtimeHandler{
+		service:  initRealtimeHandler.Service,
+		events:   initRealtimeHandler.Events,
+		presence: initRealtimeHandler.Presence,
+		config:   config,
+		upgrader: upgrader,
+		log:      initRealtimeHandler.Log,
+		conns:    map[*realtimeConn]struct{}{},
+	}
+}
+
+// realtimeConn is one WebSocket connection.
+type realtimeConn struct {
+	ws      *websocket.Conn
+	user    string
+	session string
+	loc     *i18n.Localizer
+	// validate validates the requests of the connection.
+	validate func(i interface{}) error
+
+	// send queues the messages for the write loop.
+	send   chan *ServerMessage
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	closeCode int
+	closeText string
+
+	// unsubscribe ends the current event subscription. It is only used by the read loop.
+	unsubscribe context.CancelFunc
+}
+
+// push queues msg for the client. A client that does not keep up is
+// disconnected instead of buffering without bound; it reconnects and resumes
+// its subscription with lastEventId.
+func (c *realtimeConn) push(msg *ServerMessage) {
+	select {
+	case c.send <- msg:
+	default:
+		c.close(websocket.CloseTryAgainLater, "client is too slow")
+	}
+}
+
+// close ends the connection, telling the client why. Only the first reason is kept.
+func (c *realtimeConn) close(code int, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closeCode == 0 {
+		c.closeCode, c.closeText = code, text
+	}
+	c.cancel()
+}
+
+// closeReason returns the close code and text to send to the client.
+func (c *realtimeConn) closeReason() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closeCode == 0 {
+		return websocket.CloseGoingAway, "server is shutting down"
+	}
+	return c.closeCode, c.closeText
+}
+
+// @Summary	Connect to the realtime API
+// @Description	Upgrades to a WebSocket speaking JSON messages of the form {"type","id","data"}. Clients send subscribe (model.EventsRequest), create (model.CreateRequest), update (model.UpdateRequest), delete (model.DeleteRequest) and view (model.ViewRequest); each is answered with an ack carrying the same id, or an error carrying a problem. The server pushes event messages for the subscription and presence messages when the viewers of a todo change.
+// @Tags		realtime
+// @Param		X-User	header		string	false	"user"
+// @Param		user	query		string	false	"user, for clients that cannot set headers"
+// @Success	101
+// @Failure	400		{object}	errors.Problem
+// @Router		/ws [get]
+func (r *realtimeHandler) Connect(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	user := c.Request().Header.Get(HeaderUser)
+	if user == "" {
+		user = c.QueryParam("user")
+	}
+	if user == "" {
+		return r.Fail(c, errMissingRealtimeUser)
+	}
+
+	ws, err := r.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader has written the error response.
+		r.log.Error(ctx, err.Error())
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	conn := &realtimeConn{
+		ws:       ws,
+		user:     user,
+		session:  strconv.FormatUint(atomic.AddUint64(&r.sessions, 1), 10),
+		loc:      localizer(c),
+		validate: c.Validate,
+		send:     make(chan *ServerMessage, r.config.SendBuffer),
+		cancel:   cancel,
+	}
+	r.add(conn)
+	defer r.remove(conn)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.writeLoop(ctx, conn)
+	}()
+	r.readLoop(ctx, conn)
+	conn.close(websocket.CloseNormalClosure, "")
+	<-done
+	return nil
+}
+
+// readLoop handles the messages of the client one at a time, so a client has
+// at most one mutation in flight.
+func (r *realtimeHandler) readLoop(ctx context.Context, conn *realtimeConn) {
+	pongWait := 2 * r.config.PingInterval
+	conn.ws.SetReadLimit(r.config.MaxMessageSize)
+	_ = conn.ws.SetReadDeadline(time.Now().Add(pongWait))
+	conn.ws.SetPongHandler(func(string) error {
+		return conn.ws.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && ctx.Err() == nil {
+				r.log.Error(ctx, fmt.Sprintf("realtime connection of %s failed: %s", conn.user, err.Error()))
+			}
+			return
+		}
+		var msg ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			r.reply(ctx, conn, &msg, nil, errUnreadableMessage)
+			continue
+		}
+		r.handle(ctx, conn, &msg)
+	}
+}
+
+// writeLoop writes the queued messages and pings the client until ctx is done.
+func (r *realtimeHandler) writeLoop(ctx context.Context, conn *realtimeConn) {
+	ping := time.NewTicker(r.config.PingInterval)
+	defer ping.Stop()
+	defer conn.ws.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			code, text := conn.closeReason()
+			_ = conn.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeWait))
+			return
+		case msg := <-conn.send:
+			_ = conn.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.ws.WriteJSON(msg); err != nil {
+				conn.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping.C:
+			if err := conn.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				conn.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+func (r *realtimeHandler) handle(ctx context.Context, conn *realtimeConn, msg *ClientMessage) {
+	switch msg.Type {
+	case MessageSubscribe:
+		var req model.EventsRequest
+		if err := r.decode(conn, msg, &req); err != nil {
+			r.reply(ctx, conn, msg, nil, err)
+			return
+		}
+		r.subscribe(ctx, conn, msg, &req)
+	case MessageCreate:
+		var req model.CreateRequest
+		if err := r.decode(conn, msg, &req); err != nil {
+			r.reply(ctx, conn, msg, nil, err)
+			return
+		}
+		todo, err := r.service.Create(ctx, &req)
+		r.reply(ctx, conn, msg, todo, err)
+	case MessageUpdate:
+		var req model.UpdateRequest
+		if err := r.decode(conn, msg, &req); err != nil {
+			r.reply(ctx, conn, msg, nil, err)
+			return
+		}
+		todo, err := r.service.Update(ctx, &req)
+		r.reply(ctx, conn, msg, todo, err)
+	case MessageDelete:
+		var req model.DeleteRequest
+		if err := r.decode(conn, msg, &req); err != nil {
+			r.reply(ctx, conn, msg, nil, err)
+			return
+		}
+		r.reply(ctx, conn, msg, nil, r.service.Delete(ctx, &req))
+	case MessageView:
+		var req model.ViewRequest
+		if err := r.decode(conn, msg, &req); err != nil {
+			r.reply(ctx, conn, msg, nil, err)
+			return
+		}
+		changed := r.presence.View(conn.session, conn.user, req.TodoID)
+		var presence *model.Presence
+		if req.TodoID > 0 {
+			presence = r.presence.Find(req.TodoID)
+		}
+		r.reply(ctx, conn, msg, presence, nil)
+		r.broadcast(changed)
+	default:
+		r.reply(ctx, conn, msg, nil, apperrors.Validation([]apperrors.Violation{{
+			Field: "type", Rule: "oneof",
+			Param: MessageSubscribe + " " + MessageCreate + " " + MessageUpdate + " " + MessageDelete + " " + MessageView,
+		}}))
+	}
+}
+
+// decode reads the data of msg into req and validates it.
+func (r *realtimeHandler) decode(conn *realtimeConn, msg *ClientMessage, req interface{}) error {
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, req); err != nil {
+			return errUnreadableMessage
+		}
+	}
+	return conn.validate(req)
+}
+
+// reply answers msg with an ack carrying data, or with an error if err is not nil.
+func (r *realtimeHandler) reply(ctx context.Context, conn *realtimeConn, msg *ClientMessage, data interface{}, err error) {
+	if err != nil {
+		r.log.Error(ctx, err.Error())
+		problem := localize(conn.loc, AppError(err).Problem("", ""))
+		conn.push(&ServerMessage{Type: MessageError, ID: msg.ID, Error: &problem})
+		return
+	}
+	conn.push(&ServerMessage{Type: MessageAck, ID: msg.ID, Data: data})
+}
+
+// subscribe replaces the event subscription of the connection. The ack is
+// queued before the first event.
+func (r *realtimeHandler) subscribe(ctx context.Context, conn *realtimeConn, msg *ClientMessage, req *model.EventsRequest) {
+	subCtx, unsubscribe := context.WithCancel(ctx)
+	events, err := r.events.Subscribe(subCtx, req)
+	if err != nil {
+		unsubscribe()
+		r.reply(ctx, conn, msg, nil, err)
+		return
+	}
+	if conn.unsubscribe != nil {
+		conn.unsubscribe()
+	}
+	conn.unsubscribe = unsubscribe
+	r.reply(ctx, conn, msg, nil, nil)
+
+	go func() {
+		for event := range events {
+			conn.push(&ServerMessage{Type: MessageEvent, Data: &RealtimeEvent{
+				ID: event.ID, Type: event.Type, Todo: json.RawMessage(event.Data),
+			}})
+		}
+		if subCtx.Err() == nil {
+			// The subscription fell behind the broker.
+			conn.close(websocket.CloseTryAgainLater, "event stream fell behind")
+		}
+	}()
+}
+
+// broadcast sends the changed presences to every connection.
+func (r *realtimeHandler) broadcast(presences []*model.Presence) {
+	if len(presences) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for conn := range r.conns {
+		for _, presence := range presences {
+			conn.push(&ServerMessage{Type: MessagePresence, Data: presence})
+		}
+	}
+}
+
+func (r *realtimeHandler) add(conn *realtimeConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conns[conn] = struct{}{}
+}
+
+// remove forgets the connection and tells the others it stopped viewing.
+func (r *realtimeHandler) remove(conn *realtimeConn) {
+	r.mu.Lock()
+	delete(r.conns, conn)
+	r.mu.Unlock()
+	r.broadcast(r.presence.Leave(conn.session))
+}
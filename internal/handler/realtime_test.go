@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// realtimeMessage is a server message with its data left to decode.
+type realtimeMessage struct {
+	Type  string
+	ID    string
+	Data  json.RawMessage
+	Error *apperrors.Problem
+}
+
+type realtimeClient struct {
+	t  *testing.T
+	ws *websocket.Conn
+}
+
+func dialRealtime(t *testing.T, url, user string) *realtimeClient {
+	header := http.Header{}
+	header.Set(HeaderUser, user)
+	header.Set("Accept-Language", "ja")
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http"), header)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return &realtimeClient{t: t, ws: ws}
+}
+
+func (c *realtimeClient) send(msgType, id, data string) {
+	c.t.Helper()
+	msg := ClientMessage{Type: msgType, ID: id}
+	if data != "" {
+		msg.Data = json.RawMessage(data)
+	}
+	require.NoError(c.t, c.ws.WriteJSON(msg))
+}
+
+func (c *realtimeClient) next() realtimeMessage {
+	c.t.Helper()
+	require.NoError(c.t, c.ws.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var msg realtimeMessage
+	require.NoError(c.t, c.ws.ReadJSON(&msg))
+	return msg
+}
+
+func (c *realtimeClient) nextPresence() model.Presence {
+	c.t.Helper()
+	msg := c.next()
+	require.Equal(c.t, MessagePresence, msg.Type)
+	var presence model.Presence
+	require.NoError(c.t, json.Unmarshal(msg.Data, &presence))
+	return presence
+}
+
+func TestRealtimeHandler_Connect(t *testing.T) {
+	env := newTestEnv(t)
+	eventService := env.eventService()
+	todoService := env.todoService(&service.InitTodoService{Outbox: env.startOutbox(t, eventService)})
+
+	e := echo.New()
+	e.Validator = NewValidator()
+	e.GET("/ws", NewRealtime(&InitRealtimeHandler{
+		Service: todoService, Events: eventService, Presence: service.NewPresence(),
+		AllowedOrigins: []string{"http://localhost:3000"}, Log: env.log,
+	}).Connect)
+	// Registered first so that it runs after the connections are closed
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	alice := dialRealtime(t, server.URL+"/ws", "alice")
+	bob := dialRealtime(t, server.URL+"/ws?user=bob", "")
+
+	t.Run("mutations", func(t *testing.T) {
+		alice.send(MessageSubscribe, "s1", `{"project":"web"}`)
+		assert.Equal(t, realtimeMessage{Type: MessageAck, ID: "s1"}, alice.next())
+
+		bob.send(MessageCreate, "c1", `{"task":"A","priority":"low","project":"web"}`)
+		ack := bob.next()
+		require.Equal(t, MessageAck, ack.Type)
+		assert.Equal(t, "c1", ack.ID)
+		var todo model.Todo
+		require.NoError(t, json.Unmarshal(ack.Data, &todo))
+		assert.Equal(t, "A", todo.Task)
+
+		bob.send(MessageUpdate, "u1", `{"id":`+strconv.Itoa(todo.ID)+`,"task":"A2"}`)
+		assert.Equal(t, MessageAck, bob.next().Type)
+		bob.send(MessageDelete, "d1", `{"id":`+strconv.Itoa(todo.ID)+`}`)
+		assert.Equal(t, realtimeMessage{Type: MessageAck, ID: "d1"}, bob.next())
+
+		var events []string
+		for i := 0; i < 3; i++ {
+			msg := alice.next()
+			require.Equal(t, MessageEvent, msg.Type)
+			var event struct {
+				ID   uint
+				Type string
+				Todo model.Todo
+			}
+			require.NoError(t, json.Unmarshal(msg.Data, &event))
+			assert.NotZero(t, event.ID)
+			events = append(events, event.Type+":"+event.Todo.Task)
+		}
+		assert.Equal(t, []string{"todo.created:A", "todo.updated:A2", "todo.deleted:A2"}, events)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		bob.send(MessageCreate, "c2", `{"task":"B"}`)
+		msg := bob.next()
+		assert.Equal(t, MessageError, msg.Type)
+		assert.Equal(t, "c2", msg.ID)
+		require.NotNil(t, msg.Error)
+		assert.Equal(t, apperrors.CodeValidationFailed, msg.Error.Code)
+		assert.Equal(t, "priority", msg.Error.Violations[0].Field)
+
+		bob.send(MessageDelete, "d2", `{"id":99999}`)
+		msg = bob.next()
+		assert.Equal(t, apperrors.CodeNotFound, msg.Error.Code)
+
+		bob.send("archive", "x1", "")
+		msg = bob.next()
+		assert.Equal(t, "type", msg.Error.Violations[0].Field)
+
+		require.NoError(t, bob.ws.WriteMessage(websocket.TextMessage, []byte("{")))
+		msg = bob.next()
+		assert.Equal(t, apperrors.CodeBadRequest, msg.Error.Code)
+
+		// Messages are localized with the Accept-Language of the connection
+		alice.send(MessageSubscribe, "s2", `{"status":"archived"}`)
+		msg = alice.next()
+		assert.Equal(t, "リクエストに不正な項目があります。", msg.Error.Detail)
+	})
+
+	t.Run("presence", func(t *testing.T) {
+		alice.send(MessageView, "v1", `{"todoId":7}`)
+		ack := alice.next()
+		assert.Equal(t, MessageAck, ack.Type)
+		assert.JSONEq(t, `{"todoId":7,"users":["alice"]}`, string(ack.Data))
+		assert.Equal(t, model.Presence{TodoID: 7, Users: []string{"alice"}}, alice.nextPresence())
+		assert.Equal(t, model.Presence{TodoID: 7, Users: []string{"alice"}}, bob.nextPresence())
+
+		bob.send(MessageView, "v2", `{"todoId":7}`)
+		assert.Equal(t, MessageAck, bob.next().Type)
+		assert.Equal(t, model.Presence{TodoID: 7, Users: []string{"alice", "bob"}}, bob.nextPresence())
+		assert.Equal(t, model.Presence{TodoID: 7, Users: []string{"alice", "bob"}}, alice.nextPresence())
+
+		// A second tab of the same user does not change the viewers
+		alice2 := dialRealtime(t, server.URL+"/ws", "alice")
+		alice2.send(MessageView, "v3", `{"todoId":7}`)
+		assert.JSONEq(t, `{"todoId":7,"users":["alice","bob"]}`, string(alice2.next().Data))
+		require.NoError(t, alice2.ws.Close())
+
+		// Disconnecting stops viewing
+		require.NoError(t, bob.ws.Close())
+		assert.Equal(t, model.Presence{TodoID: 7, Users: []string{"alice"}}, alice.nextPresence())
+	})
+
+	t.Run("handshake", func(t *testing.T) {
+		res, err := http.Get(server.URL + "/ws")
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		header := http.Header{}
+		header.Set(HeaderUser, "mallory")
+		header.Set(echo.HeaderOrigin, "http://evil.example")
+		_, res, err = websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", header)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+}
+
+func TestRealtimeConn_Backpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn := &realtimeConn{send: make(chan *ServerMessage, 1), cancel: cancel}
+
+	conn.push(&ServerMessage{Type: MessagePresence})
+	require.NoError(t, ctx.Err())
+
+	// A client that falls behind the buffer is disconnected
+	conn.push(&ServerMessage{Type: MessagePresence})
+	require.Error(t, ctx.Err())
+	code, _ := conn.closeReason()
+	assert.Equal(t, websocket.CloseTryAgainLater, code)
+}
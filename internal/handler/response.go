@@ -1,6 +1,21 @@
 package handler
 
-import "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/i18n"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/quickadd"
+)
+
+// headerContentLanguage is the response header naming the language of the messages.
+const headerContentLanguage = "Content-Language"
 
 // ResponseData is the response structure for the application.
 type ResponseData struct {
@@ -8,23 +23,175 @@ type ResponseData struct {
 	Data interface{} `json:"data,omitempty"`
 }
 
-// ResponseError is the response structure for the application.
-type ResponseError struct {
-	// Errors is the response errors.
-	Errors []Error `json:"errors,omitempty"`
+// domainErrors maps the errors returned by the services to their status and code.
+// Errors that are not listed are internal server errors.
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{err: model.ErrNotFound, status: http.StatusNotFound, code: apperrors.CodeNotFound},
+	{err: quickadd.ErrEmptyTask, status: http.StatusBadRequest, code: apperrors.CodeEmptyTask},
+	{err: model.ErrInvalidMove, status: http.StatusBadRequest, code: apperrors.CodeInvalidMove},
+	{err: model.ErrWIPLimitReached, status: http.StatusConflict, code: apperrors.CodeWIPLimitReached},
+	{err: model.ErrAttachmentTooLarge, status: http.StatusRequestEntityTooLarge, code: apperrors.CodeAttachmentTooLarge},
+	{err: model.ErrAttachmentTypeNotAllowed, status: http.StatusUnsupportedMediaType, code: apperrors.CodeAttachmentTypeNotAllowed},
+	{err: model.ErrTimerRunning, status: http.StatusConflict, code: apperrors.CodeTimerRunning},
+	{err: model.ErrNoRunningTimer, status: http.StatusConflict, code: apperrors.CodeNoRunningTimer},
+	{err: model.ErrInvalidTimeEntry, status: http.StatusBadRequest, code: apperrors.CodeInvalidTimeEntry},
+	{err: model.ErrInvalidTimeRange, status: http.StatusBadRequest, code: apperrors.CodeInvalidTimeRange},
+	{err: model.ErrInvalidImport, status: http.StatusBadRequest, code: apperrors.CodeInvalidImport},
+}
+
+// fieldErrors maps the validation errors of the models to the violation of the
+// request field, as if the validator had found it.
+var fieldErrors = []struct {
+	err       error
+	violation apperrors.Violation
+}{
+	{err: model.ErrEmptyTask, violation: apperrors.Violation{Field: "task", Rule: "required"}},
+	{err: model.ErrInvalidPriority, violation: apperrors.Violation{Field: "priority", Rule: "oneof", Param: "low medium high"}},
+}
+
+// AppError converts err into an application error. Validation, binding and
+// echo errors keep their status, service errors are looked up in fieldErrors
+// and domainErrors, and anything else becomes an internal server error that
+// hides err.
+func AppError(err error) *apperrors.Error {
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		violations := make([]apperrors.Violation, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			violations = append(violations, apperrors.Violation{Field: fieldPath(fe), Rule: fe.Tag(), Param: fe.Param()})
+		}
+		return apperrors.Validation(violations)
+	}
+
+	var bindingErr *echo.BindingError
+	if errors.As(err, &bindingErr) {
+		return apperrors.Validation([]apperrors.Violation{{Field: bindingErr.Field, Rule: "type"}})
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code < http.StatusInternalServerError {
+		code, ok := apperrors.ErrorCodeDescriptions[httpErr.Code]
+		if !ok {
+			code = apperrors.CodeBadRequest
+		}
+		detail := fmt.Sprint(httpErr.Message)
+		if httpErr.Internal != nil && detail == httpErr.Internal.Error() {
+			// The binder passed a raw Go error through, such as a strconv error for a path parameter.
+			detail = "The request could not be read."
+		}
+		return &apperrors.Error{Status: httpErr.Code, Code: code, Detail: detail, Err: err}
+	}
+
+	for _, fieldErr := range fieldErrors {
+		if errors.Is(err, fieldErr.err) {
+			appErr := apperrors.Validation([]apperrors.Violation{fieldErr.violation})
+			appErr.Err = err
+			return appErr
+		}
+	}
+
+	for _, domainErr := range domainErrors {
+		if errors.Is(err, domainErr.err) {
+			return apperrors.Wrap(err, domainErr.status, domainErr.code)
+		}
+	}
+	return apperrors.Internal(err)
+}
+
+// HTTPErrorHandler writes the errors returned by handlers and middleware as
+// problem details.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	if err := writeProblem(c, err); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// writeProblem writes err as an RFC 7807 problem details response.
+func writeProblem(c echo.Context, err error) error {
+	appErr := AppError(err)
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(appErr.Status)
+	}
+
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+	}
+	loc := localizer(c)
+	problem := localize(loc, appErr.Problem(c.Request().URL.Path, requestID))
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, apperrors.MIMEApplicationProblemJSON)
+	header.Set(headerContentLanguage, loc.Language())
+	header.Add(echo.HeaderVary, i18n.HeaderAcceptLanguage)
+	return c.JSON(appErr.Status, problem)
+}
+
+// localizer returns the localizer for the languages the client asked for.
+func localizer(c echo.Context) *i18n.Localizer {
+	return i18n.New(c.Request().Header.Get(i18n.HeaderAcceptLanguage))
+}
+
+// localize fills in the messages of the problem in the language of loc. The
+// detail is kept when no catalog has a message for the code.
+func localize(loc *i18n.Localizer, problem apperrors.Problem) apperrors.Problem {
+	if detail, ok := loc.Message("error."+problem.Code, nil); ok {
+		problem.Detail = detail
+	}
+
+	violations := make([]apperrors.Violation, 0, len(problem.Violations))
+	for _, v := range problem.Violations {
+		v.Message = violationMessage(loc, v)
+		violations = append(violations, v)
+	}
+	if len(violations) > 0 {
+		problem.Violations = violations
+	}
+	return problem
+}
+
+// fieldPath returns the path of the field below the validated struct, such as "operations[0].op".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
 }
 
-// Error is the error structure for the application.
-type Error struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+// ruleMessages maps the validation rules that share a message to its catalog key.
+var ruleMessages = map[string]string{
+	"required_if": "required", "required_unless": "required", "required_with": "required",
+	"required_without": "required", "required_without_all": "required",
+	"excluded_if": "excluded", "excluded_unless": "excluded", "excluded_with": "excluded", "excluded_without": "excluded",
+	"gte": "min", "lte": "max",
 }
 
-func (re *ResponseError) GetErrorResponse(code int, err error) (int, *ResponseError) {
-	re.Errors = append(re.Errors, Error{
-		Code:    errors.ErrorCodeDescriptions[code],
-		Message: err.Error(),
-	})
+func violationMessage(loc *i18n.Localizer, v apperrors.Violation) string {
+	rule := v.Rule
+	if shared, ok := ruleMessages[rule]; ok {
+		rule = shared
+	}
+	param := v.Param
+	if rule == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
 
-	return code, re
+	args := map[string]string{"param": param, "rule": v.Rule}
+	if msg, ok := loc.Message("validation."+rule, args); ok {
+		return msg
+	}
+	msg, _ := loc.Message("validation.default", args)
+	return msg
 }
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	e := echo.New()
+	e.Validator = NewValidator()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(middleware.RequestID())
+	todoHandler, _ := initBoardSetup(t, model.BoardConfig{})
+	e.POST("/todos", todoHandler.Create)
+	e.GET("/todos", todoHandler.FindAll)
+	e.GET("/todos/:id", todoHandler.Find)
+	e.POST("/todos\\:batch", todoHandler.Batch)
+	e.GET("/fail", func(c echo.Context) error {
+		return fmt.Errorf("database is locked")
+	})
+
+	send := func(method, target, body string) (*httptest.ResponseRecorder, apperrors.Problem) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderXRequestID, "req-1")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var problem apperrors.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem), rec.Body.String())
+		return rec, problem
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		rec, problem := send(http.MethodPost, "/todos", `{}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperrors.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, apperrors.Problem{
+			Type:      "urn:problem-type:validation-failed",
+			Title:     "Bad Request",
+			Status:    http.StatusBadRequest,
+			Detail:    "The request has invalid fields.",
+			Instance:  "/todos",
+			Code:      apperrors.CodeValidationFailed,
+			RequestID: "req-1",
+			Violations: []apperrors.Violation{
+				{Field: "task", Rule: "required", Message: "is required"},
+				{Field: "priority", Rule: "required", Message: "is required"},
+			},
+		}, problem)
+	})
+
+	t.Run("invalid_priority", func(t *testing.T) {
+		rec, problem := send(http.MethodPost, "/todos", `{"task":"x","priority":"urgent"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperrors.CodeValidationFailed, problem.Code)
+		assert.Equal(t, []apperrors.Violation{
+			{Field: "priority", Rule: "oneof", Param: "low medium high", Message: "must be one of: low, medium, high"},
+		}, problem.Violations)
+	})
+
+	t.Run("nested_fields", func(t *testing.T) {
+		_, problem := send(http.MethodPost, "/todos:batch", `{"operations":[{"op":"archive","id":1}]}`)
+		assert.Equal(t, []apperrors.Violation{
+			{Field: "operations[0].op", Rule: "oneof", Param: "create update delete", Message: "must be one of: create, update, delete"},
+		}, problem.Violations)
+	})
+
+	t.Run("query_parameter", func(t *testing.T) {
+		_, problem := send(http.MethodGet, "/todos?sort=name", "")
+		assert.Equal(t, apperrors.CodeValidationFailed, problem.Code)
+		assert.Equal(t, "sort", problem.Violations[0].Field)
+	})
+
+	t.Run("path_parameter", func(t *testing.T) {
+		rec, problem := send(http.MethodGet, "/todos/abc", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperrors.CodeBadRequest, problem.Code)
+		assert.NotContains(t, problem.Detail, "strconv")
+	})
+
+	t.Run("domain_error", func(t *testing.T) {
+		rec, problem := send(http.MethodGet, "/todos/99999", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, apperrors.CodeNotFound, problem.Code)
+		assert.Equal(t, "/todos/99999", problem.Instance)
+	})
+
+	t.Run("malformed_body", func(t *testing.T) {
+		rec, problem := send(http.MethodPost, "/todos", `{"task":`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, apperrors.CodeBadRequest, problem.Code)
+	})
+
+	t.Run("internal_error_is_hidden", func(t *testing.T) {
+		rec, problem := send(http.MethodGet, "/fail", "")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, apperrors.CodeInternalServerError, problem.Code)
+		assert.NotContains(t, problem.Detail, "database")
+	})
+
+	t.Run("unknown_route", func(t *testing.T) {
+		rec, problem := send(http.MethodGet, "/nothing", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, apperrors.CodeNotFound, problem.Code)
+		assert.Equal(t, "req-1", problem.RequestID)
+	})
+}
+
+func TestAppError(t *testing.T) {
+	// The services validate the todos they write, whatever the handler checked.
+	appErr := AppError(fmt.Errorf("%w: %q", model.ErrInvalidPriority, "urgent"))
+	assert.Equal(t, http.StatusBadRequest, appErr.Status)
+	assert.Equal(t, apperrors.CodeValidationFailed, appErr.Code)
+	assert.Equal(t, []apperrors.Violation{{Field: "priority", Rule: "oneof", Param: "low medium high"}}, appErr.Violations)
+	assert.ErrorIs(t, appErr, model.ErrInvalidPriority)
+
+	appErr = AppError(model.ErrEmptyTask)
+	assert.Equal(t, http.StatusBadRequest, appErr.Status)
+	assert.Equal(t, []apperrors.Violation{{Field: "task", Rule: "required"}}, appErr.Violations)
+	assert.Equal(t, apperrors.CodeInternalServerError, AppError(fmt.Errorf("database is locked")).Code)
+}
+
+func TestHTTPErrorHandler_Localized(t *testing.T) {
+	e := echo.New()
+	e.Validator = NewValidator()
+	todoHandler, _ := initBoardSetup(t, model.BoardConfig{})
+	e.POST("/todos\\:batch", todoHandler.Batch)
+	e.GET("/todos/:id", todoHandler.Find)
+
+	send := func(method, target, body, acceptLanguage string) (*httptest.ResponseRecorder, []byte) {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec, rec.Body.Bytes()
+	}
+
+	rec, body := send(http.MethodPost, "/todos:batch", `{"mode":"sometimes","operations":[{"op":"delete","id":1}]}`, "ja-JP,ja;q=0.9")
+	var problem apperrors.Problem
+	require.NoError(t, json.Unmarshal(body, &problem))
+	assert.Equal(t, "ja", rec.Header().Get("Content-Language"))
+	assert.Equal(t, "リクエストに不正な項目があります。", problem.Detail)
+	assert.Equal(t, []apperrors.Violation{
+		{Field: "mode", Rule: "oneof", Param: "atomic partial", Message: "atomic, partial のいずれかを指定してください"},
+	}, problem.Violations)
+
+	rec, body = send(http.MethodGet, "/todos/99999", "", "fr")
+	require.NoError(t, json.Unmarshal(body, &problem))
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	assert.Equal(t, "not found", problem.Detail)
+
+	// Batch results are localized as well
+	_, body = send(http.MethodPost, "/todos:batch", `{"mode":"partial","operations":[{"op":"delete","id":99999}]}`, "ja")
+	var res struct{ Data model.BatchResponse }
+	require.NoError(t, json.Unmarshal(body, &res))
+	require.Len(t, res.Data.Results, 1)
+	assert.Equal(t, apperrors.CodeNotFound, res.Data.Results[0].Code)
+	assert.Equal(t, "指定されたリソースが見つかりません。", res.Data.Results[0].Error)
+}
@@ -1,10 +1,18 @@
 package handler
 
 import (
-	"github.com/go-playground/validator/v10"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/go-redis/redis/v8"
 	"github.com/labstack/echo/v4"
+	"github.com/zuu-development/fullstack-examination-2024/internal/blob"
+	"github.com/zuu-development/fullstack-examination-2024/internal/broker"
 	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/quickadd"
 	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
 	"github.com/zuu-development/fullstack-examination-2024/internal/service"
 
@@ -12,17 +20,30 @@ import (
 )
 
 type ServiceRegistry struct {
+	// Context ends the background workers. Defaults to a context that is never done.
+	Context     context.Context
 	EchoEngine  *echo.Echo
 	RedisClient *redis.Client
 	DBInstance  *gorm.DB
+	BlobStore   blob.IStore
+	Config      model.Config
 	Log         *log.Logger
 }
 
 // Register registers the routes for the application.
 func Register(serviceRegistry *ServiceRegistry) {
-	serviceRegistry.EchoEngine.Validator = &CustomValidator{validator: validator.New()}
+	serviceRegistry.EchoEngine.Validator = NewValidator()
+	serviceRegistry.EchoEngine.HTTPErrorHandler = HTTPErrorHandler
 
 	api := serviceRegistry.EchoEngine.Group("/api/v1")
+	api.Use(Idempotency(&InitIdempotency{
+		Repository: repository.NewIdempotency(&repository.InitIdempotencyRepository{
+			Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+		}),
+		TTL:   serviceRegistry.Config.Idempotency.TTL,
+		Lease: serviceRegistry.Config.Idempotency.Lease,
+		Log:   serviceRegistry.Log,
+	}))
 
 	// Health check
 	healthHandler := NewHealth()
@@ -32,23 +53,223 @@ func Register(serviceRegistry *ServiceRegistry) {
 	redisRepository := repository.NewRedisCache(&repository.InitRedisCache{
 		Client: serviceRegistry.RedisClient, Log: serviceRegistry.Log,
 	})
-	repository := repository.NewTodo(&repository.InitTodoRepository{
+	var todoRepository repository.ITodo
+	if serviceRegistry.Config.TodoStore.Mode == model.StoreEventSourced {
+		todoRepository = repository.NewEventSourcedTodo(&repository.InitEventSourcedTodoRepository{
+			Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+			SnapshotInterval: serviceRegistry.Config.TodoStore.SnapshotInterval,
+		})
+	} else {
+		todoRepository = repository.NewTodo(&repository.InitTodoRepository{
+			Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+		})
+	}
+	// Inject Webhook Dependency
+	webhookService := service.NewWebhook(&service.InitWebhookService{
+		Log: serviceRegistry.Log, Config: serviceRegistry.Config.Webhooks,
+		WebhookRepository: repository.NewWebhook(&repository.InitWebhookRepository{
+			Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+		}),
+	})
+	webhookHandler := NewWebhook(&InitWebhookHandler{
+		Service: webhookService, Log: serviceRegistry.Log,
+	})
+	workerCtx := serviceRegistry.Context
+	if workerCtx == nil {
+		workerCtx = context.Background()
+	}
+	go webhookService.Run(workerCtx)
+
+	// Inject Event Dependency
+	var eventBroker broker.IBroker
+	if serviceRegistry.Config.Events.Broker == "redis" {
+		eventBroker = broker.NewRedis(&broker.InitRedisBroker{
+			Client: serviceRegistry.RedisClient, Channel: serviceRegistry.Config.Events.Channel, Log: serviceRegistry.Log,
+		})
+	} else {
+		eventBroker = broker.NewMemory(&broker.InitMemoryBroker{})
+	}
+	eventRepository := repository.NewEvent(&repository.InitEventRepository{
 		Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
 	})
-	service := service.NewTodo(&service.InitTodoService{
-		Log: serviceRegistry.Log, TodoRepository: repository, RedisCache: redisRepository,
+	eventService := service.NewEvent(&service.InitEventService{
+		Log: serviceRegistry.Log, Broker: eventBroker, EventRepository: eventRepository,
+	})
+	eventHandler := NewEvent(&InitEventHandler{
+		Service: eventService, HeartbeatInterval: serviceRegistry.Config.Events.HeartbeatInterval, Log: serviceRegistry.Log,
+	})
+
+	// Inject Outbox Dependency: the cache goes first, so that webhook receivers
+	// and stream clients reading back a todo find it current. A sink failing,
+	// such as the cache while Redis is down, does not hold back the others.
+	outboxService := service.NewOutbox(&service.InitOutboxService{
+		Log: serviceRegistry.Log, EventRepository: eventRepository, Config: serviceRegistry.Config.Outbox,
+		Retention: serviceRegistry.Config.Events.Retention,
+		Sinks: []service.ISink{
+			service.NewCacheSink(&service.InitCacheSink{
+				Log: serviceRegistry.Log, TodoRepository: todoRepository, RedisCache: redisRepository,
+			}),
+			webhookService,
+			eventService,
+		},
+	})
+	go outboxService.Run(workerCtx)
+
+	location, err := time.LoadLocation(serviceRegistry.Config.QuickAdd.Timezone)
+	if err != nil {
+		serviceRegistry.Log.Error(context.Background(), fmt.Sprintf("invalid QuickAdd.Timezone, using UTC: %s", err.Error()))
+		location = time.UTC
+	}
+	todoService := service.NewTodo(&service.InitTodoService{
+		Log: serviceRegistry.Log, TodoRepository: todoRepository, RedisCache: redisRepository,
+		QuickAddParser: quickadd.New(&quickadd.InitParser{Location: location}),
+		Board:          serviceRegistry.Config.Board,
+		Outbox:         outboxService,
 	})
 	todoHandler := NewTodo(&InitTodoHandler{
-		Service: service, Log: serviceRegistry.Log,
+		Service: todoService, Log: serviceRegistry.Log,
+	})
+
+	realtimeHandler := NewRealtime(&InitRealtimeHandler{
+		Service: todoService, Events: eventService, Presence: service.NewPresence(),
+		Config: serviceRegistry.Config.Realtime, AllowedOrigins: []string{serviceRegistry.Config.UI.URL},
+		Log: serviceRegistry.Log,
+	})
+
+	boardHandler := NewBoard(&InitBoardHandler{
+		Service: todoService, Log: serviceRegistry.Log,
+	})
+
+	// Inject Attachment Dependency
+	attachmentRepository := repository.NewAttachment(&repository.InitAttachmentRepository{
+		Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+	})
+	attachmentService := service.NewAttachment(&service.InitAttachmentService{
+		Log: serviceRegistry.Log, AttachmentRepository: attachmentRepository, TodoRepository: todoRepository,
+		BlobStore: serviceRegistry.BlobStore, Config: serviceRegistry.Config.Attachments,
+	})
+	attachmentHandler := NewAttachment(&InitAttachmentHandler{
+		Service: attachmentService, Log: serviceRegistry.Log,
+	})
+
+	// Inject TimeTracking Dependency
+	timeTrackingService := service.NewTimeTracking(&service.InitTimeTrackingService{
+		Log: serviceRegistry.Log, TodoRepository: todoRepository, Location: location,
+		TimeEntryRepository: repository.NewTimeEntry(&repository.InitTimeEntryRepository{
+			Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+		}),
+	})
+	timeTrackingHandler := NewTimeTracking(&InitTimeTrackingHandler{
+		Service: timeTrackingService, Log: serviceRegistry.Log,
+	})
+
+	// Inject Calendar Dependency
+	calendarHandler := NewCalendar(&InitCalendarHandler{
+		Service: service.NewCalendar(&service.InitCalendarService{
+			Log: serviceRegistry.Log, TodoRepository: todoRepository,
+			CalendarFeedRepository: repository.NewCalendarFeed(&repository.InitCalendarFeedRepository{
+				Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+			}),
+		}),
+		Log: serviceRegistry.Log,
+	})
+
+	// Inject CalDAV Dependency
+	calDAVHandler := NewCalDAV(&InitCalDAVHandler{
+		Service: service.NewCalDAV(&service.InitCalDAVService{
+			Log: serviceRegistry.Log, TodoService: todoService, TodoRepository: todoRepository, RedisCache: redisRepository,
+			CalDAVObjectRepository: repository.NewCalDAVObject(&repository.InitCalDAVObjectRepository{
+				Db: serviceRegistry.DBInstance, Log: serviceRegistry.Log,
+			}),
+		}),
+		Log: serviceRegistry.Log,
 	})
 
 	// Add routes for todo
 	todo := api.Group("/todos")
 	{
 		todo.POST("", todoHandler.Create)
+		todo.POST("/quick", todoHandler.QuickCreate)
+		// The colon is escaped so that it is not taken for a path parameter
+		todo.POST("\\:batch", todoHandler.Batch)
+		todo.GET("/export", todoHandler.Export)
+		todo.POST("/import", todoHandler.Import)
 		todo.GET("", todoHandler.FindAll)
 		todo.GET("/:id", todoHandler.Find)
 		todo.PUT("/:id", todoHandler.Update)
 		todo.DELETE("/:id", todoHandler.Delete)
+		todo.POST("/:id/move", todoHandler.Move)
+
+		todo.POST("/:id/attachments", attachmentHandler.Upload)
+		todo.GET("/:id/attachments", attachmentHandler.FindAll)
+		todo.GET("/:id/attachments/:attachmentId", attachmentHandler.Download)
+		todo.DELETE("/:id/attachments/:attachmentId", attachmentHandler.Delete)
+
+		todo.POST("/:id/timer/start", timeTrackingHandler.StartTimer)
+		todo.POST("/:id/timer/stop", timeTrackingHandler.StopTimer)
+		todo.POST("/:id/time-entries", timeTrackingHandler.CreateEntry)
+		todo.GET("/:id/time-entries", timeTrackingHandler.FindAllEntries)
+		todo.DELETE("/:id/time-entries/:entryId", timeTrackingHandler.DeleteEntry)
+	}
+
+	// Add routes for the board
+	api.GET("/board", boardHandler.Board)
+
+	// Add routes for events
+	api.GET("/events", eventHandler.Stream)
+
+	// Add routes for webhooks
+	webhook := api.Group("/webhooks")
+	{
+		webhook.POST("", webhookHandler.Create)
+		webhook.GET("", webhookHandler.FindAll)
+		webhook.GET("/:id", webhookHandler.Find)
+		webhook.DELETE("/:id", webhookHandler.Delete)
+		webhook.GET("/:id/deliveries", webhookHandler.FindDeliveries)
+	}
+
+	// Add routes for the realtime API
+	api.GET("/ws", realtimeHandler.Connect)
+
+	// Add routes for time tracking
+	api.GET("/timer", timeTrackingHandler.CurrentTimer)
+	api.GET("/reports/time", timeTrackingHandler.Report)
+
+	// Add routes for calendar feeds
+	calendar := api.Group("/calendar")
+	{
+		calendar.POST("/feeds", calendarHandler.CreateFeed)
+		calendar.GET("/feeds", calendarHandler.FindAllFeeds)
+		calendar.DELETE("/feeds/:id", calendarHandler.DeleteFeed)
+		// The token of the feed, usually followed by .ics for the calendar apps
+		calendar.GET("/:token", calendarHandler.Feed)
+	}
+
+	// Add routes for CalDAV, outside of the API: the clients find it from the
+	// host name through /.well-known/caldav.
+	addCalDAVRoutes(serviceRegistry.EchoEngine, calDAVHandler)
+}
+
+// addCalDAVRoutes adds the routes of the CalDAV endpoint, with and without the
+// trailing slashes of the collections, as the clients use both.
+func addCalDAVRoutes(e *echo.Echo, calDAVHandler CalDAVHandler) {
+	e.Any("/.well-known/caldav", calDAVHandler.WellKnown)
+	for _, path := range []string{CalDAVPath, strings.TrimSuffix(CalDAVPath, "/")} {
+		e.OPTIONS(path, calDAVHandler.Options)
+		e.Add("PROPFIND", path, calDAVHandler.Propfind)
+	}
+	for _, path := range []string{CalDAVCalendarPath, strings.TrimSuffix(CalDAVCalendarPath, "/")} {
+		e.OPTIONS(path, calDAVHandler.Options)
+		e.Add("PROPFIND", path, calDAVHandler.Propfind)
+		e.Add("REPORT", path, calDAVHandler.Report)
+	}
+	calDAV := e.Group(CalDAVCalendarPath)
+	{
+		calDAV.OPTIONS(":name", calDAVHandler.Options)
+		calDAV.Add("PROPFIND", ":name", calDAVHandler.Propfind)
+		calDAV.GET(":name", calDAVHandler.Get)
+		calDAV.HEAD(":name", calDAVHandler.Get)
+		calDAV.PUT(":name", calDAVHandler.Put)
+		calDAV.DELETE(":name", calDAVHandler.Delete)
 	}
 }
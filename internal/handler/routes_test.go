@@ -1,36 +1,27 @@
 package handler
 
 import (
-	cache2 "github.com/zuu-development/fullstack-examination-2024/internal/cache"
-	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
-	"github.com/zuu-development/fullstack-examination-2024/internal/db"
 )
 
 func TestRegister(t *testing.T) {
 	// Setup
 	e := echo.New()
-	cache := cache2.New(&cache2.Config{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       5,
-	})
-	dbInstance, err := db.NewMemory()
-	require.NoError(t, err)
-	err = db.Migrate(dbInstance)
-	require.NoError(t, err)
-	logger := log.New()
+	env := newTestEnv(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
 	Register(&ServiceRegistry{
+		Context:     ctx,
 		EchoEngine:  e,
-		DBInstance:  dbInstance,
-		Log:         logger,
-		RedisClient: cache,
+		DBInstance:  env.db,
+		Log:         env.log,
+		RedisClient: env.redis,
 	})
 
 	// Test cases
@@ -46,6 +37,10 @@ func TestRegister(t *testing.T) {
 		{"Get_non-existent_Todo", http.MethodGet, "/api/v1/todos/1", http.StatusNotFound},       // Assuming no todo with id 1 exists
 		{"Update_Todo_without_body", http.MethodPut, "/api/v1/todos/1", http.StatusNotFound},    // Assuming no body is sent, should return BadRequest
 		{"Delete_non-existent_Todo", http.MethodDelete, "/api/v1/todos/1", http.StatusNotFound}, // Assuming no todo with id 1 exists
+		{"Get_all_Calendar_feeds", http.MethodGet, "/api/v1/calendar/feeds", http.StatusOK},
+		{"Get_unknown_Calendar_feed", http.MethodGet, "/api/v1/calendar/unknown.ics", http.StatusNotFound},
+		{"Find_CalDAV_principal", "PROPFIND", "/caldav/", http.StatusMultiStatus},
+		{"Get_unknown_CalDAV_resource", http.MethodGet, "/caldav/todos/unknown.ics", http.StatusNotFound},
 	}
 
 	for _, tt := range tests {
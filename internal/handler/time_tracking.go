@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// HeaderUser is the request header naming the user who tracks time.
+const HeaderUser = "X-User"
+
+// errMissingUser is the error for a time tracking request without the user header.
+var errMissingUser = apperrors.New(http.StatusBadRequest, apperrors.CodeMissingUser, HeaderUser+" header is required")
+
+// TimeTrackingHandler is the request handler for the time tracking endpoint.
+type TimeTrackingHandler interface {
+	StartTimer(c echo.Context) error
+	StopTimer(c echo.Context) error
+	CurrentTimer(c echo.Context) error
+	CreateEntry(c echo.Context) error
+	FindAllEntries(c echo.Context) error
+	DeleteEntry(c echo.Context) error
+	Report(c echo.Context) error
+}
+
+type InitTimeTrackingHandler struct {
+	Service service.ITimeTracking
+	Log     *log.Logger
+}
+
+type timeTrackingHandler struct {
+	Handler
+	service service.ITimeTracking
+	log     *log.Logger
+}
+
+// NewTimeTracking returns a new instance of the time tracking handler.
+func NewTimeTracking(initTimeTrackingHandler *InitTimeTrackingHandler) TimeTrackingHandler {
+	return &timeTrackingHandler{
+		log:     initTimeTrackingHandler.Log,
+		service: initTimeTrackingHandler.Service,
+	}
+}
+
+// @Summary	Start a timer on a todo
+// @Tags		time tracking
+// @Produce	json
+// @Param		X-User	header		string				true	"user"
+// @Param		path	path		model.TimerRequest	false	"path"
+// @Success	201		{object}	ResponseData{data=model.TimeEntry}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	409		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/timer/start [post]
+func (t *timeTrackingHandler) StartTimer(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.TimerRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	if req.User = c.Request().Header.Get(HeaderUser); req.User == "" {
+		return t.Fail(c, errMissingUser)
+	}
+
+	entry, err := t.service.StartTimer(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: entry})
+}
+
+// @Summary	Stop the timer on a todo
+// @Tags		time tracking
+// @Produce	json
+// @Param		X-User	header		string				true	"user"
+// @Param		path	path		model.TimerRequest	false	"path"
+// @Success	200		{object}	ResponseData{data=model.TimeEntry}
+// @Failure	400		{object}	errors.Problem
+// @Failure	409		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/timer/stop [post]
+func (t *timeTrackingHandler) StopTimer(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.TimerRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	if req.User = c.Request().Header.Get(HeaderUser); req.User == "" {
+		return t.Fail(c, errMissingUser)
+	}
+
+	entry, err := t.service.StopTimer(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: entry})
+}
+
+// @Summary	Find the running timer of the user
+// @Tags		time tracking
+// @Produce	json
+// @Param		X-User	header		string	true	"user"
+// @Success	200		{object}	ResponseData{data=model.TimeEntry}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/timer [get]
+func (t *timeTrackingHandler) CurrentTimer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	req := model.CurrentTimerRequest{User: c.Request().Header.Get(HeaderUser)}
+	if req.User == "" {
+		return t.Fail(c, errMissingUser)
+	}
+
+	entry, err := t.service.CurrentTimer(ctx, &req)
+	if errors.Is(err, model.ErrNoRunningTimer) {
+		return t.Fail(c, apperrors.Wrap(err, http.StatusNotFound, apperrors.CodeNoRunningTimer))
+	}
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: entry})
+}
+
+// @Summary	Record time on a todo manually
+// @Tags		time tracking
+// @Accept		json
+// @Produce	json
+// @Param		X-User	header		string							true	"user"
+// @Param		path	path		model.FindAllTimeEntriesRequest	false	"path"
+// @Param		request	body		model.CreateTimeEntryRequest	true	"json"
+// @Success	201		{object}	ResponseData{data=model.TimeEntry}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/time-entries [post]
+func (t *timeTrackingHandler) CreateEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CreateTimeEntryRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	if req.User = c.Request().Header.Get(HeaderUser); req.User == "" {
+		return t.Fail(c, errMissingUser)
+	}
+
+	entry, err := t.service.CreateEntry(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: entry})
+}
+
+// @Summary	Find all time entries of a todo
+// @Tags		time tracking
+// @Produce	json
+// @Param		path	path		model.FindAllTimeEntriesRequest	false	"path"
+// @Success	200		{object}	ResponseData{Data=[]model.TimeEntry}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/time-entries [get]
+func (t *timeTrackingHandler) FindAllEntries(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.FindAllTimeEntriesRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	res, err := t.service.FindAllEntries(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
+
+// @Summary	Delete a time entry
+// @Tags		time tracking
+// @Param		path	path	model.DeleteTimeEntryRequest	false	"path"
+// @Success	204
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/todos/:id/time-entries/:entryId [delete]
+func (t *timeTrackingHandler) DeleteEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.DeleteTimeEntryRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	if err := t.service.DeleteEntry(ctx, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary	Report the tracked time
+// @Tags		time tracking
+// @Produce	json
+// @Param		from	query		string	false	"start of the range, RFC 3339 or YYYY-MM-DD"
+// @Param		to		query		string	false	"end of the range (exclusive), RFC 3339 or YYYY-MM-DD"
+// @Param		groupBy	query		string	false	"todo (default), project, user, day, week or month"
+// @Param		user	query		string	false	"user"
+// @Param		project	query		string	false	"project"
+// @Success	200		{object}	ResponseData{data=model.TimeReport}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/reports/time [get]
+func (t *timeTrackingHandler) Report(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.TimeReportRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	res, err := t.service.Report(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
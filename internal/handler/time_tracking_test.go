@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (f *fakeClock) Now() time.Time          { return f.now }
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func initTimeTrackingSetup(t *testing.T) (TimeTrackingHandler, *fakeClock, []*model.Todo) {
+	// The environment is private: the running timer of a user must not leak between tests.
+	env := newTestEnv(t)
+	var todos []*model.Todo
+	for _, todo := range []*model.Todo{
+		{Task: "Write report", Status: model.Created, Priority: model.TP_Low, Project: "docs", EstimateMinutes: 90},
+		{Task: "Fix bug", Status: model.Created, Priority: model.TP_High, Project: "app"},
+	} {
+		require.NoError(t, env.todos.Create(context.Background(), todo))
+		todos = append(todos, todo)
+	}
+
+	clock := &fakeClock{now: time.Date(2024, 10, 16, 9, 0, 0, 0, time.UTC)}
+	timeTrackingService := service.NewTimeTracking(&service.InitTimeTrackingService{
+		Log:                 env.log,
+		TimeEntryRepository: repository.NewTimeEntry(&repository.InitTimeEntryRepository{Db: env.db, Log: env.log}),
+		TodoRepository:      env.todos,
+		Now:                 clock.Now,
+	})
+	return NewTimeTracking(&InitTimeTrackingHandler{Service: timeTrackingService, Log: env.log}), clock, todos
+}
+
+func timeTrackingRequest(e *echo.Echo, method, target, body, user string, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if user != "" {
+		req.Header.Set(HeaderUser, user)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names, values = append(names, params[i]), append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	return c, rec
+}
+
+func TestTimeTrackingHandler_Timer(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, clock, todos := initTimeTrackingSetup(t)
+	first, second := strconv.Itoa(todos[0].ID), strconv.Itoa(todos[1].ID)
+
+	// The user header is required
+	c, rec := timeTrackingRequest(e, http.MethodPost, "/", "", "", "id", first)
+	require.NoError(t, handler.StartTimer(c))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", first)
+	require.NoError(t, handler.StartTimer(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	// Starting twice on the same todo conflicts
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", first)
+	require.NoError(t, handler.StartTimer(c))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Starting on another todo stops the running timer
+	clock.Advance(30 * time.Minute)
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", second)
+	require.NoError(t, handler.StartTimer(c))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	c, rec = timeTrackingRequest(e, http.MethodGet, "/", "", "alice")
+	require.NoError(t, handler.CurrentTimer(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var current struct{ Data model.TimeEntry }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &current))
+	assert.Equal(t, todos[1].ID, current.Data.TodoID)
+
+	// Other users have their own timers
+	c, rec = timeTrackingRequest(e, http.MethodGet, "/", "", "bob")
+	require.NoError(t, handler.CurrentTimer(c))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", first)
+	require.NoError(t, handler.StopTimer(c))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	clock.Advance(15 * time.Minute)
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", second)
+	require.NoError(t, handler.StopTimer(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var stopped struct{ Data model.TimeEntry }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stopped))
+	require.NotNil(t, stopped.Data.EndedAt)
+	assert.Equal(t, 15*time.Minute, stopped.Data.Duration(clock.Now()))
+
+	c, rec = timeTrackingRequest(e, http.MethodGet, "/", "", "", "id", first)
+	require.NoError(t, handler.FindAllEntries(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var entries struct{ Data []model.TimeEntry }
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Len(t, entries.Data, 1)
+	assert.Equal(t, 30*time.Minute, entries.Data[0].Duration(clock.Now()))
+}
+
+func TestTimeTrackingHandler_CreateEntry(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, _, todos := initTimeTrackingSetup(t)
+	todoID := strconv.Itoa(todos[0].ID)
+
+	tests := []struct {
+		name       string
+		todoID     string
+		body       string
+		user       string
+		wantStatus int
+	}{
+		{name: "with_duration", todoID: todoID, body: `{"startedAt":"2024-10-15T09:00:00Z","durationMinutes":45}`, user: "alice", wantStatus: http.StatusCreated},
+		{name: "with_end", todoID: todoID, body: `{"startedAt":"2024-10-15T09:00:00Z","endedAt":"2024-10-15T10:00:00Z"}`, user: "alice", wantStatus: http.StatusCreated},
+		{name: "end_before_start", todoID: todoID, body: `{"startedAt":"2024-10-15T09:00:00Z","endedAt":"2024-10-15T08:00:00Z"}`, user: "alice", wantStatus: http.StatusBadRequest},
+		{name: "no_duration", todoID: todoID, body: `{"startedAt":"2024-10-15T09:00:00Z"}`, user: "alice", wantStatus: http.StatusBadRequest},
+		{name: "missing_user", todoID: todoID, body: `{"startedAt":"2024-10-15T09:00:00Z","durationMinutes":45}`, wantStatus: http.StatusBadRequest},
+		{name: "todo_not_found", todoID: "-1", body: `{"startedAt":"2024-10-15T09:00:00Z","durationMinutes":45}`, user: "alice", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, rec := timeTrackingRequest(e, http.MethodPost, "/", tt.body, tt.user, "id", tt.todoID)
+			require.NoError(t, handler.CreateEntry(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestTimeTrackingHandler_Report(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, _, todos := initTimeTrackingSetup(t)
+
+	record := func(todo *model.Todo, user, startedAt string, minutes int) {
+		body := `{"startedAt":"` + startedAt + `","durationMinutes":` + strconv.Itoa(minutes) + `}`
+		c, rec := timeTrackingRequest(e, http.MethodPost, "/", body, user, "id", strconv.Itoa(todo.ID))
+		require.NoError(t, handler.CreateEntry(c))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+	record(todos[0], "alice", "2024-10-14T09:00:00Z", 60)
+	record(todos[0], "bob", "2024-10-15T09:00:00Z", 30)
+	record(todos[1], "alice", "2024-10-15T13:00:00Z", 15)
+	record(todos[1], "alice", "2024-10-21T13:00:00Z", 15)
+
+	report := func(query string) (int, model.TimeReport) {
+		c, rec := timeTrackingRequest(e, http.MethodGet, "/reports/time?"+query, "", "")
+		require.NoError(t, handler.Report(c))
+		var res struct{ Data model.TimeReport }
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res.Data
+	}
+
+	status, res := report("from=2024-10-14&to=2024-10-21")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(105*60), res.TotalSeconds)
+	require.Len(t, res.Groups, 2)
+	assert.Equal(t, "Write report", res.Groups[0].Label)
+	assert.Equal(t, int64(90*60), res.Groups[0].Seconds)
+	assert.Equal(t, 90, res.Groups[0].EstimateMinutes)
+	assert.Equal(t, 2, res.Groups[0].Entries)
+
+	status, res = report("groupBy=day&user=alice")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Groups, 3)
+	assert.Equal(t, "2024-10-14", res.Groups[0].Key)
+
+	status, res = report("groupBy=week")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Groups, 2)
+	assert.Equal(t, "2024-W42", res.Groups[0].Key)
+	assert.Equal(t, int64(105*60), res.Groups[0].Seconds)
+
+	status, res = report("groupBy=project&project=app")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, res.Groups, 1)
+	assert.Equal(t, int64(30*60), res.Groups[0].Seconds)
+
+	status, _ = report("groupBy=year")
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = report("from=yesterday")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestTimeTrackingHandler_ReportSplitsEntries(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler, clock, todos := initTimeTrackingSetup(t)
+	id := strconv.Itoa(todos[0].ID)
+
+	// From Sunday 23:00 to Monday 01:00, across a day and an ISO week
+	c, rec := timeTrackingRequest(e, http.MethodPost, "/", `{"startedAt":"2024-10-13T23:00:00Z","durationMinutes":120}`, "alice", "id", id)
+	require.NoError(t, handler.CreateEntry(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	// A timer started at 09:00 on Wednesday, still running at 12:00
+	c, rec = timeTrackingRequest(e, http.MethodPost, "/", "", "alice", "id", id)
+	require.NoError(t, handler.StartTimer(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	clock.Advance(3 * time.Hour)
+
+	report := func(query string) model.TimeReport {
+		c, rec := timeTrackingRequest(e, http.MethodGet, "/reports/time?"+query, "", "")
+		require.NoError(t, handler.Report(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res struct{ Data model.TimeReport }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res.Data
+	}
+	type group struct {
+		Key     string
+		Seconds int64
+	}
+	groups := func(res model.TimeReport) []group {
+		var got []group
+		for _, g := range res.Groups {
+			got = append(got, group{Key: g.Key, Seconds: g.Seconds})
+		}
+		return got
+	}
+
+	res := report("groupBy=day")
+	assert.Equal(t, []group{{"2024-10-13", 3600}, {"2024-10-14", 3600}, {"2024-10-16", 3 * 3600}}, groups(res))
+	res = report("groupBy=week")
+	assert.Equal(t, []group{{"2024-W41", 3600}, {"2024-W42", 4 * 3600}}, groups(res))
+
+	// Only the parts within the range count
+	res = report("from=2024-10-14&to=2024-10-16T10:00:00Z")
+	assert.Equal(t, int64(2*3600), res.TotalSeconds)
+	res = report("from=2024-10-16T11:00:00Z")
+	assert.Equal(t, int64(3600), res.TotalSeconds)
+}
@@ -1,21 +1,29 @@
 package handler
 
 import (
-	"errors"
+	"fmt"
 	"github.com/labstack/echo/v4"
+	apperrors "github.com/zuu-development/fullstack-examination-2024/internal/errors"
+	"github.com/zuu-development/fullstack-examination-2024/internal/exchange"
 	"github.com/zuu-development/fullstack-examination-2024/internal/log"
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
 	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+	"mime"
 	"net/http"
 )
 
 // TodoHandler is the request handler for the todo endpoint.
 type TodoHandler interface {
 	Create(c echo.Context) error
+	QuickCreate(c echo.Context) error
 	Update(c echo.Context) error
 	Delete(c echo.Context) error
 	Find(c echo.Context) error
 	FindAll(c echo.Context) error
+	Move(c echo.Context) error
+	Batch(c echo.Context) error
+	Export(c echo.Context) error
+	Import(c echo.Context) error
 }
 
 type InitTodoHandler struct {
@@ -42,29 +50,56 @@ func NewTodo(initTodoHandler *InitTodoHandler) TodoHandler {
 // @Accept		json
 // @Produce	json
 // @Param		request	body		model.CreateRequest	true	"json"
-// @Success	201		{object}	ResponseError{data=model.Todo}
-// @Failure	400		{object}	ResponseError
-// @Failure	500		{object}	ResponseError
+// @Success	201		{object}	ResponseData{data=model.Todo}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
 // @Router		/todos [post]
 func (t *todoHandler) Create(c echo.Context) error {
 	ctx := c.Request().Context()
 	var req model.CreateRequest
-	var responseErr ResponseError
 
 	if err := t.MustBind(c, &req); err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusBadRequest, err))
+		return t.Fail(c, err)
 	}
 
 	todo, err := t.service.Create(ctx, &req)
 	if err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusInternalServerError, err))
+		return t.Fail(c, err)
 	}
 
 	return c.JSON(http.StatusCreated, ResponseData{Data: todo})
 }
 
+// @Summary	Create a new todo from free-form text
+// @Description	Parses text such as "Pay invoice tomorrow 5pm !high #finance @alice" into priority, tags, assignee and due date.
+// @Tags		todos
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.QuickCreateRequest	true	"json"
+// @Success	201		{object}	ResponseData{data=model.QuickCreateResponse}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/quick [post]
+func (t *todoHandler) QuickCreate(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.QuickCreateRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	res, err := t.service.QuickCreate(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: res})
+}
+
 // @Summary	Update a todo
 // @Tags		todos
 // @Accept		json
@@ -72,26 +107,23 @@ func (t *todoHandler) Create(c echo.Context) error {
 // @Param		body	body		model.UpdateRequestBody	true	"body"
 // @Param		path	path		model.UpdateRequestPath	false	"path"
 // @Success	201		{object}	ResponseData{Data=model.Todo}
-// @Failure	400		{object}	ResponseError
-// @Failure	500		{object}	ResponseError
+// @Failure	400		{object}	errors.Problem
+// @Failure	409		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
 // @Router		/todos/:id [put]
 func (t *todoHandler) Update(c echo.Context) error {
 	ctx := c.Request().Context()
 	var req model.UpdateRequest
-	var responseErr ResponseError
 
 	if err := t.MustBind(c, &req); err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusBadRequest, err))
+		return t.Fail(c, err)
 	}
 
 	todo, err := t.service.Update(ctx, &req)
 	if err != nil {
 		t.log.Error(ctx, err.Error())
-		if errors.Is(err, model.ErrNotFound) {
-			return c.JSON(responseErr.GetErrorResponse(http.StatusNotFound, err))
-		}
-		return c.JSON(responseErr.GetErrorResponse(http.StatusInternalServerError, err))
+		return t.Fail(c, err)
 	}
 
 	return c.JSON(http.StatusOK, ResponseData{Data: todo})
@@ -101,26 +133,22 @@ func (t *todoHandler) Update(c echo.Context) error {
 // @Tags		todos
 // @Param		path	path	model.DeleteRequest	false	"path"
 // @Success	204
-// @Failure	400	{object}	ResponseError
-// @Failure	404	{object}	ResponseError
-// @Failure	500	{object}	ResponseError
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
 // @Router		/todos/:id [delete]
 func (t *todoHandler) Delete(c echo.Context) error {
 	ctx := c.Request().Context()
 	var req model.DeleteRequest
-	var responseErr ResponseError
 
 	if err := t.MustBind(c, &req); err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusBadRequest, err))
+		return t.Fail(c, err)
 	}
 
 	if err := t.service.Delete(ctx, &req); err != nil {
 		t.log.Error(ctx, err.Error())
-		if errors.Is(err, model.ErrNotFound) {
-			return c.JSON(responseErr.GetErrorResponse(http.StatusNotFound, err))
-		}
-		return c.JSON(responseErr.GetErrorResponse(http.StatusInternalServerError, err))
+		return t.Fail(c, err)
 	}
 
 	return c.JSON(http.StatusOK, "Deleted successfully")
@@ -130,27 +158,23 @@ func (t *todoHandler) Delete(c echo.Context) error {
 // @Tags		todos
 // @Param		path	path		model.FindRequest	false	"path"
 // @Success	200		{object}	ResponseData{Data=model.Todo}
-// @Failure	400		{object}	ResponseError
-// @Failure	404		{object}	ResponseError
-// @Failure	500		{object}	ResponseError
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
 // @Router		/todos/:id [get]
 func (t *todoHandler) Find(c echo.Context) error {
 	ctx := c.Request().Context()
 	var req model.FindRequest
-	var responseErr ResponseError
 
 	if err := t.MustBind(c, &req); err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusBadRequest, err))
+		return t.Fail(c, err)
 	}
 
 	res, err := t.service.Find(ctx, &req)
 	if err != nil {
 		t.log.Error(ctx, err.Error())
-		if errors.Is(err, model.ErrNotFound) {
-			return c.JSON(responseErr.GetErrorResponse(http.StatusNotFound, err))
-		}
-		return c.JSON(responseErr.GetErrorResponse(http.StatusInternalServerError, err))
+		return t.Fail(c, err)
 	}
 
 	return c.JSON(http.StatusOK, ResponseData{Data: res})
@@ -158,30 +182,221 @@ func (t *todoHandler) Find(c echo.Context) error {
 
 // @Summary	Find all todos
 // @Tags		todos
+// @Param		task	query		string	false	"substring of the task or description"
+// @Param		status	query		string	false	"status"
+// @Param		project	query		string	false	"project"
+// @Param		assignee	query		string	false	"assignee"
+// @Param		render	query		string	false	"set to html to include DescriptionHTML"	Enums(html)
+// @Param		sort	query		string	false	"set to manual to order by status column and rank"	Enums(manual)
 // @Success	200	{object}	ResponseData{Data=[]model.Todo}
-// @Failure	500	{object}	ResponseError
+// @Failure	500	{object}	errors.Problem
 // @Router		/todos [get]
 func (t *todoHandler) FindAll(c echo.Context) error {
 	ctx := c.Request().Context()
-	var responseErr ResponseError
 
-	// Retrieve query parameters for 'task' and 'status'
+	// Retrieve query parameters for 'task', 'status', 'project', 'assignee', 'render' and 'sort'
 	task := c.QueryParam("task")
 	status := c.QueryParam("status")
+	project := c.QueryParam("project")
+	assignee := c.QueryParam("assignee")
+	render := c.QueryParam("render")
+	sort := c.QueryParam("sort")
+
+	if render != "" && render != model.RenderHTML {
+		t.log.Error(ctx, fmt.Sprintf("invalid render mode: %s", render))
+		return t.Fail(c, apperrors.Validation([]apperrors.Violation{{Field: "render", Rule: "oneof", Param: model.RenderHTML}}))
+	}
+
+	if sort != "" && sort != model.SortManual {
+		t.log.Error(ctx, fmt.Sprintf("invalid sort mode: %s", sort))
+		return t.Fail(c, apperrors.Validation([]apperrors.Violation{{Field: "sort", Rule: "oneof", Param: model.SortManual}}))
+	}
 
 	// Populate request params model with extracted values
 	reqParams := &model.FindAllRequest{
-		Task:   task,
-		Status: status,
+		Task:     task,
+		Status:   status,
+		Project:  project,
+		Assignee: assignee,
+		Render:   render,
+		Sort:     sort,
 	}
 
 	// Call the service to find all tasks based on the request params
 	res, err := t.service.FindAll(ctx, reqParams)
 	if err != nil {
 		t.log.Error(ctx, err.Error())
-		return c.JSON(responseErr.GetErrorResponse(http.StatusInternalServerError, err))
+		return t.Fail(c, err)
 	}
 
 	// Return the successful result
 	return c.JSON(http.StatusOK, ResponseData{Data: res})
 }
+
+// @Summary	Move a todo
+// @Description	Places the todo right before or after another todo, taking over its status, or at the end of a status column.
+// @Tags		todos
+// @Accept		json
+// @Produce	json
+// @Param		path	path		model.FindRequest	false	"path"
+// @Param		request	body		model.MoveRequest	true	"json"
+// @Success	200		{object}	ResponseData{Data=model.Todo}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	409		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/:id/move [post]
+func (t *todoHandler) Move(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.MoveRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	todo, err := t.service.Move(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: todo})
+}
+
+// @Summary	Apply many create, update and delete operations at once
+// @Description	All operations run in one transaction. In atomic mode (default) any failure rolls back the whole batch; in partial mode each operation succeeds or fails on its own and is reported in Results.
+// @Tags		todos
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.BatchRequest	true	"json"
+// @Success	200		{object}	ResponseData{Data=model.BatchResponse}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	409		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos:batch [post]
+func (t *todoHandler) Batch(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.BatchRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	res, err := t.service.Batch(ctx, &req)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	loc := localizer(c)
+	for _, result := range res.Results {
+		switch {
+		case result.Err != nil:
+			problem := localize(loc, AppError(result.Err).Problem("", ""))
+			result.Status, result.Code, result.Error = problem.Status, problem.Code, problem.Detail
+		case result.Op == model.BatchCreate:
+			result.Status = http.StatusCreated
+		case result.Op == model.BatchDelete:
+			result.Status = http.StatusNoContent
+		default:
+			result.Status = http.StatusOK
+		}
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
+
+// @Summary	Export todos
+// @Description	Streams the todos in board order as JSON, as the API returns them, CSV, todo.txt, iCalendar VTODOs or a Markdown report.
+// @Tags		todos
+// @Produce	json
+// @Produce	text/csv
+// @Produce	plain
+// @Produce	text/markdown
+// @Produce	text/calendar
+// @Param		request	query		model.ExportRequest	false	"query"
+// @Success	200		{file}		file
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/export [get]
+func (t *todoHandler) Export(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.ExportRequest
+
+	if err := t.MustBind(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	if req.Format == "" {
+		req.Format = model.FormatJSON
+	}
+
+	res := c.Response()
+	enc, err := exchange.NewEncoder(req.Format, res)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	res.Header().Set(echo.HeaderContentType, exchange.ContentType(req.Format))
+	res.Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+		"filename": "todos" + exchange.Extension(req.Format),
+	}))
+
+	err = t.service.Export(ctx, &req, enc.Encode)
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		// Once the file has started, the client can only be left with a truncated one.
+		if res.Committed {
+			return nil
+		}
+		res.Header().Del(echo.HeaderContentDisposition)
+		return t.Fail(c, err)
+	}
+	return nil
+}
+
+// @Summary	Import todos
+// @Description	Reads a JSON, CSV, todo.txt or iCalendar file from the request body. A todo with the ID or the task of an existing one conflicts: it is skipped, or updates the existing one with onConflict=update. An import with invalid lines writes nothing; a dry run reports what the import would do without writing either.
+// @Tags		todos
+// @Accept		json
+// @Accept		text/csv
+// @Accept		plain
+// @Produce	json
+// @Param		query	query		model.ImportRequest	false	"query"
+// @Param		request	body		string				true	"file"
+// @Success	200		{object}	ResponseData{Data=model.ImportResponse}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/todos/import [post]
+func (t *todoHandler) Import(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.ImportRequest
+
+	if err := t.MustBindQuery(c, &req); err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+	if req.Format == "" {
+		req.Format = model.FormatJSON
+	}
+
+	records, err := exchange.Decode(req.Format, c.Request().Body)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	res, err := t.service.Import(ctx, &req, records)
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return t.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: res})
+}
@@ -4,8 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	cache2 "github.com/zuu-development/fullstack-examination-2024/internal/cache"
-	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -16,33 +15,15 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/zuu-development/fullstack-examination-2024/internal/db"
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/rank"
 	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
 	"github.com/zuu-development/fullstack-examination-2024/internal/service"
 )
 
 func InitSetup(t *testing.T) TodoHandler {
-	logger := log.New()
-	dbInstance, err := db.NewMemory()
-	require.NoError(t, err)
-	err = db.Migrate(dbInstance)
-	require.NoError(t, err)
-
-	redis := cache2.New(&cache2.Config{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       5,
-	})
-
-	redisRepository := repository.NewRedisCache(&repository.InitRedisCache{
-		Client: redis, Log: logger,
-	})
-	redisRepository.DeleteAll(context.Background())
-	repository := repository.NewTodo(&repository.InitTodoRepository{Db: dbInstance, Log: logger})
-	service := service.NewTodo(&service.InitTodoService{Log: logger, TodoRepository: repository, RedisCache: redisRepository})
-	todoHandler := NewTodo(&InitTodoHandler{Service: service, Log: logger})
-	return todoHandler
+	env := newTestEnv(t)
+	return NewTodo(&InitTodoHandler{Service: env.todoService(&service.InitTodoService{}), Log: env.log})
 }
 
 func TestTodoHandler_Create(t *testing.T) {
@@ -427,6 +408,46 @@ func TestTodoHandler_FindAll(t *testing.T) {
 	}
 }
 
+func TestTodoHandler_FindAll_Filters(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler := InitSetup(t)
+
+	createTask(t, e, handler, `{"task":"Ship release","priority":"high","assignee":"alice","project":"web"}`)
+	createTask(t, e, handler, `{"task":"Write notes","description":"Release notes","priority":"low","assignee":"bob","project":"web"}`)
+	createTask(t, e, handler, `{"task":"Buy milk","priority":"medium","assignee":"alice"}`)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"all", "", []string{"Buy milk", "Write notes", "Ship release"}},
+		{"assignee", "assignee=alice", []string{"Buy milk", "Ship release"}},
+		{"project_and_assignee", "project=web&assignee=bob", []string{"Write notes"}},
+		{"task_or_description", "task=RELEASE", []string{"Write notes", "Ship release"}},
+		{"status", "status=done", nil},
+	}
+
+	// The first request fills the cache, which the others are read from.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			require.NoError(t, handler.FindAll(e.NewContext(req, rec)))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var res struct{ Data []model.Todo }
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			var tasks []string
+			for _, todo := range res.Data {
+				tasks = append(tasks, todo.Task)
+			}
+			assert.ElementsMatch(t, tt.want, tasks)
+		})
+	}
+}
+
 func createTask(t *testing.T, e *echo.Echo, handler TodoHandler, body string) int {
 	req := httptest.NewRequest(http.MethodPost, "/todos", bytes.NewReader([]byte(body)))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -448,3 +469,232 @@ func createTask(t *testing.T, e *echo.Echo, handler TodoHandler, body string) in
 
 	return res.Data.ID
 }
+
+func TestTodoHandler_FindRenderHTML(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler := InitSetup(t)
+
+	id := strconv.Itoa(createTask(t, e, handler, `{"task":"Rendered Task","priority":"low","description":"Read **this** <script>x</script>"}`))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantHTML   string
+	}{
+		{name: "markdown_only", wantStatus: http.StatusOK},
+		{name: "render_html", query: "?render=html", wantStatus: http.StatusOK, wantHTML: "<p>Read <strong>this</strong> x</p>\n"},
+		{name: "invalid_render_mode", query: "?render=pdf", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/dummy/target"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/todos/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(id)
+
+			require.NoError(t, handler.Find(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var res struct{ Data model.Todo }
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, "Read **this** <script>x</script>", res.Data.Description)
+			assert.Equal(t, tt.wantHTML, res.Data.DescriptionHTML)
+		})
+	}
+}
+
+// jsonCache is a todo cache keeping each todo as a JSON string under its key,
+// which Find reads with Get.
+type jsonCache struct {
+	repository.IRedisCache
+	values map[string]string
+}
+
+func (j *jsonCache) Get(_ context.Context, key string) (string, error) {
+	return j.values[key], nil
+}
+
+func (j *jsonCache) Add(_ context.Context, key string, todo *model.Todo) error {
+	b, err := json.Marshal(todo)
+	j.values[key] = string(b)
+	return err
+}
+
+func TestTodoHandler_FindRenderHTMLFromCache(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	env := newTestEnv(t)
+	cache := &jsonCache{values: map[string]string{}}
+	handler := NewTodo(&InitTodoHandler{Service: env.todoService(&service.InitTodoService{RedisCache: cache}), Log: env.log})
+
+	todo := model.NewTodo(&model.CreateRequest{Task: "Cached", Priority: "low", Description: "Read **this**"})
+	require.NoError(t, env.todos.Create(context.Background(), todo))
+
+	// The first find reads the database and caches the todo, the second reads the cache
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/dummy/target?render=html", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/todos/:id")
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(todo.ID))
+		require.NoError(t, handler.Find(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res struct{ Data model.Todo }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, "<p>Read <strong>this</strong></p>\n", res.Data.DescriptionHTML, "find %d", i+1)
+	}
+	assert.Contains(t, cache.values, fmt.Sprintf("todo:%d", todo.ID))
+}
+
+func TestTodoHandler_QuickCreate(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	handler := InitSetup(t)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       *model.Todo
+	}{
+		{
+			name:       "successful_quick_create",
+			body:       `{"text":"Pay invoice 2030-01-15 5pm !high #finance @alice"}`,
+			wantStatus: http.StatusCreated,
+			want: &model.Todo{
+				Task:     "Pay invoice",
+				Status:   model.Created,
+				Priority: model.TP_High,
+				Tags:     []string{"finance"},
+				Assignee: "alice",
+			},
+		},
+		{
+			name:       "default_priority",
+			body:       `{"text":"Water plants"}`,
+			wantStatus: http.StatusCreated,
+			want:       &model.Todo{Task: "Water plants", Status: model.Created, Priority: model.TP_Medium},
+		},
+		{
+			name:       "only_tokens",
+			body:       `{"text":"tomorrow !high"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "missing_text",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/dummy/target", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/todos/quick")
+
+			require.NoError(t, handler.QuickCreate(c))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.want == nil {
+				return
+			}
+
+			var res struct{ Data model.QuickCreateResponse }
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			require.NotNil(t, res.Data.Todo)
+			require.NotNil(t, res.Data.Parsed)
+			assert.Equal(t, tt.want.Task, res.Data.Todo.Task)
+			assert.Equal(t, tt.want.Status, res.Data.Todo.Status)
+			assert.Equal(t, tt.want.Priority, res.Data.Todo.Priority)
+			assert.Equal(t, tt.want.Tags, res.Data.Todo.Tags)
+			assert.Equal(t, tt.want.Assignee, res.Data.Todo.Assignee)
+			assert.Equal(t, res.Data.Parsed.DueAt, res.Data.Todo.DueAt)
+		})
+	}
+}
+
+func TestTodoHandler_Move(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+
+	// The environment is private: the move works on whole status columns.
+	env := newTestEnv(t)
+	handler := NewTodo(&InitTodoHandler{Service: env.todoService(&service.InitTodoService{}), Log: env.log})
+
+	a := createTask(t, e, handler, `{"task":"A","priority":"low"}`)
+	b := createTask(t, e, handler, `{"task":"B","priority":"high"}`)
+	c := createTask(t, e, handler, `{"task":"C","priority":"medium"}`)
+
+	move := func(id int, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/dummy/target", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetPath("/todos/:id/move")
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(strconv.Itoa(id))
+		require.NoError(t, handler.Move(ctx))
+		return rec.Code
+	}
+	order := func() []string {
+		req := httptest.NewRequest(http.MethodGet, "/dummy/target?sort=manual", nil)
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler.FindAll(e.NewContext(req, rec)))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res struct{ Data []model.Todo }
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		var got []string
+		for _, todo := range res.Data {
+			got = append(got, string(todo.Status)+":"+todo.Task)
+		}
+		return got
+	}
+
+	// New todos are appended to their column
+	assert.Equal(t, []string{"created:A", "created:B", "created:C"}, order())
+
+	assert.Equal(t, http.StatusOK, move(c, fmt.Sprintf(`{"before":%d}`, a)))
+	assert.Equal(t, []string{"created:C", "created:A", "created:B"}, order())
+
+	assert.Equal(t, http.StatusOK, move(a, fmt.Sprintf(`{"after":%d}`, b)))
+	assert.Equal(t, []string{"created:C", "created:B", "created:A"}, order())
+
+	assert.Equal(t, http.StatusOK, move(b, `{"status":"done"}`))
+	assert.Equal(t, http.StatusOK, move(c, fmt.Sprintf(`{"before":%d,"status":"done"}`, b)))
+	assert.Equal(t, []string{"created:A", "done:C", "done:B"}, order())
+
+	// Moving to the top again and again makes the ranks dense until the column is rebalanced
+	for i := 0; i < 40; i++ {
+		assert.Equal(t, http.StatusOK, move(b, fmt.Sprintf(`{"before":%d}`, c)))
+		assert.Equal(t, http.StatusOK, move(c, fmt.Sprintf(`{"before":%d}`, b)))
+	}
+	assert.Equal(t, []string{"created:A", "done:C", "done:B"}, order())
+	column, err := env.todos.FindAll(context.Background(), &model.FindAllRequest{Status: string(model.Done), Sort: model.SortManual})
+	require.NoError(t, err)
+	require.Len(t, column, 2)
+	assert.Equal(t, "C", column[0].Task)
+	for _, todo := range column {
+		assert.LessOrEqual(t, len(todo.Rank), rank.MaxLength)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, move(a, fmt.Sprintf(`{"before":%d}`, a)))
+	assert.Equal(t, http.StatusBadRequest, move(a, fmt.Sprintf(`{"before":%d,"after":%d}`, b, c)))
+	assert.Equal(t, http.StatusBadRequest, move(a, fmt.Sprintf(`{"before":%d,"status":"created"}`, b)))
+	assert.Equal(t, http.StatusBadRequest, move(a, `{}`))
+	assert.Equal(t, http.StatusBadRequest, move(a, `{"status":"archived"}`))
+	assert.Equal(t, http.StatusNotFound, move(a, `{"before":99999}`))
+	assert.Equal(t, http.StatusNotFound, move(99999, `{"status":"done"}`))
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -9,6 +12,24 @@ type CustomValidator struct {
 	validator *validator.Validate
 }
 
+// NewValidator returns a validator that reports fields by the name clients send them with.
+func NewValidator() *CustomValidator {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "param", "query", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	return &CustomValidator{validator: v}
+}
+
 // Validate validates the input struct
 func (cv *CustomValidator) Validate(i interface{}) error {
 	if err := cv.validator.Struct(i); err != nil {
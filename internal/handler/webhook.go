@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// WebhookHandler is the request handler for the webhook endpoint.
+type WebhookHandler interface {
+	Create(c echo.Context) error
+	Find(c echo.Context) error
+	FindAll(c echo.Context) error
+	Delete(c echo.Context) error
+	FindDeliveries(c echo.Context) error
+}
+
+type InitWebhookHandler struct {
+	Service service.IWebhook
+	Log     *log.Logger
+}
+
+type webhookHandler struct {
+	Handler
+	service service.IWebhook
+	log     *log.Logger
+}
+
+// NewWebhook returns a new instance of the webhook handler.
+func NewWebhook(initWebhookHandler *InitWebhookHandler) WebhookHandler {
+	return &webhookHandler{
+		log:     initWebhookHandler.Log,
+		service: initWebhookHandler.Service,
+	}
+}
+
+// @Summary	Create a webhook
+// @Description	Subscribes a URL to todo events. Each delivery is a POST of {"id","type","createdAt","data"} signed with the secret: X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the body. Deliveries answered with anything but 2xx are retried with exponential backoff.
+// @Tags		webhooks
+// @Accept		json
+// @Produce	json
+// @Param		request	body		model.CreateWebhookRequest	true	"json"
+// @Success	201		{object}	ResponseData{data=model.Webhook}
+// @Failure	400		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/webhooks [post]
+func (w *webhookHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.CreateWebhookRequest
+
+	if err := w.MustBind(c, &req); err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	webhook, err := w.service.Create(ctx, &req)
+	if err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, ResponseData{Data: webhook})
+}
+
+// @Summary	Find a webhook
+// @Tags		webhooks
+// @Produce	json
+// @Param		path	path		model.FindWebhookRequest	false	"path"
+// @Success	200		{object}	ResponseData{data=model.Webhook}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/webhooks/:id [get]
+func (w *webhookHandler) Find(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.FindWebhookRequest
+
+	if err := w.MustBind(c, &req); err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	webhook, err := w.service.Find(ctx, &req)
+	if err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: webhook})
+}
+
+// @Summary	Find all webhooks
+// @Tags		webhooks
+// @Produce	json
+// @Success	200	{object}	ResponseData{data=[]model.Webhook}
+// @Failure	500	{object}	errors.Problem
+// @Router		/webhooks [get]
+func (w *webhookHandler) FindAll(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	webhooks, err := w.service.FindAll(ctx)
+	if err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: webhooks})
+}
+
+// @Summary	Delete a webhook
+// @Description	Deletes the webhook along with its pending deliveries and delivery log.
+// @Tags		webhooks
+// @Param		path	path	model.DeleteWebhookRequest	false	"path"
+// @Success	204
+// @Failure	400	{object}	errors.Problem
+// @Failure	404	{object}	errors.Problem
+// @Failure	500	{object}	errors.Problem
+// @Router		/webhooks/:id [delete]
+func (w *webhookHandler) Delete(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.DeleteWebhookRequest
+
+	if err := w.MustBind(c, &req); err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	if err := w.service.Delete(ctx, &req); err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary	Find the deliveries of a webhook
+// @Description	Returns the delivery log of the webhook, newest first: the status, attempts and last response of each delivery.
+// @Tags		webhooks
+// @Produce	json
+// @Param		path	path		model.FindDeliveriesRequest	false	"path"
+// @Param		status	query		string						false	"status"	Enums(pending, succeeded, failed)
+// @Param		limit	query		int							false	"maximum number of deliveries, 100 by default"
+// @Success	200		{object}	ResponseData{data=[]model.WebhookDelivery}
+// @Failure	400		{object}	errors.Problem
+// @Failure	404		{object}	errors.Problem
+// @Failure	500		{object}	errors.Problem
+// @Router		/webhooks/:id/deliveries [get]
+func (w *webhookHandler) FindDeliveries(c echo.Context) error {
+	ctx := c.Request().Context()
+	var req model.FindDeliveriesRequest
+
+	if err := w.MustBind(c, &req); err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	deliveries, err := w.service.FindDeliveries(ctx, &req)
+	if err != nil {
+		w.log.Error(ctx, err.Error())
+		return w.Fail(c, err)
+	}
+
+	return c.JSON(http.StatusOK, ResponseData{Data: deliveries})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"github.com/zuu-development/fullstack-examination-2024/internal/service"
+)
+
+// receiver is a webhook receiver that answers with the statuses it is given in turn.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*receivedDelivery
+}
+
+type receivedDelivery struct {
+	Header http.Header
+	Body   []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, &receivedDelivery{Header: req.Header, Body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []*receivedDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*receivedDelivery(nil), r.requests...)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	env := newTestEnv(t)
+	webhookService := service.NewWebhook(&service.InitWebhookService{
+		Log: env.log,
+		Config: model.Webhooks{
+			PollInterval: 10 * time.Millisecond, Timeout: time.Second, MaxAttempts: 3,
+			RetryDelay: 10 * time.Millisecond, MaxRetryDelay: 20 * time.Millisecond,
+		},
+		WebhookRepository: repository.NewWebhook(&repository.InitWebhookRepository{Db: env.db, Log: env.log}),
+	})
+	eventService := env.eventService()
+	todoService := env.todoService(&service.InitTodoService{Outbox: env.startOutbox(t, webhookService, eventService)})
+
+	webhookHandler := NewWebhook(&InitWebhookHandler{Service: webhookService, Log: env.log})
+	e := echo.New()
+	e.Validator = NewValidator()
+	e.POST("/webhooks", webhookHandler.Create)
+	e.GET("/webhooks", webhookHandler.FindAll)
+	e.GET("/webhooks/:id", webhookHandler.Find)
+	e.DELETE("/webhooks/:id", webhookHandler.Delete)
+	e.GET("/webhooks/:id/deliveries", webhookHandler.FindDeliveries)
+
+	send := func(method, target, body string, out interface{}) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		if out != nil {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ResponseData{Data: out}), rec.Body.String())
+		}
+		return rec.Code
+	}
+	deliveries := func(target string) []*model.WebhookDelivery {
+		var deliveries []*model.WebhookDelivery
+		require.Equal(t, http.StatusOK, send(http.MethodGet, target, "", &deliveries))
+		return deliveries
+	}
+
+	// The first attempt fails, the retry succeeds
+	flaky := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	flakyServer := httptest.NewServer(flaky)
+	t.Cleanup(flakyServer.Close)
+	// Every attempt fails
+	down := &receiver{statuses: []int{500, 500, 500, 500}}
+	downServer := httptest.NewServer(down)
+	t.Cleanup(downServer.Close)
+
+	var completed, created model.Webhook
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/webhooks",
+		`{"url":"`+flakyServer.URL+`","events":["todo.completed"],"secret":"0123456789abcdef"}`, &completed))
+	require.Equal(t, http.StatusCreated, send(http.MethodPost, "/webhooks",
+		`{"url":"`+downServer.URL+`","events":["todo.created"],"secret":"fedcba9876543210"}`, &created))
+	assert.Equal(t, []string{model.EventTodoCompleted}, completed.Events)
+	assert.Empty(t, completed.Secret)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go webhookService.Run(ctx)
+
+	todo, err := todoService.Create(ctx, &model.CreateRequest{Task: "Ship it", Priority: "high"})
+	require.NoError(t, err)
+	_, err = todoService.Update(ctx, &model.UpdateRequest{
+		UpdateRequestPath: model.UpdateRequestPath{ID: todo.ID},
+		UpdateRequestBody: model.UpdateRequestBody{Status: model.Done},
+	})
+	require.NoError(t, err)
+	// Editing a done todo does not complete it again
+	_, err = todoService.Update(ctx, &model.UpdateRequest{
+		UpdateRequestPath: model.UpdateRequestPath{ID: todo.ID},
+		UpdateRequestBody: model.UpdateRequestBody{Task: "Shipped"},
+	})
+	require.NoError(t, err)
+
+	t.Run("signed_delivery_with_retry", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			d := deliveries("/webhooks/" + strconv.Itoa(completed.ID) + "/deliveries")
+			return len(d) == 1 && d[0].Status == model.DeliverySucceeded
+		}, 5*time.Second, 10*time.Millisecond)
+
+		d := deliveries("/webhooks/" + strconv.Itoa(completed.ID) + "/deliveries")[0]
+		assert.Equal(t, 2, d.Attempts)
+		assert.Equal(t, http.StatusOK, d.ResponseStatus)
+		assert.Equal(t, model.EventTodoCompleted, d.EventType)
+		assert.Empty(t, d.Error)
+
+		received := flaky.received()
+		require.Len(t, received, 2)
+		// Both attempts are the same delivery with the same body
+		assert.Equal(t, received[0].Header.Get(service.HeaderWebhookDelivery), received[1].Header.Get(service.HeaderWebhookDelivery))
+		assert.Equal(t, received[0].Body, received[1].Body)
+
+		last := received[1]
+		assert.Equal(t, model.EventTodoCompleted, last.Header.Get(service.HeaderWebhookEvent))
+		timestamp := last.Header.Get(service.HeaderWebhookTimestamp)
+		assert.Equal(t, "sha256="+service.Sign("0123456789abcdef", timestamp, last.Body), last.Header.Get(service.HeaderWebhookSignature))
+		assert.NotEqual(t, "sha256="+service.Sign("wrong secret....", timestamp, last.Body), last.Header.Get(service.HeaderWebhookSignature))
+
+		var payload struct {
+			ID   uint
+			Type string
+			Data model.Todo
+		}
+		require.NoError(t, json.Unmarshal(last.Body, &payload))
+		assert.NotZero(t, payload.ID)
+		assert.Equal(t, model.EventTodoCompleted, payload.Type)
+		assert.Equal(t, "Ship it", payload.Data.Task)
+		assert.Equal(t, model.Done, payload.Data.Status)
+	})
+
+	t.Run("gives_up_after_max_attempts", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			return len(deliveries("/webhooks/"+strconv.Itoa(created.ID)+"/deliveries?status=failed")) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		d := deliveries("/webhooks/" + strconv.Itoa(created.ID) + "/deliveries")[0]
+		assert.Equal(t, 3, d.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, d.ResponseStatus)
+		assert.Contains(t, d.Error, "500")
+		assert.Len(t, down.received(), 3)
+	})
+
+	t.Run("invalid_requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/webhooks",
+			`{"url":"ftp://example.com","events":["todo.created"],"secret":"0123456789abcdef"}`, nil))
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/webhooks",
+			`{"url":"http://example.com","events":["todo.archived"],"secret":"0123456789abcdef"}`, nil))
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/webhooks",
+			`{"url":"http://example.com","events":["todo.created"],"secret":"short"}`, nil))
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/webhooks/99999/deliveries", "", nil))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var webhooks []*model.Webhook
+		require.Equal(t, http.StatusOK, send(http.MethodGet, "/webhooks", "", &webhooks))
+		assert.Len(t, webhooks, 2)
+
+		assert.Equal(t, http.StatusNoContent, send(http.MethodDelete, "/webhooks/"+strconv.Itoa(created.ID), "", nil))
+		assert.Equal(t, http.StatusNotFound, send(http.MethodGet, "/webhooks/"+strconv.Itoa(created.ID), "", nil))
+		assert.Equal(t, http.StatusNotFound, send(http.MethodDelete, "/webhooks/"+strconv.Itoa(created.ID), "", nil))
+	})
+}
@@ -0,0 +1,16 @@
+package i18n
+
+// english has no error details: the details of the application errors are
+// written in English and carry more context than a catalog message.
+var english = Catalog{
+	"validation.required": "is required",
+	"validation.excluded": "must not be set",
+	"validation.oneof":    "must be one of: {param}",
+	"validation.min":      "must be at least {param}",
+	"validation.max":      "must be at most {param}",
+	"validation.gt":       "must be greater than {param}",
+	"validation.lt":       "must be less than {param}",
+	"validation.len":      "must have length {param}",
+	"validation.type":     "has an invalid value",
+	"validation.default":  "failed the {rule} rule",
+}
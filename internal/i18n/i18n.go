@@ -0,0 +1,68 @@
+// Package i18n provides the message catalogs the API answers in and picks one
+// from the Accept-Language header.
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// HeaderAcceptLanguage is the request header clients state their languages in.
+const HeaderAcceptLanguage = "Accept-Language"
+
+// Catalog maps message keys to messages. Messages refer to their arguments as {name}.
+type Catalog map[string]string
+
+// supported lists the languages with a catalog. The first one is the default.
+var supported = []language.Tag{language.English, language.Japanese}
+
+var catalogs = map[language.Tag]Catalog{
+	language.English:  english,
+	language.Japanese: japanese,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// Localizer looks messages up in the language chosen for a request, falling
+// back to the default language.
+type Localizer struct {
+	lang  language.Tag
+	chain []Catalog
+}
+
+// New returns a localizer for the value of an Accept-Language header. An
+// empty or invalid value, or one naming no supported language, selects the
+// default language.
+func New(acceptLanguage string) *Localizer {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, index, _ := matcher.Match(tags...)
+
+	lang := supported[index]
+	chain := []Catalog{catalogs[lang]}
+	if lang != supported[0] {
+		chain = append(chain, catalogs[supported[0]])
+	}
+	return &Localizer{lang: lang, chain: chain}
+}
+
+// Language returns the BCP 47 tag of the chosen language.
+func (l *Localizer) Language() string {
+	return l.lang.String()
+}
+
+// Message returns the message for key with args filled in. It reports false
+// when no catalog in the fallback chain has the key.
+func (l *Localizer) Message(key string, args map[string]string) (string, bool) {
+	for _, catalog := range l.chain {
+		msg, ok := catalog[key]
+		if !ok {
+			continue
+		}
+		for name, value := range args {
+			msg = strings.ReplaceAll(msg, "{"+name+"}", value)
+		}
+		return msg, true
+	}
+	return "", false
+}
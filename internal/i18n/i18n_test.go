@@ -0,0 +1,62 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "ja", want: "ja"},
+		{acceptLanguage: "ja-JP,ja;q=0.9,en;q=0.8", want: "ja"},
+		{acceptLanguage: "en-US,en;q=0.9,ja;q=0.8", want: "en"},
+		{acceptLanguage: "en;q=0.3, ja;q=0.9", want: "ja"},
+		{acceptLanguage: "fr-FR, ja;q=0.5", want: "ja"},
+		{acceptLanguage: "fr-FR", want: "en"},
+		{acceptLanguage: "*", want: "en"},
+		{acceptLanguage: ";;;", want: "en"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, New(tt.acceptLanguage).Language(), tt.acceptLanguage)
+	}
+}
+
+func TestLocalizer_Message(t *testing.T) {
+	ja := New("ja")
+	msg, ok := ja.Message("validation.oneof", map[string]string{"param": "low, high"})
+	assert.True(t, ok)
+	assert.Equal(t, "low, high のいずれかを指定してください", msg)
+
+	msg, ok = New("en").Message("validation.max", map[string]string{"param": "500"})
+	assert.True(t, ok)
+	assert.Equal(t, "must be at most 500", msg)
+
+	// English has no error details, so the caller keeps its own
+	_, ok = New("en").Message("error.NOT_FOUND", nil)
+	assert.False(t, ok)
+	msg, ok = ja.Message("error.NOT_FOUND", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "指定されたリソースが見つかりません。", msg)
+
+	// Keys missing from Japanese fall back to English
+	english["test.fallback"] = "fallback"
+	defer delete(english, "test.fallback")
+	msg, ok = ja.Message("test.fallback", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "fallback", msg)
+}
+
+func TestCatalogs(t *testing.T) {
+	// Every message of the default language is translated.
+	for _, lang := range supported[1:] {
+		for key := range english {
+			assert.Contains(t, catalogs[lang], key, "%s: %s", lang, key)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package i18n
+
+var japanese = Catalog{
+	"validation.required": "必須です",
+	"validation.excluded": "指定できません",
+	"validation.oneof":    "{param} のいずれかを指定してください",
+	"validation.min":      "{param} 以上で指定してください",
+	"validation.max":      "{param} 以下で指定してください",
+	"validation.gt":       "{param} より大きい値を指定してください",
+	"validation.lt":       "{param} より小さい値を指定してください",
+	"validation.len":      "長さ {param} で指定してください",
+	"validation.type":     "値が不正です",
+	"validation.default":  "{rule} ルールを満たしていません",
+
+	"error.INTERNAL_SERVER_ERROR":       "予期しないエラーが発生しました。",
+	"error.NOT_FOUND":                   "指定されたリソースが見つかりません。",
+	"error.CONFLICT":                    "リソースの現在の状態と競合しています。",
+	"error.PAYLOAD_TOO_LARGE":           "リクエストが大きすぎます。",
+	"error.UNPROCESSABLE_ENTITY":        "リクエストを処理できません。",
+	"error.UNSUPPORTED_MEDIA_TYPE":      "サポートされていないメディアタイプです。",
+	"error.METHOD_NOT_ALLOWED":          "このメソッドは許可されていません。",
+	"error.VALIDATION_FAILED":           "リクエストに不正な項目があります。",
+	"error.EMPTY_TASK":                  "解析後にタスク名が残りませんでした。",
+	"error.INVALID_MOVE":                "指定された位置には移動できません。",
+	"error.WIP_LIMIT_REACHED":           "ステータス列が WIP 上限に達しています。",
+	"error.ATTACHMENT_TOO_LARGE":        "添付ファイルがサイズ上限を超えています。",
+	"error.ATTACHMENT_TYPE_NOT_ALLOWED": "この種類のファイルは添付できません。",
+	"error.MISSING_USER":                "X-User ヘッダーが必要です。",
+	"error.TIMER_RUNNING":               "タイマーはすでに動いています。",
+	"error.NO_RUNNING_TIMER":            "動いているタイマーはありません。",
+	"error.INVALID_TIME_ENTRY":          "作業記録の終了時刻は開始時刻より後にしてください。",
+	"error.INVALID_TIME_RANGE":          "期間の指定が不正です。",
+	"error.INVALID_IMPORT":              "インポートするファイルを読み込めません。",
+	"error.INVALID_IDEMPOTENCY_KEY":     "Idempotency-Key ヘッダーは 255 文字以内で指定してください。",
+	"error.IDEMPOTENCY_KEY_REUSED":      "この Idempotency-Key は別のリクエストで使用済みです。",
+	"error.IDEMPOTENCY_KEY_IN_PROGRESS": "この Idempotency-Key のリクエストはまだ処理中です。",
+}
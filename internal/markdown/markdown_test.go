@@ -0,0 +1,69 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "emphasis",
+			source: "Pay the **invoice**",
+			want:   "<p>Pay the <strong>invoice</strong></p>\n",
+		},
+		{
+			name:   "auto_link",
+			source: "See https://example.com/docs",
+			want:   `<p>See <a href="https://example.com/docs" rel="nofollow noopener" target="_blank">https://example.com/docs</a></p>` + "\n",
+		},
+		{
+			name:   "script_is_removed",
+			source: "hello <script>alert(1)</script>",
+			want:   "<p>hello alert(1)</p>\n",
+		},
+		{
+			name:   "javascript_link_is_removed",
+			source: "[click](javascript:alert(1))",
+			want:   "<p>click</p>\n",
+		},
+		{
+			name:   "task_list",
+			source: "- [x] done",
+			want:   "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n</ul>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderHTML(tt.source)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "empty", source: "", want: ""},
+		{name: "inline_markup", source: "Pay the **invoice** by _Friday_", want: "Pay the invoice by Friday"},
+		{name: "link", source: "See [the docs](https://example.com) and https://example.org", want: "See the docs and https://example.org"},
+		{name: "blocks", source: "# Title\n\n- one\n- two\n\n```\ncode\n```", want: "Title\none\ntwo\ncode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PlainText(tt.source))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrAttachmentTooLarge is the error for an upload exceeding Attachments.MaxSize.
+var ErrAttachmentTooLarge = fmt.Errorf("attachment too large")
+
+// ErrAttachmentTypeNotAllowed is the error for an upload whose MIME type is not allowed.
+var ErrAttachmentTypeNotAllowed = fmt.Errorf("attachment type not allowed")
+
+// Attachment is the model for a file attached to a todo.
+// The content itself lives in the blob store and is addressed by Digest.
+type Attachment struct {
+	ID          int `gorm:"primaryKey"`
+	TodoID      int `gorm:"index"`
+	Filename    string
+	ContentType string
+	Size        int64
+	Digest      string    `gorm:"index"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+// AttachmentRequestPath is the request parameter for the attachments of a todo
+type AttachmentRequestPath struct {
+	TodoID int `param:"id" validate:"required"`
+}
+
+// FindAttachmentRequest is the request parameter for finding an attachment
+type FindAttachmentRequest struct {
+	TodoID       int `param:"id" validate:"required"`
+	AttachmentID int `param:"attachmentId" validate:"required"`
+}
+
+// DeleteAttachmentRequest is the request parameter for deleting an attachment
+type DeleteAttachmentRequest struct {
+	TodoID       int `param:"id" validate:"required"`
+	AttachmentID int `param:"attachmentId" validate:"required"`
+}
+
+// UploadAttachmentRequest is the request parameter for uploading an attachment
+type UploadAttachmentRequest struct {
+	TodoID   int
+	Filename string
+	Size     int64
+}
+
+// GarbageCollectRequest is the request parameter for collecting orphaned blobs
+type GarbageCollectRequest struct {
+	// DryRun reports what would be removed without removing anything.
+	DryRun bool
+	// GracePeriod protects blobs written recently, which may belong to an
+	// upload whose attachment row is not committed yet.
+	GracePeriod time.Duration
+}
+
+// GarbageCollectResult is the result of collecting orphaned blobs
+type GarbageCollectResult struct {
+	OrphanAttachments int64
+	Blobs             int
+	Bytes             int64
+}
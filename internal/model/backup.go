@@ -0,0 +1,26 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidBackup is returned when restoring a file that is not a backup of the todo database.
+var ErrInvalidBackup = errors.New("not a backup of the todo database")
+
+// BackupFile is a backup of the SQLite database.
+type BackupFile struct {
+	Path      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// RestoreResult is the result of restoring a backup.
+type RestoreResult struct {
+	// Version is the schema version of the backup.
+	Version int
+	// Migrations is the number of migrations applied to bring the backup up to date.
+	Migrations int
+	// Previous is where the replaced database was moved. It is empty if there was none.
+	Previous string
+}
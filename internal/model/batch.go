@@ -0,0 +1,56 @@
+package model
+
+// Batch modes
+const (
+	// BatchAtomic applies all operations or none of them.
+	BatchAtomic = "atomic"
+	// BatchPartial applies every operation that succeeds and reports each result.
+	BatchPartial = "partial"
+)
+
+// Batch operations
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchRequest is the request body for applying many operations at once
+type BatchRequest struct {
+	// Mode is atomic (default) or partial.
+	Mode       string           `json:"mode" validate:"omitempty,oneof=atomic partial"`
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+// BatchOperation is a single create, update or delete in a batch
+type BatchOperation struct {
+	Op string `json:"op" validate:"required,oneof=create update delete"`
+	// ID is the todo to update or delete.
+	ID int `json:"id,omitempty" validate:"required_unless=Op create,excluded_if=Op create"`
+	// Create is the todo to create when Op is create.
+	Create *CreateRequest `json:"create,omitempty" validate:"required_if=Op create,excluded_unless=Op create"`
+	// Update is the fields to update when Op is update.
+	Update *UpdateRequestBody `json:"update,omitempty" validate:"required_if=Op update,excluded_unless=Op update"`
+}
+
+// BatchResult is the outcome of one operation, in request order
+type BatchResult struct {
+	Op string
+	ID int `json:",omitempty"`
+	// Status is the HTTP status the operation would have had on its own.
+	Status int
+	Todo   *Todo `json:",omitempty"`
+	// Code is the error code of a failed operation.
+	Code  string `json:",omitempty"`
+	Error string `json:",omitempty"`
+	// Err is the error of a failed operation; the handler turns it into Status, Code and Error.
+	Err error `json:"-"`
+}
+
+// BatchResponse is the outcome of a batch
+type BatchResponse struct {
+	Mode      string
+	Succeeded int
+	Failed    int
+	Results   []*BatchResult
+}
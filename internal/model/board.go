@@ -0,0 +1,30 @@
+package model
+
+import "fmt"
+
+// ErrWIPLimitReached is the error for moving a todo into a column that has reached its WIP limit.
+var ErrWIPLimitReached = fmt.Errorf("WIP limit reached")
+
+// BoardRequest is the request parameter for the board
+type BoardRequest struct {
+	Project string `query:"project"`
+}
+
+// BoardColumn is the todos of one status, in manual order
+type BoardColumn struct {
+	Status Status
+	Count  int
+	// WIPLimit is the configured limit of the column, or 0 when unlimited.
+	WIPLimit int `json:",omitempty"`
+	// WIPCount is the number of todos in the column across every project,
+	// which the WIP limit applies to. It is only set for a column with a limit.
+	WIPCount int `json:",omitempty"`
+	// OverLimit is true when WIPCount is over the WIP limit.
+	OverLimit bool
+	Todos     []*Todo
+}
+
+// Board is the todos grouped into status columns in workflow order
+type Board struct {
+	Columns []*BoardColumn
+}
@@ -0,0 +1,53 @@
+package model
+
+import "errors"
+
+var (
+	// ErrPreconditionFailed is the error for a CalDAV request whose If-Match
+	// or If-None-Match does not hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrUIDConflict is the error for a todo put with the UID of another resource.
+	ErrUIDConflict = errors.New("UID used by another resource")
+)
+
+// CalDAVObject maps a todo to the calendar object resource a CalDAV client
+// created it as. The todos without one are "todo-<id>.ics", with the UID of
+// their iCalendar export.
+type CalDAVObject struct {
+	TodoID int    `gorm:"primaryKey;autoIncrement:false"`
+	Name   string `gorm:"size:255;uniqueIndex"`
+	UID    string `gorm:"column:uid;size:255;uniqueIndex"`
+}
+
+// TableName keeps gorm from naming the table cal_dav_objects.
+func (CalDAVObject) TableName() string {
+	return "caldav_objects"
+}
+
+// CalDAVResource is a todo as a calendar object resource.
+type CalDAVResource struct {
+	// Name is the last segment of the path of the resource.
+	Name string
+	UID  string
+	// ETag is the strong entity tag of Data, quoted.
+	ETag string
+	// Data is the iCalendar calendar of the todo.
+	Data []byte
+	Todo *Todo
+}
+
+// PutCalDAVRequest is the request parameter for writing a calendar object resource
+type PutCalDAVRequest struct {
+	Name string
+	Data []byte
+	// IfMatch is the If-Match header: "*" or entity tags.
+	IfMatch string
+	// IfNoneMatch is the If-None-Match header: only "*" is supported.
+	IfNoneMatch string
+}
+
+// DeleteCalDAVRequest is the request parameter for deleting a calendar object resource
+type DeleteCalDAVRequest struct {
+	Name    string
+	IfMatch string
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// CalendarFeed is an iCalendar subscription to the todos of a user, a project
+// or both, for calendar apps.
+type CalendarFeed struct {
+	ID   int `gorm:"primaryKey"`
+	Name string
+	// Assignee and Project select the todos of the feed. An empty one selects them all.
+	Assignee string
+	Project  string
+	// Token is the secret in the URL of the feed: whoever knows it can read the
+	// feed. It is only returned once, by CreateCalendarFeedResponse.
+	Token     string    `gorm:"size:64;uniqueIndex" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// CreateCalendarFeedRequest is the request parameter for creating a calendar feed
+type CreateCalendarFeedRequest struct {
+	// Name is the name of the calendar in the calendar apps.
+	Name     string `json:"name" validate:"max=100"`
+	Assignee string `json:"assignee" validate:"required_without=Project"`
+	Project  string `json:"project" validate:"required_without=Assignee"`
+}
+
+// CreateCalendarFeedResponse is the created calendar feed along with its token
+type CreateCalendarFeedResponse struct {
+	CalendarFeed
+	Token string
+}
+
+// DeleteCalendarFeedRequest is the request parameter for deleting a calendar feed
+type DeleteCalendarFeedRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+// CalendarFeedRequest is the request parameter for reading a calendar feed
+type CalendarFeedRequest struct {
+	// Token is the token of the feed, optionally followed by ".ics".
+	Token string `param:"token" validate:"required"`
+}
@@ -1,15 +1,31 @@
 // Package model provides the data models for the application.
 package model
 
-import "github.com/zuu-development/fullstack-examination-2024/internal/cache"
+import (
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
+)
 
 // Config is the configuration for the application.
 type Config struct {
 	UI            UI
 	APIServer     Server
 	SwaggerServer Server
+	Database      Database
 	SQLite        SQLite
 	Redis         *cache.Config
+	Attachments   Attachments
+	QuickAdd      QuickAdd
+	Board         BoardConfig
+	Idempotency   Idempotency
+	Events        Events
+	Realtime      Realtime
+	Webhooks      Webhooks
+	Outbox        Outbox
+	TodoStore     TodoStore
+	Backup        Backup
+	Client        Client
 }
 
 // UI is the configuration for the UI.
@@ -23,7 +39,174 @@ type Server struct {
 	Port   int
 }
 
-// SQLite is the configuration for the SQLite database.
+// SQLite is the configuration for the SQLite database. Empty settings keep
+// the defaults of SQLite.
 type SQLite struct {
-	DBFilename string `validate:"required"`
+	// DBFilename is the database file. It is required when Database.Driver is sqlite.
+	DBFilename string
+	// JournalMode is the journal_mode pragma. With "wal", reads go on while a
+	// write is in progress.
+	JournalMode string `validate:"omitempty,oneof=delete truncate persist memory wal off"`
+	// Synchronous is the synchronous pragma. With "normal" in WAL mode, a
+	// power loss may undo the latest transactions but never corrupts the database.
+	Synchronous string `validate:"omitempty,oneof=off normal full extra"`
+	// BusyTimeout is how long a connection waits for a lock held by another
+	// process before failing with "database is locked".
+	BusyTimeout time.Duration `validate:"gte=0"`
+	// ForeignKeys enforces foreign key constraints.
+	ForeignKeys bool
+	// MaxReadConns is the number of connections for reads. Writes go through
+	// a single connection, since SQLite has one writer at a time. Zero sends
+	// reads to the write connection too.
+	MaxReadConns int `validate:"gte=0"`
+}
+
+const (
+	// DriverSQLite is the database driver for SQLite.
+	DriverSQLite = "sqlite"
+	// DriverPostgres is the database driver for PostgreSQL.
+	DriverPostgres = "postgres"
+	// DriverMySQL is the database driver for MySQL 8.
+	DriverMySQL = "mysql"
+)
+
+// Database is the configuration for the database connection.
+type Database struct {
+	// Driver is "sqlite", "postgres" or "mysql".
+	Driver string `validate:"oneof=sqlite postgres mysql"`
+	// DSN is the data source name for postgres and mysql, such as
+	// "host=localhost user=todo dbname=todo" or "todo:secret@tcp(localhost:3306)/todo".
+	// SQLite uses SQLite.DBFilename instead.
+	DSN string `validate:"required_unless=Driver sqlite"`
+	// LogLevel is the level SQL statements are logged at: "silent", "error",
+	// "warn" for errors and slow statements, or "info" for every statement.
+	LogLevel string `validate:"omitempty,oneof=silent error warn info"`
+	// SlowThreshold is how long a statement runs before it is logged as slow.
+	SlowThreshold time.Duration `validate:"gte=0"`
+}
+
+// Attachments is the configuration for todo attachments.
+type Attachments struct {
+	// Dir is the root directory of the local blob store.
+	Dir string `validate:"required"`
+	// MaxSize is the maximum size of a single attachment in bytes.
+	MaxSize int64 `validate:"gt=0"`
+	// AllowedTypes is the list of accepted MIME types. Empty allows any type.
+	AllowedTypes []string
+}
+
+// QuickAdd is the configuration for parsing quick-add text.
+type QuickAdd struct {
+	// Timezone is the IANA timezone relative dates are resolved in. Defaults to UTC.
+	Timezone string `validate:"omitempty,timezone"`
+}
+
+// BoardConfig is the configuration for the Kanban board.
+type BoardConfig struct {
+	// WIPLimits is the maximum number of todos per status column. A missing or zero limit is unlimited.
+	WIPLimits map[string]int `validate:"dive,keys,oneof=created processing done,endkeys,gte=0"`
+	// EnforceWIPLimits rejects moving a todo into a column that has reached its limit.
+	// Otherwise the column is only flagged as over its limit.
+	EnforceWIPLimits bool
+}
+
+// Idempotency is the configuration for Idempotency-Key handling.
+type Idempotency struct {
+	// TTL is how long a key and its stored response are kept.
+	TTL time.Duration `validate:"gt=0"`
+	// Lease is how long a request in progress holds its key. A retry after the
+	// lease takes the key over, in case the request died without a response.
+	Lease time.Duration `validate:"gt=0"`
+}
+
+// Events is the configuration for the event stream.
+type Events struct {
+	// Broker delivers events to the streams: "memory" within one process, or
+	// "redis" to reach the streams of every replica.
+	Broker string `validate:"oneof=memory redis"`
+	// Channel is the Redis channel events are published on.
+	Channel string
+	// HeartbeatInterval is how often an idle stream gets a comment to keep it open.
+	HeartbeatInterval time.Duration `validate:"gt=0"`
+	// Retention is how long events are kept for resuming streams. Zero keeps them forever.
+	Retention time.Duration `validate:"gte=0"`
+}
+
+// Realtime is the configuration for the WebSocket API.
+type Realtime struct {
+	// SendBuffer is the number of messages queued for a connection. A client
+	// that falls further behind is disconnected.
+	SendBuffer int `validate:"gt=0"`
+	// PingInterval is how often the server pings a connection. A connection
+	// that does not answer within two intervals is closed.
+	PingInterval time.Duration `validate:"gt=0"`
+	// MaxMessageSize is the maximum size of a client message in bytes.
+	MaxMessageSize int64 `validate:"gt=0"`
+}
+
+// Webhooks is the configuration for delivering webhooks.
+type Webhooks struct {
+	// PollInterval is how often the queue is checked for due deliveries.
+	PollInterval time.Duration `validate:"gt=0"`
+	// Timeout is how long a receiver may take to answer.
+	Timeout time.Duration `validate:"gt=0"`
+	// MaxAttempts is the number of attempts before a delivery fails.
+	MaxAttempts int `validate:"gt=0"`
+	// RetryDelay is the wait after the first failed attempt. It doubles with
+	// every further attempt, up to MaxRetryDelay.
+	RetryDelay    time.Duration `validate:"gt=0"`
+	MaxRetryDelay time.Duration `validate:"gtefield=RetryDelay"`
+}
+
+// Outbox is the configuration for dispatching the events of the outbox.
+type Outbox struct {
+	// PollInterval is how often the outbox is checked for events committed by
+	// other processes or due for a retry.
+	PollInterval time.Duration `validate:"gt=0"`
+	// BatchSize is the number of events dispatched at a time.
+	BatchSize int `validate:"gt=0"`
+	// RetryDelay is the wait after the first failed dispatch of an event. It
+	// doubles with every further failure, up to MaxRetryDelay.
+	RetryDelay    time.Duration `validate:"gt=0"`
+	MaxRetryDelay time.Duration `validate:"gtefield=RetryDelay"`
+}
+
+const (
+	// StoreCRUD is the todo store mode keeping only the current todos.
+	StoreCRUD = "crud"
+	// StoreEventSourced is the todo store mode keeping every change as an
+	// event, with the todos table as a projection of them.
+	StoreEventSourced = "eventsourced"
+)
+
+// TodoStore is the configuration for storing todos.
+type TodoStore struct {
+	// Mode is "crud" or "eventsourced". Todos created in crud mode need a
+	// "todo-cli replay --baseline" after switching to eventsourced.
+	Mode string `validate:"oneof=crud eventsourced"`
+	// SnapshotInterval is how many events apart the projection is snapshotted
+	// in eventsourced mode. Zero disables snapshots.
+	SnapshotInterval int `validate:"gte=0"`
+}
+
+// Backup is the configuration for backing up the SQLite database.
+type Backup struct {
+	// Dir is where the backups are written.
+	Dir string `validate:"required"`
+	// Gzip compresses the backups.
+	Gzip bool
+	// Keep is the number of latest backups kept. Zero keeps any number.
+	Keep int `validate:"gte=0"`
+	// MaxAge is how long backups are kept. Zero keeps them forever. The latest
+	// backup is always kept.
+	MaxAge time.Duration `validate:"gte=0"`
+}
+
+// Client is the configuration for the todo commands, which use the API of a
+// running server.
+type Client struct {
+	// URL is the address of the server, without the /api/v1 prefix.
+	URL string
+	// Token is sent as a bearer token, for servers behind a proxy that requires one.
+	Token string
 }
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// EventTodoCreated is the type of the event for a created todo.
+	EventTodoCreated = "todo.created"
+	// EventTodoUpdated is the type of the event for an updated or moved todo.
+	EventTodoUpdated = "todo.updated"
+	// EventTodoDeleted is the type of the event for a deleted todo.
+	EventTodoDeleted = "todo.deleted"
+	// EventTodoCompleted is the type of the event for a todo that moved to done.
+	// It follows the todo.updated event of the same change.
+	EventTodoCompleted = "todo.completed"
+)
+
+// Event is a change to a todo. Events are stored in order so that a client
+// can resume a stream from the last event it received.
+//
+// The events table is also the transactional outbox: an event is written in
+// the transaction of its change and dispatched to the sinks once committed.
+// The ID deduplicates an event that is dispatched more than once.
+type Event struct {
+	ID     uint `gorm:"primaryKey"`
+	Type   string
+	TodoID int `gorm:"index"`
+	// Project and Status are those of the todo after the change, or before it for a delete.
+	Project string
+	Status  Status
+	// Data is the JSON of the todo after the change, or before it for a delete.
+	Data      string
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+
+	// DispatchedAt is when every sink handled the event. It is nil while the event is pending.
+	DispatchedAt *time.Time `gorm:"index" json:"-"`
+	// NextAttemptAt is when a pending event is due. A dispatcher handling it
+	// moves it ahead, so another one only takes it over if the first one died.
+	NextAttemptAt time.Time `gorm:"index" json:"-"`
+	// Attempts is the number of failed dispatches.
+	Attempts int `json:"-"`
+	// Error is why the last dispatch failed.
+	Error string `json:"-"`
+	// HandledBy are the names of the sinks that handled the pending event, so
+	// that a redispatch only hands it to those that failed.
+	HandledBy []string `gorm:"-" json:"-"`
+}
+
+// EventSink records a sink that handled a pending event. The records of an
+// event are deleted once every sink handled it.
+type EventSink struct {
+	EventID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Sink    string `gorm:"primaryKey;size:64"`
+}
+
+// Handled reports whether the sink named name handled the event.
+func (e *Event) Handled(name string) bool {
+	for _, handled := range e.HandledBy {
+		if handled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEvent returns a pending event of eventType for todo.
+func NewEvent(eventType string, todo *Todo) (*Event, error) {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &Event{
+		Type:          eventType,
+		TodoID:        todo.ID,
+		Project:       todo.Project,
+		Status:        todo.Status,
+		Data:          string(data),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// EventsRequest is the request parameter for streaming events
+type EventsRequest struct {
+	Project string `query:"project" json:"project"`
+	Status  string `query:"status" json:"status" validate:"omitempty,oneof=created processing done"`
+	// LastEventID is the ID of the last event the client received. Zero streams new events only.
+	LastEventID uint `query:"-" json:"lastEventId"`
+}
+
+// Matches reports whether event passes the filters of the request.
+func (r *EventsRequest) Matches(event *Event) bool {
+	if r.Project != "" && event.Project != r.Project {
+		return false
+	}
+	if r.Status != "" && string(event.Status) != r.Status {
+		return false
+	}
+	return true
+}
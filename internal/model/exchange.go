@@ -0,0 +1,91 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidImport is the error for an import file that cannot be read at
+// all, as opposed to the invalid lines reported in ImportResponse.
+var ErrInvalidImport = errors.New("invalid import")
+
+// The formats of exported and imported todos.
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatTodoTxt  = "todotxt"
+	FormatMarkdown = "markdown"
+	// FormatICal is iCalendar (RFC 5545), a VTODO per todo.
+	FormatICal = "ical"
+)
+
+// The conflict modes of an import.
+const (
+	// ConflictSkip leaves the existing todo alone.
+	ConflictSkip = "skip"
+	// ConflictUpdate overwrites the existing todo with the imported one.
+	ConflictUpdate = "update"
+)
+
+// The actions taken for an imported line.
+const (
+	ImportCreate  = "create"
+	ImportUpdate  = "update"
+	ImportSkip    = "skip"
+	ImportInvalid = "invalid"
+)
+
+// ExportRequest is the request parameter for exporting todos.
+type ExportRequest struct {
+	Format  string `query:"format" validate:"omitempty,oneof=json csv todotxt markdown ical"`
+	Status  string `query:"status" validate:"omitempty,oneof=created processing done"`
+	Project string `query:"project"`
+}
+
+// ImportRequest is the request parameter for importing todos. The file is the request body.
+type ImportRequest struct {
+	Format string `query:"format" validate:"omitempty,oneof=json csv todotxt ical"`
+	// DryRun reports what the import would do without changing anything.
+	DryRun bool `query:"dryRun"`
+	// OnConflict is what happens to a todo that exists already, ConflictSkip by default.
+	OnConflict string `query:"onConflict" validate:"omitempty,oneof=skip update"`
+}
+
+// ImportRecord is a todo read from a line of an import file.
+type ImportRecord struct {
+	// Line is the line of the file the todo starts on.
+	Line int
+	// ID is the ID of the exported todo, if the format has one. It finds the
+	// todo to update: new todos get an ID of their own.
+	ID     int
+	Todo   CreateRequest
+	Status Status
+	// CreatedAt is kept when set.
+	CreatedAt *time.Time
+	// Err is why the line could not be read.
+	Err error
+}
+
+// ImportLine is the outcome of an imported line.
+type ImportLine struct {
+	Line int    `json:"line"`
+	Task string `json:"task,omitempty"`
+	// Action is one of ImportCreate, ImportUpdate, ImportSkip and ImportInvalid.
+	Action string `json:"action"`
+	// ID is the created or updated todo, or the existing one that conflicts.
+	ID    int    `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResponse is the report of an import.
+type ImportResponse struct {
+	DryRun bool `json:"dryRun"`
+	// Applied tells whether the todos were written. An import with invalid
+	// lines writes none of them.
+	Applied bool          `json:"applied"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Invalid int           `json:"invalid"`
+	Lines   []*ImportLine `json:"lines"`
+}
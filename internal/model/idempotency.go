@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key.
+// A record without a StatusCode belongs to a request that is still being processed.
+type IdempotencyRecord struct {
+	// Key is the hash of the caller, the method, the path and the Idempotency-Key,
+	// so that a key only replays the responses of the same caller and endpoint.
+	Key string `gorm:"column:idempotency_key;primaryKey;size:255"`
+	// Fingerprint is the hash of the method, path and body the key was first used with.
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	// ExpiresAt is the end of the lease while the request is processed, then of
+	// the TTL once its response is stored.
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// Completed reports whether the response of the request has been stored.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
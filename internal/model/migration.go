@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMigrationChecksum is returned when an applied migration was edited afterwards.
+	ErrMigrationChecksum = errors.New("migration changed after it was applied")
+	// ErrUnknownMigration is returned when the database has a migration applied
+	// that is not among the migrations of this build.
+	ErrUnknownMigration = errors.New("applied migration not found")
+)
+
+const (
+	// MigrationApplied is the state of a migration applied as it is.
+	MigrationApplied = "applied"
+	// MigrationPending is the state of a migration not applied yet.
+	MigrationPending = "pending"
+	// MigrationModified is the state of a migration edited after it was applied.
+	MigrationModified = "modified"
+	// MigrationUnknown is the state of an applied migration missing from this build.
+	MigrationUnknown = "unknown"
+)
+
+// SchemaMigration is a migration applied to the database.
+type SchemaMigration struct {
+	Version int `gorm:"primaryKey;autoIncrement:false"`
+	Name    string
+	// Checksum is the SHA-256 of the up SQL, in hex.
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationStatus is the state of a migration in the database.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	State     string
+	AppliedAt *time.Time
+}
@@ -0,0 +1,15 @@
+package model
+
+// Presence is who is viewing a todo.
+type Presence struct {
+	TodoID int `json:"todoId"`
+	// Users are the names of the viewers, sorted. A user viewing the todo in
+	// several tabs is listed once.
+	Users []string `json:"users"`
+}
+
+// ViewRequest is the request parameter for telling which todo a user is viewing
+type ViewRequest struct {
+	// TodoID is the todo being viewed. Zero stops viewing.
+	TodoID int `json:"todoId" validate:"gte=0"`
+}
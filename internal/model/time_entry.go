@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrTimerRunning is the error for starting a timer on a todo that already has the user's timer running.
+var ErrTimerRunning = fmt.Errorf("timer already running")
+
+// ErrNoRunningTimer is the error for stopping a timer when none is running.
+var ErrNoRunningTimer = fmt.Errorf("no running timer")
+
+// ErrInvalidTimeRange is the error for a report range that cannot be parsed.
+var ErrInvalidTimeRange = fmt.Errorf("invalid time range")
+
+// ErrInvalidTimeEntry is the error for a manual time entry whose end is not after its start.
+var ErrInvalidTimeEntry = fmt.Errorf("time entry must end after it starts")
+
+// TimeEntry is the model for time spent on a todo.
+// A running timer is an entry without EndedAt; each user has at most one.
+type TimeEntry struct {
+	ID        int    `gorm:"primaryKey"`
+	TodoID    int    `gorm:"index"`
+	User      string `gorm:"column:user_name;index"`
+	StartedAt time.Time
+	EndedAt   *time.Time
+	Note      string `json:",omitempty"`
+	// Manual is true for entries recorded after the fact rather than with a timer.
+	Manual    bool
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// Duration returns the tracked time, counting a running timer up to now.
+func (e *TimeEntry) Duration(now time.Time) time.Duration {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	if end.Before(e.StartedAt) {
+		return 0
+	}
+	return end.Sub(e.StartedAt)
+}
+
+// TimerRequest is the request parameter for starting or stopping a timer
+type TimerRequest struct {
+	TodoID int `param:"id" validate:"required"`
+	User   string
+}
+
+// CurrentTimerRequest is the request parameter for finding the user's running timer
+type CurrentTimerRequest struct {
+	User string
+}
+
+// CreateTimeEntryRequest is the request parameter for recording time manually
+type CreateTimeEntryRequest struct {
+	TodoID    int        `param:"id" json:"-" validate:"required"`
+	StartedAt time.Time  `json:"startedAt" validate:"required"`
+	EndedAt   *time.Time `json:"endedAt"`
+	// DurationMinutes is used when EndedAt is not given.
+	DurationMinutes int    `json:"durationMinutes" validate:"required_without=EndedAt,omitempty,gt=0"`
+	Note            string `json:"note"`
+	User            string `json:"-"`
+}
+
+// FindAllTimeEntriesRequest is the request parameter for listing the time entries of a todo
+type FindAllTimeEntriesRequest struct {
+	TodoID int `param:"id" validate:"required"`
+}
+
+// DeleteTimeEntryRequest is the request parameter for deleting a time entry
+type DeleteTimeEntryRequest struct {
+	TodoID  int `param:"id" validate:"required"`
+	EntryID int `param:"entryId" validate:"required"`
+}
+
+// TimeReportRequest is the request parameter for the time report
+type TimeReportRequest struct {
+	// From and To bound the time counted, as RFC 3339 or YYYY-MM-DD. The
+	// entries that overlap a bound count their part within the range.
+	From    string `query:"from"`
+	To      string `query:"to"`
+	GroupBy string `query:"groupBy" validate:"omitempty,oneof=todo project user day week month"`
+	User    string `query:"user"`
+	Project string `query:"project"`
+}
+
+// TimeReportGroup is the tracked time for one todo, project, user or period
+type TimeReportGroup struct {
+	Key string
+	// Label is the task of the todo when grouping by todo.
+	Label           string `json:",omitempty"`
+	Seconds         int64
+	Entries         int
+	EstimateMinutes int `json:",omitempty"`
+}
+
+// TimeReport is the result of the time report
+type TimeReport struct {
+	From         *time.Time `json:",omitempty"`
+	To           *time.Time `json:",omitempty"`
+	GroupBy      string
+	TotalSeconds int64
+	Groups       []*TimeReportGroup
+}
@@ -1,26 +1,64 @@
 package model
 
 import (
-	"errors"
 	"fmt"
+	"sort"
 	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/markdown"
 )
 
+// ErrInvalidMove is the error for moving a todo relative to itself or into a status other than its target's.
+var ErrInvalidMove = fmt.Errorf("invalid move")
+
+// ErrInvalidTodo is the error for a todo replaced with invalid fields.
+var ErrInvalidTodo = fmt.Errorf("invalid todo")
+
+// ErrEmptyTask is the error for a todo without a task.
+var ErrEmptyTask = fmt.Errorf("task cannot be empty")
+
+// ErrInvalidPriority is the error for a priority other than low, medium or high.
+var ErrInvalidPriority = fmt.Errorf("invalid priority")
+
 // Todo is the model for the todo endpoint.
 type Todo struct {
-	ID        int `gorm:"primaryKey"`
-	Task      string
-	Status    Status
-	Priority  TodoPriority
+	ID   int `gorm:"primaryKey"`
+	Task string
+	// Description is the long-form description in Markdown.
+	Description string `json:",omitempty"`
+	// DescriptionText is the plain-text projection of Description used for search.
+	DescriptionText string `json:"-"`
+	// DescriptionHTML is the sanitized HTML rendering of Description, only set on request.
+	DescriptionHTML string `gorm:"-" json:",omitempty"`
+	Status          Status
+	Priority        TodoPriority
+	Tags            []string   `gorm:"serializer:json" json:",omitempty"`
+	Assignee        string     `json:",omitempty"`
+	DueAt           *time.Time `json:",omitempty"`
+	Project         string     `gorm:"index" json:",omitempty"`
+	EstimateMinutes int        `json:",omitempty"`
+	// Rank is the manual position of the todo within its status column, see package rank.
+	// Clients order with sort=manual and the move endpoint instead of reading it.
+	Rank      string    `gorm:"column:sort_rank;index" json:"-"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
 type FindAllRequest struct {
-	Task   string
-	Status string
+	Task     string
+	Status   string
+	Project  string
+	Assignee string
+	Render   string
+	Sort     string
 }
 
+// SortManual is the sort mode for ordering todos by status column and then by rank.
+const SortManual = "manual"
+
+// RenderHTML is the render mode for returning the description as sanitized HTML.
+const RenderHTML = "html"
+
 // UpdateRequestPath is the request parameter for updating a todo
 type UpdateRequestPath struct {
 	ID int `param:"id" validate:"required"`
@@ -34,14 +72,74 @@ type UpdateRequest struct {
 
 // CreateRequest is the request parameter for creating a new todo
 type CreateRequest struct {
-	Task     string `json:"task" validate:"required"`
-	Priority string `json:"priority" validate:"required"`
+	Task        string     `json:"task" validate:"required"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority" validate:"required"`
+	Tags        []string   `json:"tags"`
+	Assignee    string     `json:"assignee"`
+	DueAt       *time.Time `json:"dueAt"`
+	Project     string     `json:"project"`
+	// EstimateMinutes is the expected effort in minutes.
+	EstimateMinutes int `json:"estimateMinutes" validate:"gte=0"`
+}
+
+// QuickCreateRequest is the request parameter for creating a todo from free-form text
+type QuickCreateRequest struct {
+	Text string `json:"text" validate:"required"`
+}
+
+// QuickAddResult is what the quick-add parser extracted from the text
+type QuickAddResult struct {
+	// Task is the input with every recognised token removed.
+	Task string
+	// Priority is one of "low", "medium" or "high", or empty when not given.
+	Priority string
+	// Tags are the "#tag" tokens without the leading '#', in input order.
+	Tags []string
+	// Assignee is the "@user" token without the leading '@'.
+	Assignee string
+	// DueAt is the resolved due date, or nil when no date or time was given.
+	DueAt *time.Time
+	// Matched lists the input fragments that were recognised, in input order.
+	Matched []string
+}
+
+// QuickCreateResponse is the created todo along with what was parsed from the text
+type QuickCreateResponse struct {
+	Todo   *Todo
+	Parsed *QuickAddResult
 }
 
 // UpdateRequestBody is the request body for updating a todo
 type UpdateRequestBody struct {
-	Task   string `json:"task,omitempty"`
-	Status Status `json:"status,omitempty"`
+	Task        string     `json:"task,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Status      Status     `json:"status,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Assignee    string     `json:"assignee,omitempty"`
+	DueAt       *time.Time `json:"dueAt,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	// EstimateMinutes is the expected effort in minutes.
+	EstimateMinutes int `json:"estimateMinutes,omitempty" validate:"gte=0"`
+}
+
+// ReplaceRequest is the request parameter for replacing a todo as a whole:
+// unlike an update, the fields left empty are cleared.
+type ReplaceRequest struct {
+	// ID is the todo replaced. Zero creates a new todo.
+	ID     int
+	Todo   CreateRequest
+	Status Status
+}
+
+// MoveRequest is the request parameter for moving a todo.
+// The todo is placed right before or right after another todo, taking over its
+// status, or at the end of the given status column.
+type MoveRequest struct {
+	ID     int    `param:"id" json:"-" validate:"required"`
+	Before int    `json:"before,omitempty" validate:"omitempty,gt=0,excluded_with=After"`
+	After  int    `json:"after,omitempty" validate:"omitempty,gt=0"`
+	Status Status `json:"status,omitempty" validate:"required_without_all=Before After,omitempty,oneof=created processing done"`
 }
 
 // DeleteRequest is the request parameter for deleting a todo
@@ -51,25 +149,60 @@ type DeleteRequest struct {
 
 // FindRequest is the request parameter for finding a todo
 type FindRequest struct {
-	ID int `param:"id" validate:"required"`
+	ID     int    `param:"id" validate:"required"`
+	Render string `query:"render" validate:"omitempty,oneof=html"`
 }
 
 // NewTodo returns a new instance of the todo model.
 func NewTodo(req *CreateRequest) *Todo {
-	return &Todo{
-		Task:     req.Task,
-		Status:   Created,                    // Set default status
-		Priority: TodoPriority(req.Priority), // Map priority directly
+	todo := &Todo{
+		Task:            req.Task,
+		Status:          Created,                    // Set default status
+		Priority:        TodoPriority(req.Priority), // Map priority directly
+		Tags:            req.Tags,
+		Assignee:        req.Assignee,
+		DueAt:           req.DueAt,
+		Project:         req.Project,
+		EstimateMinutes: req.EstimateMinutes,
 	}
+	todo.SetDescription(req.Description)
+	return todo
 }
 
 // NewUpdateTodo returns a new instance of the todo model for updating.
 func NewUpdateTodo(req *UpdateRequest) *Todo {
-	return &Todo{
-		ID:     req.ID,
-		Task:   req.Task,
-		Status: req.Status,
+	todo := &Todo{
+		ID:              req.ID,
+		Task:            req.Task,
+		Status:          req.Status,
+		Tags:            req.Tags,
+		Assignee:        req.Assignee,
+		DueAt:           req.DueAt,
+		Project:         req.Project,
+		EstimateMinutes: req.EstimateMinutes,
+	}
+	todo.SetDescription(req.Description)
+	return todo
+}
+
+// SetDescription sets the Markdown description along with its plain-text projection.
+func (t *Todo) SetDescription(source string) {
+	t.Description = source
+	t.DescriptionText = markdown.PlainText(source)
+}
+
+// RenderDescription fills DescriptionHTML with the sanitized HTML of the description.
+func (t *Todo) RenderDescription() error {
+	if t.Description == "" {
+		return nil
+	}
+
+	html, err := markdown.RenderHTML(t.Description)
+	if err != nil {
+		return err
 	}
+	t.DescriptionHTML = html
+	return nil
 }
 
 // Status is the status of the task.
@@ -94,6 +227,9 @@ const (
 	Done = Status("done")
 )
 
+// StatusColumns is the order of the status columns when sorting manually.
+var StatusColumns = []Status{Created, Processing, Done}
+
 // StatusMap is a map of task status.
 var StatusMap = map[Status]bool{
 	Created:    true,
@@ -101,13 +237,36 @@ var StatusMap = map[Status]bool{
 	Done:       true,
 }
 
+// SortByRank orders todos by status column, then by rank, then by ID.
+func SortByRank(todos []*Todo) {
+	column := func(s Status) int {
+		for i, c := range StatusColumns {
+			if c == s {
+				return i
+			}
+		}
+		return len(StatusColumns)
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		a, b := todos[i], todos[j]
+		if ca, cb := column(a.Status), column(b.Status); ca != cb {
+			return ca < cb
+		}
+		if a.Rank != b.Rank {
+			return a.Rank < b.Rank
+		}
+		return a.ID < b.ID
+	})
+}
+
 func (t *Todo) ValidateCreateRequest() error {
 	if t.Task == "" {
-		return errors.New("task cannot be empty")
+		return ErrEmptyTask
 	}
 
 	if t.Priority != TP_High && t.Priority != TP_Low && t.Priority != TP_Medium {
-		return errors.New("invalid priority not accepted")
+		return fmt.Errorf("%w: %q", ErrInvalidPriority, t.Priority)
 	}
 	// Add additional validation as needed
 	return nil
@@ -120,13 +279,37 @@ func (t *Todo) PrepareUpdatedTodo(currentTodo *Todo) *Todo {
 		t.Task = currentTodo.Task
 	}
 
-	fmt.Println(t.Status)
-
 	if t.Status == "" {
 		t.Status = currentTodo.Status
 	}
 
-	fmt.Println(t.Status)
+	if t.Description == "" {
+		t.Description = currentTodo.Description
+		t.DescriptionText = currentTodo.DescriptionText
+	}
+
+	if t.Tags == nil {
+		t.Tags = currentTodo.Tags
+	}
+
+	if t.Assignee == "" {
+		t.Assignee = currentTodo.Assignee
+	}
+
+	if t.DueAt == nil {
+		t.DueAt = currentTodo.DueAt
+	}
+
+	if t.Project == "" {
+		t.Project = currentTodo.Project
+	}
+
+	if t.EstimateMinutes == 0 {
+		t.EstimateMinutes = currentTodo.EstimateMinutes
+	}
+
+	// Ranks only change by moving the todo
+	t.Rank = currentTodo.Rank
 
 	t.CreatedAt = currentTodo.CreatedAt
 	t.Priority = currentTodo.Priority
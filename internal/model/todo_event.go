@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// TodoCreated is the type of the stored event for a created todo. Its data is the whole todo.
+	TodoCreated = "TodoCreated"
+	// TodoUpdated is the type of the stored event for a changed todo. Its data is the changed fields.
+	TodoUpdated = "TodoUpdated"
+	// TodoDeleted is the type of the stored event for a deleted todo. It has no data.
+	TodoDeleted = "TodoDeleted"
+)
+
+// ErrUntrackedTodos is the error for replaying while todos stored before event
+// sourcing was enabled have no events yet, so the replay would drop them.
+var ErrUntrackedTodos = errors.New("todos without events")
+
+// TodoEvent is a change stored by the event-sourced todo repository. The
+// todos table is a projection of these events and can be rebuilt from them.
+//
+// Unlike Event, which notifies others of a change, a TodoEvent is the change
+// itself and is kept forever.
+type TodoEvent struct {
+	ID     uint `gorm:"primaryKey"`
+	TodoID int  `gorm:"uniqueIndex:idx_todo_events_version"`
+	// Version is the position of the event among those of its todo, from 1.
+	// Two writers appending to the same todo at once cannot both succeed.
+	Version int `gorm:"uniqueIndex:idx_todo_events_version"`
+	Type    string
+	// Data is the JSON of the whole todo for TodoCreated, and of the changed
+	// fields only for TodoUpdated.
+	Data      string
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TodoSnapshot is the todos projection as of an event, so that a replay starts
+// from it instead of from the first event.
+type TodoSnapshot struct {
+	ID uint `gorm:"primaryKey"`
+	// EventID is the last event the snapshot includes.
+	EventID uint `gorm:"uniqueIndex"`
+	// Data is the JSON of every todo.
+	Data      string
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ReplayResult is the outcome of rebuilding the todos projection.
+type ReplayResult struct {
+	// SnapshotEventID is the event of the snapshot the replay started from, or zero.
+	SnapshotEventID uint
+	// Events is the number of events applied after the snapshot.
+	Events int
+	// Todos is the number of todos in the rebuilt projection.
+	Todos int
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook is a subscription of a URL to todo events.
+type Webhook struct {
+	ID  int `gorm:"primaryKey"`
+	URL string
+	// Events are the event types delivered to the URL.
+	Events []string `gorm:"serializer:json"`
+	// Secret is the key the deliveries are signed with. It is never returned.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// Subscribes reports whether the webhook wants events of eventType.
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, subscribed := range w.Events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending is the status of a delivery waiting for its next attempt.
+	DeliveryPending = DeliveryStatus("pending")
+	// DeliverySucceeded is the status of a delivery the receiver accepted.
+	DeliverySucceeded = DeliveryStatus("succeeded")
+	// DeliveryFailed is the status of a delivery that ran out of attempts.
+	DeliveryFailed = DeliveryStatus("failed")
+)
+
+// WebhookDelivery is one event to deliver to a webhook. Deliveries are queued
+// in the database, so they survive restarts, and double as the delivery log.
+type WebhookDelivery struct {
+	ID int `gorm:"primaryKey"`
+	// An event is delivered to a webhook once, however often it is dispatched.
+	WebhookID int  `gorm:"uniqueIndex:idx_webhook_deliveries_event"`
+	EventID   uint `gorm:"uniqueIndex:idx_webhook_deliveries_event"`
+	EventType string
+	// Payload is the exact body sent on every attempt.
+	Payload string `json:"-"`
+	Status  DeliveryStatus
+	// Attempts is the number of attempts made so far.
+	Attempts int
+	// NextAttemptAt is when the delivery is due. A worker delivering it moves it
+	// ahead, so another worker only takes it over if the first one died.
+	NextAttemptAt time.Time `gorm:"index"`
+	// LastAttemptAt is when the delivery was last attempted.
+	LastAttemptAt *time.Time `json:",omitempty"`
+	// ResponseStatus is the HTTP status the receiver answered the last attempt with.
+	ResponseStatus int `json:",omitempty"`
+	// Error is why the last attempt failed.
+	Error     string    `json:",omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// WebhookPayload is the body of a webhook delivery.
+type WebhookPayload struct {
+	// ID is the ID of the event. Receivers use it to ignore redeliveries.
+	ID        uint      `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Data is the todo after the change, or before it for a delete.
+	Data json.RawMessage `json:"data"`
+}
+
+// CreateWebhookRequest is the request parameter for creating a webhook
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=todo.created todo.updated todo.deleted todo.completed"`
+	// Secret is the key for the X-Webhook-Signature header of the deliveries.
+	Secret string `json:"secret" validate:"required,min=16"`
+}
+
+// FindWebhookRequest is the request parameter for finding a webhook
+type FindWebhookRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+// DeleteWebhookRequest is the request parameter for deleting a webhook
+type DeleteWebhookRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+// FindDeliveriesRequest is the request parameter for the delivery log of a webhook
+type FindDeliveriesRequest struct {
+	WebhookID int            `param:"id" validate:"required"`
+	Status    DeliveryStatus `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	// Limit is the maximum number of deliveries returned, newest first.
+	Limit int `query:"limit" validate:"omitempty,gt=0,lte=500"`
+}
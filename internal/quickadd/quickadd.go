@@ -0,0 +1,361 @@
+// Package quickadd parses free-form "quick add" text such as
+// "Pay invoice tomorrow 5pm !high #finance @alice" into todo fields.
+package quickadd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// ErrEmptyTask is the error for input that holds nothing but recognised tokens.
+var ErrEmptyTask = fmt.Errorf("task cannot be empty")
+
+// InitParser is the options for the parser.
+type InitParser struct {
+	// Location is the timezone relative dates are resolved in. Defaults to UTC.
+	Location *time.Location
+	// Now is the reference clock. Defaults to time.Now; tests pin it.
+	Now func() time.Time
+}
+
+// Parser turns quick-add text into a model.QuickAddResult.
+type Parser struct {
+	location *time.Location
+	now      func() time.Time
+}
+
+// New returns a new Parser.
+func New(initParser *InitParser) *Parser {
+	p := &Parser{
+		location: initParser.Location,
+		now:      initParser.Now,
+	}
+	if p.location == nil {
+		p.location = time.UTC
+	}
+	if p.now == nil {
+		p.now = time.Now
+	}
+	return p
+}
+
+var priorities = map[string]string{
+	"high": "high", "h": "high", "1": "high", "!": "high",
+	"medium": "medium", "med": "medium", "m": "medium", "2": "medium",
+	"low": "low", "l": "low", "3": "low",
+}
+
+// weekdays leaves out "sat" and "sun", which are too often ordinary words.
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+var (
+	clockPattern   = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	isoDatePattern = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})$`)
+	dayPattern     = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)?$`)
+	tokenTrim      = ".,;"
+)
+
+// Parse extracts the todo fields from input.
+func (p *Parser) Parse(input string) (*model.QuickAddResult, error) {
+	now := p.now().In(p.location)
+	res := &model.QuickAddResult{}
+
+	var (
+		words    = strings.Fields(input)
+		task     []string
+		day      *time.Time
+		clock    *timeOfDay
+		consumed int
+	)
+
+	for i := 0; i < len(words); i += consumed {
+		consumed = 1
+		word := words[i]
+		lower := strings.ToLower(strings.TrimRight(word, tokenTrim))
+
+		switch {
+		case strings.HasPrefix(word, "!") && priorities[lower[1:]] != "":
+			res.Priority = priorities[lower[1:]]
+			res.Matched = append(res.Matched, word)
+			continue
+		case strings.HasPrefix(word, "#") && len(lower) > 1:
+			res.Tags = append(res.Tags, strings.TrimRight(word[1:], tokenTrim))
+			res.Matched = append(res.Matched, word)
+			continue
+		case strings.HasPrefix(word, "@") && len(lower) > 1:
+			res.Assignee = strings.TrimRight(word[1:], tokenTrim)
+			res.Matched = append(res.Matched, word)
+			continue
+		}
+
+		if day == nil {
+			if d, n := p.parseDate(now, words[i:]); n > 0 {
+				day, consumed = &d, n
+				res.Matched = append(res.Matched, strings.Join(words[i:i+n], " "))
+				continue
+			}
+		}
+
+		if clock == nil {
+			if c, n := parseClock(words[i:]); n > 0 {
+				clock, consumed = &c, n
+				res.Matched = append(res.Matched, strings.Join(words[i:i+n], " "))
+				continue
+			}
+		}
+
+		task = append(task, word)
+	}
+
+	res.Task = strings.Join(task, " ")
+	if res.Task == "" {
+		return nil, ErrEmptyTask
+	}
+	res.DueAt = resolve(now, day, clock)
+
+	return res, nil
+}
+
+// timeOfDay is a time on the wall clock, kept apart from the date until both
+// are known so that it holds on days with a DST change.
+type timeOfDay struct {
+	hour, minute int
+}
+
+// on returns the time of day on the date of d, in the location of d.
+func (c timeOfDay) on(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), c.hour, c.minute, 0, 0, d.Location())
+}
+
+// resolve combines a date and a time of day. A date alone is due at the start
+// of that day; a time alone is due today, or tomorrow once it has passed.
+func resolve(now time.Time, day *time.Time, clock *timeOfDay) *time.Time {
+	if day == nil && clock == nil {
+		return nil
+	}
+
+	var due time.Time
+	switch {
+	case day != nil && clock != nil:
+		due = clock.on(*day)
+	case day != nil:
+		due = *day
+	default:
+		due = clock.on(now)
+		if !due.After(now) {
+			due = clock.on(startOfDay(now).AddDate(0, 0, 1))
+		}
+	}
+
+	return &due
+}
+
+// parseDate recognises a date phrase at the start of words and returns the
+// start of that day and the number of words consumed, or 0 when there is none.
+func (p *Parser) parseDate(now time.Time, words []string) (time.Time, int) {
+	today := startOfDay(now)
+	w := func(i int) string {
+		if i >= len(words) {
+			return ""
+		}
+		return strings.ToLower(strings.TrimRight(words[i], tokenTrim))
+	}
+
+	switch first := w(0); first {
+	case "today", "tonight":
+		return today, 1
+	case "tomorrow", "tmr", "tmrw":
+		return today.AddDate(0, 0, 1), 1
+	case "yesterday":
+		return today.AddDate(0, 0, -1), 1
+	case "next":
+		if wd, ok := weekdays[w(1)]; ok {
+			return nextWeekday(today, wd), 2
+		}
+		switch w(1) {
+		case "week":
+			return today.AddDate(0, 0, 7), 2
+		case "month":
+			return today.AddDate(0, 1, 0), 2
+		}
+	case "in":
+		// "in 3 days", "in 2 weeks"
+		if n, err := strconv.Atoi(w(1)); err == nil && n > 0 {
+			switch strings.TrimSuffix(w(2), "s") {
+			case "day":
+				return today.AddDate(0, 0, n), 3
+			case "week":
+				return today.AddDate(0, 0, 7*n), 3
+			case "month":
+				return today.AddDate(0, n, 0), 3
+			}
+		}
+	case "on":
+		// "on friday", "on 2024-12-31"
+		if d, n := p.parseDate(now, words[1:]); n > 0 {
+			return d, n + 1
+		}
+	default:
+		if wd, ok := weekdays[first]; ok {
+			return nextWeekday(today, wd), 1
+		}
+
+		if m := isoDatePattern.FindStringSubmatch(first); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			day, _ := strconv.Atoi(m[3])
+			if d, ok := date(year, time.Month(month), day, p.location); ok {
+				return d, 1
+			}
+		}
+
+		// "dec 31" / "31 dec"
+		if month, ok := months[first]; ok {
+			if m := dayPattern.FindStringSubmatch(w(1)); m != nil {
+				day, _ := strconv.Atoi(m[1])
+				if d, ok := upcoming(today, month, day, p.location); ok {
+					return d, 2
+				}
+			}
+		}
+		if m := dayPattern.FindStringSubmatch(first); m != nil {
+			if month, ok := months[w(1)]; ok {
+				day, _ := strconv.Atoi(m[1])
+				if d, ok := upcoming(today, month, day, p.location); ok {
+					return d, 2
+				}
+			}
+		}
+	}
+
+	return time.Time{}, 0
+}
+
+// parseClock recognises a time of day at the start of words ("5pm", "5:30 pm",
+// "17:00", "at 9am", "noon") and returns it with the number of words consumed,
+// or 0 when there is none.
+func parseClock(words []string) (timeOfDay, int) {
+	if len(words) == 0 {
+		return timeOfDay{}, 0
+	}
+
+	first := strings.ToLower(strings.TrimRight(words[0], tokenTrim))
+	switch first {
+	case "noon", "midday":
+		return timeOfDay{hour: 12}, 1
+	case "midnight":
+		return timeOfDay{}, 1
+	case "at":
+		if c, n := parseClock(words[1:]); n > 0 {
+			return c, n + 1
+		}
+		return timeOfDay{}, 0
+	}
+
+	// Allow a detached meridiem: "5 pm"
+	token, n := first, 1
+	if len(words) > 1 {
+		next := strings.ToLower(strings.TrimRight(words[1], tokenTrim))
+		if next == "am" || next == "pm" {
+			token, n = first+next, 2
+		}
+	}
+
+	m := clockPattern.FindStringSubmatch(token)
+	if m == nil {
+		return timeOfDay{}, 0
+	}
+	// A bare number such as "3" is part of the task, not a time.
+	if m[2] == "" && m[3] == "" {
+		return timeOfDay{}, 0
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return timeOfDay{}, 0
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return timeOfDay{}, 0
+		}
+		if hour != 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return timeOfDay{}, 0
+	}
+
+	return timeOfDay{hour: hour, minute: minute}, n
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextWeekday returns the next day strictly after today falling on wd.
+func nextWeekday(today time.Time, wd time.Weekday) time.Time {
+	days := (int(wd) - int(today.Weekday()) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// upcoming returns month/day in the current year, or next year if it has passed.
+func upcoming(today time.Time, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	d, ok := date(today.Year(), month, day, loc)
+	if !ok {
+		return time.Time{}, false
+	}
+	if d.Before(today) {
+		return date(today.Year()+1, month, day, loc)
+	}
+	return d, true
+}
+
+// date builds a date and rejects overflowing values such as February 30.
+func date(year int, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	d := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if d.Month() != month || d.Day() != day {
+		return time.Time{}, false
+	}
+	return d, true
+}
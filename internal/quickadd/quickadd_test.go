@@ -0,0 +1,180 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	// Wednesday 2024-10-16 10:00 in Tokyo
+	now := time.Date(2024, 10, 16, 10, 0, 0, 0, tokyo)
+	parser := New(&InitParser{Location: tokyo, Now: func() time.Time { return now }})
+
+	at := func(month time.Month, day, hour, minute int) *time.Time {
+		t := time.Date(2024, month, day, hour, minute, 0, 0, tokyo)
+		return &t
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  *model.QuickAddResult
+	}{
+		{
+			name:  "everything",
+			input: "Pay invoice tomorrow 5pm !high #finance @alice",
+			want: &model.QuickAddResult{
+				Task:     "Pay invoice",
+				Priority: "high",
+				Tags:     []string{"finance"},
+				Assignee: "alice",
+				DueAt:    at(time.October, 17, 17, 0),
+				Matched:  []string{"tomorrow", "5pm", "!high", "#finance", "@alice"},
+			},
+		},
+		{
+			name:  "plain_task",
+			input: "Buy sun cream",
+			want:  &model.QuickAddResult{Task: "Buy sun cream"},
+		},
+		{
+			name:  "bare_numbers_stay_in_task",
+			input: "Order 3 chairs",
+			want:  &model.QuickAddResult{Task: "Order 3 chairs"},
+		},
+		{
+			name:  "weekday_and_24h_clock",
+			input: "Standup on friday at 09:30 !m",
+			want: &model.QuickAddResult{
+				Task:     "Standup",
+				Priority: "medium",
+				DueAt:    at(time.October, 18, 9, 30),
+				Matched:  []string{"on friday", "at 09:30", "!m"},
+			},
+		},
+		{
+			name:  "same_weekday_means_next_week",
+			input: "Retro wednesday",
+			want:  &model.QuickAddResult{Task: "Retro", DueAt: at(time.October, 23, 0, 0), Matched: []string{"wednesday"}},
+		},
+		{
+			name:  "time_already_passed_today",
+			input: "Call mom 9am",
+			want:  &model.QuickAddResult{Task: "Call mom", DueAt: at(time.October, 17, 9, 0), Matched: []string{"9am"}},
+		},
+		{
+			name:  "time_later_today",
+			input: "Call dad 5 pm",
+			want:  &model.QuickAddResult{Task: "Call dad", DueAt: at(time.October, 16, 17, 0), Matched: []string{"5 pm"}},
+		},
+		{
+			name:  "relative",
+			input: "Renew passport in 2 weeks #admin #travel",
+			want: &model.QuickAddResult{
+				Task:    "Renew passport",
+				Tags:    []string{"admin", "travel"},
+				DueAt:   at(time.October, 30, 0, 0),
+				Matched: []string{"in 2 weeks", "#admin", "#travel"},
+			},
+		},
+		{
+			name:  "iso_date",
+			input: "File taxes 2025-03-15 noon",
+			want: &model.QuickAddResult{
+				Task:    "File taxes",
+				DueAt:   &[]time.Time{time.Date(2025, 3, 15, 12, 0, 0, 0, tokyo)}[0],
+				Matched: []string{"2025-03-15", "noon"},
+			},
+		},
+		{
+			name:  "month_day_rolls_over_to_next_year",
+			input: "Dentist jan 5th",
+			want: &model.QuickAddResult{
+				Task:    "Dentist",
+				DueAt:   &[]time.Time{time.Date(2025, 1, 5, 0, 0, 0, 0, tokyo)}[0],
+				Matched: []string{"jan 5th"},
+			},
+		},
+		{
+			name:  "invalid_date_stays_in_task",
+			input: "Party feb 30",
+			want:  &model.QuickAddResult{Task: "Party feb 30"},
+		},
+		{
+			name:  "empty_tag_and_assignee_stay_in_task",
+			input: "Fix typo #. @, #docs.",
+			want:  &model.QuickAddResult{Task: "Fix typo #. @,", Tags: []string{"docs"}, Matched: []string{"#docs."}},
+		},
+		{
+			name:  "unknown_priority_stays_in_task",
+			input: "Wow !important",
+			want:  &model.QuickAddResult{Task: "Wow !important"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Parse() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParser_ParseAcrossDSTChange(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// The clocks go forward at 2:00 on Sunday 2024-03-10
+	tests := []struct {
+		name  string
+		now   time.Time
+		input string
+		want  time.Time
+	}{
+		{
+			name:  "date_and_time",
+			now:   time.Date(2024, 3, 9, 10, 0, 0, 0, newYork),
+			input: "Call mom tomorrow 5pm",
+			want:  time.Date(2024, 3, 10, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:  "time_later_today",
+			now:   time.Date(2024, 3, 10, 8, 0, 0, 0, newYork),
+			input: "Call dad 5pm",
+			want:  time.Date(2024, 3, 10, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:  "time_already_passed_today",
+			now:   time.Date(2024, 3, 9, 18, 0, 0, 0, newYork),
+			input: "Call dad 5pm",
+			want:  time.Date(2024, 3, 10, 17, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := New(&InitParser{Location: newYork, Now: func() time.Time { return tt.now }})
+			got, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+			require.NotNil(t, got.DueAt)
+			require.True(t, tt.want.Equal(*got.DueAt), "want %s, got %s", tt.want, got.DueAt)
+		})
+	}
+}
+
+func TestParser_ParseEmptyTask(t *testing.T) {
+	parser := New(&InitParser{})
+
+	_, err := parser.Parse("tomorrow !high #finance")
+	require.ErrorIs(t, err, ErrEmptyTask)
+}
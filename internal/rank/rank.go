@@ -0,0 +1,156 @@
+// Package rank generates lexicographic ranks for manual ordering.
+//
+// A rank is a base-36 fraction written without the leading "0." and without
+// trailing zeros, so "i" is 0.5 and "9i" sits between "9" and "a". Comparing
+// two ranks as strings compares their values, which lets the database and the
+// cache order by a plain string column. A new rank can always be put between
+// two others; ranks grow longer as the gap closes, and Spread hands out short,
+// evenly spaced ranks again when they get too long.
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// appendWidth is the precision After steps at, which leaves room for many appends before ranks grow.
+const appendWidth = 4
+
+// MaxLength is the length past which ranks should be rebalanced with Spread.
+const MaxLength = 12
+
+// ErrInvalidRank is the error for a rank with characters outside the alphabet or a trailing zero.
+var ErrInvalidRank = errors.New("invalid rank")
+
+// ErrOutOfOrder is the error for bounds that are not strictly increasing.
+var ErrOutOfOrder = errors.New("ranks out of order")
+
+// Between returns a rank strictly between a and b. An empty a means the start
+// of the list and an empty b means its end.
+func Between(a, b string) (string, error) {
+	if err := validate(a); err != nil {
+		return "", err
+	}
+	if err := validate(b); err != nil {
+		return "", err
+	}
+	if b != "" && a >= b {
+		return "", fmt.Errorf("%w: %q >= %q", ErrOutOfOrder, a, b)
+	}
+
+	return midpoint(a, b), nil
+}
+
+// After returns a rank after a that stays short when called repeatedly, as
+// when appending to the end of a list. An empty a means the start of the list.
+func After(a string) (string, error) {
+	if err := validate(a); err != nil {
+		return "", err
+	}
+
+	width := len(a)
+	if width < appendWidth {
+		width = appendWidth
+	}
+	b := []byte(a + strings.Repeat("0", width-len(a)))
+	for i := width - 1; i >= 0; i-- {
+		d := strings.IndexByte(digits, b[i])
+		if d < base-1 {
+			b[i] = digits[d+1]
+			return strings.TrimRight(string(b), "0"), nil
+		}
+		b[i] = digits[0]
+	}
+
+	// Every digit is the largest: fall back to splitting the gap to the end.
+	return midpoint(a, ""), nil
+}
+
+// Spread returns n evenly spaced ranks in increasing order.
+func Spread(n int) []string {
+	width, space := 1, base
+	for space <= n {
+		width++
+		space *= base
+	}
+	step := space / (n + 1)
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		ranks[i] = strings.TrimRight(format((i+1)*step, width), "0")
+	}
+	return ranks
+}
+
+// midpoint assumes a < b, with an empty b standing for 1.
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix and split the remainder.
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	lo := 0
+	if a != "" {
+		lo = strings.IndexByte(digits, a[0])
+	}
+	hi := base
+	if b != "" {
+		hi = strings.IndexByte(digits, b[0])
+	}
+
+	if hi-lo > 1 {
+		return string(digits[(lo+hi+1)/2])
+	}
+	// The first digits are adjacent: a shorter prefix of b still fits, or go one level deeper after a.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[lo]) + midpoint(rest, "")
+}
+
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return digits[0]
+}
+
+func format(v, width int) string {
+	b := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		b[i] = digits[v%base]
+		v /= base
+	}
+	return string(b)
+}
+
+func validate(r string) error {
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidRank, r)
+		}
+	}
+	if strings.HasSuffix(r, "0") {
+		return fmt.Errorf("%w: %q has a trailing zero", ErrInvalidRank, r)
+	}
+	return nil
+}
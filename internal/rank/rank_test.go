@@ -0,0 +1,118 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "empty_list", want: "i"},
+		{name: "append", a: "i", want: "r"},
+		{name: "prepend", b: "i", want: "9"},
+		{name: "adjacent_digits", a: "a", b: "b", want: "ai"},
+		{name: "shorter_prefix", a: "a", b: "a5", want: "a3"},
+		{name: "before_smallest", b: "1", want: "0i"},
+		{name: "after_largest", a: "z", want: "zi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Between(tt.a, tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBetween_Errors(t *testing.T) {
+	_, err := Between("b", "a")
+	assert.ErrorIs(t, err, ErrOutOfOrder)
+	_, err = Between("a", "a")
+	assert.ErrorIs(t, err, ErrOutOfOrder)
+	_, err = Between("A", "")
+	assert.ErrorIs(t, err, ErrInvalidRank)
+	_, err = Between("a0", "")
+	assert.ErrorIs(t, err, ErrInvalidRank)
+}
+
+func TestBetween_RepeatedInserts(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	ranks := []string{}
+
+	for i := 0; i < 500; i++ {
+		pos := rnd.Intn(len(ranks) + 1)
+		var a, b string
+		if pos > 0 {
+			a = ranks[pos-1]
+		}
+		if pos < len(ranks) {
+			b = ranks[pos]
+		}
+
+		r, err := Between(a, b)
+		require.NoError(t, err)
+		if a != "" {
+			require.Less(t, a, r)
+		}
+		if b != "" {
+			require.Less(t, r, b)
+		}
+
+		ranks = append(ranks[:pos], append([]string{r}, ranks[pos:]...)...)
+	}
+	assert.True(t, sort.StringsAreSorted(ranks))
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		a    string
+		want string
+	}{
+		{a: "", want: "0001"},
+		{a: "i", want: "i001"},
+		{a: "i00z", want: "i01"},
+		{a: "i0000001", want: "i0000002"},
+		{a: "zzzz", want: "zzzzi"},
+	}
+
+	for _, tt := range tests {
+		got, err := After(tt.a)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "After(%q)", tt.a)
+	}
+
+	// Appending stays short
+	r := "i"
+	for i := 0; i < 10000; i++ {
+		next, err := After(r)
+		require.NoError(t, err)
+		require.Less(t, r, next)
+		r = next
+	}
+	assert.LessOrEqual(t, len(r), appendWidth)
+}
+
+func TestSpread(t *testing.T) {
+	for _, n := range []int{0, 1, 35, 36, 1000} {
+		ranks := Spread(n)
+		require.Len(t, ranks, n)
+		assert.True(t, sort.StringsAreSorted(ranks))
+		for i, r := range ranks {
+			require.NoError(t, validate(r))
+			require.NotEmpty(t, r)
+			if i > 0 {
+				require.NotEqual(t, ranks[i-1], r)
+			}
+			require.LessOrEqual(t, len(r), 2)
+		}
+	}
+}
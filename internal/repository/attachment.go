@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// IAttachment is the repository for todo attachments.
+type IAttachment interface {
+	Create(ctx context.Context, attachment *model.Attachment) error
+	Delete(ctx context.Context, reqParams *model.DeleteAttachmentRequest) error
+	Find(ctx context.Context, reqParams *model.FindAttachmentRequest) (*model.Attachment, error)
+	FindAll(ctx context.Context, reqParams *model.AttachmentRequestPath) ([]*model.Attachment, error)
+	// Digests returns the set of blob digests referenced by any attachment.
+	Digests(ctx context.Context) (map[string]bool, error)
+	// DeleteOrphans removes attachments whose todo no longer exists.
+	DeleteOrphans(ctx context.Context) (int64, error)
+}
+
+type InitAttachmentRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type attachmentReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewAttachment returns a new instance of the attachment repository.
+func NewAttachment(initAttachmentRepository *InitAttachmentRepository) IAttachment {
+	return &attachmentReceiver{
+		log: initAttachmentRepository.Log,
+		db:  initAttachmentRepository.Db,
+	}
+}
+
+func (ar *attachmentReceiver) Create(ctx context.Context, attachment *model.Attachment) error {
+	if err := conn(ctx, ar.db).Create(attachment).Error; err != nil {
+		ar.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ar *attachmentReceiver) Delete(ctx context.Context, reqParams *model.DeleteAttachmentRequest) error {
+	result := conn(ctx, ar.db).Where("id = ? AND todo_id = ?", reqParams.AttachmentID, reqParams.TodoID).
+		Delete(&model.Attachment{})
+	if result.Error != nil {
+		ar.log.Error(ctx, result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+func (ar *attachmentReceiver) Find(ctx context.Context, reqParams *model.FindAttachmentRequest) (*model.Attachment, error) {
+	var attachment *model.Attachment
+	err := conn(ctx, ar.db).Where("id = ? AND todo_id = ?", reqParams.AttachmentID, reqParams.TodoID).
+		Take(&attachment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		ar.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (ar *attachmentReceiver) FindAll(ctx context.Context, reqParams *model.AttachmentRequestPath) ([]*model.Attachment, error) {
+	attachments := []*model.Attachment{}
+	err := conn(ctx, ar.db).Where("todo_id = ?", reqParams.TodoID).
+		Order("created_at ASC").
+		Find(&attachments).Error
+	if err != nil {
+		ar.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (ar *attachmentReceiver) Digests(ctx context.Context) (map[string]bool, error) {
+	var digests []string
+	err := conn(ctx, ar.db).Model(&model.Attachment{}).
+		Distinct("digest").
+		Pluck("digest", &digests).Error
+	if err != nil {
+		ar.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(digests))
+	for _, d := range digests {
+		set[d] = true
+	}
+
+	return set, nil
+}
+
+func (ar *attachmentReceiver) DeleteOrphans(ctx context.Context) (int64, error) {
+	result := conn(ctx, ar.db).Where("todo_id NOT IN (?)", ar.db.Model(&model.Todo{}).Select("id")).
+		Delete(&model.Attachment{})
+	if result.Error != nil {
+		ar.log.Error(ctx, result.Error.Error())
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
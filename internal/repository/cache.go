@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis/v8"
@@ -10,6 +11,7 @@ import (
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
 	"go.uber.org/zap"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,6 +22,8 @@ type IRedisCache interface {
 	FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error)
 	DeleteAll(ctx context.Context) error
 	Delete(ctx context.Context, todoKey string) error
+	// Sync replaces the cached upserted todos and drops the deleted ones in a single round trip.
+	Sync(ctx context.Context, upserted []*model.Todo, deletedIDs []int) error
 }
 
 type InitRedisCache struct {
@@ -69,14 +73,7 @@ func (td *redisCache) Add(ctx context.Context, todoKey string, todo *model.Todo)
 	encodedID := base64.StdEncoding.EncodeToString([]byte(todoKey))
 
 	// Store the Todo details
-	err := td.client.HMSet(ctx, todoKey, map[string]interface{}{
-		"Id":        strconv.Itoa(todo.ID),
-		"Task":      todo.Task,
-		"Status":    string(todo.Status),
-		"Priority":  string(todo.Priority),
-		"CreatedAt": todo.CreatedAt,
-		"UpdatedAt": todo.UpdatedAt,
-	}).Err()
+	err := td.client.HMSet(ctx, todoKey, todoFields(todo)).Err()
 	if err != nil {
 		td.log.Error(ctx, "in hset ", zap.Error(err))
 		return err
@@ -100,6 +97,53 @@ func (td *redisCache) Add(ctx context.Context, todoKey string, todo *model.Todo)
 	return nil
 }
 
+func (td *redisCache) Sync(ctx context.Context, upserted []*model.Todo, deletedIDs []int) error {
+	_, err := td.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range deletedIDs {
+			todoKey := fmt.Sprintf("todo:%d", id)
+			pipe.Del(ctx, todoKey)
+			pipe.ZRem(ctx, "todos_sorted", base64.StdEncoding.EncodeToString([]byte(todoKey)))
+		}
+
+		for _, todo := range upserted {
+			todoKey := fmt.Sprintf("todo:%d", todo.ID)
+			pipe.Del(ctx, todoKey)
+			pipe.HMSet(ctx, todoKey, todoFields(todo))
+			pipe.ZAdd(ctx, "todos_sorted", &redis.Z{
+				Score:  CalculateScore(todo),
+				Member: base64.StdEncoding.EncodeToString([]byte(todoKey)),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		td.log.Error(ctx, "Error syncing todos", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// todoFields returns the hash fields a todo is cached as.
+func todoFields(todo *model.Todo) map[string]interface{} {
+	return map[string]interface{}{
+		"Id":              strconv.Itoa(todo.ID),
+		"Task":            todo.Task,
+		"Description":     todo.Description,
+		"DescriptionText": todo.DescriptionText,
+		"Status":          string(todo.Status),
+		"Priority":        string(todo.Priority),
+		"Tags":            encodeTags(todo.Tags),
+		"Assignee":        todo.Assignee,
+		"DueAt":           encodeTime(todo.DueAt),
+		"Project":         todo.Project,
+		"EstimateMinutes": strconv.Itoa(todo.EstimateMinutes),
+		"Rank":            todo.Rank,
+		"CreatedAt":       todo.CreatedAt,
+		"UpdatedAt":       todo.UpdatedAt,
+	}
+}
+
 func (td *redisCache) DeleteAll(ctx context.Context) error {
 	// Use FLUSHDB to remove all keys in the current database
 	_, err := td.client.FlushDB(ctx).Result()
@@ -170,22 +214,85 @@ func (td *redisCache) FindAll(ctx context.Context, reqParams *model.FindAllReque
 		}
 
 		todoIDInt, _ := strconv.Atoi(todoData["Id"])
+		estimateMinutes, _ := strconv.Atoi(todoData["EstimateMinutes"])
 		todo := &model.Todo{
-			ID:        todoIDInt,
-			Task:      todoData["Task"],
-			Status:    model.Status(todoData["Status"]),
-			Priority:  model.TodoPriority(todoData["Priority"]),
-			CreatedAt: parseTime(todoData["CreatedAt"]),
-			UpdatedAt: parseTime(todoData["UpdatedAt"]),
+			ID:              todoIDInt,
+			Task:            todoData["Task"],
+			Description:     todoData["Description"],
+			DescriptionText: todoData["DescriptionText"],
+			Status:          model.Status(todoData["Status"]),
+			Priority:        model.TodoPriority(todoData["Priority"]),
+			Tags:            decodeTags(todoData["Tags"]),
+			Assignee:        todoData["Assignee"],
+			DueAt:           decodeTime(todoData["DueAt"]),
+			Project:         todoData["Project"],
+			EstimateMinutes: estimateMinutes,
+			Rank:            todoData["Rank"],
+			CreatedAt:       parseTime(todoData["CreatedAt"]),
+			UpdatedAt:       parseTime(todoData["UpdatedAt"]),
+		}
+		if !matchesFilter(reqParams, todo) {
+			continue
 		}
 		todos = append(todos, todo)
 	}
 
+	// The sorted set holds the default order; the manual order is applied here.
+	if reqParams.Sort == model.SortManual {
+		model.SortByRank(todos)
+	}
+
 	return todos, nil
 }
 
+// matchesFilter tells whether todo passes the filters of reqParams, as the
+// database filters them in todoReceiver.FindAll.
+func matchesFilter(reqParams *model.FindAllRequest, todo *model.Todo) bool {
+	if reqParams.Task != "" {
+		task := strings.ToLower(reqParams.Task)
+		if !strings.Contains(strings.ToLower(todo.Task), task) && !strings.Contains(strings.ToLower(todo.DescriptionText), task) {
+			return false
+		}
+	}
+	return (reqParams.Status == "" || string(todo.Status) == reqParams.Status) &&
+		(reqParams.Project == "" || todo.Project == reqParams.Project) &&
+		(reqParams.Assignee == "" || todo.Assignee == reqParams.Assignee)
+}
+
 func parseTime(timeStr string) time.Time {
 	layout := time.RFC3339
 	t, _ := time.Parse(layout, timeStr)
 	return t
 }
+
+func encodeTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(tags)
+	return string(b)
+}
+
+func decodeTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var tags []string
+	_ = json.Unmarshal([]byte(s), &tags)
+	return tags
+}
+
+func encodeTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func decodeTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t := parseTime(s)
+	return &t
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// ICalDAVObject is the repository for the CalDAV names of todos.
+type ICalDAVObject interface {
+	// Save stores object, replacing the objects of the same todo, name or UID:
+	// those left by todos deleted from the API.
+	Save(ctx context.Context, object *model.CalDAVObject) error
+	FindAll(ctx context.Context) ([]*model.CalDAVObject, error)
+	// FindByName, FindByUID and FindByTodo return the object, or model.ErrNotFound.
+	FindByName(ctx context.Context, name string) (*model.CalDAVObject, error)
+	FindByUID(ctx context.Context, uid string) (*model.CalDAVObject, error)
+	FindByTodo(ctx context.Context, todoID int) (*model.CalDAVObject, error)
+	// Delete deletes the object of a todo, if it has one.
+	Delete(ctx context.Context, todoID int) error
+}
+
+type InitCalDAVObjectRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type calDAVObjectReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewCalDAVObject returns a new instance of the CalDAV object repository.
+func NewCalDAVObject(initCalDAVObjectRepository *InitCalDAVObjectRepository) ICalDAVObject {
+	return &calDAVObjectReceiver{
+		log: initCalDAVObjectRepository.Log,
+		db:  initCalDAVObjectRepository.Db,
+	}
+}
+
+func (cr *calDAVObjectReceiver) Save(ctx context.Context, object *model.CalDAVObject) error {
+	return WithTx(ctx, cr.db, func(ctx context.Context) error {
+		err := conn(ctx, cr.db).Where("todo_id = ? OR name = ? OR uid = ?", object.TodoID, object.Name, object.UID).
+			Delete(&model.CalDAVObject{}).Error
+		if err == nil {
+			err = conn(ctx, cr.db).Create(object).Error
+		}
+		if err != nil {
+			cr.log.Error(ctx, err.Error())
+			return err
+		}
+		return nil
+	})
+}
+
+func (cr *calDAVObjectReceiver) FindAll(ctx context.Context) ([]*model.CalDAVObject, error) {
+	var objects []*model.CalDAVObject
+	if err := conn(ctx, cr.db).Find(&objects).Error; err != nil {
+		cr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (cr *calDAVObjectReceiver) FindByName(ctx context.Context, name string) (*model.CalDAVObject, error) {
+	return cr.find(ctx, "name = ?", name)
+}
+
+func (cr *calDAVObjectReceiver) FindByUID(ctx context.Context, uid string) (*model.CalDAVObject, error) {
+	return cr.find(ctx, "uid = ?", uid)
+}
+
+func (cr *calDAVObjectReceiver) FindByTodo(ctx context.Context, todoID int) (*model.CalDAVObject, error) {
+	return cr.find(ctx, "todo_id = ?", todoID)
+}
+
+func (cr *calDAVObjectReceiver) find(ctx context.Context, query string, arg interface{}) (*model.CalDAVObject, error) {
+	var object *model.CalDAVObject
+	err := conn(ctx, cr.db).Where(query, arg).Take(&object).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		cr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return object, nil
+}
+
+func (cr *calDAVObjectReceiver) Delete(ctx context.Context, todoID int) error {
+	if err := conn(ctx, cr.db).Where("todo_id = ?", todoID).Delete(&model.CalDAVObject{}).Error; err != nil {
+		cr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// ICalendarFeed is the repository for calendar feeds.
+type ICalendarFeed interface {
+	Create(ctx context.Context, feed *model.CalendarFeed) error
+	FindAll(ctx context.Context) ([]*model.CalendarFeed, error)
+	// FindByToken returns the feed of the token, or model.ErrNotFound.
+	FindByToken(ctx context.Context, token string) (*model.CalendarFeed, error)
+	Delete(ctx context.Context, reqParams *model.DeleteCalendarFeedRequest) error
+}
+
+type InitCalendarFeedRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type calendarFeedReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewCalendarFeed returns a new instance of the calendar feed repository.
+func NewCalendarFeed(initCalendarFeedRepository *InitCalendarFeedRepository) ICalendarFeed {
+	return &calendarFeedReceiver{
+		log: initCalendarFeedRepository.Log,
+		db:  initCalendarFeedRepository.Db,
+	}
+}
+
+func (cr *calendarFeedReceiver) Create(ctx context.Context, feed *model.CalendarFeed) error {
+	if err := conn(ctx, cr.db).Create(feed).Error; err != nil {
+		cr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (cr *calendarFeedReceiver) FindAll(ctx context.Context) ([]*model.CalendarFeed, error) {
+	var feeds []*model.CalendarFeed
+	if err := conn(ctx, cr.db).Order("id").Find(&feeds).Error; err != nil {
+		cr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return feeds, nil
+}
+
+func (cr *calendarFeedReceiver) FindByToken(ctx context.Context, token string) (*model.CalendarFeed, error) {
+	var feed *model.CalendarFeed
+	err := conn(ctx, cr.db).Where("token = ?", token).Take(&feed).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		cr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (cr *calendarFeedReceiver) Delete(ctx context.Context, reqParams *model.DeleteCalendarFeedRequest) error {
+	result := conn(ctx, cr.db).Where("id = ?", reqParams.ID).Delete(&model.CalendarFeed{})
+	if result.Error != nil {
+		cr.log.Error(ctx, result.Error.Error())
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
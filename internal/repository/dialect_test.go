@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// dialectDSNs are the environment variables enabling the tests against a
+// database server. Its migrations are reverted before each test.
+var dialectDSNs = map[string]string{
+	model.DriverPostgres: "TEST_POSTGRES_DSN",
+	model.DriverMySQL:    "TEST_MYSQL_DSN",
+}
+
+// testDialects returns a function opening an empty, migrated database for
+// SQLite, and for each database server whose DSN is set.
+func testDialects() map[string]func(t *testing.T) *gorm.DB {
+	dialects := map[string]func(t *testing.T) *gorm.DB{
+		model.DriverSQLite: func(t *testing.T) *gorm.DB {
+			dbInstance, err := db.New(filepath.Join(t.TempDir(), "todos.db"))
+			require.NoError(t, err)
+			require.NoError(t, db.Migrate(dbInstance))
+			return dbInstance
+		},
+	}
+	for driver, env := range dialectDSNs {
+		dsn := os.Getenv(env)
+		if dsn == "" {
+			continue
+		}
+		driver := driver
+		dialects[driver] = func(t *testing.T) *gorm.DB {
+			dbInstance, err := db.Open(driver, dsn)
+			require.NoError(t, err)
+			_, err = db.MigrateTo(dbInstance, 0)
+			require.NoError(t, err)
+			require.NoError(t, db.Migrate(dbInstance))
+			t.Cleanup(func() { _ = db.Close(dbInstance) })
+			return dbInstance
+		}
+	}
+	return dialects
+}
+
+// TestDialects covers the queries whose behavior differs between databases.
+func TestDialects(t *testing.T) {
+	ctx := context.Background()
+
+	for dialect, newDB := range testDialects() {
+		newDB := newDB
+		t.Run(dialect, func(t *testing.T) {
+			t.Run("case_insensitive_search", func(t *testing.T) {
+				repo := NewTodo(&InitTodoRepository{Db: newDB(t), Log: log.New()})
+				todo := &model.Todo{Task: "Call Alice", Priority: model.TP_Low, Status: model.Created}
+				todo.SetDescription("About the *Budget*")
+				require.NoError(t, repo.Create(ctx, todo))
+
+				for _, search := range []string{"alice", "CALL", "budget"} {
+					found, err := repo.FindAll(ctx, &model.FindAllRequest{Task: search})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"Call Alice"}, tasks(found), search)
+				}
+			})
+
+			t.Run("ranks_compare_bytes", func(t *testing.T) {
+				repo := NewTodo(&InitTodoRepository{Db: newDB(t), Log: log.New()})
+				for _, r := range []string{"a0", "a", "0z", "b"} {
+					createTodo(ctx, t, repo, r, model.TP_Low, model.Created, r)
+				}
+
+				manual, err := repo.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+				require.NoError(t, err)
+				assert.Equal(t, []string{"0z", "a", "a0", "b"}, tasks(manual))
+				last, err := repo.LastRank(ctx, model.Created)
+				require.NoError(t, err)
+				assert.Equal(t, "b", last)
+			})
+
+			t.Run("one_running_timer", func(t *testing.T) {
+				dbInstance := newDB(t)
+				repo := NewTimeEntry(&InitTimeEntryRepository{Db: dbInstance, Log: log.New()})
+				now := time.Now().UTC()
+				ended := now.Add(time.Minute)
+
+				require.NoError(t, repo.Create(ctx, &model.TimeEntry{TodoID: 1, User: "alice", StartedAt: now, EndedAt: &ended}))
+				require.NoError(t, repo.Create(ctx, &model.TimeEntry{TodoID: 1, User: "alice", StartedAt: now, EndedAt: &ended}))
+				require.NoError(t, repo.Create(ctx, &model.TimeEntry{TodoID: 1, User: "alice", StartedAt: now}))
+				require.NoError(t, repo.Create(ctx, &model.TimeEntry{TodoID: 1, User: "bob", StartedAt: now}))
+				assert.Error(t, repo.Create(ctx, &model.TimeEntry{TodoID: 2, User: "alice", StartedAt: now}))
+
+				running, err := repo.FindRunning(ctx, "alice")
+				require.NoError(t, err)
+				assert.Nil(t, running.EndedAt)
+			})
+
+			t.Run("deliveries_queued_once", func(t *testing.T) {
+				repo := NewWebhook(&InitWebhookRepository{Db: newDB(t), Log: log.New()})
+				webhook := &model.Webhook{URL: "http://example.com", Events: []string{model.EventTodoCreated}}
+				require.NoError(t, repo.Create(ctx, webhook))
+
+				delivery := func() []*model.WebhookDelivery {
+					return []*model.WebhookDelivery{{
+						WebhookID: webhook.ID, EventID: 7, EventType: model.EventTodoCreated,
+						Status: model.DeliveryPending, NextAttemptAt: time.Now().UTC(),
+					}}
+				}
+				require.NoError(t, repo.CreateDeliveries(ctx, delivery()))
+				require.NoError(t, repo.CreateDeliveries(ctx, delivery()))
+
+				deliveries, err := repo.FindDeliveries(ctx, &model.FindDeliveriesRequest{WebhookID: webhook.ID})
+				require.NoError(t, err)
+				assert.Len(t, deliveries, 1)
+			})
+
+			t.Run("claim_pending_events", func(t *testing.T) {
+				dbInstance := newDB(t)
+				todoRepository := NewTodo(&InitTodoRepository{Db: dbInstance, Log: log.New()})
+				eventRepository := NewEvent(&InitEventRepository{Db: dbInstance, Log: log.New()})
+				for _, task := range []string{"A", "B"} {
+					event, err := model.NewEvent(model.EventTodoCreated, &model.Todo{Task: task})
+					require.NoError(t, err)
+					require.NoError(t, todoRepository.CreateEvent(ctx, event))
+				}
+
+				now := time.Now().UTC().Add(time.Second)
+				claimed, err := eventRepository.ClaimPending(ctx, now, time.Minute, 10)
+				require.NoError(t, err)
+				require.Len(t, claimed, 2)
+				// Claimed events are not due again until the lease ends
+				again, err := eventRepository.ClaimPending(ctx, now, time.Minute, 10)
+				require.NoError(t, err)
+				assert.Empty(t, again)
+
+				dispatched := now
+				claimed[0].DispatchedAt = &dispatched
+				require.NoError(t, eventRepository.UpdateDispatch(ctx, claimed[0]))
+				deleted, err := eventRepository.DeleteDispatchedBefore(ctx, now.Add(time.Hour))
+				require.NoError(t, err)
+				assert.EqualValues(t, 1, deleted)
+			})
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IEvent is the repository for the event log, which is also the outbox.
+// Events are created along with their change, see ITodo.CreateEvent.
+type IEvent interface {
+	// FindAfter returns up to limit events with an ID greater than afterID, in order.
+	FindAfter(ctx context.Context, afterID uint, limit int) ([]*model.Event, error)
+	// ClaimPending returns up to limit pending events due at now, in order, and
+	// moves them ahead by lease, so that no other dispatcher takes them meanwhile.
+	// The events come with the sinks that handled them already.
+	ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Event, error)
+	// UpdateDispatch stores the outcome of dispatching event, along with the
+	// sinks that handled it while it is pending.
+	UpdateDispatch(ctx context.Context, event *model.Event) error
+	// DeleteDispatchedBefore deletes the dispatched events created before t.
+	DeleteDispatchedBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type InitEventRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type eventReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewEvent returns a new instance of the event repository.
+func NewEvent(initEventRepository *InitEventRepository) IEvent {
+	return &eventReceiver{
+		log: initEventRepository.Log,
+		db:  initEventRepository.Db,
+	}
+}
+
+func (er *eventReceiver) FindAfter(ctx context.Context, afterID uint, limit int) ([]*model.Event, error) {
+	var events []*model.Event
+	err := er.db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	if err != nil {
+		er.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (er *eventReceiver) ClaimPending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Event, error) {
+	var due []*model.Event
+	err := er.db.Where("dispatched_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").Limit(limit).Find(&due).Error
+	if err != nil {
+		er.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	claimed := make([]*model.Event, 0, len(due))
+	for _, event := range due {
+		// The due condition is checked again, so an event another dispatcher
+		// claimed in between is skipped.
+		result := er.db.Model(&model.Event{}).
+			Where("id = ? AND dispatched_at IS NULL AND next_attempt_at <= ?", event.ID, now).
+			Update("next_attempt_at", now.Add(lease))
+		if result.Error != nil {
+			er.log.Error(ctx, result.Error.Error())
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			event.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, event)
+		}
+	}
+	if len(claimed) == 0 {
+		return claimed, nil
+	}
+
+	ids := make([]uint, 0, len(claimed))
+	byID := make(map[uint]*model.Event, len(claimed))
+	for _, event := range claimed {
+		ids = append(ids, event.ID)
+		byID[event.ID] = event
+	}
+	var handled []*model.EventSink
+	if err := er.db.Where("event_id IN ?", ids).Order("event_id, sink").Find(&handled).Error; err != nil {
+		er.log.Error(ctx, err.Error())
+		return nil, err
+	}
+	for _, record := range handled {
+		byID[record.EventID].HandledBy = append(byID[record.EventID].HandledBy, record.Sink)
+	}
+
+	return claimed, nil
+}
+
+func (er *eventReceiver) UpdateDispatch(ctx context.Context, event *model.Event) error {
+	err := er.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(event).Select("DispatchedAt", "NextAttemptAt", "Attempts", "Error").Updates(event).Error
+		if err != nil {
+			return err
+		}
+		if event.DispatchedAt != nil {
+			return tx.Where("event_id = ?", event.ID).Delete(&model.EventSink{}).Error
+		}
+		if len(event.HandledBy) == 0 {
+			return nil
+		}
+
+		handled := make([]*model.EventSink, 0, len(event.HandledBy))
+		for _, sink := range event.HandledBy {
+			handled = append(handled, &model.EventSink{EventID: event.ID, Sink: sink})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&handled).Error
+	})
+	if err != nil {
+		er.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (er *eventReceiver) DeleteDispatchedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := er.db.Where("dispatched_at IS NOT NULL AND created_at < ?", t).Delete(&model.Event{})
+	if result.Error != nil {
+		er.log.Error(ctx, result.Error.Error())
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
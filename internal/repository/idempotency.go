@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IIdempotency is the repository for idempotency records.
+type IIdempotency interface {
+	// Reserve stores record unless its key is already taken by a record that has
+	// not expired, in which case that record is returned instead.
+	Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error)
+	// Complete stores the response and the expiry of a reserved record. It
+	// returns model.ErrNotFound when the reservation was taken over meanwhile.
+	Complete(ctx context.Context, record *model.IdempotencyRecord) error
+	// Release deletes a reservation so the key can be used again, unless it was
+	// taken over meanwhile.
+	Release(ctx context.Context, record *model.IdempotencyRecord) error
+	// DeleteExpired deletes the records that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type InitIdempotencyRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type idempotencyReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewIdempotency returns a new instance of the idempotency repository.
+func NewIdempotency(initIdempotencyRepository *InitIdempotencyRepository) IIdempotency {
+	return &idempotencyReceiver{
+		log: initIdempotencyRepository.Log,
+		db:  initIdempotencyRepository.Db,
+	}
+}
+
+func (ir *idempotencyReceiver) Reserve(ctx context.Context, record *model.IdempotencyRecord) (*model.IdempotencyRecord, error) {
+	// The second attempt runs after an expired record was removed.
+	for attempt := 0; attempt < 2; attempt++ {
+		result := ir.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			ir.log.Error(ctx, result.Error.Error())
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return nil, nil
+		}
+
+		var existing *model.IdempotencyRecord
+		err := ir.db.Where("idempotency_key = ?", record.Key).Take(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			ir.log.Error(ctx, err.Error())
+			return nil, err
+		}
+		if existing.ExpiresAt.After(record.CreatedAt) {
+			return existing, nil
+		}
+
+		err = ir.db.Where("idempotency_key = ? AND expires_at = ?", existing.Key, existing.ExpiresAt).
+			Delete(&model.IdempotencyRecord{}).Error
+		if err != nil {
+			ir.log.Error(ctx, err.Error())
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("idempotency key is contended")
+}
+
+func (ir *idempotencyReceiver) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	// The creation time tells the reservation apart from one that took it over.
+	result := ir.db.Model(&model.IdempotencyRecord{}).
+		Where("idempotency_key = ? AND created_at = ?", record.Key, record.CreatedAt).
+		Updates(map[string]interface{}{
+			"status_code":  record.StatusCode,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+			"expires_at":   record.ExpiresAt,
+		})
+	if result.Error != nil {
+		ir.log.Error(ctx, result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+func (ir *idempotencyReceiver) Release(ctx context.Context, record *model.IdempotencyRecord) error {
+	err := ir.db.Where("idempotency_key = ? AND created_at = ?", record.Key, record.CreatedAt).
+		Delete(&model.IdempotencyRecord{}).Error
+	if err != nil {
+		ir.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (ir *idempotencyReceiver) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := ir.db.Where("expires_at < ?", now).Delete(&model.IdempotencyRecord{})
+	if result.Error != nil {
+		ir.log.Error(ctx, result.Error.Error())
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// ITimeEntry is the repository for time entries.
+type ITimeEntry interface {
+	Create(ctx context.Context, entry *model.TimeEntry) error
+	Update(ctx context.Context, entry *model.TimeEntry) error
+	Delete(ctx context.Context, reqParams *model.DeleteTimeEntryRequest) error
+	FindAll(ctx context.Context, reqParams *model.FindAllTimeEntriesRequest) ([]*model.TimeEntry, error)
+	// FindRunning returns the running timer of the user, or model.ErrNotFound.
+	FindRunning(ctx context.Context, user string) (*model.TimeEntry, error)
+	// FindBetween returns the entries that overlap [from, to), running ones
+	// included. A nil bound is open.
+	FindBetween(ctx context.Context, from, to *time.Time) ([]*model.TimeEntry, error)
+}
+
+type InitTimeEntryRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type timeEntryReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewTimeEntry returns a new instance of the time entry repository.
+func NewTimeEntry(initTimeEntryRepository *InitTimeEntryRepository) ITimeEntry {
+	return &timeEntryReceiver{
+		log: initTimeEntryRepository.Log,
+		db:  initTimeEntryRepository.Db,
+	}
+}
+
+func (tr *timeEntryReceiver) Create(ctx context.Context, entry *model.TimeEntry) error {
+	if err := conn(ctx, tr.db).Create(entry).Error; err != nil {
+		tr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (tr *timeEntryReceiver) Update(ctx context.Context, entry *model.TimeEntry) error {
+	if err := conn(ctx, tr.db).Save(entry).Error; err != nil {
+		tr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (tr *timeEntryReceiver) Delete(ctx context.Context, reqParams *model.DeleteTimeEntryRequest) error {
+	result := conn(ctx, tr.db).Where("id = ? AND todo_id = ?", reqParams.EntryID, reqParams.TodoID).
+		Delete(&model.TimeEntry{})
+	if result.Error != nil {
+		tr.log.Error(ctx, result.Error.Error())
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr *timeEntryReceiver) FindAll(ctx context.Context, reqParams *model.FindAllTimeEntriesRequest) ([]*model.TimeEntry, error) {
+	entries := []*model.TimeEntry{}
+	err := conn(ctx, tr.db).Where("todo_id = ?", reqParams.TodoID).
+		Order("started_at ASC").
+		Find(&entries).Error
+	if err != nil {
+		tr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (tr *timeEntryReceiver) FindRunning(ctx context.Context, user string) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	err := conn(ctx, tr.db).Where("user_name = ? AND ended_at IS NULL", user).
+		Take(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		tr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (tr *timeEntryReceiver) FindBetween(ctx context.Context, from, to *time.Time) ([]*model.TimeEntry, error) {
+	entries := []*model.TimeEntry{}
+
+	query := conn(ctx, tr.db).Model(&model.TimeEntry{})
+	if from != nil {
+		query = query.Where("ended_at IS NULL OR ended_at > ?", *from)
+	}
+	if to != nil {
+		query = query.Where("started_at < ?", *to)
+	}
+
+	if err := query.Order("started_at ASC").Find(&entries).Error; err != nil {
+		tr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+
 	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
 	"gorm.io/gorm"
@@ -17,6 +19,20 @@ type ITodo interface {
 	Update(ctx context.Context, todo *model.Todo) error
 	Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error)
 	FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error)
+	// CountByStatus returns the number of todos in the status column.
+	CountByStatus(ctx context.Context, status model.Status) (int64, error)
+	// LastRank returns the largest rank in the status column, or "" when it has none.
+	LastRank(ctx context.Context, status model.Status) (string, error)
+	// Move saves the moved todo and the new ranks of the rebalanced todos in one transaction.
+	Move(ctx context.Context, todo *model.Todo, rebalanced []*model.Todo) error
+	// CreateEvent writes event to the outbox. Within WithTx it is
+	// committed or rolled back along with the change it records.
+	CreateEvent(ctx context.Context, event *model.Event) error
+	// WithTx runs fn in a transaction of the todo database, as the package
+	// function WithTx: the calls made with the context passed to fn, to this
+	// repository or another of the same database, are committed together
+	// when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type InitTodoRepository struct {
@@ -38,7 +54,7 @@ func NewTodo(initTodoRepository *InitTodoRepository) ITodo {
 }
 
 func (td *todoReceiver) Create(ctx context.Context, todo *model.Todo) error {
-	if err := td.db.Create(todo).Error; err != nil {
+	if err := conn(ctx, td.db).Create(todo).Error; err != nil {
 		td.log.Error(ctx, err.Error())
 		return err
 	}
@@ -47,7 +63,7 @@ func (td *todoReceiver) Create(ctx context.Context, todo *model.Todo) error {
 }
 
 func (td *todoReceiver) Update(ctx context.Context, todo *model.Todo) error {
-	if err := td.db.Save(todo).Error; err != nil {
+	if err := conn(ctx, td.db).Save(todo).Error; err != nil {
 		td.log.Error(ctx, err.Error())
 		return err
 	}
@@ -56,7 +72,7 @@ func (td *todoReceiver) Update(ctx context.Context, todo *model.Todo) error {
 }
 
 func (td *todoReceiver) Delete(ctx context.Context, reqParams *model.DeleteRequest) error {
-	result := td.db.Where("id = ?", reqParams.ID).
+	result := conn(ctx, td.db).Where("id = ?", reqParams.ID).
 		Delete(&model.Todo{})
 	if result.RowsAffected == 0 {
 		td.log.Error(ctx, model.ErrNotFound.Error())
@@ -74,7 +90,7 @@ func (td *todoReceiver) Delete(ctx context.Context, reqParams *model.DeleteReque
 
 func (td *todoReceiver) Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error) {
 	var todo *model.Todo
-	err := td.db.Where("id = ?", reqParams.ID).
+	err := conn(ctx, td.db).Where("id = ?", reqParams.ID).
 		Take(&todo).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -91,11 +107,13 @@ func (td *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllReq
 	var todos []*model.Todo
 
 	// Build the base query
-	query := td.db.Model(&model.Todo{})
+	query := conn(ctx, td.db).Model(&model.Todo{})
 
-	// Filter by task name using LIKE for substring search (if provided)
+	// Filter by task name or description text using LIKE for substring search (if provided).
+	// Both sides are lowercased, as LIKE is case-sensitive in PostgreSQL.
 	if reqParams.Task != "" {
-		query = query.Where("task LIKE ?", "%"+reqParams.Task+"%")
+		pattern := "%" + strings.ToLower(reqParams.Task) + "%"
+		query = query.Where("LOWER(task) LIKE ? OR LOWER(description_text) LIKE ?", pattern, pattern)
 	}
 
 	// Optional filtering by status (if provided)
@@ -103,19 +121,51 @@ func (td *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllReq
 		query = query.Where("status = ?", reqParams.Status)
 	}
 
+	// Optional filtering by project (if provided)
+	if reqParams.Project != "" {
+		query = query.Where("project = ?", reqParams.Project)
+	}
+
+	// Optional filtering by assignee (if provided)
+	if reqParams.Assignee != "" {
+		query = query.Where("assignee = ?", reqParams.Assignee)
+	}
+
+	if reqParams.Sort == model.SortManual {
+		// Status columns in board order, then the hand-made rank within each column.
+		query = query.Order(`
+			CASE status
+				WHEN 'created' THEN 1
+				WHEN 'processing' THEN 2
+				WHEN 'done' THEN 3
+				ELSE 4
+			END ASC`).
+			Order("sort_rank ASC").
+			Order("id ASC")
+		if err := query.Find(&todos).Error; err != nil {
+			td.log.Error(ctx, err.Error())
+			return nil, err
+		}
+		return todos, nil
+	}
+
 	// Ordering logic:
-	// 1. Incomplete tasks (status != 'done') come first.
+	// 1. Incomplete tasks (status <> 'done') come first.
 	// 2. Sort incomplete tasks by priority (high > medium > low).
 	// 3. Sort incomplete tasks by created_at in descending order.
 	// 4. "Done" tasks should be sorted by updated_at in ascending order.
+	// 5. Ties are broken by id, so that every database returns the same order.
+	// The keys of one group are NULL for the other; as the first key already
+	// separates the groups, NULL only ever ties with NULL, and where databases
+	// sort NULL does not matter.
 	query = query.Order(`
-			CASE 
-				WHEN status != 'done' THEN 0
+			CASE
+				WHEN status <> 'done' THEN 0
 				ELSE 1
 			END ASC,
-		
-			CASE 
-				WHEN status != 'done' THEN
+
+			CASE
+				WHEN status <> 'done' THEN
 					CASE
 						WHEN priority = 'high' THEN 1
 						WHEN priority = 'medium' THEN 2
@@ -124,16 +174,18 @@ func (td *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllReq
 					END
 				ELSE NULL
 			END ASC,
-		
-			CASE 
-				WHEN status != 'done' THEN created_at
+
+			CASE
+				WHEN status <> 'done' THEN created_at
 				ELSE NULL
 			END DESC,
-		
-			CASE 
+
+			CASE
 				WHEN status = 'done' THEN updated_at
 				ELSE NULL
-			END ASC
+			END ASC,
+
+			id ASC
 		`)
 
 	// Execute the query to retrieve sorted tasks
@@ -145,3 +197,69 @@ func (td *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllReq
 
 	return todos, nil
 }
+
+func (td *todoReceiver) CountByStatus(ctx context.Context, status model.Status) (int64, error) {
+	var count int64
+	err := conn(ctx, td.db).Model(&model.Todo{}).
+		Where("status = ?", status).
+		Count(&count).Error
+	if err != nil {
+		td.log.Error(ctx, err.Error())
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (td *todoReceiver) LastRank(ctx context.Context, status model.Status) (string, error) {
+	var last *string
+	err := conn(ctx, td.db).Model(&model.Todo{}).
+		Where("status = ?", status).
+		Select("MAX(sort_rank)").
+		Scan(&last).Error
+	if err != nil {
+		td.log.Error(ctx, err.Error())
+		return "", err
+	}
+
+	if last == nil {
+		return "", nil
+	}
+	return *last, nil
+}
+
+func (td *todoReceiver) Move(ctx context.Context, todo *model.Todo, rebalanced []*model.Todo) error {
+	err := conn(ctx, td.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(todo).Error; err != nil {
+			return err
+		}
+
+		// Rebalancing is not an edit of the other todos, so their UpdatedAt is left alone.
+		for _, other := range rebalanced {
+			if err := tx.Model(other).UpdateColumn("sort_rank", other.Rank).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		td.log.Error(ctx, err.Error())
+		return err
+	}
+
+	td.log.Info(ctx, fmt.Sprintf("Moved todo with id: %d to %s/%s", todo.ID, todo.Status, todo.Rank))
+	return nil
+}
+
+func (td *todoReceiver) CreateEvent(ctx context.Context, event *model.Event) error {
+	if err := conn(ctx, td.db).Create(event).Error; err != nil {
+		td.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (td *todoReceiver) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithTx(ctx, td.db, fn)
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// todoRepositories are the implementations of ITodo the conformance suite runs against.
+var todoRepositories = map[string]func(dbInstance *gorm.DB) ITodo{
+	"crud": func(dbInstance *gorm.DB) ITodo {
+		return NewTodo(&InitTodoRepository{Db: dbInstance, Log: log.New()})
+	},
+	"eventsourced": func(dbInstance *gorm.DB) ITodo {
+		return NewEventSourcedTodo(&InitEventSourcedTodoRepository{Db: dbInstance, Log: log.New(), SnapshotInterval: 3})
+	},
+}
+
+func createTodo(ctx context.Context, t *testing.T, repo ITodo, task string, priority model.TodoPriority, status model.Status, rank string) *model.Todo {
+	t.Helper()
+	todo := &model.Todo{Task: task, Priority: priority, Status: status, Rank: rank}
+	require.NoError(t, repo.Create(ctx, todo))
+	return todo
+}
+
+func tasks(todos []*model.Todo) []string {
+	names := make([]string, 0, len(todos))
+	for _, todo := range todos {
+		names = append(names, todo.Task)
+	}
+	return names
+}
+
+// TestTodoConformance runs the same expectations against every implementation
+// of ITodo, on every database of testDialects.
+func TestTodoConformance(t *testing.T) {
+	ctx := context.Background()
+
+	for dialect, newTestDB := range testDialects() {
+		for name, newRepo := range todoRepositories {
+			newTestDB, newRepo := newTestDB, newRepo
+			t.Run(dialect+"/"+name, func(t *testing.T) {
+				t.Run("create_find_update_delete", func(t *testing.T) {
+					repo := newRepo(newTestDB(t))
+					todo := &model.Todo{Task: "Write", Priority: model.TP_High, Status: model.Created, Tags: []string{"docs"}}
+					todo.SetDescription("**Draft** the guide")
+					require.NoError(t, repo.Create(ctx, todo))
+					assert.NotZero(t, todo.ID)
+					assert.False(t, todo.CreatedAt.IsZero())
+
+					found, err := repo.Find(ctx, &model.FindRequest{ID: todo.ID})
+					require.NoError(t, err)
+					assert.Equal(t, "Write", found.Task)
+					assert.Equal(t, []string{"docs"}, found.Tags)
+					assert.Equal(t, "Draft the guide", found.DescriptionText)
+
+					found.Task, found.Status, found.Tags = "Rewrite", model.Processing, nil
+					require.NoError(t, repo.Update(ctx, found))
+					updated, err := repo.Find(ctx, &model.FindRequest{ID: todo.ID})
+					require.NoError(t, err)
+					assert.Equal(t, "Rewrite", updated.Task)
+					assert.Equal(t, model.Processing, updated.Status)
+					assert.Empty(t, updated.Tags)
+					assert.Equal(t, "Draft the guide", updated.DescriptionText)
+
+					require.NoError(t, repo.Delete(ctx, &model.DeleteRequest{ID: todo.ID}))
+					_, err = repo.Find(ctx, &model.FindRequest{ID: todo.ID})
+					assert.ErrorIs(t, err, model.ErrNotFound)
+					assert.ErrorIs(t, repo.Delete(ctx, &model.DeleteRequest{ID: todo.ID}), model.ErrNotFound)
+				})
+
+				t.Run("find_all", func(t *testing.T) {
+					repo := newRepo(newTestDB(t))
+					createTodo(ctx, t, repo, "Low", model.TP_Low, model.Created, "a")
+					createTodo(ctx, t, repo, "Finished", model.TP_High, model.Done, "a")
+					high := createTodo(ctx, t, repo, "High", model.TP_High, model.Processing, "a")
+					high.Project = "web"
+					high.SetDescription("needs a *review*")
+					require.NoError(t, repo.Update(ctx, high))
+
+					all, err := repo.FindAll(ctx, &model.FindAllRequest{})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"High", "Low", "Finished"}, tasks(all))
+
+					manual, err := repo.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"Low", "High", "Finished"}, tasks(manual))
+
+					byText, err := repo.FindAll(ctx, &model.FindAllRequest{Task: "review"})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"High"}, tasks(byText))
+					byStatus, err := repo.FindAll(ctx, &model.FindAllRequest{Status: string(model.Done)})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"Finished"}, tasks(byStatus))
+					byProject, err := repo.FindAll(ctx, &model.FindAllRequest{Project: "web"})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"High"}, tasks(byProject))
+				})
+
+				t.Run("ranks_and_move", func(t *testing.T) {
+					repo := newRepo(newTestDB(t))
+					last, err := repo.LastRank(ctx, model.Created)
+					require.NoError(t, err)
+					assert.Empty(t, last)
+
+					a := createTodo(ctx, t, repo, "A", model.TP_Low, model.Created, "b")
+					b := createTodo(ctx, t, repo, "B", model.TP_Low, model.Created, "c")
+					c := createTodo(ctx, t, repo, "C", model.TP_Low, model.Processing, "b")
+					count, err := repo.CountByStatus(ctx, model.Created)
+					require.NoError(t, err)
+					assert.EqualValues(t, 2, count)
+					last, err = repo.LastRank(ctx, model.Created)
+					require.NoError(t, err)
+					assert.Equal(t, "c", last)
+
+					// C moves to the top of created, pushing A and B down
+					bUpdatedAt := b.UpdatedAt
+					c.Status, c.Rank = model.Created, "b"
+					a.Rank, b.Rank = "c", "d"
+					require.NoError(t, repo.Move(ctx, c, []*model.Todo{a, b}))
+
+					manual, err := repo.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"C", "A", "B"}, tasks(manual))
+					movedB, err := repo.Find(ctx, &model.FindRequest{ID: b.ID})
+					require.NoError(t, err)
+					assert.Equal(t, "d", movedB.Rank)
+					assert.True(t, bUpdatedAt.Equal(movedB.UpdatedAt), "rebalancing does not touch UpdatedAt")
+					count, err = repo.CountByStatus(ctx, model.Processing)
+					require.NoError(t, err)
+					assert.Zero(t, count)
+				})
+
+				t.Run("transaction", func(t *testing.T) {
+					dbInstance := newTestDB(t)
+					repo := newRepo(dbInstance)
+					failure := errors.New("failure")
+
+					err := repo.WithTx(ctx, func(ctx context.Context) error {
+						todo := createTodo(ctx, t, repo, "Rolled back", model.TP_Low, model.Created, "a")
+						event, err := model.NewEvent(model.EventTodoCreated, todo)
+						require.NoError(t, err)
+						require.NoError(t, repo.CreateEvent(ctx, event))
+						return failure
+					})
+					assert.ErrorIs(t, err, failure)
+
+					err = repo.WithTx(ctx, func(ctx context.Context) error {
+						createTodo(ctx, t, repo, "Kept", model.TP_Low, model.Created, "a")
+						// A nested transaction is a savepoint: only its own changes are undone.
+						assert.ErrorIs(t, repo.WithTx(ctx, func(ctx context.Context) error {
+							createTodo(ctx, t, repo, "Undone", model.TP_Low, model.Created, "b")
+							return failure
+						}), failure)
+						return nil
+					})
+					require.NoError(t, err)
+
+					// The other repositories of the database join the transaction of the context.
+					timeEntries := NewTimeEntry(&InitTimeEntryRepository{Db: dbInstance, Log: log.New()})
+					err = repo.WithTx(ctx, func(ctx context.Context) error {
+						todo := createTodo(ctx, t, repo, "Timed", model.TP_Low, model.Created, "c")
+						found, err := repo.Find(ctx, &model.FindRequest{ID: todo.ID})
+						require.NoError(t, err, "reads see the changes of the transaction")
+						require.NoError(t, timeEntries.Create(ctx, &model.TimeEntry{TodoID: found.ID, User: "alice", StartedAt: time.Now()}))
+						return failure
+					})
+					assert.ErrorIs(t, err, failure)
+					var entries int64
+					require.NoError(t, dbInstance.Model(&model.TimeEntry{}).Count(&entries).Error)
+					assert.Zero(t, entries)
+
+					all, err := repo.FindAll(ctx, &model.FindAllRequest{})
+					require.NoError(t, err)
+					assert.Equal(t, []string{"Kept"}, tasks(all))
+					var events int64
+					require.NoError(t, dbInstance.Model(&model.Event{}).Count(&events).Error)
+					assert.Zero(t, events)
+				})
+			})
+		}
+	}
+}
+
+// normalized returns todos with their times in UTC, for comparing reads.
+func normalized(todos []*model.Todo) []*model.Todo {
+	for _, todo := range todos {
+		todo.CreatedAt, todo.UpdatedAt = todo.CreatedAt.UTC(), todo.UpdatedAt.UTC()
+	}
+	return todos
+}
+
+func TestEventSourcedTodo_Replay(t *testing.T) {
+	for dialect, newTestDB := range testDialects() {
+		newTestDB := newTestDB
+		t.Run(dialect, func(t *testing.T) {
+			testReplay(t, newTestDB(t))
+		})
+	}
+}
+
+func testReplay(t *testing.T, dbInstance *gorm.DB) {
+	ctx := context.Background()
+	repo := NewEventSourcedTodo(&InitEventSourcedTodoRepository{Db: dbInstance, Log: log.New(), SnapshotInterval: 4})
+
+	a := createTodo(ctx, t, repo, "A", model.TP_Low, model.Created, "b")
+	b := createTodo(ctx, t, repo, "B", model.TP_High, model.Created, "c")
+	c := createTodo(ctx, t, repo, "C", model.TP_Medium, model.Created, "d")
+	a.Task, a.Tags, a.DueAt = "A2", []string{"x"}, &time.Time{}
+	require.NoError(t, repo.Update(ctx, a))
+	c.Status, c.Rank, b.Rank = model.Done, "b", "e"
+	require.NoError(t, repo.Move(ctx, c, []*model.Todo{b}))
+	require.NoError(t, repo.Delete(ctx, &model.DeleteRequest{ID: a.ID}))
+	// A rolled back change leaves no event
+	assert.Error(t, repo.WithTx(ctx, func(ctx context.Context) error {
+		createTodo(ctx, t, repo, "Rolled back", model.TP_Low, model.Created, "z")
+		return errors.New("failure")
+	}))
+
+	before, err := repo.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+	require.NoError(t, err)
+	require.Equal(t, []string{"B", "C"}, tasks(before))
+
+	var versions []int
+	require.NoError(t, dbInstance.Model(&model.TodoEvent{}).Where("todo_id = ?", c.ID).Order("id").
+		Pluck("version", &versions).Error)
+	assert.Equal(t, []int{1, 2}, versions)
+	var types []string
+	require.NoError(t, dbInstance.Model(&model.TodoEvent{}).Order("id").Pluck("type", &types).Error)
+	assert.Equal(t, []string{
+		model.TodoCreated, model.TodoCreated, model.TodoCreated, model.TodoUpdated,
+		model.TodoUpdated, model.TodoUpdated, model.TodoDeleted,
+	}, types)
+
+	for _, fromScratch := range []bool{false, true} {
+		// Lose the projection, then rebuild it
+		require.NoError(t, dbInstance.Where("1 = 1").Delete(&model.Todo{}).Error)
+		res, err := repo.Replay(ctx, fromScratch)
+		require.NoError(t, err)
+		after, err := repo.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+		require.NoError(t, err)
+		assert.Equal(t, normalized(before), normalized(after))
+		assert.Equal(t, 2, res.Todos)
+
+		if fromScratch {
+			assert.Zero(t, res.SnapshotEventID)
+			assert.Equal(t, 7, res.Events)
+		} else {
+			// The snapshot at the fourth event leaves three to apply
+			assert.EqualValues(t, 4, res.SnapshotEventID)
+			assert.Equal(t, 3, res.Events)
+		}
+	}
+
+	t.Run("baseline", func(t *testing.T) {
+		// Stored without events, as before event sourcing was enabled
+		untracked := createTodo(ctx, t, NewTodo(&InitTodoRepository{Db: dbInstance, Log: log.New()}), "Old", model.TP_Low, model.Created, "f")
+
+		_, err := repo.Replay(ctx, false)
+		assert.ErrorIs(t, err, model.ErrUntrackedTodos)
+
+		n, err := repo.Baseline(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		require.NoError(t, repo.Snapshot(ctx))
+
+		res, err := repo.Replay(ctx, false)
+		require.NoError(t, err)
+		assert.Zero(t, res.Events)
+		found, err := repo.Find(ctx, &model.FindRequest{ID: untracked.ID})
+		require.NoError(t, err)
+		assert.Equal(t, "Old", found.Task)
+	})
+}
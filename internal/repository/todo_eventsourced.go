@@ -0,0 +1,412 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+)
+
+// replayPageSize is the number of events read at a time when replaying.
+const replayPageSize = 500
+
+// IEventSourcedTodo is the todo repository storing every change as a
+// model.TodoEvent. The todos table is a projection of the events, updated in
+// the transaction of each change, so reads behave as with NewTodo.
+type IEventSourcedTodo interface {
+	ITodo
+	// Replay rebuilds the todos projection from the events, starting from the
+	// latest snapshot unless fromScratch is set.
+	Replay(ctx context.Context, fromScratch bool) (*model.ReplayResult, error)
+	// Baseline records a TodoCreated event for every todo without events, such
+	// as those created before event sourcing was enabled. It returns their number.
+	Baseline(ctx context.Context) (int, error)
+	// Snapshot stores the projection as of the latest event.
+	Snapshot(ctx context.Context) error
+}
+
+type InitEventSourcedTodoRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+	// SnapshotInterval is how many events apart the projection is snapshotted.
+	// Zero disables snapshots.
+	SnapshotInterval int
+}
+
+type eventSourcedTodoReceiver struct {
+	// todoReceiver reads the projection.
+	*todoReceiver
+	snapshotInterval int
+}
+
+// NewEventSourcedTodo returns a new instance of the event-sourced todo repository.
+func NewEventSourcedTodo(initRepository *InitEventSourcedTodoRepository) IEventSourcedTodo {
+	return &eventSourcedTodoReceiver{
+		todoReceiver:     &todoReceiver{log: initRepository.Log, db: initRepository.Db},
+		snapshotInterval: initRepository.SnapshotInterval,
+	}
+}
+
+// todoState is the JSON of the stored events. It has the fields of model.Todo,
+// so that a new field does not compile until it is given a name here.
+type todoState struct {
+	ID              int                `json:"id"`
+	Task            string             `json:"task"`
+	Description     string             `json:"description"`
+	DescriptionText string             `json:"descriptionText"`
+	DescriptionHTML string             `json:"-"`
+	Status          model.Status       `json:"status"`
+	Priority        model.TodoPriority `json:"priority"`
+	Tags            []string           `json:"tags"`
+	Assignee        string             `json:"assignee"`
+	DueAt           *time.Time         `json:"dueAt"`
+	Project         string             `json:"project"`
+	EstimateMinutes int                `json:"estimateMinutes"`
+	Rank            string             `json:"rank"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+func (es *eventSourcedTodoReceiver) Create(ctx context.Context, todo *model.Todo) error {
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(todo).Error; err != nil {
+			return err
+		}
+		state := todoState(*todo)
+		return es.append(tx, todo.ID, model.TodoCreated, &state)
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (es *eventSourcedTodoReceiver) Update(ctx context.Context, todo *model.Todo) error {
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		return es.save(tx, todo)
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (es *eventSourcedTodoReceiver) Delete(ctx context.Context, reqParams *model.DeleteRequest) error {
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", reqParams.ID).Delete(&model.Todo{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrNotFound
+		}
+		return es.append(tx, reqParams.ID, model.TodoDeleted, nil)
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return err
+	}
+
+	es.log.Info(ctx, fmt.Sprintf("Deleted todo with id: %d", reqParams.ID))
+	return nil
+}
+
+func (es *eventSourcedTodoReceiver) Move(ctx context.Context, todo *model.Todo, rebalanced []*model.Todo) error {
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		if err := es.save(tx, todo); err != nil {
+			return err
+		}
+
+		// Rebalancing is not an edit of the other todos, so their UpdatedAt is left alone.
+		for _, other := range rebalanced {
+			if err := tx.Model(other).UpdateColumn("sort_rank", other.Rank).Error; err != nil {
+				return err
+			}
+			if err := es.append(tx, other.ID, model.TodoUpdated, map[string]string{"rank": other.Rank}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return err
+	}
+
+	es.log.Info(ctx, fmt.Sprintf("Moved todo with id: %d to %s/%s", todo.ID, todo.Status, todo.Rank))
+	return nil
+}
+
+// save stores todo in the projection through tx and appends the changed fields.
+func (es *eventSourcedTodoReceiver) save(tx *gorm.DB, todo *model.Todo) error {
+	var current model.Todo
+	err := tx.Where("id = ?", todo.ID).Take(&current).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Saving a todo that does not exist creates it, as with NewTodo.
+		if err := tx.Create(todo).Error; err != nil {
+			return err
+		}
+		state := todoState(*todo)
+		return es.append(tx, todo.ID, model.TodoCreated, &state)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Save(todo).Error; err != nil {
+		return err
+	}
+	before, after := todoState(current), todoState(*todo)
+	changed, err := diffStates(&before, &after)
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	return es.append(tx, todo.ID, model.TodoUpdated, changed)
+}
+
+// append stores an event of todoID through tx, with data as its JSON, and
+// snapshots the projection every snapshotInterval events.
+func (es *eventSourcedTodoReceiver) append(tx *gorm.DB, todoID int, eventType string, data interface{}) error {
+	event := &model.TodoEvent{TodoID: todoID, Type: eventType}
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		event.Data = string(b)
+	}
+
+	var version int
+	err := tx.Model(&model.TodoEvent{}).Where("todo_id = ?", todoID).
+		Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return err
+	}
+	event.Version = version + 1
+
+	if err := tx.Create(event).Error; err != nil {
+		return err
+	}
+	if es.snapshotInterval > 0 && event.ID%uint(es.snapshotInterval) == 0 {
+		return snapshot(tx, event.ID)
+	}
+	return nil
+}
+
+func (es *eventSourcedTodoReceiver) Snapshot(ctx context.Context) error {
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		var last *uint
+		if err := tx.Model(&model.TodoEvent{}).Select("MAX(id)").Scan(&last).Error; err != nil {
+			return err
+		}
+		if last == nil {
+			return nil
+		}
+
+		var existing int64
+		if err := tx.Model(&model.TodoSnapshot{}).Where("event_id = ?", *last).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+		return snapshot(tx, *last)
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// snapshot stores the projection read through tx as of eventID.
+func snapshot(tx *gorm.DB, eventID uint) error {
+	var todos []*model.Todo
+	if err := tx.Order("id").Find(&todos).Error; err != nil {
+		return err
+	}
+	states := make([]todoState, 0, len(todos))
+	for _, todo := range todos {
+		states = append(states, todoState(*todo))
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.TodoSnapshot{EventID: eventID, Data: string(data)}).Error
+}
+
+func (es *eventSourcedTodoReceiver) Baseline(ctx context.Context) (int, error) {
+	var count int
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		var untracked []*model.Todo
+		err := tx.Where("id NOT IN (?)", tx.Model(&model.TodoEvent{}).Select("todo_id")).
+			Order("id").Find(&untracked).Error
+		if err != nil {
+			return err
+		}
+
+		for _, todo := range untracked {
+			state := todoState(*todo)
+			if err := es.append(tx, todo.ID, model.TodoCreated, &state); err != nil {
+				return err
+			}
+		}
+		count = len(untracked)
+		return nil
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return 0, err
+	}
+
+	es.log.Info(ctx, fmt.Sprintf("Recorded %d todos without events", count))
+	return count, nil
+}
+
+func (es *eventSourcedTodoReceiver) Replay(ctx context.Context, fromScratch bool) (*model.ReplayResult, error) {
+	res := &model.ReplayResult{}
+	err := conn(ctx, es.db).Transaction(func(tx *gorm.DB) error {
+		var untracked int64
+		err := tx.Model(&model.Todo{}).Where("id NOT IN (?)", tx.Model(&model.TodoEvent{}).Select("todo_id")).
+			Count(&untracked).Error
+		if err != nil {
+			return err
+		}
+		if untracked > 0 {
+			return fmt.Errorf("%w: %d todos would be lost, record them with a baseline first", model.ErrUntrackedTodos, untracked)
+		}
+
+		states := map[int]*todoState{}
+		if !fromScratch {
+			var snapshots []*model.TodoSnapshot
+			if err := tx.Order("event_id desc").Limit(1).Find(&snapshots).Error; err != nil {
+				return err
+			}
+			if len(snapshots) == 1 {
+				var stored []*todoState
+				if err := json.Unmarshal([]byte(snapshots[0].Data), &stored); err != nil {
+					return fmt.Errorf("snapshot %d: %w", snapshots[0].ID, err)
+				}
+				for _, state := range stored {
+					states[state.ID] = state
+				}
+				res.SnapshotEventID = snapshots[0].EventID
+			}
+		}
+
+		after := res.SnapshotEventID
+		for {
+			var page []*model.TodoEvent
+			if err := tx.Where("id > ?", after).Order("id").Limit(replayPageSize).Find(&page).Error; err != nil {
+				return err
+			}
+			for _, event := range page {
+				if err := applyEvent(states, event); err != nil {
+					return err
+				}
+				after = event.ID
+			}
+			res.Events += len(page)
+			if len(page) < replayPageSize {
+				break
+			}
+		}
+
+		if err := tx.Where("1 = 1").Delete(&model.Todo{}).Error; err != nil {
+			return err
+		}
+		todos := make([]*model.Todo, 0, len(states))
+		for _, state := range states {
+			todo := model.Todo(*state)
+			todos = append(todos, &todo)
+		}
+		sort.Slice(todos, func(i, j int) bool { return todos[i].ID < todos[j].ID })
+		if len(todos) > 0 {
+			if err := tx.CreateInBatches(todos, 100).Error; err != nil {
+				return err
+			}
+		}
+		res.Todos = len(todos)
+
+		// PostgreSQL does not move the ID sequence past explicit IDs.
+		if tx.Dialector.Name() == model.DriverPostgres {
+			return tx.Exec("SELECT setval(pg_get_serial_sequence('todos', 'id'), COALESCE((SELECT MAX(id) FROM todos), 0) + 1, false)").Error
+		}
+		return nil
+	})
+	if err != nil {
+		es.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	es.log.Info(ctx, fmt.Sprintf("Replayed %d events into %d todos", res.Events, res.Todos))
+	return res, nil
+}
+
+// applyEvent folds event into the states of the todos by ID.
+func applyEvent(states map[int]*todoState, event *model.TodoEvent) error {
+	switch event.Type {
+	case model.TodoCreated:
+		var state todoState
+		if err := json.Unmarshal([]byte(event.Data), &state); err != nil {
+			return fmt.Errorf("event %d: %w", event.ID, err)
+		}
+		states[event.TodoID] = &state
+	case model.TodoUpdated:
+		state, ok := states[event.TodoID]
+		if !ok {
+			return fmt.Errorf("event %d updates todo %d, which does not exist", event.ID, event.TodoID)
+		}
+		// Unmarshalling only sets the fields present, the changed ones.
+		if err := json.Unmarshal([]byte(event.Data), state); err != nil {
+			return fmt.Errorf("event %d: %w", event.ID, err)
+		}
+	case model.TodoDeleted:
+		delete(states, event.TodoID)
+	default:
+		return fmt.Errorf("event %d has unknown type %q", event.ID, event.Type)
+	}
+	return nil
+}
+
+// diffStates returns the fields of after that differ from before, by JSON name.
+func diffStates(before, after *todoState) (map[string]json.RawMessage, error) {
+	var old, changed map[string]json.RawMessage
+	b, err := json.Marshal(before)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &old); err != nil {
+		return nil, err
+	}
+	a, err := json.Marshal(after)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(a, &changed); err != nil {
+		return nil, err
+	}
+
+	for name, value := range changed {
+		if bytes.Equal(old[name], value) {
+			delete(changed, name)
+		}
+	}
+	return changed, nil
+}
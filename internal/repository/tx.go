@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction started by WithTx.
+type txKey struct{}
+
+// WithTx runs fn in a transaction of db, which is committed when fn returns
+// nil and rolled back otherwise. The repositories called with the context
+// passed to fn run their statements in the transaction, whichever of them
+// started it. Within a transaction already, it nests a savepoint.
+func WithTx(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	return conn(ctx, db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction of ctx, or db outside one.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultDeliveryLimit is the number of deliveries listed when no limit is given.
+const defaultDeliveryLimit = 100
+
+// IWebhook is the repository for webhooks and their deliveries.
+type IWebhook interface {
+	Create(ctx context.Context, webhook *model.Webhook) error
+	Find(ctx context.Context, reqParams *model.FindWebhookRequest) (*model.Webhook, error)
+	FindAll(ctx context.Context) ([]*model.Webhook, error)
+	// Delete deletes the webhook along with its deliveries.
+	Delete(ctx context.Context, reqParams *model.DeleteWebhookRequest) error
+
+	// CreateDeliveries queues the deliveries, skipping those of an event
+	// already queued for the same webhook.
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	// FindDeliveries returns the deliveries of a webhook, newest first.
+	FindDeliveries(ctx context.Context, reqParams *model.FindDeliveriesRequest) ([]*model.WebhookDelivery, error)
+	// ClaimDeliveries returns up to limit pending deliveries due at now and
+	// moves them ahead by lease, so that no other worker takes them meanwhile.
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error)
+	// UpdateDelivery stores the outcome of an attempt.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+}
+
+type InitWebhookRepository struct {
+	Db  *gorm.DB
+	Log *log.Logger
+}
+
+type webhookReceiver struct {
+	log *log.Logger
+	db  *gorm.DB
+}
+
+// NewWebhook returns a new instance of the webhook repository.
+func NewWebhook(initWebhookRepository *InitWebhookRepository) IWebhook {
+	return &webhookReceiver{
+		log: initWebhookRepository.Log,
+		db:  initWebhookRepository.Db,
+	}
+}
+
+func (wr *webhookReceiver) Create(ctx context.Context, webhook *model.Webhook) error {
+	if err := wr.db.Create(webhook).Error; err != nil {
+		wr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookReceiver) Find(ctx context.Context, reqParams *model.FindWebhookRequest) (*model.Webhook, error) {
+	var webhook *model.Webhook
+	err := wr.db.Where("id = ?", reqParams.ID).Take(&webhook).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, model.ErrNotFound
+		}
+		wr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (wr *webhookReceiver) FindAll(ctx context.Context) ([]*model.Webhook, error) {
+	var webhooks []*model.Webhook
+	if err := wr.db.Order("id").Find(&webhooks).Error; err != nil {
+		wr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (wr *webhookReceiver) Delete(ctx context.Context, reqParams *model.DeleteWebhookRequest) error {
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", reqParams.ID).Delete(&model.Webhook{})
+		if result.Error != nil {
+			wr.log.Error(ctx, result.Error.Error())
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.ErrNotFound
+		}
+
+		if err := tx.Where("webhook_id = ?", reqParams.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			wr.log.Error(ctx, err.Error())
+			return err
+		}
+		return nil
+	})
+}
+
+func (wr *webhookReceiver) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := wr.db.Clauses(clause.OnConflict{DoNothing: true}).Create(deliveries).Error; err != nil {
+		wr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookReceiver) FindDeliveries(ctx context.Context, reqParams *model.FindDeliveriesRequest) ([]*model.WebhookDelivery, error) {
+	limit := reqParams.Limit
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	}
+
+	query := wr.db.Where("webhook_id = ?", reqParams.WebhookID)
+	if reqParams.Status != "" {
+		query = query.Where("status = ?", reqParams.Status)
+	}
+	var deliveries []*model.WebhookDelivery
+	if err := query.Order("id desc").Limit(limit).Find(&deliveries).Error; err != nil {
+		wr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (wr *webhookReceiver) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.WebhookDelivery, error) {
+	var due []*model.WebhookDelivery
+	err := wr.db.Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&due).Error
+	if err != nil {
+		wr.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	claimed := make([]*model.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		// The due condition is checked again, so a delivery another worker
+		// claimed in between is skipped.
+		result := wr.db.Model(&model.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", delivery.ID, model.DeliveryPending, now).
+			Update("next_attempt_at", now.Add(lease))
+		if result.Error != nil {
+			wr.log.Error(ctx, result.Error.Error())
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			delivery.NextAttemptAt = now.Add(lease)
+			claimed = append(claimed, delivery)
+		}
+	}
+
+	return claimed, nil
+}
+
+func (wr *webhookReceiver) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := wr.db.Save(delivery).Error; err != nil {
+		wr.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
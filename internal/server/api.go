@@ -4,8 +4,11 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/zuu-development/fullstack-examination-2024/internal/blob"
 	"github.com/zuu-development/fullstack-examination-2024/internal/cache"
 	"github.com/zuu-development/fullstack-examination-2024/internal/common"
 	"github.com/zuu-development/fullstack-examination-2024/internal/db"
@@ -39,20 +42,32 @@ type InitNewAPI struct {
 // NewAPI returns a new instance of the Todo API server
 func NewAPI(ctx context.Context, init *InitNewAPI) (Server, error) {
 
-	dbInstance, err := db.New(init.TodoAPIServerOpts.Config.SQLite.DBFilename)
+	dbInstance, err := db.FromConfig(&init.TodoAPIServerOpts.Config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 	redisClient := cache.New(init.TodoAPIServerOpts.Config.Redis)
+	blobStore := blob.NewLocal(&blob.InitLocalStore{
+		Dir: init.TodoAPIServerOpts.Config.Attachments.Dir,
+	})
 
 	engine := echo.New()
 	engine.HideBanner = true
 	engine.HidePort = true
+	// Event streams only end with their request context, so it is cancelled on
+	// shutdown instead of waiting for every client to disconnect. It also stops
+	// the background workers.
+	baseCtx, cancel := context.WithCancel(context.Background())
+	engine.Server.BaseContext = func(net.Listener) context.Context { return baseCtx }
+	engine.Server.RegisterOnShutdown(cancel)
 
 	handler.Register(&handler.ServiceRegistry{
+		Context:     baseCtx,
 		EchoEngine:  engine,
 		DBInstance:  dbInstance,
 		RedisClient: redisClient,
+		BlobStore:   blobStore,
+		Config:      init.TodoAPIServerOpts.Config,
 		Log:         init.Log,
 	})
 
@@ -62,11 +77,14 @@ func NewAPI(ctx context.Context, init *InitNewAPI) (Server, error) {
 	}
 	init.Log.Info(ctx, "CORS allowed origins: ", zap.Any("origins: ", allowOrigins))
 	engine.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: allowOrigins,
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
+		AllowOrigins:  allowOrigins,
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderXRequestID, handler.HeaderUser, handler.HeaderIdempotencyKey, handler.HeaderLastEventID},
+		ExposeHeaders: []string{echo.HeaderXRequestID, handler.HeaderIdempotentReplayed},
 	}))
 
+	engine.Use(middleware.RequestID())
+
 	engine.Use(requestLogger())
 
 	s := &todoAPIServer{
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/blob"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// sniffLen is the number of leading bytes http.DetectContentType looks at.
+const sniffLen = 512
+
+// IAttachment is the service for todo attachments.
+type IAttachment interface {
+	Upload(ctx context.Context, reqParams *model.UploadAttachmentRequest, r io.Reader) (*model.Attachment, error)
+	Download(ctx context.Context, reqParams *model.FindAttachmentRequest) (*model.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, reqParams *model.DeleteAttachmentRequest) error
+	FindAll(ctx context.Context, reqParams *model.AttachmentRequestPath) ([]*model.Attachment, error)
+	GarbageCollect(ctx context.Context, reqParams *model.GarbageCollectRequest) (*model.GarbageCollectResult, error)
+}
+
+type attachmentReceiver struct {
+	log                  *log.Logger
+	attachmentRepository repository.IAttachment
+	todoRepository       repository.ITodo
+	blobStore            blob.IStore
+	config               model.Attachments
+}
+
+type InitAttachmentService struct {
+	Log                  *log.Logger
+	AttachmentRepository repository.IAttachment
+	TodoRepository       repository.ITodo
+	BlobStore            blob.IStore
+	Config               model.Attachments
+}
+
+// NewAttachment creates a new Attachment service.
+func NewAttachment(initAttachmentService *InitAttachmentService) IAttachment {
+	return &attachmentReceiver{
+		log:                  initAttachmentService.Log,
+		attachmentRepository: initAttachmentService.AttachmentRepository,
+		todoRepository:       initAttachmentService.TodoRepository,
+		blobStore:            initAttachmentService.BlobStore,
+		config:               initAttachmentService.Config,
+	}
+}
+
+func (a *attachmentReceiver) Upload(ctx context.Context, reqParams *model.UploadAttachmentRequest, r io.Reader) (*model.Attachment, error) {
+	if _, err := a.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.TodoID}); err != nil {
+		a.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", reqParams.TodoID, err.Error()))
+		return nil, err
+	}
+
+	if reqParams.Size > a.config.MaxSize {
+		return nil, model.ErrAttachmentTooLarge
+	}
+
+	// Sniff the content type from the leading bytes rather than trusting the client.
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if !a.allowedType(contentType) {
+		a.log.Error(ctx, fmt.Sprintf("rejected attachment with content type: %s", contentType))
+		return nil, model.ErrAttachmentTypeNotAllowed
+	}
+
+	info, err := a.blobStore.Put(ctx, &limitedReader{
+		r:      io.MultiReader(bytes.NewReader(head), r),
+		remain: a.config.MaxSize,
+	})
+	if err != nil {
+		a.log.Error(ctx, fmt.Sprintf("failed to store attachment: %s", err.Error()))
+		return nil, err
+	}
+
+	attachment := &model.Attachment{
+		TodoID:      reqParams.TodoID,
+		Filename:    filepath.Base(reqParams.Filename),
+		ContentType: contentType,
+		Size:        info.Size,
+		Digest:      info.Digest,
+	}
+	if err := a.attachmentRepository.Create(ctx, attachment); err != nil {
+		a.log.Error(ctx, fmt.Sprintf("failed to create attachment: %s", err.Error()))
+		return nil, err
+	}
+
+	a.log.Info(ctx, fmt.Sprintf("Attachment created successfully with ID: %d", attachment.ID))
+	return attachment, nil
+}
+
+func (a *attachmentReceiver) Download(ctx context.Context, reqParams *model.FindAttachmentRequest) (*model.Attachment, io.ReadCloser, error) {
+	attachment, err := a.attachmentRepository.Find(ctx, reqParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err := a.blobStore.Get(ctx, attachment.Digest)
+	if err != nil {
+		a.log.Error(ctx, fmt.Sprintf("failed to open blob %s: %s", attachment.Digest, err.Error()))
+		if errors.Is(err, blob.ErrNotFound) {
+			return nil, nil, model.ErrNotFound
+		}
+		return nil, nil, err
+	}
+
+	return attachment, rc, nil
+}
+
+// Delete removes the attachment but not its blob. The blob may be shared with
+// other attachments or be uploaded again at the same time, so it is left for
+// GarbageCollect, which removes it once unreferenced for its grace period.
+func (a *attachmentReceiver) Delete(ctx context.Context, reqParams *model.DeleteAttachmentRequest) error {
+	if err := a.attachmentRepository.Delete(ctx, reqParams); err != nil {
+		a.log.Error(ctx, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (a *attachmentReceiver) FindAll(ctx context.Context, reqParams *model.AttachmentRequestPath) ([]*model.Attachment, error) {
+	if _, err := a.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.TodoID}); err != nil {
+		return nil, err
+	}
+
+	return a.attachmentRepository.FindAll(ctx, reqParams)
+}
+
+func (a *attachmentReceiver) GarbageCollect(ctx context.Context, reqParams *model.GarbageCollectRequest) (*model.GarbageCollectResult, error) {
+	result := &model.GarbageCollectResult{}
+
+	if !reqParams.DryRun {
+		orphans, err := a.attachmentRepository.DeleteOrphans(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result.OrphanAttachments = orphans
+	}
+
+	referenced, err := a.attachmentRepository.Digests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := a.blobStore.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-reqParams.GracePeriod)
+	for _, b := range blobs {
+		if referenced[b.Digest] || b.ModTime.After(cutoff) {
+			continue
+		}
+
+		if !reqParams.DryRun {
+			if err := a.blobStore.Delete(ctx, b.Digest); err != nil && !errors.Is(err, blob.ErrNotFound) {
+				return nil, err
+			}
+		}
+		result.Blobs++
+		result.Bytes += b.Size
+	}
+
+	a.log.Info(ctx, fmt.Sprintf("Garbage collected %d blobs (%d bytes), %d orphan attachments", result.Blobs, result.Bytes, result.OrphanAttachments))
+	return result, nil
+}
+
+func (a *attachmentReceiver) allowedType(contentType string) bool {
+	if len(a.config.AllowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range a.config.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+		// Support wildcards such as "image/*".
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitedReader fails with model.ErrAttachmentTooLarge once more than remain bytes are read.
+type limitedReader struct {
+	r      io.Reader
+	remain int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remain -= int64(n)
+	if l.remain < 0 {
+		return n, model.ErrAttachmentTooLarge
+	}
+	return n, err
+}
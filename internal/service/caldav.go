@@ -0,0 +1,281 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/exchange"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// defaultResourceName is the name of the todos that were not put by a CalDAV client.
+var defaultResourceName = regexp.MustCompile(`^todo-(\d+)\.ics$`)
+
+// ICalDAV is the service for the todos as the calendar object resources of CalDAV.
+type ICalDAV interface {
+	// Resources returns every todo as a resource, in board order.
+	Resources(ctx context.Context) ([]*model.CalDAVResource, error)
+	// Resource returns the resource of a name, or model.ErrNotFound.
+	Resource(ctx context.Context, name string) (*model.CalDAVResource, error)
+	// Put creates or replaces the todo of a resource, and tells whether it
+	// created it. A calendar that cannot be read is model.ErrInvalidImport.
+	Put(ctx context.Context, reqParams *model.PutCalDAVRequest) (*model.CalDAVResource, bool, error)
+	Delete(ctx context.Context, reqParams *model.DeleteCalDAVRequest) error
+}
+
+type InitCalDAVService struct {
+	Log         *log.Logger
+	TodoService ITodo
+	// TodoRepository runs the writes of a resource in a transaction, and reads
+	// the todo checked against the preconditions in it.
+	TodoRepository         repository.ITodo
+	RedisCache             repository.IRedisCache
+	CalDAVObjectRepository repository.ICalDAVObject
+}
+
+type calDAVReceiver struct {
+	log                    *log.Logger
+	todoService            ITodo
+	todoRepository         repository.ITodo
+	redisCache             repository.IRedisCache
+	calDAVObjectRepository repository.ICalDAVObject
+}
+
+// findTodo is the lookup of the todo of a resource: the todo service, or the
+// todo repository within a transaction.
+type findTodo func(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error)
+
+// NewCalDAV creates a new CalDAV service.
+func NewCalDAV(initCalDAVService *InitCalDAVService) ICalDAV {
+	return &calDAVReceiver{
+		log:                    initCalDAVService.Log,
+		todoService:            initCalDAVService.TodoService,
+		todoRepository:         initCalDAVService.TodoRepository,
+		redisCache:             initCalDAVService.RedisCache,
+		calDAVObjectRepository: initCalDAVService.CalDAVObjectRepository,
+	}
+}
+
+func (c *calDAVReceiver) Resources(ctx context.Context) ([]*model.CalDAVResource, error) {
+	objects, err := c.calDAVObjectRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byTodo := make(map[int]*model.CalDAVObject, len(objects))
+	for _, object := range objects {
+		byTodo[object.TodoID] = object
+	}
+
+	var resources []*model.CalDAVResource
+	err = c.todoService.Export(ctx, &model.ExportRequest{}, func(todo *model.Todo) error {
+		name, uid := fmt.Sprintf("todo-%d.ics", todo.ID), exchange.ICalUID(todo.ID)
+		if object, ok := byTodo[todo.ID]; ok {
+			name, uid = object.Name, object.UID
+		}
+		resource, err := newCalDAVResource(name, uid, todo)
+		if err != nil {
+			return err
+		}
+		resources = append(resources, resource)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+func (c *calDAVReceiver) Resource(ctx context.Context, name string) (*model.CalDAVResource, error) {
+	return c.resource(ctx, name, c.todoService.Find)
+}
+
+func (c *calDAVReceiver) resource(ctx context.Context, name string, find findTodo) (*model.CalDAVResource, error) {
+	var id int
+	uid := ""
+	object, err := c.calDAVObjectRepository.FindByName(ctx, name)
+	switch {
+	case err == nil:
+		id, uid = object.TodoID, object.UID
+	case errors.Is(err, model.ErrNotFound):
+		match := defaultResourceName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, model.ErrNotFound
+		}
+		id, _ = strconv.Atoi(match[1])
+		uid = exchange.ICalUID(id)
+		// A todo put by a client only has the name it was put as.
+		if _, err := c.calDAVObjectRepository.FindByTodo(ctx, id); err == nil {
+			return nil, model.ErrNotFound
+		} else if !errors.Is(err, model.ErrNotFound) {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	todo, err := find(ctx, &model.FindRequest{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	return newCalDAVResource(name, uid, todo)
+}
+
+func (c *calDAVReceiver) Put(ctx context.Context, reqParams *model.PutCalDAVRequest) (*model.CalDAVResource, bool, error) {
+	record, uid, err := exchange.ReadICalObject(bytes.NewReader(reqParams.Data))
+	if err != nil {
+		return nil, false, err
+	}
+
+	// The preconditions are checked in the transaction of the writes, so that
+	// two requests with the same ETag cannot both pass them, and a new todo is
+	// only kept along with the name it was put as.
+	var (
+		current *model.CalDAVResource
+		todo    *model.Todo
+	)
+	err = c.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = c.resource(ctx, reqParams.Name, c.todoRepository.Find)
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+		if reqParams.IfMatch != "" && !etagMatches(reqParams.IfMatch, current) {
+			return model.ErrPreconditionFailed
+		}
+		if reqParams.IfNoneMatch != "" && etagMatches(reqParams.IfNoneMatch, current) {
+			return model.ErrPreconditionFailed
+		}
+
+		// A resource keeps its UID, which no other resource has.
+		id := 0
+		if current != nil {
+			if current.UID != uid {
+				return model.ErrUIDConflict
+			}
+			id = current.Todo.ID
+		} else if err := c.checkUIDFree(ctx, uid, record.ID); err != nil {
+			return err
+		}
+
+		todo, err = c.todoService.Replace(ctx, &model.ReplaceRequest{ID: id, Todo: record.Todo, Status: record.Status})
+		if err != nil {
+			return err
+		}
+		if reqParams.Name != fmt.Sprintf("todo-%d.ics", todo.ID) || uid != exchange.ICalUID(todo.ID) {
+			return c.calDAVObjectRepository.Save(ctx, &model.CalDAVObject{TodoID: todo.ID, Name: reqParams.Name, UID: uid})
+		}
+		return nil
+	})
+	if err != nil {
+		if todo != nil {
+			c.restoreCache(ctx, current, todo.ID)
+		}
+		return nil, false, err
+	}
+
+	resource, err := newCalDAVResource(reqParams.Name, uid, todo)
+	if err != nil {
+		return nil, false, err
+	}
+	c.log.Info(ctx, fmt.Sprintf("CalDAV resource %s put as todo with ID: %d", reqParams.Name, todo.ID))
+	return resource, current == nil, nil
+}
+
+// checkUIDFree rejects the UID of a new resource when another resource has it.
+// id is the todo the UID names, if it is the UID of an exported todo.
+func (c *calDAVReceiver) checkUIDFree(ctx context.Context, uid string, id int) error {
+	_, err := c.calDAVObjectRepository.FindByUID(ctx, uid)
+	if err == nil {
+		return model.ErrUIDConflict
+	}
+	if !errors.Is(err, model.ErrNotFound) {
+		return err
+	}
+
+	if id == 0 || uid != exchange.ICalUID(id) {
+		return nil
+	}
+	_, err = c.resource(ctx, fmt.Sprintf("todo-%d.ics", id), c.todoRepository.Find)
+	if err == nil {
+		return model.ErrUIDConflict
+	}
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (c *calDAVReceiver) Delete(ctx context.Context, reqParams *model.DeleteCalDAVRequest) error {
+	var (
+		current *model.CalDAVResource
+		deleted bool
+	)
+	err := c.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		current, err = c.resource(ctx, reqParams.Name, c.todoRepository.Find)
+		if err != nil {
+			return err
+		}
+		if reqParams.IfMatch != "" && !etagMatches(reqParams.IfMatch, current) {
+			return model.ErrPreconditionFailed
+		}
+
+		if err := c.todoService.Delete(ctx, &model.DeleteRequest{ID: current.Todo.ID}); err != nil {
+			return err
+		}
+		deleted = true
+		return c.calDAVObjectRepository.Delete(ctx, current.Todo.ID)
+	})
+	if err != nil && deleted {
+		c.restoreCache(ctx, current, current.Todo.ID)
+	}
+	return err
+}
+
+// restoreCache puts back the cached todo of a resource whose transaction was
+// rolled back after the todo service had cached the change.
+func (c *calDAVReceiver) restoreCache(ctx context.Context, current *model.CalDAVResource, id int) {
+	var err error
+	if current != nil {
+		err = c.redisCache.Sync(ctx, []*model.Todo{current.Todo}, nil)
+	} else {
+		err = c.redisCache.Sync(ctx, nil, []int{id})
+	}
+	if err != nil {
+		c.log.Error(ctx, fmt.Sprintf("failed to restore cached todo with ID: %d and Error: %s", id, err.Error()))
+	}
+}
+
+// newCalDAVResource returns todo as the resource of a name and UID.
+func newCalDAVResource(name, uid string, todo *model.Todo) (*model.CalDAVResource, error) {
+	var data bytes.Buffer
+	if err := exchange.WriteICalObject(&data, todo, uid); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data.Bytes())
+	return &model.CalDAVResource{
+		Name: name, UID: uid, ETag: fmt.Sprintf(`"%x"`, sum[:16]), Data: data.Bytes(), Todo: todo,
+	}, nil
+}
+
+// etagMatches tells whether an If-Match or If-None-Match header matches
+// resource, which is nil when it does not exist.
+func etagMatches(header string, resource *model.CalDAVResource) bool {
+	if resource == nil {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == resource.ETag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+var errObjectStore = errors.New("object store unavailable")
+
+// failingObjects is a CalDAV object repository that cannot save, failing the
+// puts of resources named by a client after their todo was written.
+type failingObjects struct {
+	repository.ICalDAVObject
+}
+
+func (failingObjects) Save(context.Context, *model.CalDAVObject) error {
+	return errObjectStore
+}
+
+func vtodo(uid, summary string) []byte {
+	return []byte(fmt.Sprintf("BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:%s\r\nSUMMARY:%s\r\n"+
+		"PRIORITY:5\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\nEND:VCALENDAR\r\n", uid, summary))
+}
+
+func TestCalDAV_Put(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	objectRepository := repository.NewCalDAVObject(&repository.InitCalDAVObjectRepository{Db: env.db, Log: env.log})
+	todoService := env.todoService(&InitTodoService{})
+	newService := func(objects repository.ICalDAVObject) ICalDAV {
+		return NewCalDAV(&InitCalDAVService{
+			Log: env.log, TodoService: todoService, TodoRepository: env.todos, RedisCache: env.cache,
+			CalDAVObjectRepository: objects,
+		})
+	}
+	calDAV := newService(objectRepository)
+
+	t.Run("failed_save_leaves_no_todo", func(t *testing.T) {
+		_, _, err := newService(failingObjects{objectRepository}).Put(ctx, &model.PutCalDAVRequest{
+			Name: "phone.ics", Data: vtodo("phone", "Lost"), IfNoneMatch: "*",
+		})
+		require.ErrorIs(t, err, errObjectStore)
+
+		todos, err := env.todos.FindAll(ctx, &model.FindAllRequest{})
+		require.NoError(t, err)
+		assert.Empty(t, todos)
+		cached, _ := env.cache.Get(ctx, "todo:1")
+		assert.Empty(t, cached, "the cached todo is rolled back too")
+
+		// The retry of the client creates the todo once.
+		_, created, err := calDAV.Put(ctx, &model.PutCalDAVRequest{
+			Name: "phone.ics", Data: vtodo("phone", "Kept"), IfNoneMatch: "*",
+		})
+		require.NoError(t, err)
+		assert.True(t, created)
+		todos, err = env.todos.FindAll(ctx, &model.FindAllRequest{})
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		assert.Equal(t, "Kept", todos[0].Task)
+	})
+
+	t.Run("concurrent_puts_with_one_etag", func(t *testing.T) {
+		current, err := calDAV.Resource(ctx, "phone.ics")
+		require.NoError(t, err)
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			failures []error
+		)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, _, err := calDAV.Put(ctx, &model.PutCalDAVRequest{
+					Name: "phone.ics", Data: vtodo("phone", fmt.Sprintf("Edit %d", i)), IfMatch: current.ETag,
+				})
+				if err != nil {
+					mu.Lock()
+					failures = append(failures, err)
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		require.Len(t, failures, 3, "only the first put matches the ETag")
+		for _, err := range failures {
+			assert.ErrorIs(t, err, model.ErrPreconditionFailed)
+		}
+	})
+}
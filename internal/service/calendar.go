@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// feedTokenBytes is the number of random bytes of a feed token, which is their hex.
+const feedTokenBytes = 32
+
+// ICalendar is the service for the calendar feeds of todos.
+type ICalendar interface {
+	CreateFeed(ctx context.Context, reqParams *model.CreateCalendarFeedRequest) (*model.CalendarFeed, error)
+	FindAllFeeds(ctx context.Context) ([]*model.CalendarFeed, error)
+	DeleteFeed(ctx context.Context, reqParams *model.DeleteCalendarFeedRequest) error
+	// Feed returns the feed of the token along with its todos, in board order.
+	// An unknown token is model.ErrNotFound.
+	Feed(ctx context.Context, reqParams *model.CalendarFeedRequest) (*model.CalendarFeed, []*model.Todo, error)
+}
+
+type InitCalendarService struct {
+	Log                    *log.Logger
+	CalendarFeedRepository repository.ICalendarFeed
+	TodoRepository         repository.ITodo
+}
+
+type calendarReceiver struct {
+	log                    *log.Logger
+	calendarFeedRepository repository.ICalendarFeed
+	todoRepository         repository.ITodo
+}
+
+// NewCalendar creates a new Calendar service.
+func NewCalendar(initCalendarService *InitCalendarService) ICalendar {
+	return &calendarReceiver{
+		log:                    initCalendarService.Log,
+		calendarFeedRepository: initCalendarService.CalendarFeedRepository,
+		todoRepository:         initCalendarService.TodoRepository,
+	}
+}
+
+func (c *calendarReceiver) CreateFeed(ctx context.Context, reqParams *model.CreateCalendarFeedRequest) (*model.CalendarFeed, error) {
+	token := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	feed := &model.CalendarFeed{
+		Name: reqParams.Name, Assignee: reqParams.Assignee, Project: reqParams.Project, Token: hex.EncodeToString(token),
+	}
+	if feed.Name == "" {
+		feed.Name = feedName(feed)
+	}
+	if err := c.calendarFeedRepository.Create(ctx, feed); err != nil {
+		return nil, err
+	}
+
+	c.log.Info(ctx, fmt.Sprintf("Calendar feed created successfully with ID: %d", feed.ID))
+	return feed, nil
+}
+
+// feedName returns the default name of a feed, after the todos it has.
+func feedName(feed *model.CalendarFeed) string {
+	switch {
+	case feed.Assignee == "":
+		return "Todos of " + feed.Project
+	case feed.Project == "":
+		return "Todos of " + feed.Assignee
+	default:
+		return fmt.Sprintf("Todos of %s in %s", feed.Assignee, feed.Project)
+	}
+}
+
+func (c *calendarReceiver) FindAllFeeds(ctx context.Context) ([]*model.CalendarFeed, error) {
+	return c.calendarFeedRepository.FindAll(ctx)
+}
+
+func (c *calendarReceiver) DeleteFeed(ctx context.Context, reqParams *model.DeleteCalendarFeedRequest) error {
+	if err := c.calendarFeedRepository.Delete(ctx, reqParams); err != nil {
+		return err
+	}
+
+	c.log.Info(ctx, fmt.Sprintf("Calendar feed deleted successfully with ID: %d", reqParams.ID))
+	return nil
+}
+
+func (c *calendarReceiver) Feed(ctx context.Context, reqParams *model.CalendarFeedRequest) (*model.CalendarFeed, []*model.Todo, error) {
+	feed, err := c.calendarFeedRepository.FindByToken(ctx, reqParams.Token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	todos, err := c.todoRepository.FindAll(ctx, &model.FindAllRequest{
+		Assignee: feed.Assignee, Project: feed.Project, Sort: model.SortManual,
+	})
+	if err != nil {
+		c.log.Error(ctx, err.Error())
+		return nil, nil, err
+	}
+	return feed, todos, nil
+}
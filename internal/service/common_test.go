@@ -0,0 +1,60 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	cache2 "github.com/zuu-development/fullstack-examination-2024/internal/cache"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"gorm.io/gorm"
+)
+
+// testEnv is what the services of a test are wired with: a migrated database
+// and a Redis of the test's own, and the todo repositories over them.
+type testEnv struct {
+	log   *log.Logger
+	db    *gorm.DB
+	cache repository.IRedisCache
+	todos repository.ITodo
+}
+
+// newTestEnv returns a new environment for t. Redis is an in-process
+// miniredis, so that tests running at the same time, in this package or in
+// another, do not share or flush each other's cache.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	logger := log.New()
+	dbInstance, err := db.New(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(dbInstance))
+	t.Cleanup(func() { _ = db.Close(dbInstance) })
+
+	client := cache2.New(&cache2.Config{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return &testEnv{
+		log:   logger,
+		db:    dbInstance,
+		cache: repository.NewRedisCache(&repository.InitRedisCache{Client: client, Log: logger}),
+		todos: repository.NewTodo(&repository.InitTodoRepository{Db: dbInstance, Log: logger}),
+	}
+}
+
+// todoService returns a todo service configured by init, whose Log,
+// TodoRepository and RedisCache default to those of env.
+func (env *testEnv) todoService(init *InitTodoService) ITodo {
+	if init.Log == nil {
+		init.Log = env.log
+	}
+	if init.TodoRepository == nil {
+		init.TodoRepository = env.todos
+	}
+	if init.RedisCache == nil {
+		init.RedisCache = env.cache
+	}
+	return NewTodo(init)
+}
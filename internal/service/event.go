@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/broker"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// replayPageSize is the number of logged events read at a time when a stream resumes.
+const replayPageSize = 500
+
+// IEvent is the service for the todo event stream.
+type IEvent interface {
+	// ISink delivers the events dispatched from the outbox to the subscribers.
+	ISink
+	// Subscribe returns the events after reqParams.LastEventID that match its
+	// filters, followed by the new ones. The channel is closed when ctx is done
+	// or the subscriber fell too far behind and should resume.
+	Subscribe(ctx context.Context, reqParams *model.EventsRequest) (<-chan *model.Event, error)
+}
+
+type InitEventService struct {
+	Log             *log.Logger
+	EventRepository repository.IEvent
+	Broker          broker.IBroker
+}
+
+type eventReceiver struct {
+	log             *log.Logger
+	eventRepository repository.IEvent
+	broker          broker.IBroker
+}
+
+// NewEvent creates a new Event service.
+func NewEvent(initEventService *InitEventService) IEvent {
+	return &eventReceiver{
+		log:             initEventService.Log,
+		eventRepository: initEventService.EventRepository,
+		broker:          initEventService.Broker,
+	}
+}
+
+func (e *eventReceiver) Name() string {
+	return "events"
+}
+
+// Handle publishes event to the subscribers. A redispatched event reaches
+// them again; clients skip the IDs they have already seen.
+func (e *eventReceiver) Handle(ctx context.Context, event *model.Event) error {
+	if err := e.broker.Publish(ctx, event); err != nil {
+		e.log.Error(ctx, fmt.Sprintf("failed to publish event %d: %s", event.ID, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (e *eventReceiver) Subscribe(ctx context.Context, reqParams *model.EventsRequest) (<-chan *model.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Subscribe before reading the log, so that nothing falls between the two.
+	live, err := e.broker.Subscribe(ctx)
+	if err != nil {
+		cancel()
+		e.log.Error(ctx, fmt.Sprintf("failed to subscribe to events: %s", err.Error()))
+		return nil, err
+	}
+
+	var backlog []*model.Event
+	replayed := reqParams.LastEventID
+	if reqParams.LastEventID > 0 {
+		for {
+			page, err := e.eventRepository.FindAfter(ctx, replayed, replayPageSize)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			backlog = append(backlog, page...)
+			if len(page) > 0 {
+				replayed = page[len(page)-1].ID
+			}
+			if len(page) < replayPageSize {
+				break
+			}
+		}
+	}
+
+	out := make(chan *model.Event)
+	go func() {
+		defer cancel()
+		defer close(out)
+
+		send := func(event *model.Event) bool {
+			if !reqParams.Matches(event) {
+				return true
+			}
+			select {
+			case out <- event:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, event := range backlog {
+			if !send(event) {
+				return
+			}
+		}
+		for event := range live {
+			// Events already replayed from the log are skipped.
+			if event.ID <= replayed {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// dispatchLease is how long a claimed event is left to its dispatcher before
+// another one takes it over.
+const dispatchLease = time.Minute
+
+// pruneInterval is how often dispatched events past the retention are deleted.
+const pruneInterval = time.Hour
+
+// defaultOutbox is the configuration used for the settings left at zero.
+var defaultOutbox = model.Outbox{
+	PollInterval: time.Second, BatchSize: 100, RetryDelay: time.Second, MaxRetryDelay: time.Minute,
+}
+
+// IOutbox is the service dispatching the events of the outbox to the sinks.
+type IOutbox interface {
+	// Notify wakes the dispatcher after events were committed.
+	Notify()
+	// Run dispatches the pending events until ctx is done. Several processes
+	// may run it against the same database.
+	Run(ctx context.Context)
+	// Dispatch hands the due events to the sinks, in order, and returns how
+	// many were dispatched. Each sink gets an event until it handles it once.
+	Dispatch(ctx context.Context) (int, error)
+}
+
+type InitOutboxService struct {
+	Log             *log.Logger
+	EventRepository repository.IEvent
+	// Sinks receive every event, in the given order. Their names are unique.
+	Sinks []ISink
+	// Config is the dispatch configuration. Settings left at zero get defaults.
+	Config model.Outbox
+	// Retention is how long dispatched events are kept for resuming streams.
+	// Zero keeps them forever.
+	Retention time.Duration
+}
+
+type outboxReceiver struct {
+	log             *log.Logger
+	eventRepository repository.IEvent
+	sinks           []ISink
+	config          model.Outbox
+	retention       time.Duration
+	wake            chan struct{}
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+// NewOutbox creates a new Outbox service.
+func NewOutbox(initOutboxService *InitOutboxService) IOutbox {
+	config := initOutboxService.Config
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultOutbox.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultOutbox.BatchSize
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultOutbox.RetryDelay
+	}
+	if config.MaxRetryDelay < config.RetryDelay {
+		config.MaxRetryDelay = config.RetryDelay
+	}
+
+	return &outboxReceiver{
+		log:             initOutboxService.Log,
+		eventRepository: initOutboxService.EventRepository,
+		sinks:           initOutboxService.Sinks,
+		config:          config,
+		retention:       initOutboxService.Retention,
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+func (o *outboxReceiver) Notify() {
+	// A wake-up already pending covers this one too.
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (o *outboxReceiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(o.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := o.Dispatch(ctx)
+			if err != nil || n < o.config.BatchSize {
+				break
+			}
+		}
+		o.prune(ctx, time.Now().UTC())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-o.wake:
+		}
+	}
+}
+
+func (o *outboxReceiver) Dispatch(ctx context.Context) (int, error) {
+	events, err := o.eventRepository.ClaimPending(ctx, time.Now().UTC(), dispatchLease, o.config.BatchSize)
+	if err != nil {
+		o.log.Error(ctx, fmt.Sprintf("failed to claim events: %s", err.Error()))
+		return 0, err
+	}
+
+	for _, event := range events {
+		// An event that fails is retried later; the events after it go ahead.
+		o.dispatch(ctx, event)
+	}
+	return len(events), nil
+}
+
+// dispatch hands event to every sink that has not handled it yet and records
+// the outcome. A failed sink does not hold back the others: it alone gets the
+// event again on the retry.
+func (o *outboxReceiver) dispatch(ctx context.Context, event *model.Event) {
+	var failures []string
+	for _, sink := range o.sinks {
+		if event.Handled(sink.Name()) {
+			continue
+		}
+		if err := sink.Handle(ctx, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", sink.Name(), err.Error()))
+			continue
+		}
+		event.HandledBy = append(event.HandledBy, sink.Name())
+	}
+	if ctx.Err() != nil {
+		// Shutting down: the claim expires and the event is dispatched again.
+		return
+	}
+
+	now := time.Now().UTC()
+	if len(failures) == 0 {
+		event.DispatchedAt = &now
+		event.Error = ""
+	} else {
+		event.Attempts++
+		event.NextAttemptAt = now.Add(o.retryDelay(event.Attempts))
+		event.Error = strings.Join(failures, "; ")
+		o.log.Error(ctx, fmt.Sprintf("event %d dispatch attempt %d failed: %s", event.ID, event.Attempts, event.Error))
+	}
+
+	if err := o.eventRepository.UpdateDispatch(ctx, event); err != nil {
+		o.log.Error(ctx, fmt.Sprintf("failed to record dispatch of event %d: %s", event.ID, err.Error()))
+	}
+}
+
+// retryDelay is the wait after the given number of failed dispatches: the
+// retry delay doubled for every failure after the first, up to the maximum.
+func (o *outboxReceiver) retryDelay(attempts int) time.Duration {
+	delay := o.config.RetryDelay
+	for i := 1; i < attempts && delay < o.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > o.config.MaxRetryDelay {
+		delay = o.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// prune deletes the dispatched events past the retention, at most once per pruneInterval.
+func (o *outboxReceiver) prune(ctx context.Context, now time.Time) {
+	if o.retention <= 0 {
+		return
+	}
+
+	o.pruneMu.Lock()
+	defer o.pruneMu.Unlock()
+	if now.Sub(o.lastPrune) < pruneInterval {
+		return
+	}
+	o.lastPrune = now
+	if _, err := o.eventRepository.DeleteDispatchedBefore(ctx, now.Add(-o.retention)); err != nil {
+		o.log.Error(ctx, fmt.Sprintf("failed to delete old events: %s", err.Error()))
+	}
+}
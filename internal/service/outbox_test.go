@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// recordingSink records the events it handles and fails as often as it is told to.
+type recordingSink struct {
+	mu       sync.Mutex
+	name     string
+	failures int
+	handled  []*model.Event
+}
+
+func (s *recordingSink) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "recording"
+}
+
+func (s *recordingSink) Handle(_ context.Context, event *model.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handled = append(s.handled, event)
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+func (s *recordingSink) ids() []uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]uint, 0, len(s.handled))
+	for _, event := range s.handled {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func TestOutbox(t *testing.T) {
+	env := newTestEnv(t)
+	eventRepository := repository.NewEvent(&repository.InitEventRepository{Db: env.db, Log: env.log})
+	webhookRepository := repository.NewWebhook(&repository.InitWebhookRepository{Db: env.db, Log: env.log})
+	webhookService := NewWebhook(&InitWebhookService{Log: env.log, WebhookRepository: webhookRepository})
+	first, sink := &recordingSink{name: "first"}, &recordingSink{}
+	outbox := NewOutbox(&InitOutboxService{
+		Log: env.log, EventRepository: eventRepository, Sinks: []ISink{first, webhookService, sink},
+		Config: model.Outbox{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond},
+	})
+	todoService := env.todoService(&InitTodoService{Outbox: outbox})
+	ctx := context.Background()
+
+	webhook := &model.Webhook{URL: "http://example.com", Events: []string{model.EventTodoCreated}, Secret: "0123456789abcdef"}
+	require.NoError(t, webhookRepository.Create(ctx, webhook))
+
+	t.Run("rolled_back_changes_leave_no_events", func(t *testing.T) {
+		_, err := todoService.Batch(ctx, &model.BatchRequest{Operations: []model.BatchOperation{
+			{Op: model.BatchCreate, Create: &model.CreateRequest{Task: "Lost", Priority: "low"}},
+			{Op: model.BatchDelete, ID: 99999},
+		}})
+		require.Error(t, err)
+
+		n, err := outbox.Dispatch(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("redispatched_until_every_sink_succeeds", func(t *testing.T) {
+		sink.failures = 1
+		todo, err := todoService.Create(ctx, &model.CreateRequest{Task: "Kept", Priority: "low"})
+		require.NoError(t, err)
+
+		n, err := outbox.Dispatch(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		events, err := eventRepository.FindAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Nil(t, events[0].DispatchedAt)
+		assert.Equal(t, 1, events[0].Attempts)
+		assert.Contains(t, events[0].Error, "recording: sink unavailable")
+		assert.Equal(t, todo.ID, events[0].TodoID)
+
+		time.Sleep(5 * time.Millisecond)
+		n, err = outbox.Dispatch(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		// Handled twice under the same ID, so sinks can tell the redispatch apart
+		assert.Equal(t, []uint{events[0].ID, events[0].ID}, sink.ids())
+
+		events, err = eventRepository.FindAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.NotNil(t, events[0].DispatchedAt)
+		assert.Empty(t, events[0].Error)
+
+		// The webhook got a single delivery for both dispatches
+		deliveries, err := webhookRepository.FindDeliveries(ctx, &model.FindDeliveriesRequest{WebhookID: webhook.ID})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, events[0].ID, deliveries[0].EventID)
+
+		n, err = outbox.Dispatch(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("failed_sink_does_not_hold_back_the_others", func(t *testing.T) {
+		first.failures = 2
+		_, err := todoService.Create(ctx, &model.CreateRequest{Task: "Queued", Priority: "low"})
+		require.NoError(t, err)
+		events, err := eventRepository.FindAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		id := events[len(events)-1].ID
+
+		n, err := outbox.Dispatch(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, id, sink.ids()[len(sink.ids())-1], "the sinks after the failed one got the event")
+		handled := len(sink.ids())
+		deliveries, err := webhookRepository.FindDeliveries(ctx, &model.FindDeliveriesRequest{WebhookID: webhook.ID})
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+
+		for i := 0; i < 2; i++ {
+			time.Sleep(5 * time.Millisecond)
+			n, err = outbox.Dispatch(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+		}
+		events, err = eventRepository.FindAfter(ctx, id-1, 1)
+		require.NoError(t, err)
+		assert.NotNil(t, events[0].DispatchedAt)
+		assert.Equal(t, 2, events[0].Attempts)
+		assert.Equal(t, []uint{id, id, id}, first.ids()[len(first.ids())-3:], "the failed sink got it until it handled it")
+		assert.Len(t, sink.ids(), handled, "the others got it once")
+	})
+}
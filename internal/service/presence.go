@@ -0,0 +1,130 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// IPresence tracks which users are viewing which todo. Presence is kept in
+// memory, so it only covers the connections of this process.
+type IPresence interface {
+	// View records that the session of user is viewing todoID, replacing the
+	// todo it viewed before. Zero todoID stops viewing. It returns the presence
+	// of the todos whose viewers changed.
+	View(session, user string, todoID int) []*model.Presence
+	// Leave forgets the session and returns the presence of the todo it was
+	// viewing if its viewers changed.
+	Leave(session string) []*model.Presence
+	// Find returns the presence of todoID.
+	Find(todoID int) *model.Presence
+}
+
+type viewer struct {
+	user   string
+	todoID int
+}
+
+type presenceReceiver struct {
+	mu       sync.Mutex
+	sessions map[string]viewer
+	// todos counts the sessions of each user per todo.
+	todos map[int]map[string]int
+}
+
+// NewPresence creates a new Presence service.
+func NewPresence() IPresence {
+	return &presenceReceiver{
+		sessions: map[string]viewer{},
+		todos:    map[int]map[string]int{},
+	}
+}
+
+func (p *presenceReceiver) View(session, user string, todoID int) []*model.Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next := viewer{user: user, todoID: todoID}
+	var changed []int
+	if prev, ok := p.sessions[session]; ok {
+		if prev == next {
+			return nil
+		}
+		delete(p.sessions, session)
+		if p.remove(prev) {
+			changed = append(changed, prev.todoID)
+		}
+	}
+	if todoID > 0 {
+		p.sessions[session] = next
+		if p.add(next) {
+			changed = append(changed, todoID)
+		}
+	}
+	return p.presences(changed)
+}
+
+func (p *presenceReceiver) Leave(session string) []*model.Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prev, ok := p.sessions[session]
+	if !ok {
+		return nil
+	}
+	delete(p.sessions, session)
+	if !p.remove(prev) {
+		return nil
+	}
+	return p.presences([]int{prev.todoID})
+}
+
+func (p *presenceReceiver) Find(todoID int) *model.Presence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.find(todoID)
+}
+
+// add counts a session of v and reports whether v.user started viewing v.todoID.
+func (p *presenceReceiver) add(v viewer) bool {
+	counts, ok := p.todos[v.todoID]
+	if !ok {
+		counts = map[string]int{}
+		p.todos[v.todoID] = counts
+	}
+	counts[v.user]++
+	return counts[v.user] == 1
+}
+
+// remove uncounts a session of v and reports whether v.user stopped viewing v.todoID.
+func (p *presenceReceiver) remove(v viewer) bool {
+	counts := p.todos[v.todoID]
+	counts[v.user]--
+	if counts[v.user] > 0 {
+		return false
+	}
+	delete(counts, v.user)
+	if len(counts) == 0 {
+		delete(p.todos, v.todoID)
+	}
+	return true
+}
+
+func (p *presenceReceiver) presences(todoIDs []int) []*model.Presence {
+	presences := make([]*model.Presence, 0, len(todoIDs))
+	for _, todoID := range todoIDs {
+		presences = append(presences, p.find(todoID))
+	}
+	return presences
+}
+
+func (p *presenceReceiver) find(todoID int) *model.Presence {
+	users := make([]string, 0, len(p.todos[todoID]))
+	for user := range p.todos[todoID] {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	return &model.Presence{TodoID: todoID, Users: users}
+}
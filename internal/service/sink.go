@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// ISink receives the events dispatched from the outbox. An event is handed to
+// a sink at least once, so sinks must tolerate seeing it again, using its ID.
+type ISink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Handle processes event. An error makes the outbox dispatch it again later.
+	Handle(ctx context.Context, event *model.Event) error
+}
+
+type InitCacheSink struct {
+	Log            *log.Logger
+	TodoRepository repository.ITodo
+	RedisCache     repository.IRedisCache
+}
+
+type cacheSinkReceiver struct {
+	log            *log.Logger
+	todoRepository repository.ITodo
+	redisCache     repository.IRedisCache
+}
+
+// NewCacheSink returns a sink that brings the cached todo of each event in
+// line with the database.
+func NewCacheSink(initCacheSink *InitCacheSink) ISink {
+	return &cacheSinkReceiver{
+		log:            initCacheSink.Log,
+		todoRepository: initCacheSink.TodoRepository,
+		redisCache:     initCacheSink.RedisCache,
+	}
+}
+
+func (c *cacheSinkReceiver) Name() string {
+	return "cache"
+}
+
+// Handle caches the todo as it is now rather than as the event saw it, so that
+// events handled late or twice do not bring back an older state.
+func (c *cacheSinkReceiver) Handle(ctx context.Context, event *model.Event) error {
+	todoKey := fmt.Sprintf("todo:%d", event.TodoID)
+
+	todo, err := c.todoRepository.Find(ctx, &model.FindRequest{ID: event.TodoID})
+	if errors.Is(err, model.ErrNotFound) {
+		return c.redisCache.Delete(ctx, todoKey)
+	}
+	if err != nil {
+		return err
+	}
+	return c.redisCache.Add(ctx, todoKey, todo)
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// ITimeTracking is the service for estimates, timers and time entries.
+type ITimeTracking interface {
+	StartTimer(ctx context.Context, reqParams *model.TimerRequest) (*model.TimeEntry, error)
+	StopTimer(ctx context.Context, reqParams *model.TimerRequest) (*model.TimeEntry, error)
+	CurrentTimer(ctx context.Context, reqParams *model.CurrentTimerRequest) (*model.TimeEntry, error)
+	CreateEntry(ctx context.Context, reqParams *model.CreateTimeEntryRequest) (*model.TimeEntry, error)
+	FindAllEntries(ctx context.Context, reqParams *model.FindAllTimeEntriesRequest) ([]*model.TimeEntry, error)
+	DeleteEntry(ctx context.Context, reqParams *model.DeleteTimeEntryRequest) error
+	Report(ctx context.Context, reqParams *model.TimeReportRequest) (*model.TimeReport, error)
+}
+
+type timeTrackingReceiver struct {
+	log                 *log.Logger
+	timeEntryRepository repository.ITimeEntry
+	todoRepository      repository.ITodo
+	location            *time.Location
+	now                 func() time.Time
+}
+
+type InitTimeTrackingService struct {
+	Log                 *log.Logger
+	TimeEntryRepository repository.ITimeEntry
+	TodoRepository      repository.ITodo
+	// Location is the timezone report periods are cut in. Defaults to UTC.
+	Location *time.Location
+	// Now is the clock. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewTimeTracking creates a new TimeTracking service.
+func NewTimeTracking(initTimeTrackingService *InitTimeTrackingService) ITimeTracking {
+	t := &timeTrackingReceiver{
+		log:                 initTimeTrackingService.Log,
+		timeEntryRepository: initTimeTrackingService.TimeEntryRepository,
+		todoRepository:      initTimeTrackingService.TodoRepository,
+		location:            initTimeTrackingService.Location,
+		now:                 initTimeTrackingService.Now,
+	}
+	if t.location == nil {
+		t.location = time.UTC
+	}
+	if t.now == nil {
+		t.now = time.Now
+	}
+	return t
+}
+
+func (t *timeTrackingReceiver) StartTimer(ctx context.Context, reqParams *model.TimerRequest) (*model.TimeEntry, error) {
+	var entry *model.TimeEntry
+	// The running timer is stopped in the transaction of the new one, so that
+	// two starts at once cannot leave both running.
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.TodoID}); err != nil {
+			return err
+		}
+
+		now := t.now()
+
+		// Only one timer may run at a time: starting a new one stops the previous.
+		running, err := t.timeEntryRepository.FindRunning(ctx, reqParams.User)
+		switch {
+		case err == nil && running.TodoID == reqParams.TodoID:
+			return model.ErrTimerRunning
+		case err == nil:
+			running.EndedAt = &now
+			if err := t.timeEntryRepository.Update(ctx, running); err != nil {
+				return err
+			}
+			t.log.Info(ctx, fmt.Sprintf("Stopped timer %d of %s on todo %d", running.ID, reqParams.User, running.TodoID))
+		case !errors.Is(err, model.ErrNotFound):
+			return err
+		}
+
+		entry = &model.TimeEntry{
+			TodoID:    reqParams.TodoID,
+			User:      reqParams.User,
+			StartedAt: now,
+		}
+		if err := t.timeEntryRepository.Create(ctx, entry); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to start timer: %s", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Started timer %d of %s on todo %d", entry.ID, reqParams.User, entry.TodoID))
+	return entry, nil
+}
+
+func (t *timeTrackingReceiver) StopTimer(ctx context.Context, reqParams *model.TimerRequest) (*model.TimeEntry, error) {
+	var running *model.TimeEntry
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		running, err = t.timeEntryRepository.FindRunning(ctx, reqParams.User)
+		if errors.Is(err, model.ErrNotFound) || (err == nil && running.TodoID != reqParams.TodoID) {
+			return model.ErrNoRunningTimer
+		}
+		if err != nil {
+			return err
+		}
+
+		now := t.now()
+		running.EndedAt = &now
+		if err := t.timeEntryRepository.Update(ctx, running); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to stop timer: %s", err.Error()))
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return running, nil
+}
+
+func (t *timeTrackingReceiver) CurrentTimer(ctx context.Context, reqParams *model.CurrentTimerRequest) (*model.TimeEntry, error) {
+	running, err := t.timeEntryRepository.FindRunning(ctx, reqParams.User)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil, model.ErrNoRunningTimer
+	}
+
+	return running, err
+}
+
+func (t *timeTrackingReceiver) CreateEntry(ctx context.Context, reqParams *model.CreateTimeEntryRequest) (*model.TimeEntry, error) {
+	if _, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.TodoID}); err != nil {
+		return nil, err
+	}
+
+	endedAt := reqParams.StartedAt.Add(time.Duration(reqParams.DurationMinutes) * time.Minute)
+	if reqParams.EndedAt != nil {
+		endedAt = *reqParams.EndedAt
+	}
+	if !endedAt.After(reqParams.StartedAt) {
+		return nil, model.ErrInvalidTimeEntry
+	}
+
+	entry := &model.TimeEntry{
+		TodoID:    reqParams.TodoID,
+		User:      reqParams.User,
+		StartedAt: reqParams.StartedAt,
+		EndedAt:   &endedAt,
+		Note:      reqParams.Note,
+		Manual:    true,
+	}
+	if err := t.timeEntryRepository.Create(ctx, entry); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to create time entry: %s", err.Error()))
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (t *timeTrackingReceiver) FindAllEntries(ctx context.Context, reqParams *model.FindAllTimeEntriesRequest) ([]*model.TimeEntry, error) {
+	if _, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.TodoID}); err != nil {
+		return nil, err
+	}
+
+	return t.timeEntryRepository.FindAll(ctx, reqParams)
+}
+
+func (t *timeTrackingReceiver) DeleteEntry(ctx context.Context, reqParams *model.DeleteTimeEntryRequest) error {
+	return t.timeEntryRepository.Delete(ctx, reqParams)
+}
+
+func (t *timeTrackingReceiver) Report(ctx context.Context, reqParams *model.TimeReportRequest) (*model.TimeReport, error) {
+	groupBy := reqParams.GroupBy
+	if groupBy == "" {
+		groupBy = "todo"
+	}
+
+	from, err := t.parseBound(reqParams.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := t.parseBound(reqParams.To)
+	if err != nil {
+		return nil, err
+	}
+	if from != nil && to != nil && !to.After(*from) {
+		return nil, model.ErrInvalidTimeRange
+	}
+
+	entries, err := t.timeEntryRepository.FindBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := t.todoRepository.FindAll(ctx, &model.FindAllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	todoByID := make(map[int]*model.Todo, len(todos))
+	for _, todo := range todos {
+		todoByID[todo.ID] = todo
+	}
+
+	now := t.now()
+	report := &model.TimeReport{From: from, To: to, GroupBy: groupBy, Groups: []*model.TimeReportGroup{}}
+	groups := map[string]*model.TimeReportGroup{}
+
+	for _, entry := range entries {
+		todo := todoByID[entry.TodoID]
+		if reqParams.User != "" && entry.User != reqParams.User {
+			continue
+		}
+		if reqParams.Project != "" && (todo == nil || todo.Project != reqParams.Project) {
+			continue
+		}
+
+		// Only the time within the range counts.
+		start, end := entry.StartedAt, entry.StartedAt.Add(entry.Duration(now))
+		if from != nil && start.Before(*from) {
+			start = *from
+		}
+		if to != nil && end.After(*to) {
+			end = *to
+		}
+		if end.Before(start) {
+			continue
+		}
+
+		for _, s := range t.split(groupBy, start, end) {
+			key := t.groupKey(groupBy, s.start, entry, todo)
+			group, ok := groups[key]
+			if !ok {
+				group = &model.TimeReportGroup{Key: key}
+				if groupBy == "todo" && todo != nil {
+					group.Label = todo.Task
+					group.EstimateMinutes = todo.EstimateMinutes
+				}
+				groups[key] = group
+				report.Groups = append(report.Groups, group)
+			}
+
+			seconds := int64(s.end.Sub(s.start) / time.Second)
+			group.Seconds += seconds
+			group.Entries++
+			report.TotalSeconds += seconds
+		}
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		if groupBy == "todo" {
+			a, _ := strconv.Atoi(report.Groups[i].Key)
+			b, _ := strconv.Atoi(report.Groups[j].Key)
+			return a < b
+		}
+		return report.Groups[i].Key < report.Groups[j].Key
+	})
+
+	return report, nil
+}
+
+// parseBound parses a report bound given as RFC 3339 or as a date in the service's timezone.
+func (t *timeTrackingReceiver) parseBound(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if v, err := time.Parse(time.RFC3339, s); err == nil {
+		return &v, nil
+	}
+	if v, err := time.ParseInLocation("2006-01-02", s, t.location); err == nil {
+		return &v, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", model.ErrInvalidTimeRange, s)
+}
+
+// span is a part of a time entry, from start until end.
+type span struct {
+	start, end time.Time
+}
+
+// split cuts [start, end) at the starts of the periods of the day, week and
+// month grouping, so that each period counts its own part of an entry. Other
+// groupings keep it whole.
+func (t *timeTrackingReceiver) split(groupBy string, start, end time.Time) []span {
+	var spans []span
+	for {
+		next, ok := t.nextPeriod(groupBy, start)
+		if !ok || !next.Before(end) {
+			return append(spans, span{start: start, end: end})
+		}
+		spans = append(spans, span{start: start, end: next})
+		start = next
+	}
+}
+
+// nextPeriod returns the start of the day, ISO week or month after the one
+// holding at, or false when groupBy is not a period.
+func (t *timeTrackingReceiver) nextPeriod(groupBy string, at time.Time) (time.Time, bool) {
+	at = at.In(t.location)
+	year, month, day := at.Date()
+
+	switch groupBy {
+	case "day":
+		return time.Date(year, month, day+1, 0, 0, 0, 0, t.location), true
+	case "week":
+		// ISO weeks start on Monday.
+		return time.Date(year, month, day+7-(int(at.Weekday())+6)%7, 0, 0, 0, 0, t.location), true
+	case "month":
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, t.location), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// groupKey returns the group of the part of entry that starts at.
+func (t *timeTrackingReceiver) groupKey(groupBy string, at time.Time, entry *model.TimeEntry, todo *model.Todo) string {
+	started := at.In(t.location)
+
+	switch groupBy {
+	case "project":
+		if todo == nil {
+			return ""
+		}
+		return todo.Project
+	case "user":
+		return entry.User
+	case "day":
+		return started.Format("2006-01-02")
+	case "week":
+		year, week := started.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case "month":
+		return started.Format("2006-01")
+	default:
+		return strconv.Itoa(entry.TodoID)
+	}
+}
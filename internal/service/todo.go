@@ -9,92 +9,146 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/zuu-development/fullstack-examination-2024/internal/log"
 	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/quickadd"
+	"github.com/zuu-development/fullstack-examination-2024/internal/rank"
 	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+	"strings"
 )
 
 // Todo is the service for the todo endpoint.
 type ITodo interface {
 	Create(ctx context.Context, reqTodo *model.CreateRequest) (*model.Todo, error)
+	QuickCreate(ctx context.Context, reqTodo *model.QuickCreateRequest) (*model.QuickCreateResponse, error)
 	Update(ctx context.Context, reqTodo *model.UpdateRequest) (*model.Todo, error)
+	// Replace writes every field of the todo of reqParams, creating it without
+	// an ID. Invalid fields are model.ErrInvalidTodo.
+	Replace(ctx context.Context, reqParams *model.ReplaceRequest) (*model.Todo, error)
 	Delete(ctx context.Context, reqParams *model.DeleteRequest) error
 	Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error)
 	FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error)
+	Move(ctx context.Context, reqParams *model.MoveRequest) (*model.Todo, error)
+	Board(ctx context.Context, reqParams *model.BoardRequest) (*model.Board, error)
+	Batch(ctx context.Context, reqParams *model.BatchRequest) (*model.BatchResponse, error)
+	// Export calls fn with each todo matching reqParams, in board order.
+	Export(ctx context.Context, reqParams *model.ExportRequest, fn func(todo *model.Todo) error) error
+	// Import creates the todos of records, or updates those that exist already,
+	// and reports the action taken for each.
+	Import(ctx context.Context, reqParams *model.ImportRequest, records []*model.ImportRecord) (*model.ImportResponse, error)
 }
 
 type todoReceiver struct {
 	log            *log.Logger
 	todoRepository repository.ITodo
 	redisCache     repository.IRedisCache
+	quickAdd       *quickadd.Parser
+	board          model.BoardConfig
+	outbox         IOutbox
 }
 
 type InitTodoService struct {
 	Log            *log.Logger
 	TodoRepository repository.ITodo
 	RedisCache     repository.IRedisCache
+	// QuickAddParser parses quick-add text. Defaults to a parser using UTC.
+	QuickAddParser *quickadd.Parser
+	// Board is the WIP limit configuration of the status columns.
+	Board model.BoardConfig
+	// Outbox dispatches the events recorded for the changes to todos. Nil disables events.
+	Outbox IOutbox
 }
 
 // NewTodo creates a new Todo service.
 func NewTodo(initTodoService *InitTodoService) ITodo {
+	quickAdd := initTodoService.QuickAddParser
+	if quickAdd == nil {
+		quickAdd = quickadd.New(&quickadd.InitParser{})
+	}
+
 	return &todoReceiver{
 		log:            initTodoService.Log,
 		todoRepository: initTodoService.TodoRepository,
 		redisCache:     initTodoService.RedisCache,
+		quickAdd:       quickAdd,
+		board:          initTodoService.Board,
+		outbox:         initTodoService.Outbox,
 	}
 }
 
 func (t *todoReceiver) Create(ctx context.Context, reqTodo *model.CreateRequest) (*model.Todo, error) {
-
-	// Create a new Todo instance using the struct-based constructor
-	todoModel := model.NewTodo(reqTodo)
-
-	// Validate the input before proceeding
-	if err := todoModel.ValidateCreateRequest(); err != nil {
-		t.log.Error(ctx, fmt.Sprintf("invalid request: %s", err.Error()))
-		return nil, err
-	}
-
-	// Attempt to store the new todo using the repository pattern
-	if err := t.todoRepository.Create(ctx, todoModel); err != nil {
-		t.log.Error(ctx, fmt.Sprintf("failed to create todo: %s", err.Error()))
-		return nil, err
-	}
-
-	todoKey := fmt.Sprintf("todo:%d", todoModel.ID)
-	err := t.redisCache.Add(ctx, todoKey, todoModel)
+	var todoModel *model.Todo
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		created, err := t.create(ctx, reqTodo)
+		if err != nil {
+			return err
+		}
+		todoModel = created
+		return t.record(ctx, model.EventTodoCreated, created)
+	})
 	if err != nil {
-		t.log.Error(ctx, fmt.Sprintf("failed to create todo: %s", err.Error()))
 		return nil, err
 	}
+	t.notify()
 
+	// The cache is refreshed again when the event is dispatched, so a failure here only delays it.
+	todoKey := fmt.Sprintf("todo:%d", todoModel.ID)
 	err = t.redisCache.Add(ctx, todoKey, todoModel)
 	if err != nil {
-		t.log.Error(ctx, fmt.Sprintf("failed to create todo: %s", err.Error()))
-		return nil, err
+		t.log.Error(ctx, fmt.Sprintf("failed to add todo in redis : %s", err.Error()))
 	}
 
 	t.log.Info(ctx, fmt.Sprintf("Todo created successfully with ID: %d", todoModel.ID))
 	return todoModel, nil
 }
 
-func (t *todoReceiver) Update(ctx context.Context, reqTodo *model.UpdateRequest) (*model.Todo, error) {
-	// 現在の値を取得
-	currentTodo, err := t.Find(ctx, &model.FindRequest{
-		ID: reqTodo.ID,
+func (t *todoReceiver) QuickCreate(ctx context.Context, reqTodo *model.QuickCreateRequest) (*model.QuickCreateResponse, error) {
+	parsed, err := t.quickAdd.Parse(reqTodo.Text)
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("invalid quick-add text: %s", err.Error()))
+		return nil, err
+	}
+
+	priority := parsed.Priority
+	if priority == "" {
+		priority = string(model.TP_Medium)
+	}
+
+	todo, err := t.Create(ctx, &model.CreateRequest{
+		Task:     parsed.Task,
+		Priority: priority,
+		Tags:     parsed.Tags,
+		Assignee: parsed.Assignee,
+		DueAt:    parsed.DueAt,
 	})
 	if err != nil {
-		t.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", reqTodo.ID, err.Error()))
 		return nil, err
 	}
 
-	// Update fields only if they are provided in the request
-	updatedTodo := model.NewUpdateTodo(reqTodo)
-	updatedTodo.PrepareUpdatedTodo(currentTodo)
+	return &model.QuickCreateResponse{Todo: todo, Parsed: parsed}, nil
+}
 
-	// Save updated todo in the repository
-	if err := t.todoRepository.Update(ctx, updatedTodo); err != nil {
-		t.log.Error(ctx, fmt.Sprintf("failed to update  todo with ID: %d and Error: %s", reqTodo.ID, err.Error()))
+func (t *todoReceiver) Update(ctx context.Context, reqTodo *model.UpdateRequest) (*model.Todo, error) {
+	// The current todo is read in the transaction of the update, from the
+	// database rather than the cache, so that no concurrent change is lost.
+	var updatedTodo *model.Todo
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		// 現在の値を取得
+		currentTodo, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqTodo.ID})
+		if err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", reqTodo.ID, err.Error()))
+			return err
+		}
+
+		updated, err := t.update(ctx, currentTodo, reqTodo)
+		if err != nil {
+			return err
+		}
+		updatedTodo = updated
+		return t.recordUpdate(ctx, currentTodo.Status, updated)
+	})
+	if err != nil {
 		return nil, err
 	}
+	t.notify()
 
 	todoKey := fmt.Sprintf("todo:%d", updatedTodo.ID)
 	err = t.redisCache.Delete(ctx, todoKey)
@@ -111,21 +165,152 @@ func (t *todoReceiver) Update(ctx context.Context, reqTodo *model.UpdateRequest)
 	return updatedTodo, nil
 }
 
+func (t *todoReceiver) Replace(ctx context.Context, reqParams *model.ReplaceRequest) (*model.Todo, error) {
+	todo := model.NewTodo(&reqParams.Todo)
+	todo.Status = reqParams.Status
+	if err := validateReplacement(todo); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("invalid request: %s", err.Error()))
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidTodo, err.Error())
+	}
+
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		if reqParams.ID == 0 {
+			if err := t.checkWIPLimit(ctx, todo.Status); err != nil {
+				return err
+			}
+			return t.insert(ctx, todo)
+		}
+
+		current, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.ID})
+		if err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", reqParams.ID, err.Error()))
+			return err
+		}
+		if todo.Status != current.Status {
+			if err := t.checkWIPLimit(ctx, todo.Status); err != nil {
+				return err
+			}
+		}
+		return t.replace(ctx, current, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.notify()
+
+	if err := t.redisCache.Sync(ctx, []*model.Todo{todo}, nil); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to sync todo to redis with ID: %d and Error: %s", todo.ID, err.Error()))
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Todo replaced successfully with ID: %d", todo.ID))
+	return todo, nil
+}
+
+// create validates, ranks and stores a new todo.
+func (t *todoReceiver) create(ctx context.Context, reqTodo *model.CreateRequest) (*model.Todo, error) {
+	// Create a new Todo instance using the struct-based constructor
+	todoModel := model.NewTodo(reqTodo)
+
+	// Validate the input before proceeding
+	if err := todoModel.ValidateCreateRequest(); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("invalid request: %s", err.Error()))
+		return nil, err
+	}
+
+	// New todos go to the end of their status column
+	r, err := t.appendRank(ctx, todoModel.Status)
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to rank todo: %s", err.Error()))
+		return nil, err
+	}
+	todoModel.Rank = r
+
+	// Attempt to store the new todo using the repository pattern
+	if err := t.todoRepository.Create(ctx, todoModel); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to create todo: %s", err.Error()))
+		return nil, err
+	}
+
+	return todoModel, nil
+}
+
+// update applies the request to currentTodo and stores the result.
+func (t *todoReceiver) update(ctx context.Context, currentTodo *model.Todo, reqTodo *model.UpdateRequest) (*model.Todo, error) {
+	// Update fields only if they are provided in the request
+	updatedTodo := model.NewUpdateTodo(reqTodo)
+	updatedTodo.PrepareUpdatedTodo(currentTodo)
+
+	// A todo changing status goes to the end of its new column
+	if updatedTodo.Status != currentTodo.Status {
+		if err := t.checkWIPLimit(ctx, updatedTodo.Status); err != nil {
+			return nil, err
+		}
+		r, err := t.appendRank(ctx, updatedTodo.Status)
+		if err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to rank todo with ID: %d and Error: %s", reqTodo.ID, err.Error()))
+			return nil, err
+		}
+		updatedTodo.Rank = r
+	}
+
+	// Save updated todo in the repository
+	if err := t.todoRepository.Update(ctx, updatedTodo); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to update  todo with ID: %d and Error: %s", reqTodo.ID, err.Error()))
+		return nil, err
+	}
+
+	return updatedTodo, nil
+}
+
 func (t *todoReceiver) Delete(ctx context.Context, reqParams *model.DeleteRequest) error {
 	cacheKey := fmt.Sprintf("todo:%d", reqParams.ID)
 
-	if err := t.todoRepository.Delete(ctx, reqParams); err != nil {
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		// The deleted event carries the todo as it was, so that filtered streams see it.
+		var deleted *model.Todo
+		if t.outbox != nil {
+			todo, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.ID})
+			if err != nil {
+				return err
+			}
+			deleted = todo
+		}
+
+		if err := t.todoRepository.Delete(ctx, reqParams); err != nil {
+			return err
+		}
+		return t.record(ctx, model.EventTodoDeleted, deleted)
+	})
+	if err != nil {
 		t.log.Error(ctx, err.Error())
 		return err
 	}
-	err := t.redisCache.Delete(ctx, cacheKey)
+	t.notify()
+
+	err = t.redisCache.Delete(ctx, cacheKey)
 	if err != nil {
 		t.log.Error(ctx, err.Error())
-		return err
 	}
 	return nil
 }
 func (t *todoReceiver) Find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error) {
+	todo, err := t.find(ctx, reqParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if reqParams.Render == model.RenderHTML {
+		if err := todo.RenderDescription(); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to render description: %s", err.Error()))
+			return nil, err
+		}
+	}
+
+	return todo, nil
+}
+
+// find returns the todo from the Redis cache, or from the database when it is not cached.
+func (t *todoReceiver) find(ctx context.Context, reqParams *model.FindRequest) (*model.Todo, error) {
 	// Try to fetch from Redis cache first
 	cacheKey := fmt.Sprintf("todo:%d", reqParams.ID)
 	cachedTodo, err := t.redisCache.Get(ctx, cacheKey)
@@ -136,6 +321,8 @@ func (t *todoReceiver) Find(ctx context.Context, reqParams *model.FindRequest) (
 		if err != nil {
 			t.log.Error(ctx, fmt.Sprintf("failed to unmarshal cached todo: %s ", err.Error()))
 		} else {
+			// The plain text of the description is not cached.
+			todo.SetDescription(todo.Description)
 			return todo, nil
 		}
 	} else if !errors.Is(err, redis.Nil) && err != nil {
@@ -161,7 +348,7 @@ func (t *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllRequ
 	//t.redisCache.DeleteAll(ctx)
 	all, err := t.redisCache.FindAll(ctx, reqParams)
 	if err == nil || len(all) > 0 {
-		return all, nil
+		return t.render(ctx, reqParams, all)
 	}
 
 	// Cache miss, fetch from the database
@@ -181,6 +368,600 @@ func (t *todoReceiver) FindAll(ctx context.Context, reqParams *model.FindAllRequ
 		}
 	}
 
+	return t.render(ctx, reqParams, todos)
+}
+
+func (t *todoReceiver) Move(ctx context.Context, reqParams *model.MoveRequest) (*model.Todo, error) {
+	// The todos are read in the transaction of the move, so that the ranks are
+	// placed among those of the column as it is committed.
+	var todo *model.Todo
+	var rebalanced []*model.Todo
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		todo, rebalanced, err = t.move(ctx, reqParams)
+		return err
+	})
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to move todo with ID: %d and Error: %s", reqParams.ID, err.Error()))
+		return nil, err
+	}
+	t.notify()
+
+	for _, changed := range append([]*model.Todo{todo}, rebalanced...) {
+		todoKey := fmt.Sprintf("todo:%d", changed.ID)
+		if err := t.redisCache.Delete(ctx, todoKey); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to delete todo in redis with ID: %d and Error: %s", changed.ID, err.Error()))
+		}
+		if err := t.redisCache.Add(ctx, todoKey, changed); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to add todo in redis : %s", err.Error()))
+		}
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Todo moved successfully with ID: %d", todo.ID))
+	return todo, nil
+}
+
+// move places the todo as requested and stores it with the rebalanced todos
+// of its column. It returns both.
+func (t *todoReceiver) move(ctx context.Context, reqParams *model.MoveRequest) (*model.Todo, []*model.Todo, error) {
+	todo, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.ID})
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", reqParams.ID, err.Error()))
+		return nil, nil, err
+	}
+	previous := todo.Status
+
+	// Moving next to another todo puts the todo into that todo's column.
+	targetID := reqParams.Before
+	if targetID == 0 {
+		targetID = reqParams.After
+	}
+	status := reqParams.Status
+	if targetID != 0 {
+		if targetID == todo.ID {
+			return nil, nil, fmt.Errorf("%w: todo %d cannot be moved next to itself", model.ErrInvalidMove, todo.ID)
+		}
+		target, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: targetID})
+		if err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to find todo with ID: %d and Error: %s", targetID, err.Error()))
+			return nil, nil, err
+		}
+		if status != "" && status != target.Status {
+			return nil, nil, fmt.Errorf("%w: todo %d is %s, not %s", model.ErrInvalidMove, targetID, target.Status, status)
+		}
+		status = target.Status
+	}
+
+	if status != todo.Status {
+		if err := t.checkWIPLimit(ctx, status); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	column, err := t.todoRepository.FindAll(ctx, &model.FindAllRequest{Status: string(status), Sort: model.SortManual})
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return nil, nil, err
+	}
+
+	// Find the position among the other todos of the column
+	others := make([]*model.Todo, 0, len(column))
+	for _, other := range column {
+		if other.ID != todo.ID {
+			others = append(others, other)
+		}
+	}
+	pos := len(others)
+	for i, other := range others {
+		if other.ID == targetID {
+			pos = i
+			if reqParams.After != 0 {
+				pos++
+			}
+			break
+		}
+	}
+
+	todo.Status = status
+	rebalanced, err := t.placeAt(ctx, todo, others, pos)
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to rank todo with ID: %d and Error: %s", todo.ID, err.Error()))
+		return nil, nil, err
+	}
+
+	if err := t.todoRepository.Move(ctx, todo, rebalanced); err != nil {
+		return nil, nil, err
+	}
+	if err := t.recordUpdate(ctx, previous, todo); err != nil {
+		return nil, nil, err
+	}
+	return todo, rebalanced, nil
+}
+
+func (t *todoReceiver) Board(ctx context.Context, reqParams *model.BoardRequest) (*model.Board, error) {
+	// Read from the database: the cache does not filter by project.
+	todos, err := t.todoRepository.FindAll(ctx, &model.FindAllRequest{Project: reqParams.Project, Sort: model.SortManual})
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return nil, err
+	}
+
+	board := &model.Board{}
+	columns := map[model.Status]*model.BoardColumn{}
+	for _, status := range model.StatusColumns {
+		column := &model.BoardColumn{Status: status, WIPLimit: t.board.WIPLimits[string(status)], Todos: []*model.Todo{}}
+		columns[status] = column
+		board.Columns = append(board.Columns, column)
+	}
+
+	for _, todo := range todos {
+		column, ok := columns[todo.Status]
+		if !ok {
+			t.log.Error(ctx, fmt.Sprintf("todo %d has unknown status %q", todo.ID, todo.Status))
+			continue
+		}
+		column.Todos = append(column.Todos, todo)
+	}
+
+	for _, column := range board.Columns {
+		column.Count = len(column.Todos)
+		if column.WIPLimit <= 0 {
+			continue
+		}
+		// The limits hold for every project, as checkWIPLimit enforces them.
+		column.WIPCount = column.Count
+		if reqParams.Project != "" {
+			count, err := t.todoRepository.CountByStatus(ctx, column.Status)
+			if err != nil {
+				t.log.Error(ctx, err.Error())
+				return nil, err
+			}
+			column.WIPCount = int(count)
+		}
+		column.OverLimit = column.WIPCount > column.WIPLimit
+	}
+
+	return board, nil
+}
+
+func (t *todoReceiver) Batch(ctx context.Context, reqParams *model.BatchRequest) (*model.BatchResponse, error) {
+	mode := reqParams.Mode
+	if mode == "" {
+		mode = model.BatchAtomic
+	}
+	res := &model.BatchResponse{Mode: mode, Results: make([]*model.BatchResult, 0, len(reqParams.Operations))}
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		for i := range reqParams.Operations {
+			op := &reqParams.Operations[i]
+			result := &model.BatchResult{Op: op.Op, ID: op.ID}
+			res.Results = append(res.Results, result)
+
+			apply := func(ctx context.Context) error {
+				// The todo as it was, for the deleted and completed events
+				var before *model.Todo
+				if op.Op != model.BatchCreate && t.outbox != nil {
+					todo, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: op.ID})
+					if err != nil {
+						return err
+					}
+					before = todo
+				}
+
+				todo, err := t.applyBatchOperation(ctx, op)
+				if err != nil {
+					return err
+				}
+				// The events are written with the operation, so a rolled back one leaves none.
+				switch op.Op {
+				case model.BatchDelete:
+					err = t.record(ctx, model.EventTodoDeleted, before)
+				case model.BatchCreate:
+					err = t.record(ctx, model.EventTodoCreated, todo)
+				case model.BatchUpdate:
+					var previous model.Status
+					if before != nil {
+						previous = before.Status
+					}
+					err = t.recordUpdate(ctx, previous, todo)
+				}
+				if err != nil {
+					return err
+				}
+				if todo != nil {
+					result.Todo, result.ID = todo, todo.ID
+				}
+				return nil
+			}
+
+			// In partial mode each operation gets a savepoint, so a failure only undoes itself.
+			if mode == model.BatchPartial {
+				if err := t.todoRepository.WithTx(ctx, apply); err != nil {
+					result.Err = err
+				}
+				continue
+			}
+			if err := apply(ctx); err != nil {
+				return fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("batch rolled back: %s", err.Error()))
+		return nil, err
+	}
+	t.notify()
+
+	// Only the final state of each todo goes to the cache, in one round trip.
+	final := map[int]*model.Todo{}
+	var order []int
+	for _, result := range res.Results {
+		if result.Err != nil {
+			res.Failed++
+			continue
+		}
+		res.Succeeded++
+		if _, ok := final[result.ID]; !ok {
+			order = append(order, result.ID)
+		}
+		final[result.ID] = result.Todo
+	}
+	var upserted []*model.Todo
+	var deletedIDs []int
+	for _, id := range order {
+		if todo := final[id]; todo != nil {
+			upserted = append(upserted, todo)
+		} else {
+			deletedIDs = append(deletedIDs, id)
+		}
+	}
+	if err := t.redisCache.Sync(ctx, upserted, deletedIDs); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to sync batch to redis: %s", err.Error()))
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Batch applied: %d succeeded, %d failed", res.Succeeded, res.Failed))
+	return res, nil
+}
+
+func (t *todoReceiver) Export(ctx context.Context, reqParams *model.ExportRequest, fn func(todo *model.Todo) error) error {
+	// Read from the database: the cache does not filter by project.
+	todos, err := t.todoRepository.FindAll(ctx, &model.FindAllRequest{
+		Status: reqParams.Status, Project: reqParams.Project, Sort: model.SortManual,
+	})
+	if err != nil {
+		t.log.Error(ctx, err.Error())
+		return err
+	}
+
+	for _, todo := range todos {
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errImportRollback rolls back the transaction of a dry run or of an import with invalid lines.
+var errImportRollback = errors.New("import rolled back")
+
+func (t *todoReceiver) Import(ctx context.Context, reqParams *model.ImportRequest, records []*model.ImportRecord) (*model.ImportResponse, error) {
+	onConflict := reqParams.OnConflict
+	if onConflict == "" {
+		onConflict = model.ConflictSkip
+	}
+	res := &model.ImportResponse{DryRun: reqParams.DryRun, Lines: make([]*model.ImportLine, 0, len(records))}
+
+	// A dry run imports as well, in a transaction that is rolled back, so that
+	// it reports the conflicts between the lines of the file too.
+	var changed []*model.Todo
+	err := t.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := t.todoRepository.FindAll(ctx, &model.FindAllRequest{})
+		if err != nil {
+			return err
+		}
+		im := &importer{byID: map[int]*model.Todo{}, byTask: map[string]*model.Todo{}}
+		for _, todo := range existing {
+			im.add(todo)
+		}
+
+		for _, record := range records {
+			line := &model.ImportLine{Line: record.Line, Task: record.Todo.Task}
+			res.Lines = append(res.Lines, line)
+
+			todo, err := t.importRecord(ctx, im, record, onConflict, line)
+			if err != nil {
+				return err
+			}
+			switch line.Action {
+			case model.ImportCreate:
+				res.Created++
+			case model.ImportUpdate:
+				res.Updated++
+			case model.ImportSkip:
+				res.Skipped++
+			case model.ImportInvalid:
+				res.Invalid++
+			}
+			if todo != nil {
+				changed = append(changed, todo)
+			}
+		}
+
+		if reqParams.DryRun || res.Invalid > 0 {
+			return errImportRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		t.log.Error(ctx, fmt.Sprintf("import rolled back: %s", err.Error()))
+		return nil, err
+	}
+	res.Applied = err == nil
+	if !res.Applied {
+		return res, nil
+	}
+	t.notify()
+
+	if err := t.redisCache.Sync(ctx, changed, nil); err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to sync import to redis: %s", err.Error()))
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Imported %d todos: %d created, %d updated, %d skipped", len(records), res.Created, res.Updated, res.Skipped))
+	return res, nil
+}
+
+// importer finds the todos an import conflicts with: those of the same ID, or
+// else of the same task.
+type importer struct {
+	byID   map[int]*model.Todo
+	byTask map[string]*model.Todo
+}
+
+func (im *importer) add(todo *model.Todo) {
+	im.byID[todo.ID] = todo
+	im.byTask[strings.ToLower(todo.Task)] = todo
+}
+
+func (im *importer) conflict(record *model.ImportRecord) *model.Todo {
+	if todo, ok := im.byID[record.ID]; ok && record.ID != 0 {
+		return todo
+	}
+	return im.byTask[strings.ToLower(record.Todo.Task)]
+}
+
+// importRecord creates or updates the todo of record, or skips it, and fills
+// line with what it did. It returns the todo written, if any. Invalid records
+// are reported in line: the error returned aborts the import.
+func (t *todoReceiver) importRecord(ctx context.Context, im *importer, record *model.ImportRecord, onConflict string, line *model.ImportLine) (*model.Todo, error) {
+	todo := model.NewTodo(&record.Todo)
+	todo.Status = record.Status
+	err := record.Err
+	if err == nil {
+		err = validateReplacement(todo)
+	}
+	if err != nil {
+		line.Action, line.Error = model.ImportInvalid, err.Error()
+		return nil, nil
+	}
+
+	current := im.conflict(record)
+	if current != nil && onConflict == model.ConflictSkip {
+		line.Action, line.ID = model.ImportSkip, current.ID
+		return nil, nil
+	}
+
+	if current == nil {
+		if record.CreatedAt != nil {
+			todo.CreatedAt = *record.CreatedAt
+		}
+		if err := t.insert(ctx, todo); err != nil {
+			return nil, err
+		}
+		im.add(todo)
+		line.Action, line.ID = model.ImportCreate, todo.ID
+		return todo, nil
+	}
+
+	if err := t.replace(ctx, current, todo); err != nil {
+		return nil, err
+	}
+	im.add(todo)
+	line.Action, line.ID = model.ImportUpdate, todo.ID
+	return todo, nil
+}
+
+// validateReplacement checks the fields of a todo written as a whole, status included.
+func validateReplacement(todo *model.Todo) error {
+	if err := todo.ValidateCreateRequest(); err != nil {
+		return err
+	}
+	if !model.StatusMap[todo.Status] {
+		return fmt.Errorf("invalid status: %q", todo.Status)
+	}
+	if todo.EstimateMinutes < 0 {
+		return fmt.Errorf("invalid estimate: %d minutes", todo.EstimateMinutes)
+	}
+	return nil
+}
+
+// insert stores a new todo at the end of its status column.
+func (t *todoReceiver) insert(ctx context.Context, todo *model.Todo) error {
+	r, err := t.appendRank(ctx, todo.Status)
+	if err != nil {
+		return err
+	}
+	todo.Rank = r
+	if err := t.todoRepository.Create(ctx, todo); err != nil {
+		return err
+	}
+	return t.record(ctx, model.EventTodoCreated, todo)
+}
+
+// replace writes todo over every field of current. It keeps the place of
+// current in its column unless it changes status.
+func (t *todoReceiver) replace(ctx context.Context, current, todo *model.Todo) error {
+	todo.ID, todo.Rank, todo.CreatedAt = current.ID, current.Rank, current.CreatedAt
+	if todo.Status != current.Status {
+		r, err := t.appendRank(ctx, todo.Status)
+		if err != nil {
+			return err
+		}
+		todo.Rank = r
+	}
+	if err := t.todoRepository.Update(ctx, todo); err != nil {
+		return err
+	}
+	return t.recordUpdate(ctx, current.Status, todo)
+}
+
+// applyBatchOperation runs one batch operation. It returns the
+// created or updated todo, or nil for a delete.
+func (t *todoReceiver) applyBatchOperation(ctx context.Context, op *model.BatchOperation) (*model.Todo, error) {
+	switch op.Op {
+	case model.BatchCreate:
+		return t.create(ctx, op.Create)
+	case model.BatchUpdate:
+		currentTodo, err := t.todoRepository.Find(ctx, &model.FindRequest{ID: op.ID})
+		if err != nil {
+			return nil, err
+		}
+		return t.update(ctx, currentTodo, &model.UpdateRequest{
+			UpdateRequestBody: *op.Update,
+			UpdateRequestPath: model.UpdateRequestPath{ID: op.ID},
+		})
+	case model.BatchDelete:
+		return nil, t.todoRepository.Delete(ctx, &model.DeleteRequest{ID: op.ID})
+	default:
+		return nil, fmt.Errorf("unknown batch operation: %s", op.Op)
+	}
+}
+
+// record writes an event for todo to the outbox when events are
+// enabled. Called within a transaction, the event commits with the change.
+func (t *todoReceiver) record(ctx context.Context, eventType string, todo *model.Todo) error {
+	if t.outbox == nil {
+		return nil
+	}
+
+	event, err := model.NewEvent(eventType, todo)
+	if err != nil {
+		t.log.Error(ctx, fmt.Sprintf("failed to marshal todo %d for %s: %s", todo.ID, eventType, err.Error()))
+		return err
+	}
+	return t.todoRepository.CreateEvent(ctx, event)
+}
+
+// recordUpdate records the updated event for todo, followed by the completed
+// event when it moved to done from previous.
+func (t *todoReceiver) recordUpdate(ctx context.Context, previous model.Status, todo *model.Todo) error {
+	if err := t.record(ctx, model.EventTodoUpdated, todo); err != nil {
+		return err
+	}
+	if previous != model.Done && todo.Status == model.Done {
+		return t.record(ctx, model.EventTodoCompleted, todo)
+	}
+	return nil
+}
+
+// notify wakes the outbox dispatcher after a commit, so that the events
+// recorded go out without waiting for its next poll.
+func (t *todoReceiver) notify() {
+	if t.outbox != nil {
+		t.outbox.Notify()
+	}
+}
+
+// checkWIPLimit rejects a todo entering a column that is already at its limit, when limits are enforced.
+// The limit counts the todos of every project, as the WIPCount of the board does.
+func (t *todoReceiver) checkWIPLimit(ctx context.Context, status model.Status) error {
+	limit := t.board.WIPLimits[string(status)]
+	if !t.board.EnforceWIPLimits || limit <= 0 {
+		return nil
+	}
+
+	count, err := t.todoRepository.CountByStatus(ctx, status)
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return fmt.Errorf("%w: %s holds %d of %d", model.ErrWIPLimitReached, status, count, limit)
+	}
+
+	return nil
+}
+
+// placeAt ranks todo to sit at pos among the ordered others. When the
+// neighbouring ranks leave no usable gap, or have no rank yet, the whole column
+// is spread out again and the others whose rank changed are returned.
+func (t *todoReceiver) placeAt(ctx context.Context, todo *model.Todo, others []*model.Todo, pos int) ([]*model.Todo, error) {
+	var prev, next string
+	if pos > 0 {
+		prev = others[pos-1].Rank
+	}
+	if pos < len(others) {
+		next = others[pos].Rank
+	}
+
+	if (pos == 0 || prev != "") && (pos == len(others) || next != "") {
+		var r string
+		var err error
+		if pos == len(others) && prev != "" {
+			r, err = rank.After(prev)
+		} else {
+			r, err = rank.Between(prev, next)
+		}
+		if err == nil && len(r) <= rank.MaxLength {
+			todo.Rank = r
+			return nil, nil
+		}
+		if err != nil && !errors.Is(err, rank.ErrOutOfOrder) {
+			return nil, err
+		}
+	}
+
+	ordered := make([]*model.Todo, 0, len(others)+1)
+	ordered = append(ordered, others[:pos]...)
+	ordered = append(ordered, todo)
+	ordered = append(ordered, others[pos:]...)
+
+	var rebalanced []*model.Todo
+	for i, r := range rank.Spread(len(ordered)) {
+		if ordered[i] != todo && ordered[i].Rank != r {
+			rebalanced = append(rebalanced, ordered[i])
+		}
+		ordered[i].Rank = r
+	}
+
+	t.log.Info(ctx, fmt.Sprintf("Rebalanced %d ranks in column %s", len(rebalanced), todo.Status))
+	return rebalanced, nil
+}
+
+// appendRank returns a rank at the end of the status column.
+func (t *todoReceiver) appendRank(ctx context.Context, status model.Status) (string, error) {
+	last, err := t.todoRepository.LastRank(ctx, status)
+	if err != nil {
+		return "", err
+	}
+
+	if last == "" {
+		return rank.Between("", "")
+	}
+	return rank.After(last)
+}
+
+// render fills the HTML description of each todo when requested.
+func (t *todoReceiver) render(ctx context.Context, reqParams *model.FindAllRequest, todos []*model.Todo) ([]*model.Todo, error) {
+	if reqParams.Render != model.RenderHTML {
+		return todos, nil
+	}
+
+	for _, todo := range todos {
+		if err := todo.RenderDescription(); err != nil {
+			t.log.Error(ctx, fmt.Sprintf("failed to render description of todo %d: %s", todo.ID, err.Error()))
+			return nil, err
+		}
+	}
+
 	return todos, nil
 }
 
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+var errEventStore = errors.New("event store unavailable")
+
+// failingEvents is a todo repository that cannot record events, failing the
+// changes after they were written.
+type failingEvents struct {
+	repository.ITodo
+}
+
+func (failingEvents) CreateEvent(context.Context, *model.Event) error {
+	return errEventStore
+}
+
+func TestTodo_Transactions(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	outbox := NewOutbox(&InitOutboxService{
+		Log: env.log, EventRepository: repository.NewEvent(&repository.InitEventRepository{Db: env.db, Log: env.log}),
+	})
+	todoService := env.todoService(&InitTodoService{Outbox: outbox})
+	failingService := env.todoService(&InitTodoService{TodoRepository: failingEvents{env.todos}, Outbox: outbox})
+
+	first, err := todoService.Create(ctx, &model.CreateRequest{Task: "First", Priority: "low"})
+	require.NoError(t, err)
+	second, err := todoService.Create(ctx, &model.CreateRequest{Task: "Second", Priority: "low"})
+	require.NoError(t, err)
+
+	t.Run("update_reads_the_database", func(t *testing.T) {
+		// A stale cache entry is not what the update is applied to
+		stale := *first
+		stale.Description = "Stale"
+		require.NoError(t, env.cache.Add(ctx, fmt.Sprintf("todo:%d", first.ID), &stale))
+
+		updated, err := todoService.Update(ctx, &model.UpdateRequest{
+			UpdateRequestBody: model.UpdateRequestBody{Task: "First edited"},
+			UpdateRequestPath: model.UpdateRequestPath{ID: first.ID},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "First edited", updated.Task)
+		assert.Empty(t, updated.Description)
+	})
+
+	t.Run("update_rolls_back", func(t *testing.T) {
+		_, err := failingService.Update(ctx, &model.UpdateRequest{
+			UpdateRequestBody: model.UpdateRequestBody{Task: "Lost", Status: model.Done},
+			UpdateRequestPath: model.UpdateRequestPath{ID: second.ID},
+		})
+		assert.ErrorIs(t, err, errEventStore)
+
+		found, err := env.todos.Find(ctx, &model.FindRequest{ID: second.ID})
+		require.NoError(t, err)
+		assert.Equal(t, "Second", found.Task)
+		assert.Equal(t, model.Created, found.Status)
+	})
+
+	t.Run("move_rolls_back", func(t *testing.T) {
+		_, err := failingService.Move(ctx, &model.MoveRequest{ID: second.ID, Before: first.ID})
+		assert.ErrorIs(t, err, errEventStore)
+
+		column, err := env.todos.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+		require.NoError(t, err)
+		require.Len(t, column, 2)
+		assert.Equal(t, []int{first.ID, second.ID}, []int{column[0].ID, column[1].ID})
+		assert.Equal(t, second.Rank, column[1].Rank)
+	})
+}
+
+func TestTodo_Import(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	todoService := env.todoService(&InitTodoService{
+		Outbox: NewOutbox(&InitOutboxService{
+			Log: env.log, EventRepository: repository.NewEvent(&repository.InitEventRepository{Db: env.db, Log: env.log}),
+		}),
+	})
+
+	existing, err := todoService.Create(ctx, &model.CreateRequest{Task: "Buy milk", Priority: "low"})
+	require.NoError(t, err)
+
+	records := func() []*model.ImportRecord {
+		return []*model.ImportRecord{
+			{Line: 1, Todo: model.CreateRequest{Task: "buy MILK", Priority: "high"}, Status: model.Done},
+			{Line: 2, Todo: model.CreateRequest{Task: "Call Bob", Priority: "medium"}, Status: model.Created},
+		}
+	}
+	count := func() int {
+		todos, err := env.todos.FindAll(ctx, &model.FindAllRequest{})
+		require.NoError(t, err)
+		return len(todos)
+	}
+	actions := func(res *model.ImportResponse) []string {
+		var actions []string
+		for _, line := range res.Lines {
+			actions = append(actions, line.Action)
+		}
+		return actions
+	}
+
+	t.Run("dry_run", func(t *testing.T) {
+		res, err := todoService.Import(ctx, &model.ImportRequest{DryRun: true}, records())
+		require.NoError(t, err)
+		assert.False(t, res.Applied)
+		assert.Equal(t, []string{model.ImportSkip, model.ImportCreate}, actions(res))
+		assert.Equal(t, existing.ID, res.Lines[0].ID)
+		assert.Equal(t, 1, count())
+	})
+
+	t.Run("invalid_lines_import_nothing", func(t *testing.T) {
+		invalid := append(records(),
+			&model.ImportRecord{Line: 3, Todo: model.CreateRequest{Priority: "medium"}, Status: model.Created},
+			&model.ImportRecord{Line: 4, Todo: model.CreateRequest{Task: "Walk dog", Priority: "medium"}, Status: "later"},
+			&model.ImportRecord{Line: 5, Err: errors.New("broken line")},
+		)
+		res, err := todoService.Import(ctx, &model.ImportRequest{}, invalid)
+		require.NoError(t, err)
+		assert.False(t, res.Applied)
+		assert.Equal(t, 3, res.Invalid)
+		assert.Equal(t, "broken line", res.Lines[4].Error)
+		assert.Equal(t, 1, count())
+	})
+
+	t.Run("update_on_conflict", func(t *testing.T) {
+		res, err := todoService.Import(ctx, &model.ImportRequest{OnConflict: model.ConflictUpdate}, records())
+		require.NoError(t, err)
+		assert.True(t, res.Applied)
+		assert.Equal(t, []string{model.ImportUpdate, model.ImportCreate}, actions(res))
+		assert.Equal(t, 2, count())
+
+		updated, err := env.todos.Find(ctx, &model.FindRequest{ID: existing.ID})
+		require.NoError(t, err)
+		assert.Equal(t, "buy MILK", updated.Task)
+		assert.Equal(t, model.Done, updated.Status)
+		assert.Equal(t, existing.CreatedAt.Unix(), updated.CreatedAt.Unix())
+	})
+
+	t.Run("skip_on_conflict", func(t *testing.T) {
+		res, err := todoService.Import(ctx, &model.ImportRequest{}, records())
+		require.NoError(t, err)
+		assert.True(t, res.Applied)
+		assert.Equal(t, 2, res.Skipped)
+		assert.Equal(t, 2, count())
+	})
+}
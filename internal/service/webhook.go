@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+// Headers of a webhook delivery.
+const (
+	// HeaderWebhookDelivery is the ID of the delivery. It is the same on every attempt.
+	HeaderWebhookDelivery = "X-Webhook-Delivery"
+	// HeaderWebhookEvent is the event type.
+	HeaderWebhookEvent = "X-Webhook-Event"
+	// HeaderWebhookTimestamp is the Unix time of the attempt, covered by the signature.
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	// HeaderWebhookSignature is "sha256=" followed by the result of Sign.
+	HeaderWebhookSignature = "X-Webhook-Signature"
+)
+
+// claimBatchSize is the number of deliveries attempted at a time.
+const claimBatchSize = 20
+
+// defaultWebhooks is the configuration used for the settings left at zero.
+var defaultWebhooks = model.Webhooks{
+	PollInterval: time.Second, Timeout: 10 * time.Second, MaxAttempts: 8, RetryDelay: 30 * time.Second, MaxRetryDelay: time.Hour,
+}
+
+// maxResponseRead is how much of a receiver's response is read before the connection is reused.
+const maxResponseRead = 64 << 10
+
+// IWebhook is the service for webhooks.
+type IWebhook interface {
+	Create(ctx context.Context, reqParams *model.CreateWebhookRequest) (*model.Webhook, error)
+	Find(ctx context.Context, reqParams *model.FindWebhookRequest) (*model.Webhook, error)
+	FindAll(ctx context.Context) ([]*model.Webhook, error)
+	Delete(ctx context.Context, reqParams *model.DeleteWebhookRequest) error
+	FindDeliveries(ctx context.Context, reqParams *model.FindDeliveriesRequest) ([]*model.WebhookDelivery, error)
+	// ISink queues a delivery of each event to every webhook subscribed to its
+	// type. A redispatched event is not queued twice.
+	ISink
+	// Run delivers the queued deliveries until ctx is done. Several processes
+	// may run it against the same database.
+	Run(ctx context.Context)
+}
+
+type InitWebhookService struct {
+	Log               *log.Logger
+	WebhookRepository repository.IWebhook
+	// Config is the delivery configuration. Settings left at zero get defaults.
+	Config model.Webhooks
+	// Client sends the deliveries. Defaults to a client with Config.Timeout.
+	Client *http.Client
+}
+
+type webhookReceiver struct {
+	log               *log.Logger
+	webhookRepository repository.IWebhook
+	config            model.Webhooks
+	client            *http.Client
+}
+
+// NewWebhook creates a new Webhook service.
+func NewWebhook(initWebhookService *InitWebhookService) IWebhook {
+	config := initWebhookService.Config
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultWebhooks.PollInterval
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultWebhooks.Timeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultWebhooks.MaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultWebhooks.RetryDelay
+	}
+	if config.MaxRetryDelay < config.RetryDelay {
+		config.MaxRetryDelay = config.RetryDelay
+	}
+
+	client := initWebhookService.Client
+	if client == nil {
+		client = &http.Client{Timeout: config.Timeout}
+	}
+
+	return &webhookReceiver{
+		log:               initWebhookService.Log,
+		webhookRepository: initWebhookService.WebhookRepository,
+		config:            config,
+		client:            client,
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of the timestamp, a dot and the body, keyed
+// with secret. Receivers compute it the same way, compare it in constant time
+// and reject old timestamps to stop replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *webhookReceiver) Create(ctx context.Context, reqParams *model.CreateWebhookRequest) (*model.Webhook, error) {
+	webhook := &model.Webhook{URL: reqParams.URL, Events: reqParams.Events, Secret: reqParams.Secret}
+	if err := w.webhookRepository.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	w.log.Info(ctx, fmt.Sprintf("Webhook created successfully with ID: %d", webhook.ID))
+	return webhook, nil
+}
+
+func (w *webhookReceiver) Find(ctx context.Context, reqParams *model.FindWebhookRequest) (*model.Webhook, error) {
+	return w.webhookRepository.Find(ctx, reqParams)
+}
+
+func (w *webhookReceiver) FindAll(ctx context.Context) ([]*model.Webhook, error) {
+	return w.webhookRepository.FindAll(ctx)
+}
+
+func (w *webhookReceiver) Delete(ctx context.Context, reqParams *model.DeleteWebhookRequest) error {
+	if err := w.webhookRepository.Delete(ctx, reqParams); err != nil {
+		return err
+	}
+
+	w.log.Info(ctx, fmt.Sprintf("Webhook deleted successfully with ID: %d", reqParams.ID))
+	return nil
+}
+
+func (w *webhookReceiver) FindDeliveries(ctx context.Context, reqParams *model.FindDeliveriesRequest) ([]*model.WebhookDelivery, error) {
+	if _, err := w.webhookRepository.Find(ctx, &model.FindWebhookRequest{ID: reqParams.WebhookID}); err != nil {
+		return nil, err
+	}
+	return w.webhookRepository.FindDeliveries(ctx, reqParams)
+}
+
+func (w *webhookReceiver) Name() string {
+	return "webhooks"
+}
+
+func (w *webhookReceiver) Handle(ctx context.Context, event *model.Event) error {
+	webhooks, err := w.webhookRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(&model.WebhookPayload{
+		ID: event.ID, Type: event.Type, CreatedAt: event.CreatedAt, Data: json.RawMessage(event.Data),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var deliveries []*model.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	return w.webhookRepository.CreateDeliveries(ctx, deliveries)
+}
+
+func (w *webhookReceiver) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		w.deliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverDue attempts the due deliveries, a batch at a time.
+func (w *webhookReceiver) deliverDue(ctx context.Context) {
+	// A claim outlives an attempt, so it only expires if the worker died.
+	lease := 2*w.config.Timeout + time.Minute
+	for ctx.Err() == nil {
+		deliveries, err := w.webhookRepository.ClaimDeliveries(ctx, time.Now().UTC(), lease, claimBatchSize)
+		if err != nil || len(deliveries) == 0 {
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *model.WebhookDelivery) {
+				defer wg.Done()
+				w.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < claimBatchSize {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at delivery and records the outcome.
+func (w *webhookReceiver) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	webhook, err := w.webhookRepository.Find(ctx, &model.FindWebhookRequest{ID: delivery.WebhookID})
+	if err != nil {
+		// A deleted webhook takes its deliveries with it.
+		w.log.Error(ctx, fmt.Sprintf("failed to find webhook %d of delivery %d: %s", delivery.WebhookID, delivery.ID, err.Error()))
+		return
+	}
+
+	now := time.Now().UTC()
+	status, err := w.send(ctx, webhook, delivery, now)
+	if ctx.Err() != nil {
+		// Shutting down: the claim expires and the attempt is made again.
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySucceeded
+		delivery.Error = ""
+	case delivery.Attempts >= w.config.MaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		delivery.Error = err.Error()
+	default:
+		delivery.NextAttemptAt = now.Add(w.retryDelay(delivery.Attempts))
+		delivery.Error = err.Error()
+	}
+	if err != nil {
+		w.log.Error(ctx, fmt.Sprintf("webhook delivery %d attempt %d failed: %s", delivery.ID, delivery.Attempts, err.Error()))
+	}
+
+	if err := w.webhookRepository.UpdateDelivery(ctx, delivery); err != nil {
+		w.log.Error(ctx, fmt.Sprintf("failed to record webhook delivery %d: %s", delivery.ID, err.Error()))
+	}
+}
+
+// send posts the payload of delivery to the webhook. It returns the response
+// status, and an error unless the receiver answered with 2xx.
+func (w *webhookReceiver) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, "sha256="+Sign(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxResponseRead))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("receiver answered %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// retryDelay is the wait after the given number of failed attempts: the
+// retry delay doubled for every attempt after the first, up to the maximum.
+func (w *webhookReceiver) retryDelay(attempts int) time.Duration {
+	delay := w.config.RetryDelay
+	for i := 1; i < attempts && delay < w.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxRetryDelay {
+		delay = w.config.MaxRetryDelay
+	}
+	return delay
+}
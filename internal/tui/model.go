@@ -0,0 +1,547 @@
+// Package tui is the full-screen terminal interface of todo-cli. It follows
+// the Elm architecture: a Model holds the state, Update changes it for each
+// message, a key or the result of a command, and View renders it. Only Run
+// touches the terminal, so the rest is tested without one.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// dateLayout is the layout of the due dates in the forms.
+const dateLayout = "2006-01-02"
+
+// Store is where the todos are read and written: the HTTP API through
+// client.ITodo, or the local database through NewLocalStore.
+type Store interface {
+	Create(ctx context.Context, reqParams *model.CreateRequest) (*model.Todo, error)
+	Update(ctx context.Context, reqParams *model.UpdateRequest) (*model.Todo, error)
+	Delete(ctx context.Context, reqParams *model.DeleteRequest) error
+	FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error)
+}
+
+// Msg is an event for Update: a key, a new size of the terminal, or the
+// result of a command.
+type Msg interface{}
+
+// Cmd is work done away from Update, such as a request to the store. Its
+// message is given back to Update.
+type Cmd func() Msg
+
+// KeyMsg is a key pressed: a printable character such as "a", or a name
+// such as "enter", "esc", "up" or "ctrl+c".
+type KeyMsg string
+
+// ResizeMsg is the size of the terminal.
+type ResizeMsg struct {
+	Width, Height int
+}
+
+type loadedMsg struct {
+	todos []*model.Todo
+	err   error
+}
+
+type savedMsg struct {
+	todo  *model.Todo
+	event string
+	err   error
+}
+
+type deletedMsg struct {
+	id  int
+	err error
+}
+
+// View is the layout of the todos.
+type View int
+
+const (
+	// ListView lists the todos in board order.
+	ListView View = iota
+	// BoardView shows a column per status.
+	BoardView
+)
+
+// Mode is what the keys do.
+type Mode int
+
+const (
+	// NormalMode moves the selection and acts on the selected todo.
+	NormalMode Mode = iota
+	// FilterMode edits the text filter.
+	FilterMode
+	// FormMode edits the fields of a new or an existing todo.
+	FormMode
+	// ConfirmMode asks before deleting the selected todo.
+	ConfirmMode
+)
+
+// Form is the fields of a todo being created or edited.
+type Form struct {
+	// ID is the todo edited, zero for a new one.
+	ID     int
+	Fields []*Field
+	Focus  int
+	// Err is why the form was not submitted.
+	Err string
+}
+
+// Field is a line of a form.
+type Field struct {
+	Label string
+	Value string
+	// initial is the value of the todo edited, to send only the changes.
+	initial string
+}
+
+// Model is the state of the interface.
+type Model struct {
+	ctx   context.Context
+	store Store
+
+	Width, Height int
+	View          View
+	Mode          Mode
+	// Filter is sent with every load. Its Sort is always manual.
+	Filter model.FindAllRequest
+	// FilterInput is the text filter being typed.
+	FilterInput string
+	Todos       []*model.Todo
+	// Selected is the ID of the selected todo, kept across loads.
+	Selected int
+	Form     *Form
+	// Status is the message of the last action.
+	Status   string
+	Err      error
+	Loading  bool
+	Quitting bool
+}
+
+// NewModel returns the model of the todos of store, and the command loading them.
+func NewModel(ctx context.Context, store Store) (*Model, Cmd) {
+	m := &Model{ctx: ctx, store: store, Width: 80, Height: 24, Filter: model.FindAllRequest{Sort: model.SortManual}}
+	return m, m.load()
+}
+
+// Update changes the model for msg, and returns the command to run next, if any.
+func (m *Model) Update(msg Msg) Cmd {
+	switch msg := msg.(type) {
+	case ResizeMsg:
+		m.Width, m.Height = msg.Width, msg.Height
+	case loadedMsg:
+		m.Loading = false
+		if msg.err != nil {
+			m.Err = msg.err
+			return nil
+		}
+		m.Err = nil
+		m.Todos = msg.todos
+		m.keepSelection()
+	case savedMsg:
+		if msg.err != nil {
+			if m.Form != nil {
+				m.Form.Err = msg.err.Error()
+				return nil
+			}
+			m.Err = msg.err
+			return nil
+		}
+		m.Form, m.Mode = nil, NormalMode
+		m.Selected = msg.todo.ID
+		m.Status = fmt.Sprintf("%s #%d", msg.event, msg.todo.ID)
+		return m.load()
+	case deletedMsg:
+		if msg.err != nil {
+			m.Err = msg.err
+			return nil
+		}
+		m.Status = fmt.Sprintf("Deleted #%d", msg.id)
+		return m.load()
+	case KeyMsg:
+		if msg == "ctrl+c" {
+			m.Quitting = true
+			return nil
+		}
+		switch m.Mode {
+		case FilterMode:
+			return m.updateFilter(msg)
+		case FormMode:
+			return m.updateForm(msg)
+		case ConfirmMode:
+			return m.updateConfirm(msg)
+		default:
+			return m.updateNormal(msg)
+		}
+	}
+	return nil
+}
+
+func (m *Model) updateNormal(key KeyMsg) Cmd {
+	m.Err, m.Status = nil, ""
+	switch key {
+	case "q":
+		m.Quitting = true
+	case "up", "k":
+		m.moveSelection(-1)
+	case "down", "j":
+		m.moveSelection(1)
+	case "left", "h":
+		if m.View == BoardView {
+			m.moveColumn(-1)
+		}
+	case "right", "l":
+		if m.View == BoardView {
+			m.moveColumn(1)
+		}
+	case "tab", "b":
+		m.View = (m.View + 1) % 2
+	case "r":
+		return m.load()
+	case "/":
+		m.Mode, m.FilterInput = FilterMode, m.Filter.Task
+	case "f":
+		m.Filter.Status = nextFilterStatus(m.Filter.Status)
+		return m.load()
+	case "a", "n":
+		m.Form, m.Mode = newForm(nil), FormMode
+	}
+
+	todo := m.SelectedTodo()
+	if todo == nil {
+		return nil
+	}
+	switch key {
+	case "enter", "e":
+		m.Form, m.Mode = newForm(todo), FormMode
+	case "x", " ":
+		status := model.Done
+		if todo.Status == model.Done {
+			status = model.Created
+		}
+		return m.setStatus(todo, status)
+	case "]", ">":
+		return m.shiftStatus(todo, 1)
+	case "[", "<":
+		return m.shiftStatus(todo, -1)
+	case "d", "delete":
+		m.Mode = ConfirmMode
+	}
+	return nil
+}
+
+func (m *Model) updateFilter(key KeyMsg) Cmd {
+	switch key {
+	case "enter":
+		m.Mode = NormalMode
+		m.Filter.Task = strings.TrimSpace(m.FilterInput)
+		return m.load()
+	case "esc":
+		m.Mode = NormalMode
+	case "backspace":
+		m.FilterInput = dropLastRune(m.FilterInput)
+	default:
+		if isPrintable(key) {
+			m.FilterInput += string(key)
+		}
+	}
+	return nil
+}
+
+func (m *Model) updateForm(key KeyMsg) Cmd {
+	form := m.Form
+	field := form.Fields[form.Focus]
+	switch key {
+	case "esc":
+		m.Form, m.Mode = nil, NormalMode
+	case "tab", "down":
+		form.Focus = (form.Focus + 1) % len(form.Fields)
+	case "shift+tab", "up":
+		form.Focus = (form.Focus + len(form.Fields) - 1) % len(form.Fields)
+	case "backspace":
+		field.Value = dropLastRune(field.Value)
+	case "enter":
+		return m.submit()
+	default:
+		if isPrintable(key) {
+			field.Value += string(key)
+		}
+	}
+	return nil
+}
+
+func (m *Model) updateConfirm(key KeyMsg) Cmd {
+	m.Mode = NormalMode
+	todo := m.SelectedTodo()
+	if (key != "y" && key != "Y") || todo == nil {
+		return nil
+	}
+	id := todo.ID
+	return func() Msg {
+		return deletedMsg{id: id, err: m.store.Delete(m.ctx, &model.DeleteRequest{ID: id})}
+	}
+}
+
+// submit returns the command saving the form, or sets its error.
+func (m *Model) submit() Cmd {
+	form := m.Form
+	values := form.values()
+	var due *time.Time
+	if values["Due"] != "" {
+		d, err := time.Parse(dateLayout, values["Due"])
+		if err != nil {
+			form.Err = "Due is a date such as 2024-06-01"
+			return nil
+		}
+		due = &d
+	}
+	if values["Task"] == "" {
+		form.Err = "Task is required"
+		return nil
+	}
+
+	if form.ID == 0 {
+		priority := values["Priority"]
+		if priority != string(model.TP_High) && priority != string(model.TP_Medium) && priority != string(model.TP_Low) {
+			form.Err = "Priority is high, medium or low"
+			return nil
+		}
+		req := &model.CreateRequest{
+			Task: values["Task"], Description: values["Description"], Priority: priority, Tags: splitTags(values["Tags"]),
+			Assignee: values["Assignee"], DueAt: due, Project: values["Project"],
+		}
+		return func() Msg {
+			todo, err := m.store.Create(m.ctx, req)
+			return savedMsg{todo: todo, event: "Created", err: err}
+		}
+	}
+
+	// Only the fields changed are sent: the empty ones are kept by the server.
+	changed := form.changed()
+	req := &model.UpdateRequest{UpdateRequestPath: model.UpdateRequestPath{ID: form.ID}}
+	req.Task, req.Description = changed["Task"], changed["Description"]
+	req.Assignee, req.Project = changed["Assignee"], changed["Project"]
+	if _, ok := changed["Tags"]; ok {
+		req.Tags = splitTags(changed["Tags"])
+	}
+	if _, ok := changed["Due"]; ok {
+		req.DueAt = due
+	}
+	return func() Msg {
+		todo, err := m.store.Update(m.ctx, req)
+		return savedMsg{todo: todo, event: "Updated", err: err}
+	}
+}
+
+func (m *Model) setStatus(todo *model.Todo, status model.Status) Cmd {
+	req := &model.UpdateRequest{
+		UpdateRequestBody: model.UpdateRequestBody{Status: status},
+		UpdateRequestPath: model.UpdateRequestPath{ID: todo.ID},
+	}
+	return func() Msg {
+		updated, err := m.store.Update(m.ctx, req)
+		return savedMsg{todo: updated, event: "Moved to " + string(status), err: err}
+	}
+}
+
+// shiftStatus moves todo to the next or the previous status column.
+func (m *Model) shiftStatus(todo *model.Todo, delta int) Cmd {
+	i := statusIndex(todo.Status) + delta
+	if i < 0 || i >= len(model.StatusColumns) {
+		return nil
+	}
+	return m.setStatus(todo, model.StatusColumns[i])
+}
+
+func (m *Model) load() Cmd {
+	m.Loading = true
+	filter := m.Filter
+	return func() Msg {
+		todos, err := m.store.FindAll(m.ctx, &filter)
+		return loadedMsg{todos: todos, err: err}
+	}
+}
+
+// SelectedTodo returns the selected todo, or nil when there is none.
+func (m *Model) SelectedTodo() *model.Todo {
+	for _, todo := range m.Todos {
+		if todo.ID == m.Selected {
+			return todo
+		}
+	}
+	return nil
+}
+
+// Columns returns the todos of each status column, in board order.
+func (m *Model) Columns() [][]*model.Todo {
+	columns := make([][]*model.Todo, len(model.StatusColumns))
+	for _, todo := range m.Todos {
+		i := statusIndex(todo.Status)
+		if i < 0 {
+			continue
+		}
+		columns[i] = append(columns[i], todo)
+	}
+	return columns
+}
+
+// keepSelection selects the first todo when the selected one is gone.
+func (m *Model) keepSelection() {
+	if m.SelectedTodo() != nil {
+		return
+	}
+	m.Selected = 0
+	if m.View == BoardView {
+		for _, column := range m.Columns() {
+			if len(column) > 0 {
+				m.Selected = column[0].ID
+				return
+			}
+		}
+	}
+	if len(m.Todos) > 0 {
+		m.Selected = m.Todos[0].ID
+	}
+}
+
+// moveSelection selects the todo delta rows away, in the list or in the column.
+func (m *Model) moveSelection(delta int) {
+	todos := m.Todos
+	if m.View == BoardView {
+		if todo := m.SelectedTodo(); todo != nil {
+			todos = m.Columns()[statusIndex(todo.Status)]
+		}
+	}
+	if len(todos) == 0 {
+		return
+	}
+	i := indexOf(todos, m.Selected) + delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(todos) {
+		i = len(todos) - 1
+	}
+	m.Selected = todos[i].ID
+}
+
+// moveColumn selects a todo of the next column with todos, at the row of the
+// selected one or the last row.
+func (m *Model) moveColumn(delta int) {
+	columns := m.Columns()
+	current, row := 0, 0
+	if todo := m.SelectedTodo(); todo != nil {
+		current = statusIndex(todo.Status)
+		row = indexOf(columns[current], todo.ID)
+	}
+	for i := current + delta; i >= 0 && i < len(columns); i += delta {
+		if len(columns[i]) == 0 {
+			continue
+		}
+		if row >= len(columns[i]) {
+			row = len(columns[i]) - 1
+		}
+		m.Selected = columns[i][row].ID
+		return
+	}
+}
+
+func newForm(todo *model.Todo) *Form {
+	if todo == nil {
+		return &Form{Fields: []*Field{
+			{Label: "Task"}, {Label: "Priority", Value: string(model.TP_Medium)}, {Label: "Assignee"},
+			{Label: "Project"}, {Label: "Tags"}, {Label: "Due"}, {Label: "Description"},
+		}}
+	}
+
+	due := ""
+	if todo.DueAt != nil {
+		due = todo.DueAt.Format(dateLayout)
+	}
+	// The priority of a todo is not changed by an update.
+	form := &Form{ID: todo.ID, Fields: []*Field{
+		{Label: "Task", Value: todo.Task}, {Label: "Assignee", Value: todo.Assignee},
+		{Label: "Project", Value: todo.Project}, {Label: "Tags", Value: strings.Join(todo.Tags, ", ")},
+		{Label: "Due", Value: due}, {Label: "Description", Value: todo.Description},
+	}}
+	for _, field := range form.Fields {
+		field.initial = field.Value
+	}
+	return form
+}
+
+func (f *Form) values() map[string]string {
+	values := make(map[string]string, len(f.Fields))
+	for _, field := range f.Fields {
+		values[field.Label] = strings.TrimSpace(field.Value)
+	}
+	return values
+}
+
+func (f *Form) changed() map[string]string {
+	changed := map[string]string{}
+	for _, field := range f.Fields {
+		if value := strings.TrimSpace(field.Value); value != field.initial {
+			changed[field.Label] = value
+		}
+	}
+	return changed
+}
+
+// nextFilterStatus cycles the status filter through every status, then none.
+func nextFilterStatus(status string) string {
+	i := statusIndex(model.Status(status)) + 1
+	if status == "" {
+		i = 0
+	}
+	if i >= len(model.StatusColumns) {
+		return ""
+	}
+	return string(model.StatusColumns[i])
+}
+
+func statusIndex(status model.Status) int {
+	for i, s := range model.StatusColumns {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOf(todos []*model.Todo, id int) int {
+	for i, todo := range todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return 0
+}
+
+func splitTags(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func isPrintable(key KeyMsg) bool {
+	r := []rune(string(key))
+	return len(r) == 1 && r[0] >= ' '
+}
+
+func dropLastRune(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	return string(r[:len(r)-1])
+}
@@ -0,0 +1,380 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+)
+
+// fakeStore keeps the todos in memory, in board order, and records the requests.
+type fakeStore struct {
+	todos    []*model.Todo
+	filters  []model.FindAllRequest
+	updates  []*model.UpdateRequest
+	failNext error
+}
+
+func (s *fakeStore) Create(_ context.Context, reqParams *model.CreateRequest) (*model.Todo, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	todo := model.NewTodo(reqParams)
+	todo.ID = len(s.todos) + 1
+	s.todos = append(s.todos, todo)
+	return todo, nil
+}
+
+func (s *fakeStore) Update(_ context.Context, reqParams *model.UpdateRequest) (*model.Todo, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	s.updates = append(s.updates, reqParams)
+	for i, current := range s.todos {
+		if current.ID == reqParams.ID {
+			todo := model.NewUpdateTodo(reqParams)
+			todo.PrepareUpdatedTodo(current)
+			// A todo changing status goes to the end of its new column.
+			s.todos = append(s.todos[:i], s.todos[i+1:]...)
+			if todo.Status == current.Status {
+				s.todos = append(s.todos[:i], append([]*model.Todo{todo}, s.todos[i:]...)...)
+			} else {
+				s.todos = append(s.todos, todo)
+			}
+			return todo, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (s *fakeStore) Delete(_ context.Context, reqParams *model.DeleteRequest) error {
+	for i, todo := range s.todos {
+		if todo.ID == reqParams.ID {
+			s.todos = append(s.todos[:i], s.todos[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+func (s *fakeStore) FindAll(_ context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+	s.filters = append(s.filters, *reqParams)
+	var todos []*model.Todo
+	for _, status := range model.StatusColumns {
+		for _, todo := range s.todos {
+			if todo.Status == status && (reqParams.Status == "" || string(todo.Status) == reqParams.Status) &&
+				strings.Contains(strings.ToLower(todo.Task), strings.ToLower(reqParams.Task)) {
+				todos = append(todos, todo)
+			}
+		}
+	}
+	return todos, nil
+}
+
+func (s *fakeStore) find(id int) *model.Todo {
+	for _, todo := range s.todos {
+		if todo.ID == id {
+			return todo
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) fail() error {
+	err := s.failNext
+	s.failNext = nil
+	return err
+}
+
+// newTestModel returns a loaded model of the todos.
+func newTestModel(t *testing.T, todos ...*model.Todo) (*Model, *fakeStore) {
+	store := &fakeStore{todos: todos}
+	m, load := NewModel(context.Background(), store)
+	run(m, load)
+	require.NoError(t, m.Err)
+	return m, store
+}
+
+// run gives the message of cmd to Update, and so on, as Run does.
+func run(m *Model, cmd Cmd) {
+	for cmd != nil {
+		cmd = m.Update(cmd())
+	}
+}
+
+// press presses the keys one after the other.
+func press(m *Model, keys ...KeyMsg) {
+	for _, key := range keys {
+		run(m, m.Update(key))
+	}
+}
+
+// typeText presses the keys of the characters of s.
+func typeText(m *Model, s string) {
+	for _, r := range s {
+		press(m, KeyMsg(string(r)))
+	}
+}
+
+// backspace presses backspace n times.
+func backspace(m *Model, n int) {
+	for i := 0; i < n; i++ {
+		press(m, "backspace")
+	}
+}
+
+func todos() []*model.Todo {
+	return []*model.Todo{
+		{ID: 1, Task: "Ship release", Status: model.Processing, Priority: model.TP_High},
+		{ID: 2, Task: "Write notes", Status: model.Created, Priority: model.TP_Low, Assignee: "alice"},
+		{ID: 3, Task: "Buy milk", Status: model.Created, Priority: model.TP_Medium},
+		{ID: 4, Task: "Book venue", Status: model.Done, Priority: model.TP_Medium},
+	}
+}
+
+func TestModel_List(t *testing.T) {
+	m, store := newTestModel(t, todos()...)
+	require.Len(t, m.Todos, 4)
+	assert.Equal(t, 2, m.Selected, "the first todo in board order is selected")
+	assert.Equal(t, model.SortManual, store.filters[0].Sort)
+
+	press(m, "down", "j")
+	assert.Equal(t, 1, m.Selected)
+	press(m, "down", "down", "down")
+	assert.Equal(t, 4, m.Selected, "the selection stops at the last todo")
+	press(m, "k")
+	assert.Equal(t, 1, m.Selected)
+
+	press(m, "q")
+	assert.True(t, m.Quitting)
+}
+
+func TestModel_Status(t *testing.T) {
+	t.Run("toggle_done", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		press(m, "x")
+		assert.Equal(t, model.Done, store.find(2).Status)
+		assert.Equal(t, "Moved to done #2", m.Status)
+		assert.Equal(t, 2, m.Selected, "the todo stays selected in its new place")
+		assert.Equal(t, []int{3, 1, 4, 2}, ids(m.Todos))
+
+		press(m, "x")
+		assert.Equal(t, model.Created, store.find(2).Status)
+	})
+
+	t.Run("shift", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		press(m, "]")
+		assert.Equal(t, model.Processing, store.find(2).Status)
+		press(m, "]", "]")
+		assert.Equal(t, model.Done, store.find(2).Status, "done is the last column")
+		press(m, "[")
+		assert.Equal(t, model.Processing, store.find(2).Status)
+		assert.Len(t, store.updates, 3)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		store.failNext = errors.New("WIP limit reached")
+		press(m, "]")
+		assert.EqualError(t, m.Err, "WIP limit reached")
+		assert.Contains(t, m.Render(), "Error: WIP limit reached")
+
+		press(m, "j")
+		assert.NoError(t, m.Err, "the error is cleared by the next key")
+	})
+}
+
+func TestModel_Filter(t *testing.T) {
+	m, store := newTestModel(t, todos()...)
+
+	press(m, "/")
+	typeText(m, "MILK")
+	press(m, "enter")
+	assert.Equal(t, "MILK", store.filters[len(store.filters)-1].Task)
+	assert.Equal(t, []int{3}, ids(m.Todos))
+	assert.Equal(t, 3, m.Selected)
+
+	press(m, "/")
+	backspace(m, 4)
+	press(m, "esc")
+	assert.Equal(t, "MILK", m.Filter.Task, "esc keeps the filter")
+	press(m, "/")
+	backspace(m, 4)
+	press(m, "enter")
+	assert.Len(t, m.Todos, 4)
+
+	press(m, "f")
+	assert.Equal(t, string(model.Created), m.Filter.Status)
+	assert.Equal(t, []int{2, 3}, ids(m.Todos))
+	press(m, "f", "f")
+	assert.Equal(t, string(model.Done), m.Filter.Status)
+	press(m, "f")
+	assert.Empty(t, m.Filter.Status)
+	assert.Equal(t, model.SortManual, store.filters[len(store.filters)-1].Sort)
+}
+
+func TestModel_Form(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		press(m, "a")
+		require.Equal(t, FormMode, m.Mode)
+
+		press(m, "enter")
+		assert.Equal(t, "Task is required", m.Form.Err)
+
+		typeText(m, "Call Bob")
+		press(m, "tab")
+		backspace(m, len("medium"))
+		typeText(m, "high")
+		press(m, "tab")
+		typeText(m, "bob")
+		press(m, "tab", "tab")
+		typeText(m, "a, b")
+		press(m, "tab")
+		typeText(m, "2024-06-01")
+		press(m, "enter")
+		assert.Equal(t, NormalMode, m.Mode)
+		require.Len(t, store.todos, 5)
+		todo := store.todos[4]
+		assert.Equal(t, "Call Bob", todo.Task)
+		assert.Equal(t, model.TP_High, todo.Priority)
+		assert.Equal(t, "bob", todo.Assignee)
+		assert.Equal(t, []string{"a", "b"}, todo.Tags)
+		require.NotNil(t, todo.DueAt)
+		assert.True(t, todo.DueAt.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+		assert.Equal(t, 5, m.Selected)
+		assert.Equal(t, "Created #5", m.Status)
+	})
+
+	t.Run("invalid_due", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		press(m, "a")
+		typeText(m, "Call Bob")
+		press(m, "up", "up")
+		typeText(m, "next week")
+		press(m, "enter")
+		assert.Equal(t, "Due is a date such as 2024-06-01", m.Form.Err)
+		press(m, "esc")
+		assert.Equal(t, NormalMode, m.Mode)
+		assert.Len(t, store.todos, 4)
+	})
+
+	t.Run("edit_sends_the_changes", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		press(m, "e")
+		require.Equal(t, 2, m.Form.ID)
+		assert.Equal(t, "Write notes", m.Form.Fields[0].Value)
+
+		press(m, "tab")
+		backspace(m, len("alice"))
+		typeText(m, "bob")
+		press(m, "enter")
+		require.Len(t, store.updates, 1)
+		assert.Equal(t, 2, store.updates[0].ID)
+		assert.Equal(t, model.UpdateRequestBody{Assignee: "bob"}, store.updates[0].UpdateRequestBody)
+		assert.Equal(t, "bob", store.find(2).Assignee)
+		assert.Equal(t, "Write notes", store.find(2).Task)
+		assert.Equal(t, "Updated #2", m.Status)
+	})
+
+	t.Run("save_error", func(t *testing.T) {
+		m, store := newTestModel(t, todos()...)
+		store.failNext = errors.New("400 Bad Request")
+		press(m, "a")
+		typeText(m, "Call Bob")
+		press(m, "enter")
+		assert.Equal(t, FormMode, m.Mode, "the form stays open")
+		assert.Equal(t, "400 Bad Request", m.Form.Err)
+	})
+}
+
+func TestModel_Delete(t *testing.T) {
+	m, store := newTestModel(t, todos()...)
+
+	press(m, "d")
+	assert.Equal(t, ConfirmMode, m.Mode)
+	assert.Contains(t, m.Render(), `Delete #2 "Write notes"? y/n`)
+	press(m, "n")
+	assert.Len(t, store.todos, 4)
+
+	press(m, "d", "y")
+	assert.Len(t, store.todos, 3)
+	assert.Equal(t, "Deleted #2", m.Status)
+	assert.Equal(t, 3, m.Selected)
+}
+
+func TestModel_Board(t *testing.T) {
+	m, _ := newTestModel(t, todos()...)
+	press(m, "b")
+	require.Equal(t, BoardView, m.View)
+
+	press(m, "down")
+	assert.Equal(t, 3, m.Selected, "down stays in the column")
+	press(m, "down")
+	assert.Equal(t, 3, m.Selected)
+	press(m, "right")
+	assert.Equal(t, 1, m.Selected, "the row is kept within the next column")
+	press(m, "l")
+	assert.Equal(t, 4, m.Selected)
+	press(m, "right")
+	assert.Equal(t, 4, m.Selected)
+	press(m, "h", "h")
+	assert.Equal(t, 2, m.Selected)
+
+	press(m, "tab")
+	assert.Equal(t, ListView, m.View)
+}
+
+func TestModel_Render(t *testing.T) {
+	m, _ := newTestModel(t, todos()...)
+	run(m, m.Update(ResizeMsg{Width: 60, Height: 6}))
+
+	lines := strings.Split(stripANSI(m.Render()), "\n")
+	require.Len(t, lines, 6)
+	for _, line := range lines {
+		assert.Len(t, []rune(line), 60, line)
+	}
+	assert.Equal(t, "Todos (4) - list", strings.TrimSpace(lines[0]))
+	assert.True(t, strings.HasPrefix(lines[2], "  2     created     low"), lines[2])
+	assert.Contains(t, lines[2], "Write notes @alice")
+	assert.Contains(t, lines[5], "a add")
+
+	// The list scrolls to the selection.
+	press(m, "j", "j", "j")
+	lines = strings.Split(stripANSI(m.Render()), "\n")
+	assert.Contains(t, lines[4], "Book venue")
+
+	press(m, "b")
+	lines = strings.Split(stripANSI(m.Render()), "\n")
+	assert.Equal(t, fit(" CREATED (2)", 20)+fit(" PROCESSING (1)", 20)+fit(" DONE (1)", 20), lines[1])
+	assert.Equal(t, fit(" #2 Write notes", 20)+fit(" #1 Ship release", 20)+fit(" #4 Book venue", 20), lines[2])
+	assert.Equal(t, fit(" #3 Buy milk", 60), lines[3])
+}
+
+func TestFit(t *testing.T) {
+	assert.Equal(t, "abc  ", fit("abc", 5))
+	assert.Equal(t, "ab", fit("abc", 2))
+	assert.Equal(t, "ドキ ", fit("ドキュメント", 5), "wide characters take two cells")
+	assert.Equal(t, "", fit("abc", -1))
+}
+
+func ids(todos []*model.Todo) []int {
+	var ids []int
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+func stripANSI(s string) string {
+	return strings.NewReplacer(reverse, "", bold, "", reset, "").Replace(s)
+}
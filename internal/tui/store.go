@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/rank"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+type InitLocalStore struct {
+	// TodoRepository is the repository of the configured store: an event-sourced
+	// one records the changes as events, as with the server.
+	TodoRepository repository.ITodo
+}
+
+type localStore struct {
+	todoRepository repository.ITodo
+}
+
+// NewLocalStore returns the store of the todos of a local database, for
+// working without a server. It writes the database directly: the cache,
+// the events and the WIP limits of a running server are left out.
+func NewLocalStore(initLocalStore *InitLocalStore) Store {
+	return &localStore{todoRepository: initLocalStore.TodoRepository}
+}
+
+func (s *localStore) Create(ctx context.Context, reqParams *model.CreateRequest) (*model.Todo, error) {
+	todo := model.NewTodo(reqParams)
+	if err := todo.ValidateCreateRequest(); err != nil {
+		return nil, err
+	}
+
+	err := s.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		r, err := s.appendRank(ctx, todo.Status)
+		if err != nil {
+			return err
+		}
+		todo.Rank = r
+		return s.todoRepository.Create(ctx, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *localStore) Update(ctx context.Context, reqParams *model.UpdateRequest) (*model.Todo, error) {
+	todo := model.NewUpdateTodo(reqParams)
+	err := s.todoRepository.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.todoRepository.Find(ctx, &model.FindRequest{ID: reqParams.ID})
+		if err != nil {
+			return err
+		}
+		todo.PrepareUpdatedTodo(current)
+
+		// A todo changing status goes to the end of its new column, as with the API.
+		if todo.Status != current.Status {
+			r, err := s.appendRank(ctx, todo.Status)
+			if err != nil {
+				return err
+			}
+			todo.Rank = r
+		}
+		return s.todoRepository.Update(ctx, todo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, reqParams *model.DeleteRequest) error {
+	return s.todoRepository.Delete(ctx, reqParams)
+}
+
+func (s *localStore) FindAll(ctx context.Context, reqParams *model.FindAllRequest) ([]*model.Todo, error) {
+	return s.todoRepository.FindAll(ctx, reqParams)
+}
+
+// appendRank returns a rank at the end of the status column.
+func (s *localStore) appendRank(ctx context.Context, status model.Status) (string, error) {
+	last, err := s.todoRepository.LastRank(ctx, status)
+	if err != nil {
+		return "", err
+	}
+	if last == "" {
+		return rank.Between("", "")
+	}
+	return rank.After(last)
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zuu-development/fullstack-examination-2024/internal/db"
+	"github.com/zuu-development/fullstack-examination-2024/internal/log"
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"github.com/zuu-development/fullstack-examination-2024/internal/repository"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dbInstance, err := db.New(filepath.Join(t.TempDir(), "tui.db"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(dbInstance))
+	store := NewLocalStore(&InitLocalStore{
+		TodoRepository: repository.NewTodo(&repository.InitTodoRepository{Db: dbInstance, Log: log.New()}),
+	})
+	testLocalStore(ctx, t, store)
+}
+
+func TestLocalStore_EventSourced(t *testing.T) {
+	ctx := context.Background()
+	dbInstance, err := db.New(filepath.Join(t.TempDir(), "tui.db"))
+	require.NoError(t, err)
+	require.NoError(t, db.Migrate(dbInstance))
+	todoRepository := repository.NewEventSourcedTodo(&repository.InitEventSourcedTodoRepository{
+		Db: dbInstance, Log: log.New(),
+	})
+	store := NewLocalStore(&InitLocalStore{TodoRepository: todoRepository})
+	testLocalStore(ctx, t, store)
+
+	// The changes are events, so the projection is rebuilt as it is.
+	before, err := store.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+	require.NoError(t, err)
+	require.NoError(t, dbInstance.Where("1 = 1").Delete(&model.Todo{}).Error)
+	res, err := todoRepository.Replay(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, len(before), res.Todos)
+	after, err := store.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+	require.NoError(t, err)
+	require.Len(t, after, len(before))
+	for i := range before {
+		assert.Equal(t, before[i].Task, after[i].Task)
+		assert.Equal(t, before[i].Assignee, after[i].Assignee)
+		assert.Equal(t, before[i].Status, after[i].Status)
+	}
+}
+
+func testLocalStore(ctx context.Context, t *testing.T, store Store) {
+	_, err := store.Create(ctx, &model.CreateRequest{Task: "", Priority: string(model.TP_Low)})
+	assert.Error(t, err, "the todo is validated")
+
+	var ids []int
+	for _, task := range []string{"first", "second", "third"} {
+		todo, err := store.Create(ctx, &model.CreateRequest{Task: task, Priority: string(model.TP_Medium)})
+		require.NoError(t, err)
+		assert.Equal(t, model.Created, todo.Status)
+		ids = append(ids, todo.ID)
+	}
+
+	// Moving the first todo to done and back puts it at the end of the created column.
+	for _, status := range []model.Status{model.Done, model.Created} {
+		_, err = store.Update(ctx, &model.UpdateRequest{
+			UpdateRequestBody: model.UpdateRequestBody{Status: status},
+			UpdateRequestPath: model.UpdateRequestPath{ID: ids[0]},
+		})
+		require.NoError(t, err)
+	}
+	todo, err := store.Update(ctx, &model.UpdateRequest{
+		UpdateRequestBody: model.UpdateRequestBody{Assignee: "bob"},
+		UpdateRequestPath: model.UpdateRequestPath{ID: ids[1]},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "second", todo.Task, "the fields left empty are kept")
+
+	todos, err := store.FindAll(ctx, &model.FindAllRequest{Sort: model.SortManual})
+	require.NoError(t, err)
+	var tasks []string
+	for _, todo := range todos {
+		tasks = append(tasks, todo.Task)
+	}
+	assert.Equal(t, []string{"second", "third", "first"}, tasks)
+
+	require.NoError(t, store.Delete(ctx, &model.DeleteRequest{ID: ids[2]}))
+	todos, err = store.FindAll(ctx, &model.FindAllRequest{Assignee: "bob"})
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, ids[1], todos[0].ID)
+
+	_, err = store.Update(ctx, &model.UpdateRequest{UpdateRequestPath: model.UpdateRequestPath{ID: ids[2]}})
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	home           = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+)
+
+// resizeInterval is how often the size of the terminal is checked.
+const resizeInterval = 250 * time.Millisecond
+
+// keyNames are the escape sequences and control characters of the named keys.
+var keyNames = map[string]KeyMsg{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[3~": "delete", "\x1b[Z": "shift+tab",
+	"\r": "enter", "\n": "enter", "\t": "tab", "\x7f": "backspace", "\b": "backspace",
+	"\x03": "ctrl+c", "\x1b": "esc",
+}
+
+// Run runs the model in the terminal of in and out until it quits or ctx is done.
+func Run(ctx context.Context, m *Model, init Cmd, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+	fmt.Fprint(out, enterAltScreen)
+	defer fmt.Fprint(out, exitAltScreen)
+
+	msgs := make(chan Msg, 16)
+	run := func(cmd Cmd) {
+		if cmd != nil {
+			go func() { msgs <- cmd() }()
+		}
+	}
+	go readKeys(in, msgs)
+	go watchSize(ctx, fd, msgs)
+
+	run(init)
+	for !m.Quitting {
+		render(out, m)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-msgs:
+			run(m.Update(msg))
+		}
+	}
+	return nil
+}
+
+func render(out io.Writer, m *Model) {
+	screen := strings.ReplaceAll(m.Render(), "\n", clearLine+"\r\n")
+	fmt.Fprint(out, home+screen+clearLine+clearBelow)
+}
+
+// readKeys sends the keys read from in. The input of a key is read at once,
+// so an escape alone is the escape key.
+func readKeys(in io.Reader, msgs chan<- Msg) {
+	buf := make([]byte, 256)
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range ParseKeys(buf[:n]) {
+			msgs <- key
+		}
+	}
+}
+
+// watchSize sends the size of the terminal, then each change of it.
+func watchSize(ctx context.Context, fd int, msgs chan<- Msg) {
+	ticker := time.NewTicker(resizeInterval)
+	defer ticker.Stop()
+	last := ResizeMsg{}
+	for {
+		if width, height, err := term.GetSize(fd); err == nil {
+			if size := (ResizeMsg{Width: width, Height: height}); size != last {
+				last = size
+				msgs <- size
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ParseKeys splits the input of the terminal into keys.
+func ParseKeys(b []byte) []KeyMsg {
+	var keys []KeyMsg
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			key, n := parseEscape(b)
+			if key != "" {
+				keys = append(keys, key)
+			}
+			b = b[n:]
+			continue
+		}
+		if key, ok := keyNames[string(b[:1])]; ok {
+			keys = append(keys, key)
+			b = b[1:]
+			continue
+		}
+		r, size := utf8.DecodeRune(b)
+		if r != utf8.RuneError && r >= ' ' {
+			keys = append(keys, KeyMsg(string(r)))
+		}
+		b = b[size:]
+	}
+	return keys
+}
+
+// parseEscape reads the escape sequence at the start of b. Unknown sequences
+// are skipped rather than read as keys.
+func parseEscape(b []byte) (KeyMsg, int) {
+	if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+		return "esc", 1
+	}
+	// A CSI sequence ends with a byte from @ to ~.
+	for i := 2; i < len(b); i++ {
+		if b[i] >= '@' && b[i] <= '~' {
+			return keyNames[string(b[:i+1])], i + 1
+		}
+	}
+	return "", len(b)
+}
@@ -0,0 +1,30 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []KeyMsg
+	}{
+		{name: "characters", input: "a/ ", want: []KeyMsg{"a", "/", " "}},
+		{name: "utf8", input: "ドキ", want: []KeyMsg{"ド", "キ"}},
+		{name: "arrows", input: "\x1b[A\x1b[B\x1bOC\x1b[D", want: []KeyMsg{"up", "down", "right", "left"}},
+		{name: "controls", input: "\r\t\x7f\x03", want: []KeyMsg{"enter", "tab", "backspace", "ctrl+c"}},
+		{name: "esc", input: "\x1b", want: []KeyMsg{"esc"}},
+		{name: "esc_then_key", input: "\x1bq", want: []KeyMsg{"esc", "q"}},
+		{name: "delete_and_shift_tab", input: "\x1b[3~\x1b[Z", want: []KeyMsg{"delete", "shift+tab"}},
+		{name: "unknown_sequence", input: "\x1b[1;5Ax", want: []KeyMsg{"x"}},
+		{name: "other_control", input: "\x01y", want: []KeyMsg{"y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseKeys([]byte(tt.input)))
+		})
+	}
+}
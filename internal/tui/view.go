@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zuu-development/fullstack-examination-2024/internal/model"
+	"golang.org/x/text/width"
+)
+
+const (
+	reverse = "\x1b[7m"
+	bold    = "\x1b[1m"
+	reset   = "\x1b[0m"
+)
+
+// helpText is the footer of the normal mode.
+const helpText = "a add  e edit  x done  [ ] status  d delete  / search  f filter  b board  r reload  q quit"
+
+// Render returns the screen of the model: a header line, the todos or the
+// form, and a footer line, cut to the size of the terminal.
+func (m *Model) Render() string {
+	height := m.Height
+	if height < 3 {
+		height = 3
+	}
+	lines := []string{bold + fit(m.header(), m.Width) + reset}
+
+	var body []string
+	switch {
+	case m.Mode == FormMode:
+		body = m.renderForm()
+	case m.View == BoardView:
+		body = m.renderBoard(height - 2)
+	default:
+		body = m.renderList(height - 2)
+	}
+	for len(body) < height-2 {
+		body = append(body, "")
+	}
+	lines = append(lines, body[:height-2]...)
+	lines = append(lines, m.footer())
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) header() string {
+	view := "list"
+	if m.View == BoardView {
+		view = "board"
+	}
+	header := fmt.Sprintf(" Todos (%d) - %s", len(m.Todos), view)
+	if m.Filter.Status != "" {
+		header += "  status:" + m.Filter.Status
+	}
+	if m.Filter.Task != "" {
+		header += fmt.Sprintf("  search:%q", m.Filter.Task)
+	}
+	if m.Loading {
+		header += "  loading..."
+	}
+	return header
+}
+
+func (m *Model) footer() string {
+	switch {
+	case m.Mode == FilterMode:
+		return fit(" search: "+m.FilterInput+"_", m.Width)
+	case m.Mode == ConfirmMode:
+		if todo := m.SelectedTodo(); todo != nil {
+			return fit(fmt.Sprintf(" Delete #%d %q? y/n", todo.ID, todo.Task), m.Width)
+		}
+	case m.Mode == FormMode:
+		return fit(" tab next field  enter save  esc cancel", m.Width)
+	case m.Err != nil:
+		return fit(" Error: "+m.Err.Error(), m.Width)
+	case m.Status != "":
+		return fit(" "+m.Status, m.Width)
+	}
+	return fit(" "+helpText, m.Width)
+}
+
+func (m *Model) renderList(height int) []string {
+	if len(m.Todos) == 0 {
+		return []string{"", "  No todos. Press a to add one."}
+	}
+	lines := []string{fit(fmt.Sprintf("  %-5s %-11s %-7s %-11s %s", "ID", "STATUS", "PRI", "DUE", "TASK"), m.Width)}
+	selected := indexOf(m.Todos, m.Selected)
+	offset := 0
+	if selected >= height-1 {
+		offset = selected - (height - 2)
+	}
+	for i := offset; i < len(m.Todos) && len(lines) < height; i++ {
+		todo := m.Todos[i]
+		line := fmt.Sprintf("  %-5d %-11s %-7s %-11s %s", todo.ID, todo.Status, todo.Priority, formatDue(todo), todo.Task)
+		if todo.Assignee != "" {
+			line += " @" + todo.Assignee
+		}
+		if todo.Project != "" {
+			line += " +" + todo.Project
+		}
+		lines = append(lines, m.highlight(todo, fit(line, m.Width)))
+	}
+	return lines
+}
+
+func (m *Model) renderBoard(height int) []string {
+	columns := m.Columns()
+	colWidth := m.Width / len(columns)
+	lines := make([]string, 0, height)
+
+	var header strings.Builder
+	for i, status := range model.StatusColumns {
+		header.WriteString(fit(fmt.Sprintf(" %s (%d)", strings.ToUpper(string(status)), len(columns[i])), colWidth))
+	}
+	lines = append(lines, header.String())
+
+	// Each column scrolls to its own selected todo.
+	offsets := make([]int, len(columns))
+	for i, column := range columns {
+		if row := indexOf(column, m.Selected); len(column) > 0 && column[row].ID == m.Selected && row >= height-1 {
+			offsets[i] = row - (height - 2)
+		}
+	}
+	for row := 0; len(lines) < height; row++ {
+		var line strings.Builder
+		empty := true
+		for i, column := range columns {
+			if row+offsets[i] >= len(column) {
+				line.WriteString(strings.Repeat(" ", colWidth))
+				continue
+			}
+			empty = false
+			todo := column[row+offsets[i]]
+			line.WriteString(m.highlight(todo, fit(fmt.Sprintf(" #%d %s", todo.ID, todo.Task), colWidth)))
+		}
+		if empty {
+			break
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func (m *Model) renderForm() []string {
+	title := " New todo"
+	if m.Form.ID != 0 {
+		title = fmt.Sprintf(" Edit #%d", m.Form.ID)
+	}
+	lines := []string{title, ""}
+	for i, field := range m.Form.Fields {
+		value := strings.ReplaceAll(field.Value, "\n", " ")
+		marker := "  "
+		if i == m.Form.Focus {
+			marker, value = "> ", value+"_"
+		}
+		lines = append(lines, fit(fmt.Sprintf("%s%-12s %s", marker, field.Label+":", value), m.Width))
+	}
+	if m.Form.Err != "" {
+		lines = append(lines, "", fit("  "+m.Form.Err, m.Width))
+	}
+	return lines
+}
+
+func (m *Model) highlight(todo *model.Todo, s string) string {
+	if todo.ID != m.Selected {
+		return s
+	}
+	return reverse + s + reset
+}
+
+func formatDue(todo *model.Todo) string {
+	if todo.DueAt == nil {
+		return ""
+	}
+	return todo.DueAt.Format(dateLayout)
+}
+
+// fit cuts or pads s to n cells of the terminal, wide characters taking two.
+func fit(s string, n int) string {
+	if n < 0 {
+		n = 0
+	}
+	var b strings.Builder
+	cells := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if cells+w > n {
+			break
+		}
+		b.WriteRune(r)
+		cells += w
+	}
+	return b.String() + strings.Repeat(" ", n-cells)
+}
+
+func runeWidth(r rune) int {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}